@@ -0,0 +1,192 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+)
+
+// share is a private share sent from a participant to another.
+type share struct {
+	Receiver string `json:"receiver"`
+	Share    string `json:"share"`
+}
+
+// participant is the values generated by or for a participant of DKG.
+type participant struct {
+	NodeID           string  `json:"node_id"`
+	ID               string  `json:"id"`
+	MasterPublicKeys string  `json:"master_public_keys"`
+	SentShares       []share `json:"sent_shares"`
+	PrivateKey       string  `json:"private_key"`
+	PublicKey        string  `json:"public_key"`
+	PartialSignature string  `json:"partial_signature"`
+}
+
+// vector is a test vector of DKG and TSIG, all bytes are hex encoded.
+type vector struct {
+	Seed           string        `json:"seed"`
+	Threshold      int           `json:"threshold"`
+	Message        string        `json:"message"`
+	Participants   []participant `json:"participants"`
+	Signers        []string      `json:"signers"`
+	GroupPublicKey string        `json:"group_public_key"`
+	GroupSignature string        `json:"group_signature"`
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(1)
+}
+
+// derive hashes seed with a label and an index.
+func derive(seed []byte, label string, idx int) common.Hash {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(idx))
+	return crypto.Keccak256Hash(seed, []byte(label), b)
+}
+
+func generate(seed []byte, n, t int, msg common.Hash) (*vector, error) {
+	v := &vector{
+		Seed:      hex.EncodeToString(seed),
+		Threshold: t,
+		Message:   hex.EncodeToString(msg[:]),
+	}
+	// Participants are identified the same way as nodes in consensus.
+	ids := make(dkg.IDs, n)
+	prvShares := make([]*dkg.PrivateKeyShares, n)
+	pubShares := make([]*dkg.PublicKeyShares, n)
+	for i := 0; i < n; i++ {
+		nID := derive(seed, "node", i)
+		ids[i] = dkg.NewID(nID[:])
+		prvShares[i], pubShares[i] = dkg.NewPrivateKeySharesFromSeed(
+			t, derive(seed, "polynomial", i).Bytes())
+		v.Participants = append(v.Participants, participant{
+			NodeID:           nID.String(),
+			ID:               ids[i].GetHexString(),
+			MasterPublicKeys: hex.EncodeToString(pubShares[i].MasterKeyBytes()),
+		})
+	}
+	// Exchange private shares, each of them is verified by the master public
+	// keys of its sender.
+	received := make([]*dkg.PrivateKeyShares, n)
+	for i := range received {
+		received[i] = dkg.NewEmptyPrivateKeyShares()
+	}
+	for i := 0; i < n; i++ {
+		prvShares[i].SetParticipants(ids)
+		for j := 0; j < n; j++ {
+			s, _ := prvShares[i].Share(ids[j])
+			ok, err := pubShares[i].VerifyPrvShare(ids[j], s)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("invalid share from %d to %d", i, j)
+			}
+			if err = received[j].AddShare(ids[i], s); err != nil {
+				return nil, err
+			}
+			v.Participants[i].SentShares = append(v.Participants[i].SentShares,
+				share{
+					Receiver: ids[j].GetHexString(),
+					Share:    hex.EncodeToString(s.Bytes()),
+				})
+		}
+	}
+	// Recover private keys of participants, and sign the message partially.
+	psigs := make([]dkg.PartialSignature, n)
+	for i := 0; i < n; i++ {
+		prv, err := received[i].RecoverPrivateKey(ids)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := prv.Sign(msg)
+		if err != nil {
+			return nil, err
+		}
+		psigs[i] = dkg.PartialSignature(sig)
+		v.Participants[i].PrivateKey = hex.EncodeToString(prv.Bytes())
+		v.Participants[i].PublicKey = hex.EncodeToString(
+			prv.PublicKey().Bytes())
+		v.Participants[i].PartialSignature = hex.EncodeToString(
+			sig.Signature)
+	}
+	// The group signature is recovered from the first t partial signatures.
+	gpk := dkg.RecoverGroupPublicKey(pubShares)
+	gsig, err := dkg.RecoverSignature(psigs[:t], ids[:t])
+	if err != nil {
+		return nil, err
+	}
+	if !gpk.VerifySignature(msg, gsig) {
+		return nil, fmt.Errorf("invalid group signature")
+	}
+	for _, id := range ids[:t] {
+		v.Signers = append(v.Signers, id.GetHexString())
+	}
+	v.GroupPublicKey = hex.EncodeToString(gpk.Bytes())
+	v.GroupSignature = hex.EncodeToString(gsig.Signature)
+	return v, nil
+}
+
+func main() {
+	var (
+		seed      = flag.String("seed", "dexon", "seed to derive all keys")
+		n         = flag.Int("n", 4, "count of participants")
+		threshold = flag.Int("t", 0, "threshold, n/3+1 when not positive")
+		message   = flag.String("message", "dexon", "message to be signed")
+		output    = flag.String("out", "", "write test vector to `file`")
+	)
+	flag.Parse()
+	if *threshold <= 0 {
+		*threshold = *n/3 + 1
+	}
+	if *n <= 0 || *threshold > *n {
+		fatal(fmt.Errorf("invalid threshold %d of %d participants",
+			*threshold, *n))
+	}
+	v, err := generate([]byte(*seed), *n, *threshold,
+		crypto.Keccak256Hash([]byte(*message)))
+	if err != nil {
+		fatal(err)
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fatal(err)
+	}
+	b = append(b, '\n')
+	if *output == "" {
+		if _, err = os.Stdout.Write(b); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	if err = ioutil.WriteFile(*output, b, 0644); err != nil {
+		fatal(err)
+	}
+}
@@ -27,6 +27,7 @@ import (
 )
 
 var configFile = flag.String("config", "", "path to simulation config file")
+var reportFile = flag.String("report", "", "save report to `file`")
 
 func main() {
 	flag.Parse()
@@ -45,4 +46,9 @@ func main() {
 		panic(err)
 	}
 	server.Run()
+	if *reportFile != "" {
+		if err := server.Report().Save(*reportFile); err != nil {
+			panic(err)
+		}
+	}
 }
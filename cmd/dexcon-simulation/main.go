@@ -33,33 +33,47 @@ import (
 	"github.com/dexon-foundation/dexon-consensus/simulation/config"
 )
 
-var initialize = flag.Bool("init", false, "initialize config file")
-var configFile = flag.String("config", "", "path to simulation config file")
-var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to `file`")
-var memprofile = flag.String("memprofile", "", "write memory profile to `file`")
-var logfile = flag.String("log", "", "write log to `file`-nodeID.log")
+const usage = `Usage: dexcon-simulation <command> [arguments]
 
-func main() {
-	flag.Parse()
+Commands:
+    run      run a simulation with a config file, it's the default command
+    replay   run a simulation with the config saved in a report
+    diff     compare two reports
+    report   print a report
+`
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(1)
+}
+
+// profiledRun runs a simulation with profiling flags registered to fs.
+type profiledRun struct {
+	cpuprofile *string
+	memprofile *string
+	logfile    *string
+	output     *string
+}
+
+func newProfiledRun(fs *flag.FlagSet) *profiledRun {
+	return &profiledRun{
+		cpuprofile: fs.String(
+			"cpuprofile", "", "write cpu profile to `file`"),
+		memprofile: fs.String(
+			"memprofile", "", "write memory profile to `file`"),
+		logfile: fs.String("log", "", "write log to `file`-nodeID.log"),
+		output:  fs.String("report", "", "save report to `file`"),
+	}
+}
+
+func (r *profiledRun) run(cfg *config.Config) {
 	rand.Seed(time.Now().UnixNano())
 	// Supports runtime pprof monitoring.
 	go func() {
 		log.Println(http.ListenAndServe("localhost:6060", nil))
 	}()
-	if *configFile == "" {
-		fmt.Fprintln(os.Stderr, "error: no configuration file specified")
-		os.Exit(1)
-	}
-	if *initialize {
-		if err := config.GenerateDefault(*configFile); err != nil {
-			fmt.Fprintf(os.Stderr, "error: %s", err)
-			os.Exit(1)
-		}
-		//os.Exit(0)
-	}
-
-	if *cpuprofile != "" {
-		f, err := os.Create(*cpuprofile)
+	if *r.cpuprofile != "" {
+		f, err := os.Create(*r.cpuprofile)
 		if err != nil {
 			log.Fatal("could not create CPU profile: ", err)
 		}
@@ -69,14 +83,15 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	cfg, err := config.Read(*configFile)
-	if err != nil {
-		panic(err)
+	report := simulation.Run(cfg, *r.logfile)
+	if *r.output != "" {
+		if err := report.Save(*r.output); err != nil {
+			fatal(err)
+		}
 	}
-	simulation.Run(cfg, *logfile)
 
-	if *memprofile != "" {
-		f, err := os.Create(*memprofile)
+	if *r.memprofile != "" {
+		f, err := os.Create(*r.memprofile)
 		if err != nil {
 			log.Fatal("could not create memory profile: ", err)
 		}
@@ -88,3 +103,92 @@ func main() {
 		f.Close()
 	}
 }
+
+func runCmd(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	initialize := fs.Bool("init", false, "initialize config file")
+	configFile := fs.String("config", "", "path to simulation config file")
+	r := newProfiledRun(fs)
+	// #nosec G104
+	fs.Parse(args)
+	if *configFile == "" {
+		fatal(fmt.Errorf("no configuration file specified"))
+	}
+	if *initialize {
+		if err := config.GenerateDefault(*configFile); err != nil {
+			fatal(err)
+		}
+	}
+	cfg, err := config.Read(*configFile)
+	if err != nil {
+		panic(err)
+	}
+	r.run(cfg)
+}
+
+func replayCmd(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	input := fs.String("from", "", "replay the config saved in report `file`")
+	r := newProfiledRun(fs)
+	// #nosec G104
+	fs.Parse(args)
+	if *input == "" {
+		fatal(fmt.Errorf("no report file specified"))
+	}
+	report, err := simulation.LoadReport(*input)
+	if err != nil {
+		fatal(err)
+	}
+	if report.Config == nil {
+		fatal(fmt.Errorf("no config saved in report: %s", *input))
+	}
+	r.run(report.Config)
+}
+
+func diffCmd(args []string) {
+	if len(args) != 2 {
+		fatal(fmt.Errorf("usage: dexcon-simulation diff <base> <target>"))
+	}
+	base, err := simulation.LoadReport(args[0])
+	if err != nil {
+		fatal(err)
+	}
+	target, err := simulation.LoadReport(args[1])
+	if err != nil {
+		fatal(err)
+	}
+	simulation.PrintDeltas(os.Stdout, simulation.CompareReports(base, target))
+}
+
+func reportCmd(args []string) {
+	if len(args) != 1 {
+		fatal(fmt.Errorf("usage: dexcon-simulation report <file>"))
+	}
+	report, err := simulation.LoadReport(args[0])
+	if err != nil {
+		fatal(err)
+	}
+	report.Print(os.Stdout)
+}
+
+func main() {
+	args := os.Args[1:]
+	// Flags without command are passed to 'run' for compatibility.
+	if len(args) == 0 || args[0][0] == '-' {
+		runCmd(args)
+		return
+	}
+	switch args[0] {
+	case "run":
+		runCmd(args[1:])
+	case "replay":
+		replayCmd(args[1:])
+	case "diff":
+		diffCmd(args[1:])
+	case "report":
+		reportCmd(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+}
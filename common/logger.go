@@ -132,3 +132,63 @@ func (logger *CustomLogger) Warn(msg string, ctx ...interface{}) {
 func (logger *CustomLogger) Error(msg string, ctx ...interface{}) {
 	logger.logger.Println(composeVargs(msg, ctx)...)
 }
+
+// LogLevel is the severity of logs.
+type LogLevel int
+
+// Log levels, from the least severe one.
+const (
+	LogLevelTrace LogLevel = iota
+	LogLevelDebug
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// LevelLogger drops logs less severe than a level, and passes others to
+// another logger.
+type LevelLogger struct {
+	logger Logger
+	level  LogLevel
+}
+
+// NewLevelLogger creates a new level logger.
+func NewLevelLogger(logger Logger, level LogLevel) *LevelLogger {
+	return &LevelLogger{
+		logger: logger,
+		level:  level,
+	}
+}
+
+// Trace implements Logger interface.
+func (logger *LevelLogger) Trace(msg string, ctx ...interface{}) {
+	if logger.level <= LogLevelTrace {
+		logger.logger.Trace(msg, ctx...)
+	}
+}
+
+// Debug implements Logger interface.
+func (logger *LevelLogger) Debug(msg string, ctx ...interface{}) {
+	if logger.level <= LogLevelDebug {
+		logger.logger.Debug(msg, ctx...)
+	}
+}
+
+// Info implements Logger interface.
+func (logger *LevelLogger) Info(msg string, ctx ...interface{}) {
+	if logger.level <= LogLevelInfo {
+		logger.logger.Info(msg, ctx...)
+	}
+}
+
+// Warn implements Logger interface.
+func (logger *LevelLogger) Warn(msg string, ctx ...interface{}) {
+	if logger.level <= LogLevelWarn {
+		logger.logger.Warn(msg, ctx...)
+	}
+}
+
+// Error implements Logger interface.
+func (logger *LevelLogger) Error(msg string, ctx ...interface{}) {
+	logger.logger.Error(msg, ctx...)
+}
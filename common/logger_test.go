@@ -0,0 +1,47 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LoggerTestSuite struct {
+	suite.Suite
+}
+
+func (s *LoggerTestSuite) TestLevelLogger() {
+	rec := &recordLogger{}
+	logger := NewLevelLogger(rec, LogLevelInfo)
+	logger.Trace("trace", "key", 1)
+	logger.Debug("debug", "key", 2)
+	logger.Info("info", "key", 3)
+	logger.Warn("warn", "key", 4)
+	logger.Error("error", "key", 5)
+	s.Require().Equal([]logRecord{
+		{"info", "info", []interface{}{"key", 3}},
+		{"warn", "warn", []interface{}{"key", 4}},
+		{"error", "error", []interface{}{"key", 5}},
+	}, rec.records)
+}
+
+func TestLogger(t *testing.T) {
+	suite.Run(t, new(LoggerTestSuite))
+}
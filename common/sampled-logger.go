@@ -0,0 +1,173 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxLogSamples is the count of distinct messages tracked by SampledLogger,
+// samples not logged in the latest interval are dropped when exceeded.
+const maxLogSamples = 1024
+
+type logSample struct {
+	level      string
+	msg        string
+	begin      time.Time
+	suppressed uint64
+}
+
+// SampledLogger is a decorator of Logger for hot paths, ex. errors when
+// processing messages from peers. For each message, the first occurrence in
+// an interval is logged, and the rest are counted and summarized when that
+// message is logged again in the next interval. Messages are distinguished by
+// level, message, and the value of the "error" key in context.
+type SampledLogger struct {
+	logger   Logger
+	interval time.Duration
+	lock     sync.Mutex
+	samples  map[string]*logSample
+	now      func() time.Time
+}
+
+// NewSampledLogger creates a SampledLogger logging to the given logger.
+func NewSampledLogger(logger Logger, interval time.Duration) *SampledLogger {
+	return &SampledLogger{
+		logger:   logger,
+		interval: interval,
+		samples:  make(map[string]*logSample),
+		now:      time.Now,
+	}
+}
+
+func sampleKey(level, msg string, ctx []interface{}) string {
+	for i := 0; i+1 < len(ctx); i += 2 {
+		if key, ok := ctx[i].(string); ok && key == "error" {
+			return fmt.Sprintf("%s|%s|%v", level, msg, ctx[i+1])
+		}
+	}
+	return level + "|" + msg
+}
+
+// sample returns true when the message should be logged, along with the
+// count of occurrences suppressed in the previous interval.
+func (l *SampledLogger) sample(level, msg string, ctx []interface{}) (
+	bool, uint64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := l.now()
+	key := sampleKey(level, msg, ctx)
+	s, exist := l.samples[key]
+	if exist && now.Sub(s.begin) < l.interval {
+		s.suppressed++
+		return false, 0
+	}
+	if !exist {
+		if len(l.samples) >= maxLogSamples {
+			l.purge(now)
+		}
+		s = &logSample{level: level, msg: msg}
+		l.samples[key] = s
+	}
+	suppressed := s.suppressed
+	s.begin, s.suppressed = now, 0
+	return true, suppressed
+}
+
+func (l *SampledLogger) purge(now time.Time) {
+	for key, s := range l.samples {
+		if now.Sub(s.begin) >= l.interval {
+			delete(l.samples, key)
+		}
+	}
+}
+
+func (l *SampledLogger) log(level, msg string, ctx []interface{}) {
+	ok, suppressed := l.sample(level, msg, ctx)
+	if !ok {
+		return
+	}
+	if suppressed > 0 {
+		ctx = append(ctx[:len(ctx):len(ctx)], "suppressed", suppressed)
+	}
+	l.write(level, msg, ctx)
+}
+
+func (l *SampledLogger) write(level, msg string, ctx []interface{}) {
+	switch level {
+	case "trace":
+		l.logger.Trace(msg, ctx...)
+	case "debug":
+		l.logger.Debug(msg, ctx...)
+	case "info":
+		l.logger.Info(msg, ctx...)
+	case "warn":
+		l.logger.Warn(msg, ctx...)
+	case "error":
+		l.logger.Error(msg, ctx...)
+	}
+}
+
+// Flush logs summaries of suppressed occurrences not logged yet.
+func (l *SampledLogger) Flush() {
+	var summaries []*logSample
+	func() {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		for _, s := range l.samples {
+			if s.suppressed > 0 {
+				summaries = append(summaries, &logSample{
+					level:      s.level,
+					msg:        s.msg,
+					suppressed: s.suppressed,
+				})
+				s.suppressed = 0
+			}
+		}
+	}()
+	for _, s := range summaries {
+		l.write(s.level, s.msg, []interface{}{"suppressed", s.suppressed})
+	}
+}
+
+// Trace implements Logger interface.
+func (l *SampledLogger) Trace(msg string, ctx ...interface{}) {
+	l.log("trace", msg, ctx)
+}
+
+// Debug implements Logger interface.
+func (l *SampledLogger) Debug(msg string, ctx ...interface{}) {
+	l.log("debug", msg, ctx)
+}
+
+// Info implements Logger interface.
+func (l *SampledLogger) Info(msg string, ctx ...interface{}) {
+	l.log("info", msg, ctx)
+}
+
+// Warn implements Logger interface.
+func (l *SampledLogger) Warn(msg string, ctx ...interface{}) {
+	l.log("warn", msg, ctx)
+}
+
+// Error implements Logger interface.
+func (l *SampledLogger) Error(msg string, ctx ...interface{}) {
+	l.log("error", msg, ctx)
+}
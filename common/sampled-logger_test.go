@@ -0,0 +1,119 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type logRecord struct {
+	level string
+	msg   string
+	ctx   []interface{}
+}
+
+// recordLogger keeps logs in memory.
+type recordLogger struct {
+	records []logRecord
+}
+
+func (l *recordLogger) Trace(msg string, ctx ...interface{}) {
+	l.records = append(l.records, logRecord{"trace", msg, ctx})
+}
+
+func (l *recordLogger) Debug(msg string, ctx ...interface{}) {
+	l.records = append(l.records, logRecord{"debug", msg, ctx})
+}
+
+func (l *recordLogger) Info(msg string, ctx ...interface{}) {
+	l.records = append(l.records, logRecord{"info", msg, ctx})
+}
+
+func (l *recordLogger) Warn(msg string, ctx ...interface{}) {
+	l.records = append(l.records, logRecord{"warn", msg, ctx})
+}
+
+func (l *recordLogger) Error(msg string, ctx ...interface{}) {
+	l.records = append(l.records, logRecord{"error", msg, ctx})
+}
+
+type SampledLoggerTestSuite struct {
+	suite.Suite
+}
+
+func (s *SampledLoggerTestSuite) TestSampling() {
+	var (
+		req    = s.Require()
+		rec    = &recordLogger{}
+		now    = time.Now()
+		logger = NewSampledLogger(rec, time.Second)
+		err1   = errors.New("error 1")
+		err2   = errors.New("error 2")
+	)
+	logger.now = func() time.Time { return now }
+	for i := 0; i < 100; i++ {
+		logger.Error("failed", "index", i, "error", err1)
+	}
+	logger.Error("failed", "error", err2)
+	logger.Warn("failed", "error", err1)
+	req.Len(rec.records, 3)
+	req.Equal(logRecord{"error", "failed", []interface{}{
+		"index", 0, "error", err1}}, rec.records[0])
+	req.Equal("error", rec.records[1].level)
+	req.Equal("warn", rec.records[2].level)
+	// The count of suppressed logs is attached in next interval.
+	now = now.Add(time.Second)
+	logger.Error("failed", "error", err1)
+	req.Len(rec.records, 4)
+	req.Equal(logRecord{"error", "failed", []interface{}{
+		"error", err1, "suppressed", uint64(99)}}, rec.records[3])
+	logger.Error("failed", "error", err1)
+	logger.Error("failed", "error", err2)
+	req.Len(rec.records, 5)
+	// Flush summaries of suppressed logs.
+	logger.Flush()
+	req.Len(rec.records, 6)
+	req.Equal(logRecord{"error", "failed", []interface{}{
+		"suppressed", uint64(1)}}, rec.records[5])
+	logger.Flush()
+	req.Len(rec.records, 6)
+}
+
+func (s *SampledLoggerTestSuite) TestPurge() {
+	var (
+		rec    = &recordLogger{}
+		now    = time.Now()
+		logger = NewSampledLogger(rec, time.Second)
+	)
+	logger.now = func() time.Time { return now }
+	for i := 0; i < maxLogSamples; i++ {
+		logger.Info("message", "error", i)
+	}
+	s.Require().Len(logger.samples, maxLogSamples)
+	now = now.Add(time.Second)
+	logger.Info("message", "error", maxLogSamples)
+	s.Require().Len(logger.samples, 1)
+}
+
+func TestSampledLogger(t *testing.T) {
+	suite.Run(t, new(SampledLoggerTestSuite))
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// AgreementMetrics summarizes the activity of BA since the node started.
+type AgreementMetrics struct {
+	// Decided is the count of positions confirmed by votes.
+	Decided uint64
+	// Synced is the count of positions confirmed by agreement results or
+	// finalized blocks from peers.
+	Synced uint64
+	// Periods maps the count of periods BA takes to confirm a position by
+	// votes to the count of such positions, 1 means confirmed in the initial
+	// period.
+	Periods map[uint64]uint64
+	// Votes is the count of votes received by type, duplicated votes are not
+	// counted.
+	Votes map[types.VoteType]uint64
+	// CandidateBlocks is the count of candidate blocks of all positions.
+	CandidateBlocks uint64
+}
+
+// MeanPeriods returns the average count of periods to confirm a position by
+// votes.
+func (m AgreementMetrics) MeanPeriods() float64 {
+	if m.Decided == 0 {
+		return 0
+	}
+	var total uint64
+	for periods, count := range m.Periods {
+		total += periods * count
+	}
+	return float64(total) / float64(m.Decided)
+}
+
+type agreementMetricsTracker struct {
+	lock    sync.Mutex
+	metrics AgreementMetrics
+}
+
+func newAgreementMetricsTracker() *agreementMetricsTracker {
+	return &agreementMetricsTracker{
+		metrics: AgreementMetrics{
+			Periods: make(map[uint64]uint64),
+			Votes:   make(map[types.VoteType]uint64),
+		},
+	}
+}
+
+func (t *agreementMetricsTracker) vote(voteType types.VoteType) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.metrics.Votes[voteType]++
+}
+
+func (t *agreementMetricsTracker) candidate() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.metrics.CandidateBlocks++
+}
+
+// decide records a position confirmed by votes of a period.
+func (t *agreementMetricsTracker) decide(period uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.metrics.Decided++
+	if period < baInitialPeriod {
+		period = baInitialPeriod
+	}
+	t.metrics.Periods[period-baInitialPeriod+1]++
+}
+
+func (t *agreementMetricsTracker) sync() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.metrics.Synced++
+}
+
+func (t *agreementMetricsTracker) snapshot() (m AgreementMetrics) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	m = t.metrics
+	m.Periods = make(map[uint64]uint64, len(t.metrics.Periods))
+	for k, v := range t.metrics.Periods {
+		m.Periods[k] = v
+	}
+	m.Votes = make(map[types.VoteType]uint64, len(t.metrics.Votes))
+	for k, v := range t.metrics.Votes {
+		m.Votes[k] = v
+	}
+	return
+}
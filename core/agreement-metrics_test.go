@@ -0,0 +1,60 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type AgreementMetricsTestSuite struct {
+	suite.Suite
+}
+
+func (s *AgreementMetricsTestSuite) TestTracker() {
+	t := newAgreementMetricsTracker()
+	s.Require().Equal(float64(0), t.snapshot().MeanPeriods())
+	t.vote(types.VotePreCom)
+	t.vote(types.VotePreCom)
+	t.vote(types.VoteCom)
+	t.candidate()
+	t.decide(baInitialPeriod)
+	t.decide(baInitialPeriod)
+	t.decide(baInitialPeriod + 3)
+	t.sync()
+	m := t.snapshot()
+	s.Require().Equal(uint64(3), m.Decided)
+	s.Require().Equal(uint64(1), m.Synced)
+	s.Require().Equal(map[uint64]uint64{1: 2, 4: 1}, m.Periods)
+	s.Require().Equal(uint64(2), m.Votes[types.VotePreCom])
+	s.Require().Equal(uint64(1), m.Votes[types.VoteCom])
+	s.Require().Equal(uint64(1), m.CandidateBlocks)
+	s.Require().Equal(float64(2), m.MeanPeriods())
+	// Snapshots are not affected by later updates.
+	t.vote(types.VoteCom)
+	t.decide(baInitialPeriod)
+	s.Require().Equal(uint64(1), m.Votes[types.VoteCom])
+	s.Require().Equal(uint64(2), m.Periods[1])
+}
+
+func TestAgreementMetrics(t *testing.T) {
+	suite.Run(t, new(AgreementMetricsTestSuite))
+}
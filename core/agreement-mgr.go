@@ -27,6 +27,7 @@ import (
 	lru "github.com/hashicorp/golang-lru"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
@@ -49,7 +50,7 @@ const settingLimit = 3
 func genValidLeader(
 	mgr *agreementMgr) validLeaderFn {
 	return func(block *types.Block, crs common.Hash) (bool, error) {
-		if block.Timestamp.After(time.Now()) {
+		if block.Timestamp.After(mgr.con.opts.clock()) {
 			return false, nil
 		}
 		if block.Position.Round >= DKGDelayRound {
@@ -68,8 +69,10 @@ func genValidLeader(
 		}
 		if err := mgr.bcModule.sanityCheck(block); err != nil {
 			if err == ErrRetrySanityCheckLater {
+				mgr.con.opts.incCounter("sanity-check-retries", 1)
 				return false, nil
 			}
+			mgr.con.opts.incCounter(sanityCheckFailureCounter(err), 1)
 			return false, err
 		}
 		mgr.logger.Debug("Calling Application.VerifyBlock", "block", block)
@@ -87,15 +90,19 @@ func genValidLeader(
 type agreementMgrConfig struct {
 	utils.RoundBasedConfig
 
-	notarySetSize uint32
-	lambdaBA      time.Duration
-	crs           common.Hash
+	notarySetSize     uint32
+	lambdaBA          time.Duration
+	baTimeoutGrowth   uint32
+	baTimeoutMaxScale uint32
+	crs               common.Hash
 }
 
 func (c *agreementMgrConfig) from(
 	round uint64, config *types.Config, crs common.Hash) {
 	c.notarySetSize = config.NotarySetSize
 	c.lambdaBA = config.LambdaBA
+	c.baTimeoutGrowth = config.BATimeoutGrowth
+	c.baTimeoutMaxScale = config.BATimeoutMaxScale
 	c.crs = crs
 	c.SetupRoundBasedFields(round, config)
 }
@@ -122,8 +129,10 @@ type agreementMgr struct {
 	ID                types.NodeID
 	app               Application
 	gov               Governance
+	missReporter      LeaderMissReporter
 	network           Network
 	logger            common.Logger
+	sampledLogger     common.Logger
 	cache             *utils.NodeSetCache
 	signer            *utils.Signer
 	bcModule          *blockChain
@@ -133,6 +142,7 @@ type agreementMgr struct {
 	recv              *consensusBAReceiver
 	processedBAResult map[types.Position]struct{}
 	voteFilter        *utils.VoteFilter
+	leaderMisses      *leaderMissTracker
 	settingCache      *lru.Cache
 	curRoundSetting   *baRoundSetting
 	waitGroup         sync.WaitGroup
@@ -147,14 +157,17 @@ func newAgreementMgr(con *Consensus) (mgr *agreementMgr, err error) {
 		ID:                con.ID,
 		app:               con.app,
 		gov:               con.gov,
+		missReporter:      con.govExt.missReporter,
 		network:           con.network,
 		logger:            con.logger,
+		sampledLogger:     con.sampledLogger,
 		cache:             con.nodeSetCache,
 		signer:            con.signer,
 		bcModule:          con.bcModule,
 		ctx:               con.ctx,
 		processedBAResult: make(map[types.Position]struct{}, maxResultCache),
 		voteFilter:        utils.NewVoteFilter(),
+		leaderMisses:      newLeaderMissTracker(),
 		settingCache:      settingCache,
 	}
 	mgr.recv = &consensusBAReceiver{
@@ -166,12 +179,14 @@ func newAgreementMgr(con *Consensus) (mgr *agreementMgr, err error) {
 
 func (mgr *agreementMgr) prepare() {
 	round := mgr.bcModule.tipRound()
+	leader := newLeaderSelector(genValidLeader(mgr), mgr.logger)
 	agr := newAgreement(
 		mgr.ID,
 		mgr.recv,
-		newLeaderSelector(genValidLeader(mgr), mgr.logger),
+		leader,
 		mgr.signer,
-		mgr.logger)
+		mgr.sampledLogger)
+	agr.registry = mgr.cache.Registry()
 	setting := mgr.generateSetting(round)
 	if setting == nil {
 		mgr.logger.Warn("Unable to prepare init setting", "round", round)
@@ -216,6 +231,11 @@ func (mgr *agreementMgr) calcLeader(
 	dkgSet map[types.NodeID]struct{},
 	crs common.Hash, pos types.Position) (
 	types.NodeID, error) {
+	// Leaders of rounds selected by VRF are unknown until proposals are
+	// revealed, the fast path of the precomputed leader is skipped.
+	if mgr.vrfRound(pos.Round) {
+		return types.NodeID{}, nil
+	}
 	nodeSet := types.NewNodeSetFromMap(dkgSet)
 	leader := nodeSet.GetSubSet(1, types.NewNodeLeaderTarget(
 		crs, pos.Height))
@@ -225,6 +245,66 @@ func (mgr *agreementMgr) calcLeader(
 	return types.NodeID{}, ErrNoValidLeader
 }
 
+// vrfRound checks if leaders of a round are only known when proposals are
+// revealed, instead of being precomputed from CRS.
+func (mgr *agreementMgr) vrfRound(round uint64) bool {
+	vrfGov := mgr.con.govExt.vrfGov
+	return round >= DKGDelayRound &&
+		vrfGov != nil && vrfGov.VRFLeaderSelection(round)
+}
+
+// checkLeaderProposal checks if the leader of current position of BA delivered
+// its proposal in time.
+func (mgr *agreementMgr) checkLeaderProposal() {
+	pos, leader, restarted, received := mgr.baModule.leaderProposal()
+	if isStop(pos) {
+		return
+	}
+	config := mgr.config(pos.Round)
+	if config == nil {
+		return
+	}
+	if mgr.leaderMisses.check(
+		pos, leader, restarted, received, config.lambdaBA) {
+		mgr.logger.Debug("Leader missed its proposal",
+			"position", pos, "leader", leader)
+	}
+}
+
+// restoreAgreementState restores the state persisted before restarting when
+// BA restarts at the same position.
+func (mgr *agreementMgr) restoreAgreementState(agr *agreement) {
+	store, ok := mgr.con.db.(db.AgreementStateStore)
+	if !ok {
+		return
+	}
+	state, err := store.GetAgreementState()
+	if err != nil {
+		if err != db.ErrAgreementStateDoesNotExist {
+			mgr.logger.Error("Failed to load agreement state", "error", err)
+		}
+		return
+	}
+	if agr.restoreState(&state) {
+		mgr.logger.Info("Restored agreement state",
+			"position", state.Position,
+			"period", state.Period,
+			"votes", len(state.Votes))
+	}
+}
+
+// reportLeaderMisses reports misses of leaders until a round to governance.
+func (mgr *agreementMgr) reportLeaderMisses(round uint64) {
+	misses := mgr.leaderMisses.purge(round)
+	if mgr.missReporter == nil {
+		return
+	}
+	for r, m := range misses {
+		mgr.logger.Info("Reporting leader misses", "round", r, "misses", m)
+		mgr.missReporter.ReportLeaderMiss(r, m)
+	}
+}
+
 func (mgr *agreementMgr) config(round uint64) *agreementMgrConfig {
 	mgr.lock.RLock()
 	defer mgr.lock.RUnlock()
@@ -416,6 +496,11 @@ func (mgr *agreementMgr) generateSetting(round uint64) *baRoundSetting {
 	if curConfig == nil {
 		return nil
 	}
+	notarySet, err := mgr.cache.GetNotarySet(round)
+	if err != nil {
+		mgr.logger.Error("Failed to get notarySet", "round", round, "error", err)
+		return nil
+	}
 	var dkgSet map[types.NodeID]struct{}
 	if round >= DKGDelayRound {
 		_, qualidifed, err := typesDKG.CalcQualifyNodes(
@@ -430,19 +515,20 @@ func (mgr *agreementMgr) generateSetting(round uint64) *baRoundSetting {
 		dkgSet = qualidifed
 	}
 	if len(dkgSet) == 0 {
-		var err error
-		dkgSet, err = mgr.cache.GetNotarySet(round)
-		if err != nil {
-			mgr.logger.Error("Failed to get notarySet", "round", round, "error", err)
-			return nil
-		}
+		dkgSet = notarySet
 	}
 	setting := &baRoundSetting{
-		crs:    curConfig.crs,
-		dkgSet: dkgSet,
-		round:  round,
-		threshold: utils.GetBAThreshold(&types.Config{
-			NotarySetSize: curConfig.notarySetSize}),
+		crs:       curConfig.crs,
+		dkgSet:    dkgSet,
+		round:     round,
+		threshold: baThreshold(notarySet),
+	}
+	if len(notarySet) != int(curConfig.notarySetSize) {
+		mgr.logger.Info("Notary set is smaller than configured",
+			"round", round,
+			"configured", curConfig.notarySetSize,
+			"actual", len(notarySet),
+			"threshold", setting.threshold)
 	}
 	mgr.settingCache.Add(round, setting)
 	return setting
@@ -453,7 +539,7 @@ func (mgr *agreementMgr) runBA(initRound uint64) {
 	var (
 		currentRound uint64
 		nextRound    = initRound
-		curConfig    = mgr.config(initRound)
+		curConfig    *agreementMgrConfig
 		setting      = &baRoundSetting{}
 		tickDuration time.Duration
 		ticker       Ticker
@@ -485,15 +571,24 @@ func (mgr *agreementMgr) runBA(initRound uint64) {
 				"ID", mgr.ID,
 				"round", nextRound)
 		}
-		// Setup ticker
+		// Setup ticker, it's reconfigured in place when possible, the new
+		// interval takes effect when the agreement of the first position of
+		// this round restarts the ticker.
+		curConfig = mgr.config(nextRound)
 		if tickDuration != curConfig.lambdaBA {
-			if ticker != nil {
-				ticker.Stop()
+			if t, ok := ticker.(IntervalTicker); ok {
+				t.SetInterval(curConfig.lambdaBA)
+			} else {
+				if ticker != nil {
+					ticker.Stop()
+				}
+				ticker = newTicker(mgr.gov, nextRound, TickerBA)
 			}
-			ticker = newTicker(mgr.gov, nextRound, TickerBA)
 			tickDuration = curConfig.lambdaBA
 		}
 		setting.ticker = ticker
+		mgr.baModule.setTimeoutConfig(
+			curConfig.baTimeoutGrowth, curConfig.baTimeoutMaxScale)
 		return
 	}
 Loop:
@@ -530,6 +625,11 @@ Loop:
 				"nodeID", mgr.ID)
 			break Loop
 		}
+		if mgr.recv.isNotary {
+			mgr.reportLeaderMisses(currentRound)
+		} else {
+			mgr.leaderMisses.purge(currentRound)
+		}
 	}
 }
 
@@ -539,6 +639,10 @@ func (mgr *agreementMgr) baRoutineForOneRound(
 	recv := mgr.recv
 	oldPos := agr.agreementID()
 	restart := func(restartPos types.Position) (breakLoop bool, err error) {
+		if recv.isNotary && !isStop(restartPos) &&
+			!restartPos.Older(agr.agreementID()) {
+			mgr.checkLeaderProposal()
+		}
 		if !isStop(restartPos) {
 			if restartPos.Height+1 >= mgr.config(setting.round).RoundEndHeight() {
 				for {
@@ -551,7 +655,8 @@ func (mgr *agreementMgr) baRoutineForOneRound(
 					if tipRound > setting.round {
 						break
 					} else {
-						mgr.logger.Debug("Waiting blockChain to change round...",
+						mgr.sampledLogger.Debug(
+							"Waiting blockChain to change round...",
 							"curRound", setting.round,
 							"tipRound", tipRound)
 					}
@@ -586,7 +691,7 @@ func (mgr *agreementMgr) baRoutineForOneRound(
 					break
 				}
 			}
-			mgr.logger.Debug("BlockChain not ready!!!",
+			mgr.sampledLogger.Debug("BlockChain not ready!!!",
 				"old", oldPos, "restart", restartPos, "next", nextHeight)
 			time.Sleep(100 * time.Millisecond)
 		}
@@ -603,6 +708,7 @@ func (mgr *agreementMgr) baRoutineForOneRound(
 		time.Sleep(nextTime.Sub(time.Now()))
 		setting.ticker.Restart()
 		agr.restart(setting.dkgSet, setting.threshold, nextPos, leader, setting.crs)
+		mgr.restoreAgreementState(agr)
 		return
 	}
 Loop:
@@ -654,9 +760,27 @@ Loop:
 		}
 		if agr.pullVotes() {
 			pos := agr.agreementID()
+			// Votes of this node might be lost, they are not re-broadcasted
+			// by selfRebroadcaster after attempts are exhausted.
+			if votes := agr.latestSentVotes(); len(votes) > 0 {
+				mgr.logger.Debug("Rebroadcast votes on period timeout",
+					"position", pos,
+					"count", len(votes))
+				for _, v := range votes {
+					mgr.network.BroadcastVote(v)
+				}
+				mgr.con.opts.incCounter(
+					"period-rebroadcast-votes", uint64(len(votes)))
+			}
 			mgr.logger.Debug("Calling Network.PullVotes for syncing votes",
 				"position", pos)
 			mgr.network.PullVotes(pos)
+			if puller, ok := mgr.network.(AgreementResultPuller); ok {
+				mgr.logger.Debug(
+					"Calling Network.PullAgreementResults for syncing BA",
+					"position", pos)
+				puller.PullAgreementResults([]types.Position{pos})
+			}
 		}
 		for i := 0; i < agr.clocks(); i++ {
 			// Priority select for agreement.done().
@@ -44,6 +44,28 @@ const (
 	stateSleep
 )
 
+func (s agreementStateType) String() string {
+	switch s {
+	case stateFast:
+		return "fast"
+	case stateFastVote:
+		return "fast-vote"
+	case stateInitial:
+		return "initial"
+	case statePreCommit:
+		return "pre-commit"
+	case stateCommit:
+		return "commit"
+	case stateForward:
+		return "forward"
+	case statePullVote:
+		return "pull-vote"
+	case stateSleep:
+		return "sleep"
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
 type agreementState interface {
 	state() agreementStateType
 	nextState() (agreementState, error)
@@ -67,6 +89,13 @@ func (s *fastState) nextState() (agreementState, error) {
 		defer s.a.lock.Unlock()
 		return s.a.isLeader
 	}() {
+		if s.a.recv.ProposeNullBlock() {
+			s.a.lock.Lock()
+			defer s.a.lock.Unlock()
+			s.a.recv.ProposeVote(
+				types.NewVote(types.VoteFast, types.NullBlockHash, s.a.period))
+			return newFastVoteState(s.a), nil
+		}
 		hash := s.a.recv.ProposeBlock()
 		if hash != types.NullBlockHash {
 			s.a.lock.Lock()
@@ -110,7 +139,10 @@ func (s *initialState) nextState() (agreementState, error) {
 		return !s.a.isLeader
 	}() {
 		// Leader already proposed block in fastState.
-		hash := s.a.recv.ProposeBlock()
+		hash := types.NullBlockHash
+		if !s.a.recv.ProposeNullBlock() {
+			hash = s.a.recv.ProposeBlock()
+		}
 		s.a.lock.Lock()
 		defer s.a.lock.Unlock()
 		s.a.recv.ProposeVote(types.NewVote(types.VoteInit, hash, s.a.period))
@@ -62,6 +62,8 @@ func (r *agreementStateTestReceiver) ConfirmBlock(block common.Hash,
 	r.s.confirmChan <- block
 }
 
+func (r *agreementStateTestReceiver) ProposeNullBlock() bool { return false }
+
 func (r *agreementStateTestReceiver) PullBlocks(common.Hashes) {}
 
 func (r *agreementStateTestReceiver) ReportForkVote(v1, v2 *types.Vote)   {}
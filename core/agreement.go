@@ -20,15 +20,21 @@ package core
 import (
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
 )
 
+// baInitialPeriod is the first period of BA, period 1 is reserved for the
+// votes of fast states.
+const baInitialPeriod = 2
+
 // closedchan is a reusable closed channel.
 var closedchan = make(chan struct{})
 
@@ -79,6 +85,9 @@ func newVoteListMap() []map[types.NodeID]*types.Vote {
 type agreementReceiver interface {
 	ProposeVote(vote *types.Vote)
 	ProposeBlock() common.Hash
+	// ProposeNullBlock returns true when this node has nothing to propose and
+	// prefers the null block, which is cheaper than building a block.
+	ProposeNullBlock() bool
 	// ConfirmBlock is called with lock hold. User can safely use all data within
 	// agreement module.
 	ConfirmBlock(common.Hash, map[types.NodeID]*types.Vote)
@@ -109,12 +118,39 @@ type agreementData struct {
 	lockIter     uint64
 	period       uint64
 	requiredVote int
+	timeout      *baTimeout
 	votes        map[uint64][]map[types.NodeID]*types.Vote
 	lock         sync.RWMutex
 	blocks       map[types.NodeID]*types.Block
 	blocksLock   sync.Mutex
 }
 
+// VoteTally is the count of votes of one type in one period, and notaries not
+// voting it yet.
+type VoteTally struct {
+	Period  uint64
+	Type    types.VoteType
+	Count   int
+	Missing types.NodeIDs
+}
+
+// AgreementStatus is the snapshot of BA at its current position, it's for
+// debugging why a position is not confirmed.
+type AgreementStatus struct {
+	Position     types.Position
+	Leader       types.NodeID
+	State        string
+	Period       uint64
+	RequiredVote int
+	Confirmed    bool
+	// Thresholds are derived from the notary set selected at this position,
+	// which might be smaller than the configured one.
+	Thresholds VoteThresholds
+	// Tallies are sorted by period then type, types without any vote are
+	// skipped.
+	Tallies []VoteTally
+}
+
 // agreement is the agreement protocal describe in the Crypto Shuffle Algorithm.
 type agreement struct {
 	state                  agreementState
@@ -125,13 +161,19 @@ type agreement struct {
 	hasVoteFast            bool
 	hasOutput              bool
 	lock                   sync.RWMutex
-	pendingBlock           []pendingBlock
-	pendingVote            []pendingVote
+	pending                *pendingCache
 	pendingAgreementResult map[types.Position]*types.AgreementResult
 	candidateBlock         map[common.Hash]*types.Block
 	fastForward            chan uint64
 	signer                 *utils.Signer
+	registry               utils.NodeIdentityRegistry
 	logger                 common.Logger
+	restartTime            time.Time
+	leaderBlockTime        time.Time
+	metrics                *agreementMetricsTracker
+	// sentVotes are votes proposed by this node at current position.
+	sentVotes []*types.Vote
+	sentLock  sync.Mutex
 }
 
 // newAgreement creates a agreement instance.
@@ -143,16 +185,19 @@ func newAgreement(
 	logger common.Logger) *agreement {
 	agreement := &agreement{
 		data: &agreementData{
-			recv:   recv,
-			ID:     ID,
-			leader: leader,
+			recv:    recv,
+			ID:      ID,
+			leader:  leader,
+			timeout: newBATimeout(),
 		},
 		aID:                    &atomic.Value{},
+		pending:                newPendingCache(maxPendingMessages),
 		pendingAgreementResult: make(map[types.Position]*types.AgreementResult),
 		candidateBlock:         make(map[common.Hash]*types.Block),
 		fastForward:            make(chan uint64, 1),
 		signer:                 signer,
 		logger:                 logger,
+		metrics:                newAgreementMetricsTracker(),
 	}
 	agreement.stop()
 	return agreement
@@ -180,7 +225,7 @@ func (a *agreement) restart(
 		defer a.data.blocksLock.Unlock()
 		a.data.votes = make(map[uint64][]map[types.NodeID]*types.Vote)
 		a.data.votes[1] = newVoteListMap()
-		a.data.period = 2
+		a.data.period = baInitialPeriod
 		a.data.blocks = make(map[types.NodeID]*types.Block)
 		a.data.requiredVote = threshold
 		a.data.leader.restart(crs)
@@ -197,6 +242,13 @@ func (a *agreement) restart(
 		a.state = newFastState(a.data)
 		a.notarySet = notarySet
 		a.candidateBlock = make(map[common.Hash]*types.Block)
+		a.restartTime = time.Now().UTC()
+		a.leaderBlockTime = time.Time{}
+		func() {
+			a.sentLock.Lock()
+			defer a.sentLock.Unlock()
+			a.sentVotes = nil
+		}()
 		a.aID.Store(struct {
 			pos    types.Position
 			leader types.NodeID
@@ -228,43 +280,21 @@ func (a *agreement) restart(
 
 	expireTime := time.Now().Add(-10 * time.Second)
 	replayBlock := make([]*types.Block, 0)
-	func() {
-		a.lock.Lock()
-		defer a.lock.Unlock()
-		newPendingBlock := make([]pendingBlock, 0)
-		for _, pending := range a.pendingBlock {
-			if aID.Newer(pending.block.Position) {
-				continue
-			} else if pending.block.Position == aID {
-				if result == nil ||
-					result.Position.Round < DKGDelayRound ||
-					result.BlockHash == pending.block.Hash {
-					replayBlock = append(replayBlock, pending.block)
-				}
-			} else if pending.receivedTime.After(expireTime) {
-				newPendingBlock = append(newPendingBlock, pending)
-			}
-		}
-		a.pendingBlock = newPendingBlock
-	}()
-
 	replayVote := make([]*types.Vote, 0)
 	func() {
 		a.lock.Lock()
 		defer a.lock.Unlock()
-		newPendingVote := make([]pendingVote, 0)
-		for _, pending := range a.pendingVote {
-			if aID.Newer(pending.vote.Position) {
-				continue
-			} else if pending.vote.Position == aID {
-				if result == nil || result.Position.Round < DKGDelayRound {
-					replayVote = append(replayVote, pending.vote)
-				}
-			} else if pending.receivedTime.After(expireTime) {
-				newPendingVote = append(newPendingVote, pending)
+		votes, blocks := a.pending.pop(aID, expireTime)
+		for _, block := range blocks {
+			if result == nil ||
+				result.Position.Round < DKGDelayRound ||
+				result.BlockHash == block.Hash {
+				replayBlock = append(replayBlock, block)
 			}
 		}
-		a.pendingVote = newPendingVote
+		if result == nil || result.Position.Round < DKGDelayRound {
+			replayVote = append(replayVote, votes...)
+		}
 	}()
 
 	for _, block := range replayBlock {
@@ -305,21 +335,21 @@ func isStop(aID types.Position) bool {
 func (a *agreement) clocks() int {
 	a.data.lock.RLock()
 	defer a.data.lock.RUnlock()
-	scale := int(a.data.period) - 1
+	scale := a.data.timeout.scale(a.data.period)
 	if a.state.state() == stateForward {
 		scale = 1
 	}
-	if scale < 1 {
-		// just in case.
-		scale = 1
-	}
-	// 10 is a magic number derived from many years of experience.
-	if scale > 10 {
-		scale = 10
-	}
 	return a.state.clocks() * scale
 }
 
+// setTimeoutConfig sets how clocks waited in each state grow when periods
+// fail, see types.Config for details.
+func (a *agreement) setTimeoutConfig(growth, maxScale uint32) {
+	a.data.lock.Lock()
+	defer a.data.lock.Unlock()
+	a.data.timeout.setConfig(growth, maxScale)
+}
+
 // pullVotes returns if current agreement requires more votes to continue.
 func (a *agreement) pullVotes() bool {
 	a.data.lock.RLock()
@@ -337,6 +367,22 @@ func (a *agreement) agreementID() types.Position {
 	}).pos
 }
 
+// restartTimeNoLock returns the time when BA is restarted at current position.
+func (a *agreement) restartTimeNoLock() time.Time {
+	return a.restartTime
+}
+
+// leaderProposal returns the current position and its leader, with the time
+// when BA is restarted and the time when the block, or the null fast vote,
+// from leader is received. The received time is zero if no proposal from
+// leader is received yet.
+func (a *agreement) leaderProposal() (
+	pos types.Position, leader types.NodeID, restarted, received time.Time) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.agreementID(), a.leader(), a.restartTime, a.leaderBlockTime
+}
+
 // leader returns the current leader.
 func (a *agreement) leader() types.NodeID {
 	return a.aID.Load().(struct {
@@ -361,7 +407,7 @@ func (a *agreement) sanityCheck(vote *types.Vote) error {
 	if vote.Type >= types.MaxVoteType {
 		return ErrInvalidVote
 	}
-	ok, err := utils.VerifyVoteSignature(vote)
+	ok, err := utils.VerifyVoteSignature(vote, a.registry)
 	if err != nil {
 		return err
 	}
@@ -405,6 +451,95 @@ func (a *agreement) prepareVote(vote *types.Vote) (err error) {
 	return
 }
 
+// recordSentVote records a vote proposed by this node, and returns the state
+// to be persisted before broadcasting it. When a vote of the same type and
+// period is sent, ex. before restarting, that vote is returned instead and
+// should be broadcasted again. It should be called with agreementData.lock
+// held, which is the case for agreementReceiver.ProposeVote.
+func (a *agreement) recordSentVote(vote *types.Vote) (
+	*types.Vote, *db.AgreementState) {
+	a.sentLock.Lock()
+	defer a.sentLock.Unlock()
+	for _, sent := range a.sentVotes {
+		if sent.Position == vote.Position && sent.Type == vote.Type &&
+			sent.Period == vote.Period {
+			return sent, nil
+		}
+	}
+	a.sentVotes = append(a.sentVotes, vote)
+	state := &db.AgreementState{
+		Position:  vote.Position,
+		Period:    a.data.period,
+		LockValue: a.data.lockValue,
+		LockIter:  a.data.lockIter,
+		Votes:     make([]types.Vote, 0, len(a.sentVotes)),
+	}
+	for _, sent := range a.sentVotes {
+		state.Votes = append(state.Votes, *sent)
+	}
+	return vote, state
+}
+
+// forgetSentVote removes a vote recorded by recordSentVote, it's called when
+// the state recording it fails to be persisted and the vote is not sent.
+func (a *agreement) forgetSentVote(vote *types.Vote) {
+	a.sentLock.Lock()
+	defer a.sentLock.Unlock()
+	for i, sent := range a.sentVotes {
+		if sent == vote {
+			a.sentVotes = append(a.sentVotes[:i], a.sentVotes[i+1:]...)
+			return
+		}
+	}
+}
+
+// latestSentVotes returns the vote of the latest period proposed by this node
+// at current position for each vote type.
+func (a *agreement) latestSentVotes() (votes []*types.Vote) {
+	a.sentLock.Lock()
+	defer a.sentLock.Unlock()
+	latest := make(map[types.VoteType]*types.Vote)
+	for _, v := range a.sentVotes {
+		if prev, exist := latest[v.Type]; !exist || v.Period > prev.Period {
+			latest[v.Type] = v
+		}
+	}
+	for t := types.VoteInit; t < types.MaxVoteType; t++ {
+		if v, exist := latest[t]; exist {
+			votes = append(votes, v)
+		}
+	}
+	return
+}
+
+// restoreState restores the period, locked value and votes sent by this node
+// from a persisted state of current position. Votes of types and periods
+// sent before are broadcasted again instead of proposing conflicting ones.
+func (a *agreement) restoreState(state *db.AgreementState) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if state.Position != a.agreementID() || a.hasOutput {
+		return false
+	}
+	a.data.lock.Lock()
+	defer a.data.lock.Unlock()
+	if state.Period > a.data.period {
+		a.data.setPeriod(state.Period)
+		a.state = newPreCommitState(a.data)
+	}
+	if state.LockIter > a.data.lockIter {
+		a.data.lockValue = state.LockValue
+		a.data.lockIter = state.LockIter
+	}
+	a.sentLock.Lock()
+	defer a.sentLock.Unlock()
+	a.sentVotes = make([]*types.Vote, 0, len(state.Votes))
+	for i := range state.Votes {
+		a.sentVotes = append(a.sentVotes, state.Votes[i].Clone())
+	}
+	return true
+}
+
 func (a *agreement) updateFilter(filter *utils.VoteFilter) {
 	if isStop(a.agreementID()) {
 		return
@@ -419,6 +554,14 @@ func (a *agreement) updateFilter(filter *utils.VoteFilter) {
 	filter.Position.Height = a.agreementID().Height
 }
 
+// addPendingVote caches a vote for a future position, it's replayed when the
+// agreement reaches that position.
+func (a *agreement) addPendingVote(vote *types.Vote) {
+	if !a.pending.addVote(vote, time.Now().UTC()) {
+		a.logger.Debug("Pending cache is full, drop vote", "vote", vote)
+	}
+}
+
 // processVote is the entry point for processing Vote.
 func (a *agreement) processVote(vote *types.Vote) error {
 	a.lock.Lock()
@@ -432,10 +575,7 @@ func (a *agreement) processVote(vote *types.Vote) error {
 	if isStop(aID) {
 		// Hacky way to not drop first votes when round just begins.
 		if vote.Position.Round == aID.Round {
-			a.pendingVote = append(a.pendingVote, pendingVote{
-				vote:         vote,
-				receivedTime: time.Now().UTC(),
-			})
+			a.addPendingVote(vote)
 			return nil
 		}
 		return ErrSkipButNoError
@@ -444,10 +584,7 @@ func (a *agreement) processVote(vote *types.Vote) error {
 		if aID.Newer(vote.Position) {
 			return nil
 		}
-		a.pendingVote = append(a.pendingVote, pendingVote{
-			vote:         vote,
-			receivedTime: time.Now().UTC(),
-		})
+		a.addPendingVote(vote)
 		return nil
 	}
 	exist, err := a.checkForkVote(vote)
@@ -467,6 +604,25 @@ func (a *agreement) processVote(vote *types.Vote) error {
 		return nil
 	}
 	a.data.votes[vote.Period][vote.Type][vote.ProposerID] = vote
+	a.metrics.vote(vote.Type)
+	if vote.Type == types.VoteFast &&
+		vote.BlockHash == types.NullBlockHash &&
+		vote.ProposerID == a.leader() &&
+		a.leaderBlockTime.IsZero() {
+		// The leader proposes nothing with a null fast vote, it's not a miss.
+		a.leaderBlockTime = time.Now().UTC()
+	}
+	if vote.Type == types.VoteFast &&
+		vote.BlockHash == types.NullBlockHash &&
+		vote.ProposerID == a.leader() &&
+		vote.ProposerID != a.data.ID &&
+		vote.Period == a.data.period &&
+		(a.state.state() == stateFast || a.state.state() == stateFastVote) {
+		// The leader has nothing to propose, follow it as if its block is
+		// received.
+		a.data.recv.ProposeVote(
+			types.NewVote(types.VoteFast, types.NullBlockHash, vote.Period))
+	}
 	if !a.hasOutput &&
 		(vote.Type == types.VoteCom ||
 			vote.Type == types.VoteFast ||
@@ -489,6 +645,8 @@ func (a *agreement) processVote(vote *types.Vote) error {
 				}
 			} else {
 				a.hasOutput = true
+				a.data.timeout.confirmed(vote.Period)
+				a.metrics.decide(vote.Period)
 				a.data.recv.ConfirmBlock(hash,
 					a.data.votes[vote.Period][vote.Type])
 				if a.doneChan != nil {
@@ -527,6 +685,18 @@ func (a *agreement) processVote(vote *types.Vote) error {
 				}
 				return nil
 			}
+			// Fast path: enough pre-commits in the initial period mean the
+			// leader's block reached most nodes in time, commit it right away
+			// instead of waiting for remaining clocks. Commit votes are still
+			// required to confirm since they carry partial signatures.
+			if vote.Period == baInitialPeriod && vote.Period == a.data.period {
+				switch a.state.state() {
+				case stateFastVote, stateInitial, statePreCommit, stateCommit:
+					a.data.recv.ProposeVote(
+						types.NewVote(types.VoteCom, hash, vote.Period))
+					a.state = newForwardState(a.data)
+				}
+			}
 		}
 	}
 	// Condition 3.
@@ -571,6 +741,7 @@ func (a *agreement) processFinalizedBlock(block *types.Block) {
 	}
 	a.addCandidateBlockNoLock(block)
 	a.hasOutput = true
+	a.metrics.sync()
 	a.data.lock.Lock()
 	defer a.data.lock.Unlock()
 	a.data.recv.ConfirmBlock(block.Hash, nil)
@@ -599,6 +770,7 @@ func (a *agreement) processAgreementResult(result *types.AgreementResult) error
 		a.data.recv.PullBlocks(common.Hashes{result.BlockHash})
 	}
 	a.hasOutput = true
+	a.metrics.sync()
 	a.data.recv.ConfirmBlock(result.BlockHash, nil)
 	if a.doneChan != nil {
 		close(a.doneChan)
@@ -656,7 +828,7 @@ func (a *agreement) processBlock(block *types.Block) error {
 	if checkSkip() {
 		return nil
 	}
-	if err := utils.VerifyBlockSignature(block); err != nil {
+	if err := utils.VerifyBlockSignature(block, a.registry); err != nil {
 		return err
 	}
 
@@ -669,10 +841,10 @@ func (a *agreement) processBlock(block *types.Block) error {
 	if checkSkip() {
 		return nil
 	} else if aID != block.Position {
-		a.pendingBlock = append(a.pendingBlock, pendingBlock{
-			block:        block,
-			receivedTime: time.Now().UTC(),
-		})
+		if !a.pending.addBlock(block, time.Now().UTC()) {
+			a.logger.Debug("Pending cache is full, drop block",
+				"block", block)
+		}
 		return nil
 	} else if a.confirmedNoLock() {
 		return nil
@@ -689,6 +861,9 @@ func (a *agreement) processBlock(block *types.Block) error {
 	}
 	a.data.blocks[block.ProposerID] = block
 	a.addCandidateBlockNoLock(block)
+	if block.ProposerID == a.leader() && a.leaderBlockTime.IsZero() {
+		a.leaderBlockTime = time.Now().UTC()
+	}
 	if block.ProposerID != a.data.ID &&
 		(a.state.state() == stateFast || a.state.state() == stateFastVote) &&
 		block.ProposerID == a.leader() {
@@ -738,6 +913,9 @@ func (a *agreement) addCandidateBlock(block *types.Block) {
 }
 
 func (a *agreement) addCandidateBlockNoLock(block *types.Block) {
+	if _, exist := a.candidateBlock[block.Hash]; !exist {
+		a.metrics.candidate()
+	}
 	a.candidateBlock[block.Hash] = block
 }
 
@@ -795,3 +973,46 @@ func (a *agreementData) setPeriod(period uint64) {
 	}
 	a.period = period
 }
+
+// status returns the snapshot of votes received at current position.
+func (a *agreement) status() (s AgreementStatus) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	s.Position = a.agreementID()
+	s.Leader = a.leader()
+	if isStop(s.Position) {
+		return
+	}
+	s.Confirmed = a.hasOutput
+	a.data.lock.RLock()
+	defer a.data.lock.RUnlock()
+	s.State = a.state.state().String()
+	s.Period = a.data.period
+	s.RequiredVote = a.data.requiredVote
+	s.Thresholds = NewVoteThresholds(len(a.notarySet))
+	periods := make([]uint64, 0, len(a.data.votes))
+	for period := range a.data.votes {
+		periods = append(periods, period)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i] < periods[j] })
+	for _, period := range periods {
+		for voteType, votes := range a.data.votes[period] {
+			if len(votes) == 0 {
+				continue
+			}
+			tally := VoteTally{
+				Period: period,
+				Type:   types.VoteType(voteType),
+				Count:  len(votes),
+			}
+			for nID := range a.notarySet {
+				if _, exist := votes[nID]; !exist {
+					tally.Missing = append(tally.Missing, nID)
+				}
+			}
+			sort.Sort(tally.Missing)
+			s.Tallies = append(s.Tallies, tally)
+		}
+	}
+	return
+}
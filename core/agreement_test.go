@@ -18,6 +18,7 @@
 package core
 
 import (
+	"sort"
 	"testing"
 	"time"
 
@@ -52,6 +53,10 @@ func (r *agreementTestReceiver) ProposeBlock() common.Hash {
 	return block.Hash
 }
 
+func (r *agreementTestReceiver) ProposeNullBlock() bool {
+	return r.s.idle
+}
+
 func (r *agreementTestReceiver) ConfirmBlock(block common.Hash,
 	_ map[types.NodeID]*types.Vote) {
 	r.s.confirmChan <- block
@@ -109,6 +114,7 @@ type AgreementTestSuite struct {
 	agreement          []*agreement
 	agreementID        types.Position
 	defaultValidLeader validLeaderFn
+	idle               bool
 }
 
 func (s *AgreementTestSuite) SetupTest() {
@@ -129,6 +135,7 @@ func (s *AgreementTestSuite) SetupTest() {
 	s.defaultValidLeader = func(*types.Block, common.Hash) (bool, error) {
 		return true, nil
 	}
+	s.idle = false
 }
 
 func (s *AgreementTestSuite) newAgreement(
@@ -244,6 +251,13 @@ func (s *AgreementTestSuite) TestSimpleConfirm() {
 	s.Require().Len(s.confirmChan, 1)
 	confirmBlock := <-s.confirmChan
 	s.Equal(blockHash, confirmBlock)
+	metrics := a.metrics.snapshot()
+	s.Equal(uint64(1), metrics.Decided)
+	s.Equal(map[uint64]uint64{1: 1}, metrics.Periods)
+	s.Equal(uint64(4), metrics.Votes[types.VoteFast])
+	s.Equal(uint64(4), metrics.Votes[types.VotePreCom])
+	s.Equal(uint64(4), metrics.Votes[types.VoteCom])
+	s.Equal(uint64(1), metrics.CandidateBlocks)
 }
 
 func (s *AgreementTestSuite) TestPartitionOnCommitVote() {
@@ -363,6 +377,111 @@ func (s *AgreementTestSuite) TestFastConfirmNonLeader() {
 	s.Equal(block.Hash, confirmBlock)
 }
 
+func (s *AgreementTestSuite) TestFastConfirmNullBlock() {
+	s.idle = true
+	a, leaderNode := s.newAgreement(4, 0, s.defaultValidLeader)
+	s.Require().Equal(s.ID, leaderNode)
+	// FastState
+	s.Require().NoError(a.nextState())
+	// FastVoteState, the leader votes for the null block without proposing.
+	s.Require().Len(s.blockChan, 0)
+	s.Require().Len(s.voteChan, 1)
+	vote := <-s.voteChan
+	s.Equal(types.VoteFast, vote.Type)
+	s.Equal(types.NullBlockHash, vote.BlockHash)
+	for nID := range s.signers {
+		v := s.copyVote(vote, nID)
+		s.Require().NoError(a.processVote(v))
+	}
+	// We have enough of Fast-Votes.
+	s.Require().Len(s.voteChan, 1)
+	vote = <-s.voteChan
+	s.Equal(types.VoteFastCom, vote.Type)
+	s.Equal(types.NullBlockHash, vote.BlockHash)
+	for nID := range s.signers {
+		v := s.copyVote(vote, nID)
+		s.Require().NoError(a.processVote(v))
+	}
+	// We have enough of Fast-ConfirmVotes.
+	s.Require().Len(s.confirmChan, 1)
+	s.Equal(types.NullBlockHash, <-s.confirmChan)
+}
+
+func (s *AgreementTestSuite) TestFollowLeaderNullBlock() {
+	a, leaderNode := s.newAgreement(4, 1, s.defaultValidLeader)
+	s.Require().NotEqual(s.ID, leaderNode)
+	// FastState
+	s.Require().NoError(a.nextState())
+	// FastVoteState
+	s.Require().Len(s.voteChan, 0)
+	// Null block from other nodes is ignored.
+	for nID := range s.signers {
+		if nID == leaderNode || nID == s.ID {
+			continue
+		}
+		s.Require().NoError(a.processVote(
+			s.prepareVote(nID, types.VoteFast, types.NullBlockHash,
+				a.data.period)))
+	}
+	s.Require().Len(s.voteChan, 0)
+	_, _, _, received := a.leaderProposal()
+	s.Require().True(received.IsZero())
+	s.Require().NoError(a.processVote(
+		s.prepareVote(leaderNode, types.VoteFast, types.NullBlockHash,
+			a.data.period)))
+	s.Require().Len(s.voteChan, 2)
+	// The null fast vote of the leader is taken as its proposal.
+	_, _, _, received = a.leaderProposal()
+	s.Require().False(received.IsZero())
+	vote := <-s.voteChan
+	s.Equal(types.VoteFast, vote.Type)
+	s.Equal(types.NullBlockHash, vote.BlockHash)
+}
+
+func (s *AgreementTestSuite) TestFastCommit() {
+	a, _ := s.newAgreement(4, -1, s.defaultValidLeader)
+	// FastState
+	a.nextState()
+	// FastVoteState
+	a.nextState()
+	// InitialState
+	a.nextState()
+	// PreCommitState
+	s.Require().Len(s.blockChan, 1)
+	blockHash := <-s.blockChan
+	s.Require().Len(s.voteChan, 1)
+	s.Equal(types.VoteInit, (<-s.voteChan).Type)
+	// Pre-commits are collected before this node proposes its own.
+	for nID := range a.notarySet {
+		v := s.prepareVote(nID, types.VotePreCom, blockHash, 2)
+		s.Require().NoError(a.processVote(v))
+	}
+	s.Require().Len(s.voteChan, 1)
+	vote := <-s.voteChan
+	s.Equal(types.VoteCom, vote.Type)
+	s.Equal(blockHash, vote.BlockHash)
+	s.Equal(uint64(2), vote.Period)
+	s.Equal(stateForward, a.state.state())
+	for nID := range a.notarySet {
+		v := s.copyVote(vote, nID)
+		s.Require().NoError(a.processVote(v))
+	}
+	s.Require().Len(s.confirmChan, 1)
+	s.Equal(blockHash, <-s.confirmChan)
+	// No fast path in later periods.
+	a, _ = s.newAgreement(4, -1, s.defaultValidLeader)
+	a.nextState()
+	a.nextState()
+	a.data.setPeriod(3)
+	hash := common.NewRandomHash()
+	for nID := range a.notarySet {
+		v := s.prepareVote(nID, types.VotePreCom, hash, 3)
+		s.Require().NoError(a.processVote(v))
+	}
+	s.Require().Len(s.voteChan, 0)
+	s.Equal(stateInitial, a.state.state())
+}
+
 func (s *AgreementTestSuite) TestFastForwardCond1() {
 	votes := 0
 	a, _ := s.newAgreement(4, -1, s.defaultValidLeader)
@@ -522,6 +641,74 @@ func (s *AgreementTestSuite) TestDecide() {
 	s.Equal(hash, confirmBlock)
 }
 
+func (s *AgreementTestSuite) TestRestoreState() {
+	a, _ := s.newAgreement(4, -1, s.defaultValidLeader)
+	lockValue := common.NewRandomHash()
+	a.data.setPeriod(3)
+	a.data.lockValue = lockValue
+	a.data.lockIter = 2
+	vote := types.NewVote(types.VotePreCom, lockValue, 3)
+	s.Require().NoError(a.prepareVote(vote))
+	sent, state := a.recordSentVote(vote)
+	s.Require().Equal(vote, sent)
+	s.Require().NotNil(state)
+	s.Require().Equal(s.agreementID, state.Position)
+	s.Require().Equal(uint64(3), state.Period)
+	s.Require().Equal(lockValue, state.LockValue)
+	s.Require().Len(state.Votes, 1)
+	// Conflicting votes of the same type and period are not recorded.
+	conflict := types.NewVote(types.VotePreCom, common.NewRandomHash(), 3)
+	s.Require().NoError(a.prepareVote(conflict))
+	sent, newState := a.recordSentVote(conflict)
+	s.Require().Equal(vote, sent)
+	s.Require().Nil(newState)
+	// A vote failed to be persisted is forgotten, and recorded again later.
+	a.forgetSentVote(vote)
+	sent, newState = a.recordSentVote(conflict)
+	s.Require().Equal(conflict, sent)
+	s.Require().NotNil(newState)
+	a.forgetSentVote(conflict)
+	a.recordSentVote(vote)
+	// A restarted agreement at the same position should restore the state and
+	// never send conflicting votes.
+	b, _ := s.newAgreement(4, -1, s.defaultValidLeader)
+	s.Require().True(b.restoreState(state))
+	s.Require().Equal(uint64(3), b.data.period)
+	s.Require().Equal(statePreCommit, b.state.state())
+	s.Require().Equal(lockValue, b.data.lockValue)
+	s.Require().Equal(uint64(2), b.data.lockIter)
+	sent, newState = b.recordSentVote(conflict)
+	s.Require().Equal(vote.BlockHash, sent.BlockHash)
+	s.Require().Nil(newState)
+	// States of other positions are ignored.
+	state.Position.Height++
+	c, _ := s.newAgreement(4, -1, s.defaultValidLeader)
+	s.Require().False(c.restoreState(state))
+	s.Require().Equal(uint64(2), c.data.period)
+}
+
+func (s *AgreementTestSuite) TestLatestSentVotes() {
+	a, _ := s.newAgreement(4, 0, s.defaultValidLeader)
+	s.Require().Empty(a.latestSentVotes())
+	hash := common.NewRandomHash()
+	for _, v := range []*types.Vote{
+		s.prepareVote(s.ID, types.VoteInit, hash, 2),
+		s.prepareVote(s.ID, types.VotePreCom, hash, 2),
+		s.prepareVote(s.ID, types.VoteInit, types.NullBlockHash, 3),
+		s.prepareVote(s.ID, types.VoteCom, hash, 2),
+	} {
+		a.data.lock.Lock()
+		a.recordSentVote(v)
+		a.data.lock.Unlock()
+	}
+	votes := a.latestSentVotes()
+	s.Require().Len(votes, 3)
+	s.Equal(types.VoteInit, votes[0].Type)
+	s.Equal(uint64(3), votes[0].Period)
+	s.Equal(types.VotePreCom, votes[1].Type)
+	s.Equal(types.VoteCom, votes[2].Type)
+}
+
 func (s *AgreementTestSuite) TestForkVote() {
 	a, _ := s.newAgreement(4, -1, s.defaultValidLeader)
 	a.data.period = 2
@@ -578,6 +765,76 @@ func (s *AgreementTestSuite) TestConfirmWithBlock() {
 	s.True(a.confirmed())
 }
 
+func (s *AgreementTestSuite) TestStatus() {
+	a, leaderNode := s.newAgreement(4, 0, s.defaultValidLeader)
+	status := a.status()
+	s.Require().Equal(s.agreementID, status.Position)
+	s.Require().Equal(leaderNode, status.Leader)
+	s.Require().Equal("fast", status.State)
+	s.Require().Equal(uint64(2), status.Period)
+	s.Require().Equal(3, status.RequiredVote)
+	s.Require().Equal(status.RequiredVote, status.Thresholds.Quorum)
+	s.Require().Equal(2, status.Thresholds.Weak)
+	s.Require().False(status.Confirmed)
+	s.Require().Empty(status.Tallies)
+	hash := common.NewRandomHash()
+	voters := types.NodeIDs{}
+	missing := types.NodeIDs{}
+	for nID := range a.notarySet {
+		if len(voters) < 2 {
+			voters = append(voters, nID)
+			s.Require().NoError(a.processVote(
+				s.prepareVote(nID, types.VotePreCom, hash, 2)))
+		} else {
+			missing = append(missing, nID)
+		}
+	}
+	sort.Sort(missing)
+	s.Require().NoError(a.processVote(
+		s.prepareVote(voters[0], types.VoteCom, hash, 1)))
+	status = a.status()
+	s.Require().Len(status.Tallies, 2)
+	s.Require().Equal(uint64(1), status.Tallies[0].Period)
+	s.Require().Equal(types.VoteCom, status.Tallies[0].Type)
+	s.Require().Equal(1, status.Tallies[0].Count)
+	s.Require().Len(status.Tallies[0].Missing, 3)
+	s.Require().Equal(VoteTally{
+		Period:  2,
+		Type:    types.VotePreCom,
+		Count:   2,
+		Missing: missing,
+	}, status.Tallies[1])
+	// Nothing is reported when BA is stopped.
+	a.stop()
+	s.Require().Empty(a.status().Tallies)
+}
+
+type vrfLeaderGovernance struct{}
+
+func (vrfLeaderGovernance) VRFLeaderSelection(round uint64) bool {
+	return true
+}
+
+func (s *AgreementTestSuite) TestNoPrecomputedLeaderInVRFRound() {
+	mgr := &agreementMgr{con: &Consensus{}}
+	nodes := map[types.NodeID]struct{}{s.ID: {}}
+	crs := common.NewRandomHash()
+	pos := types.Position{Round: DKGDelayRound, Height: 1}
+	leader, err := mgr.calcLeader(nodes, crs, pos)
+	s.Require().NoError(err)
+	s.Require().Equal(s.ID, leader)
+	// Leaders are unknown until proposals are revealed in VRF rounds.
+	mgr.con.govExt.vrfGov = vrfLeaderGovernance{}
+	leader, err = mgr.calcLeader(nodes, crs, pos)
+	s.Require().NoError(err)
+	s.Require().Equal(types.NodeID{}, leader)
+	// Rounds before DKG is available are never selected by VRF.
+	pos.Round = DKGDelayRound - 1
+	leader, err = mgr.calcLeader(nodes, crs, pos)
+	s.Require().NoError(err)
+	s.Require().Equal(s.ID, leader)
+}
+
 func TestAgreement(t *testing.T) {
 	suite.Run(t, new(AgreementTestSuite))
 }
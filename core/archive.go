@@ -0,0 +1,203 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+const (
+	// archiveInterval is the interval to look for positions missing in the
+	// archive and pull their agreement results.
+	archiveInterval = 5 * time.Second
+	// archivePullCount is the count of positions pulled in one interval.
+	archivePullCount = 16
+)
+
+// inArchive checks if agreement results of a height should be archived.
+func (con *Consensus) inArchive(height uint64) bool {
+	if !con.opts.archive || height < con.opts.archiveBegin {
+		return false
+	}
+	return con.opts.archiveEnd == 0 || height < con.opts.archiveEnd
+}
+
+// IsArchiveComplete checks if agreement results of all heights in the range
+// given by WithArchive are stored. It's always false when the range has no
+// end.
+func (con *Consensus) IsArchiveComplete() bool {
+	if !con.opts.archive || con.opts.archiveEnd == 0 {
+		return false
+	}
+	return atomic.LoadUint64(&con.archiveNext) >= con.opts.archiveEnd
+}
+
+// archiveAgreementResult stores an agreement result of a delivered position
+// in the archive range, it's verified the same way as finalized blocks since
+// it's not processed by BA anymore. The result should be of the block
+// delivered at that position.
+func (con *Consensus) archiveAgreementResult(result *types.AgreementResult) {
+	if !con.inArchive(result.Position.Height) {
+		return
+	}
+	store, ok := con.db.(db.AgreementResultStore)
+	if !ok {
+		return
+	}
+	if _, err := store.GetAgreementResult(result.Position); err == nil {
+		return
+	}
+	// Randomness only certifies the block hash, a valid result of another
+	// height should not be stored under this one.
+	b, err := con.db.GetBlock(result.BlockHash)
+	if err != nil {
+		con.logger.Debug("Unable to find block of agreement result to archive",
+			"result", result,
+			"error", err)
+		return
+	}
+	if b.Position != result.Position {
+		con.logger.Warn("Mismatched position of agreement result to archive",
+			"result", result,
+			"block", &b)
+		return
+	}
+	if result.Position.Round >= DKGDelayRound {
+		verifier, ok, err := con.tsigVerifierCache.UpdateAndGet(
+			result.Position.Round)
+		if err != nil || !ok {
+			con.logger.Debug("Unable to verify agreement result to archive",
+				"result", result,
+				"error", err)
+			return
+		}
+		if !verifier.VerifySignature(result.BlockHash, crypto.Signature{
+			Type:      "bls",
+			Signature: result.Randomness,
+		}) {
+			con.logger.Warn("Incorrect agreement result to archive",
+				"result", result)
+			return
+		}
+	}
+	con.storeAgreementResult(result)
+	con.opts.incCounter("archive-backfilled", 1)
+}
+
+// roundOfHeight finds the round of a height not newer than the last
+// delivered block, whose round is given.
+func (con *Consensus) roundOfHeight(height, lastRound uint64) uint64 {
+	for round := lastRound; round > 0; round-- {
+		if utils.GetRoundHeight(con.gov, round) <= height {
+			return round
+		}
+	}
+	return 0
+}
+
+// missingArchivePositions returns positions whose agreement results are not
+// archived yet, from the first height not archived to the last delivered
+// block. Heights archived contiguously are skipped in later calls.
+func (con *Consensus) missingArchivePositions(
+	store db.AgreementResultStore, count int) (positions []types.Position) {
+	last := con.bcModule.lastDeliveredBlock()
+	if last == nil {
+		return
+	}
+	limit := last.Position.Height
+	if con.opts.archiveEnd != 0 && con.opts.archiveEnd <= limit {
+		limit = con.opts.archiveEnd - 1
+	}
+	contiguous := true
+	for h := atomic.LoadUint64(&con.archiveNext); h <= limit &&
+		len(positions) < count; h++ {
+		pos := types.Position{
+			Round:  con.roundOfHeight(h, last.Position.Round),
+			Height: h,
+		}
+		if _, err := store.GetAgreementResult(pos); err == nil {
+			if contiguous {
+				atomic.StoreUint64(&con.archiveNext, h+1)
+			}
+			continue
+		}
+		contiguous = false
+		positions = append(positions, pos)
+	}
+	return
+}
+
+// backfillArchive pulls agreement results missing in the archive range
+// periodically, until all of them are stored.
+func (con *Consensus) backfillArchive(
+	store db.AgreementResultStore, puller AgreementResultPuller) {
+	defer con.waitGroup.Done()
+	ticker := time.NewTicker(archiveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-con.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		positions := con.missingArchivePositions(store, archivePullCount)
+		if con.IsArchiveComplete() {
+			con.logger.Info("Archive completed",
+				"begin", con.opts.archiveBegin,
+				"end", con.opts.archiveEnd)
+			return
+		}
+		if len(positions) == 0 {
+			continue
+		}
+		con.logger.Debug("Calling Network.PullAgreementResults for archive",
+			"from", positions[0],
+			"count", len(positions))
+		puller.PullAgreementResults(positions)
+	}
+}
+
+// startArchive launches the backfill of the archive when enabled.
+func (con *Consensus) startArchive() {
+	if !con.opts.archive {
+		return
+	}
+	store, ok := con.db.(db.AgreementResultStore)
+	if !ok {
+		con.logger.Warn("Database unable to archive agreement results")
+		return
+	}
+	puller, ok := con.network.(AgreementResultPuller)
+	if !ok {
+		con.logger.Warn("Network unable to backfill archive")
+		return
+	}
+	begin := con.opts.archiveBegin
+	if begin < types.GenesisHeight {
+		begin = types.GenesisHeight
+	}
+	atomic.StoreUint64(&con.archiveNext, begin)
+	con.waitGroup.Add(1)
+	go con.backfillArchive(store, puller)
+}
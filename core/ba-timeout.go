@@ -0,0 +1,95 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+// defaultBATimeoutMaxScale is the upper bound of the scale of clocks waited
+// in each state of BA when it's not configured. 10 is a magic number derived
+// from many years of experience.
+const defaultBATimeoutMaxScale = 10
+
+// baTimeout scales the count of clocks waited in each state of BA.
+//
+// With linear growth, the scale is the count of periods passed at current
+// position. With exponential growth, the scale is multiplied by the growth
+// factor per failed period, starting from a base scale adapted to the latency
+// observed: it's raised when a position is confirmed after failed periods,
+// and decays when a position is confirmed in the first period. Thus BA could
+// converge when the network is slow for a while, and speed up again when the
+// network recovers.
+type baTimeout struct {
+	growth   int
+	maxScale int
+	base     int
+}
+
+func newBATimeout() *baTimeout {
+	return &baTimeout{
+		maxScale: defaultBATimeoutMaxScale,
+		base:     1,
+	}
+}
+
+// setConfig applies the growth factor and the upper bound of scale, the base
+// scale adapted so far is kept.
+func (t *baTimeout) setConfig(growth, maxScale uint32) {
+	t.growth = int(growth)
+	t.maxScale = int(maxScale)
+	if t.maxScale == 0 {
+		t.maxScale = defaultBATimeoutMaxScale
+	}
+	if t.base > t.maxScale {
+		t.base = t.maxScale
+	}
+}
+
+// scale returns the scale of clocks in a period, the first period of BA is 2.
+func (t *baTimeout) scale(period uint64) int {
+	if period < 2 {
+		period = 2
+	}
+	if t.growth <= 1 {
+		return t.clamp(int(period) - 1)
+	}
+	scale := t.base
+	for i := uint64(2); i < period && scale < t.maxScale; i++ {
+		scale *= t.growth
+	}
+	return t.clamp(scale)
+}
+
+// confirmed adapts the base scale by the period a position is confirmed.
+func (t *baTimeout) confirmed(period uint64) {
+	if t.growth <= 1 {
+		return
+	}
+	if period > 2 {
+		t.base = t.clamp(t.base * t.growth)
+	} else {
+		t.base = t.clamp(t.base / t.growth)
+	}
+}
+
+func (t *baTimeout) clamp(scale int) int {
+	if scale < 1 {
+		return 1
+	}
+	if scale > t.maxScale {
+		return t.maxScale
+	}
+	return scale
+}
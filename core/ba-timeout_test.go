@@ -0,0 +1,75 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BATimeoutTestSuite struct {
+	suite.Suite
+}
+
+func (s *BATimeoutTestSuite) TestLinear() {
+	t := newBATimeout()
+	for period, scale := range map[uint64]int{
+		0: 1, 2: 1, 3: 2, 5: 4, 11: 10, 100: 10} {
+		s.Require().Equal(scale, t.scale(period))
+	}
+	// The scale is not adapted.
+	t.confirmed(5)
+	s.Require().Equal(1, t.scale(2))
+	t.setConfig(0, 4)
+	s.Require().Equal(4, t.scale(100))
+}
+
+func (s *BATimeoutTestSuite) TestExponential() {
+	t := newBATimeout()
+	t.setConfig(2, 16)
+	for period, scale := range map[uint64]int{
+		2: 1, 3: 2, 4: 4, 6: 16, 7: 16, 100: 16} {
+		s.Require().Equal(scale, t.scale(period))
+	}
+	// Confirmed after failed periods, the base is raised.
+	t.confirmed(4)
+	s.Require().Equal(2, t.scale(2))
+	s.Require().Equal(4, t.scale(3))
+	t.confirmed(3)
+	t.confirmed(3)
+	t.confirmed(3)
+	s.Require().Equal(16, t.scale(2))
+	// Confirmed in the first period, the base decays.
+	t.confirmed(2)
+	s.Require().Equal(8, t.scale(2))
+	t.confirmed(2)
+	t.confirmed(2)
+	t.confirmed(2)
+	t.confirmed(2)
+	s.Require().Equal(1, t.scale(2))
+	// The base is bounded by new max scale.
+	t.confirmed(3)
+	t.confirmed(3)
+	t.setConfig(2, 3)
+	s.Require().Equal(3, t.scale(2))
+}
+
+func TestBATimeout(t *testing.T) {
+	suite.Run(t, new(BATimeoutTestSuite))
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sort"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// BlockCertificate describes who proposed a finalized block and who confirmed
+// it, for explorers and audit tooling.
+type BlockCertificate struct {
+	BlockHash  common.Hash    `json:"block_hash"`
+	Position   types.Position `json:"position"`
+	ProposerID types.NodeID   `json:"proposer_id"`
+	// Voters are the proposers of Votes, sorted.
+	Voters types.NodeIDs `json:"voters"`
+	// Votes are the commit votes confirming the block. They are available only
+	// when the agreement result of that block is still stored, and blocks
+	// finalized by threshold signatures might come without votes.
+	Votes []types.Vote `json:"votes"`
+	// Randomness is the threshold signature aggregated from the notary set
+	// when the block is in rounds after DKG is ready.
+	Randomness []byte `json:"randomness"`
+}
+
+// newBlockCertificate constructs the certificate of a finalized block, result
+// could be nil when no agreement result is stored for it.
+func newBlockCertificate(
+	b *types.Block, result *types.AgreementResult) *BlockCertificate {
+	cert := &BlockCertificate{
+		BlockHash:  b.Hash,
+		Position:   b.Position,
+		ProposerID: b.ProposerID,
+		Randomness: common.CopyBytes(b.Randomness),
+	}
+	if result == nil || result.BlockHash != b.Hash {
+		return cert
+	}
+	cert.Votes = append([]types.Vote(nil), result.Votes...)
+	cert.Voters = make(types.NodeIDs, 0, len(result.Votes))
+	for _, vote := range result.Votes {
+		cert.Voters = append(cert.Voters, vote.ProposerID)
+	}
+	sort.Sort(cert.Voters)
+	return cert
+}
+
+// BlockCertificate returns the proposer and the confirming votes of a
+// finalized block. Votes are omitted when its agreement result is purged or
+// the database doesn't store agreement results.
+func (con *Consensus) BlockCertificate(hash common.Hash) (
+	*BlockCertificate, error) {
+	b, err := con.db.GetBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	if !b.IsFinalized() {
+		return nil, utils.ErrBlockNotFinalized
+	}
+	result, err := con.AgreementResult(b.Position)
+	switch err {
+	case nil, db.ErrNotImplemented, db.ErrAgreementResultDoesNotExist:
+	default:
+		return nil, err
+	}
+	return newBlockCertificate(&b, result), nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// maxSubscriptionBacklog is the count of delivered blocks allowed to be queued
+// for a subscriber, a subscriber lagging more than that is closed.
+const maxSubscriptionBacklog = 4096
+
+// Errors for block subscriptions.
+var (
+	ErrSubscriptionLagging = errors.New(
+		"subscriber lags behind too much")
+	ErrSubscriptionCancelled = errors.New("subscription cancelled")
+)
+
+// BlockSubscription is a stream of delivered blocks in ascending order of
+// height, without gaps or duplicates.
+type BlockSubscription struct {
+	feed    *blockFeed
+	ch      chan *types.Block
+	next    uint64
+	lock    sync.Mutex
+	queue   []*types.Block
+	notify  chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	err     error
+	errLock sync.RWMutex
+}
+
+// Blocks returns the channel of delivered blocks, it's closed when the
+// subscription ends, and Err tells the reason.
+func (s *BlockSubscription) Blocks() <-chan *types.Block {
+	return s.ch
+}
+
+// Err returns the reason why the subscription ends. A subscriber closed by
+// ErrSubscriptionLagging could subscribe again from the next height.
+func (s *BlockSubscription) Err() error {
+	s.errLock.RLock()
+	defer s.errLock.RUnlock()
+	return s.err
+}
+
+// Unsubscribe stops the subscription.
+func (s *BlockSubscription) Unsubscribe() {
+	s.close(ErrSubscriptionCancelled)
+}
+
+func (s *BlockSubscription) close(err error) {
+	s.errLock.Lock()
+	defer s.errLock.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.cancel()
+}
+
+// push queues a delivered block, it returns false when the subscriber lags
+// too much.
+func (s *BlockSubscription) push(b *types.Block) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.queue) >= maxSubscriptionBacklog {
+		return false
+	}
+	s.queue = append(s.queue, b)
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (s *BlockSubscription) send(b *types.Block) bool {
+	if b.Position.Height < s.next {
+		return true
+	}
+	select {
+	case s.ch <- b:
+	case <-s.ctx.Done():
+		return false
+	}
+	s.next = b.Position.Height + 1
+	return true
+}
+
+// run replays blocks from database up to the tip when subscribed, then
+// switches to blocks delivered afterward.
+func (s *BlockSubscription) run(tipHash common.Hash, tipHeight uint64) {
+	defer func() {
+		s.feed.remove(s)
+		s.close(ErrSubscriptionCancelled)
+		close(s.ch)
+	}()
+	if s.next <= tipHeight {
+		blocks, err := s.feed.load(s.next, tipHash, tipHeight)
+		if err != nil {
+			s.close(err)
+			return
+		}
+		for _, b := range blocks {
+			if !s.send(b) {
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case <-s.notify:
+		case <-s.ctx.Done():
+			return
+		}
+		var blocks []*types.Block
+		func() {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			blocks, s.queue = s.queue, nil
+		}()
+		for _, b := range blocks {
+			if !s.send(b) {
+				return
+			}
+		}
+	}
+}
+
+// blockFeed dispatches delivered blocks to subscribers.
+type blockFeed struct {
+	ctx       context.Context
+	db        db.Reader
+	lock      sync.Mutex
+	tipHash   common.Hash
+	tipHeight uint64
+	subs      map[*BlockSubscription]struct{}
+}
+
+func newBlockFeed(ctx context.Context, dbInst db.Reader) *blockFeed {
+	f := &blockFeed{
+		ctx:  ctx,
+		db:   dbInst,
+		subs: make(map[*BlockSubscription]struct{}),
+	}
+	f.tipHash, f.tipHeight = dbInst.GetCompactionChainTipInfo()
+	return f
+}
+
+func (f *blockFeed) subscribe(from uint64) *BlockSubscription {
+	if from < types.GenesisHeight {
+		from = types.GenesisHeight
+	}
+	s := &BlockSubscription{
+		feed:   f,
+		ch:     make(chan *types.Block),
+		next:   from,
+		notify: make(chan struct{}, 1),
+	}
+	s.ctx, s.cancel = context.WithCancel(f.ctx)
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.subs[s] = struct{}{}
+	// Blocks delivered after the tip recorded here are queued to this
+	// subscriber, thus no block is missed between replaying and live.
+	go s.run(f.tipHash, f.tipHeight)
+	return s
+}
+
+func (f *blockFeed) remove(s *BlockSubscription) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.subs, s)
+}
+
+// publish dispatches blocks delivered in ascending order of height.
+func (f *blockFeed) publish(blocks []*types.Block) {
+	if len(blocks) == 0 {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	last := blocks[len(blocks)-1]
+	f.tipHash, f.tipHeight = last.Hash, last.Position.Height
+	for s := range f.subs {
+		for _, b := range blocks {
+			if !s.push(b) {
+				s.close(ErrSubscriptionLagging)
+				break
+			}
+		}
+	}
+}
+
+// load reads blocks in range [from, tipHeight] from database by following
+// parent hashes from the tip.
+func (f *blockFeed) load(from uint64, tipHash common.Hash, tipHeight uint64) (
+	[]*types.Block, error) {
+	blocks := make([]*types.Block, tipHeight-from+1)
+	hash := tipHash
+	for h := tipHeight; h >= from; h-- {
+		b, err := f.db.GetBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		if b.Position.Height != h {
+			return nil, ErrInvalidBlockHeight
+		}
+		blocks[h-from] = &b
+		hash = b.ParentHash
+	}
+	return blocks, nil
+}
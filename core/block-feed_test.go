@@ -0,0 +1,157 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type BlockFeedTestSuite struct {
+	suite.Suite
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	db        *db.MemBackedDB
+	blocks    []*types.Block
+}
+
+func (s *BlockFeedTestSuite) SetupTest() {
+	var err error
+	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
+	s.db, err = db.NewMemBackedDB()
+	s.Require().NoError(err)
+	s.blocks = nil
+}
+
+func (s *BlockFeedTestSuite) TearDownTest() {
+	s.ctxCancel()
+}
+
+// deliver appends blocks to the chain and stores them like Consensus does.
+func (s *BlockFeedTestSuite) deliver(count int) (blocks []*types.Block) {
+	for i := 0; i < count; i++ {
+		b := &types.Block{
+			Hash:     common.NewRandomHash(),
+			Position: types.Position{Height: types.GenesisHeight},
+		}
+		if len(s.blocks) > 0 {
+			parent := s.blocks[len(s.blocks)-1]
+			b.ParentHash = parent.Hash
+			b.Position.Height = parent.Position.Height + 1
+		}
+		s.Require().NoError(s.db.PutBlock(*b))
+		s.Require().NoError(s.db.PutCompactionChainTipInfo(
+			b.Hash, b.Position.Height))
+		s.blocks = append(s.blocks, b)
+		blocks = append(blocks, b)
+	}
+	return
+}
+
+func (s *BlockFeedTestSuite) receive(
+	sub *BlockSubscription, from, to uint64) {
+	for h := from; h <= to; h++ {
+		select {
+		case b, ok := <-sub.Blocks():
+			s.Require().True(ok)
+			s.Require().Equal(h, b.Position.Height)
+			s.Require().Equal(s.blocks[h-types.GenesisHeight].Hash, b.Hash)
+		case <-time.After(time.Second):
+			s.FailNow("block not received", "height", h)
+		}
+	}
+}
+
+func (s *BlockFeedTestSuite) TestReplayThenLive() {
+	s.deliver(10)
+	feed := newBlockFeed(s.ctx, s.db)
+	sub := feed.subscribe(3)
+	// Blocks delivered while replaying should not be missed.
+	feed.publish(s.deliver(5))
+	s.receive(sub, 3, 15)
+	feed.publish(s.deliver(5))
+	s.receive(sub, 16, 20)
+	// Subscribe from genesis.
+	sub2 := feed.subscribe(0)
+	s.receive(sub2, 1, 20)
+	sub.Unsubscribe()
+	_, ok := <-sub.Blocks()
+	s.False(ok)
+	s.Equal(ErrSubscriptionCancelled, sub.Err())
+}
+
+func (s *BlockFeedTestSuite) TestFutureHeight() {
+	s.deliver(3)
+	feed := newBlockFeed(s.ctx, s.db)
+	sub := feed.subscribe(6)
+	feed.publish(s.deliver(5))
+	s.receive(sub, 6, 8)
+}
+
+func (s *BlockFeedTestSuite) TestMissingBlocks() {
+	s.deliver(3)
+	// Blocks before height 3 are not stored, ex. synced from a snapshot.
+	s.Require().NoError(s.db.PutCompactionChainTipInfo(
+		common.NewRandomHash(), 4))
+	feed := newBlockFeed(s.ctx, s.db)
+	sub := feed.subscribe(1)
+	_, ok := <-sub.Blocks()
+	s.False(ok)
+	s.Equal(db.ErrBlockDoesNotExist, sub.Err())
+}
+
+func (s *BlockFeedTestSuite) TestLagging() {
+	feed := newBlockFeed(s.ctx, s.db)
+	sub := feed.subscribe(0)
+	// Nobody receives from this subscriber, it would be closed once the
+	// queue is full.
+	for i := 0; i < 3*maxSubscriptionBacklog && sub.Err() == nil; i++ {
+		feed.publish(s.deliver(1))
+	}
+	// Blocks received before lagging are kept.
+	received := uint64(0)
+	for b := range sub.Blocks() {
+		received++
+		s.Require().Equal(received, b.Position.Height)
+	}
+	s.Equal(ErrSubscriptionLagging, sub.Err())
+	// Resume from the next height.
+	sub = feed.subscribe(received + 1)
+	s.receive(sub, received+1, uint64(len(s.blocks)))
+}
+
+func (s *BlockFeedTestSuite) TestStop() {
+	feed := newBlockFeed(s.ctx, s.db)
+	sub := feed.subscribe(0)
+	s.ctxCancel()
+	_, ok := <-sub.Blocks()
+	s.False(ok)
+	s.Equal(ErrSubscriptionCancelled, sub.Err())
+}
+
+func TestBlockFeed(t *testing.T) {
+	suite.Run(t, new(BlockFeedTestSuite))
+}
@@ -0,0 +1,235 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+// confirmEvent is a confirmation from BA, block is nil for empty blocks.
+type confirmEvent struct {
+	position types.Position
+	block    *types.Block
+}
+
+func (e confirmEvent) String() string {
+	if e.block == nil {
+		return fmt.Sprintf("empty%s", &e.position)
+	}
+	return fmt.Sprintf("block%s", &e.position)
+}
+
+type BlockChainDifferentialTestSuite struct {
+	suite.Suite
+
+	nID           types.NodeID
+	prvKeys       []crypto.PrivateKey
+	signer        *utils.Signer
+	dMoment       time.Time
+	blockInterval time.Duration
+}
+
+func (s *BlockChainDifferentialTestSuite) SetupSuite() {
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	s.prvKeys = prvKeys
+	s.nID = types.NewNodeID(pubKeys[0])
+	s.signer = utils.NewSigner(prvKeys[0])
+	s.dMoment = time.Now().UTC()
+	s.blockInterval = 1 * time.Millisecond
+}
+
+// genEvents generates confirmations of count heights in round 0, the genesis
+// height is always confirmed first like what BA does, and the rest are
+// shuffled and partially duplicated. Forks are confirmed after the blocks at
+// the same heights.
+func (s *BlockChainDifferentialTestSuite) genEvents(
+	r *rand.Rand, count int) (events []confirmEvent) {
+	gen := test.NewChainGenerator(test.ChainGeneratorConfig{
+		DMoment:          s.dMoment,
+		MinBlockInterval: s.blockInterval,
+		IntervalSkew:     5 * s.blockInterval,
+		EmptyRatio:       0.3,
+		ForkRatio:        0.1,
+		Seed:             r.Int63(),
+	}, s.prvKeys)
+	heights, err := gen.Generate(count)
+	s.Require().NoError(err)
+	forks := []confirmEvent{}
+	for _, h := range heights {
+		if h.Empty() {
+			events = append(events, confirmEvent{position: h.Block.Position})
+		} else {
+			events = append(events, confirmEvent{
+				position: h.Block.Position, block: h.Block})
+		}
+		for _, f := range h.Forks {
+			forks = append(forks, confirmEvent{position: f.Position, block: f})
+		}
+	}
+	rest := events[1:]
+	r.Shuffle(len(rest), func(i, j int) {
+		rest[i], rest[j] = rest[j], rest[i]
+	})
+	for i := 0; i < count/4; i++ {
+		events = append(events, events[r.Intn(len(events))])
+	}
+	events = append(events, forks...)
+	return
+}
+
+// runBlockChain feeds events to blockChain and collects delivered blocks.
+func (s *BlockChainDifferentialTestSuite) runBlockChain(
+	events []confirmEvent) (delivered []*types.Block) {
+	bc := newBlockChain(s.nID, s.dMoment, nil, test.NewApp(0, nil, nil),
+		&testTSigVerifierGetter{}, s.signer, &common.NullLogger{})
+	s.Require().NoError(bc.notifyRoundEvents([]utils.RoundEventParam{
+		utils.RoundEventParam{
+			Round:       0,
+			BeginHeight: types.GenesisHeight,
+			Config: &types.Config{
+				MinBlockInterval: s.blockInterval,
+				RoundLength:      1000,
+			}}}))
+	for _, e := range events {
+		// Errors are expected for duplicated confirmations, and divergence
+		// would be caught by comparing delivered blocks.
+		if e.block == nil {
+			bc.addEmptyBlock(e.position)
+		} else {
+			bc.addBlock(e.block)
+		}
+		delivered = append(delivered, bc.extractBlocks()...)
+	}
+	return
+}
+
+func (s *BlockChainDifferentialTestSuite) runReference(
+	events []confirmEvent) []*types.Block {
+	ref := test.NewReferenceChain(s.dMoment, s.blockInterval)
+	for _, e := range events {
+		if e.block == nil {
+			ref.ConfirmEmpty(e.position)
+		} else {
+			ref.Confirm(e.block)
+		}
+	}
+	return ref.Delivered()
+}
+
+// diverge compares results of the implementation under test and the reference
+// one, and describes the first difference found.
+func (s *BlockChainDifferentialTestSuite) diverge(events []confirmEvent,
+	run func([]confirmEvent) []*types.Block) (diff string) {
+	if len(events) == 0 || !events[0].position.Equal(
+		types.Position{Height: types.GenesisHeight}) {
+		// Not a valid input.
+		return
+	}
+	actual, expected := run(events), s.runReference(events)
+	for i := 0; i < len(actual) || i < len(expected); i++ {
+		switch {
+		case i >= len(actual):
+			return fmt.Sprintf("missing %s", expected[i])
+		case i >= len(expected):
+			return fmt.Sprintf("unexpected %s", actual[i])
+		}
+		a, e := actual[i], expected[i]
+		if a.Hash != e.Hash || !a.Position.Equal(e.Position) ||
+			a.ParentHash != e.ParentHash || !a.Timestamp.Equal(e.Timestamp) {
+			return fmt.Sprintf("delivered %s, expected %s", a, e)
+		}
+	}
+	return
+}
+
+// minimize drops events one by one as long as the divergence remains.
+func (s *BlockChainDifferentialTestSuite) minimize(events []confirmEvent,
+	run func([]confirmEvent) []*types.Block) []confirmEvent {
+	for shrunk := true; shrunk; {
+		shrunk = false
+		for i := range events {
+			candidate := append(append([]confirmEvent{}, events[:i]...),
+				events[i+1:]...)
+			if s.diverge(candidate, run) != "" {
+				events, shrunk = candidate, true
+				break
+			}
+		}
+	}
+	return events
+}
+
+func (s *BlockChainDifferentialTestSuite) TestAgainstReference() {
+	seed := time.Now().UnixNano()
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < 200; i++ {
+		events := s.genEvents(r, r.Intn(30)+1)
+		if s.diverge(events, s.runBlockChain) == "" {
+			continue
+		}
+		events = s.minimize(events, s.runBlockChain)
+		steps := []string{}
+		for _, e := range events {
+			steps = append(steps, e.String())
+		}
+		s.FailNow(s.diverge(events, s.runBlockChain), "seed: %d, counterexample: %s",
+			seed, strings.Join(steps, " -> "))
+	}
+}
+
+func (s *BlockChainDifferentialTestSuite) TestMinimize() {
+	// An implementation failing to deliver the block at height 3.
+	faulty := func(events []confirmEvent) (delivered []*types.Block) {
+		for _, b := range s.runBlockChain(events) {
+			if b.Position.Height == 3 {
+				break
+			}
+			delivered = append(delivered, b)
+		}
+		return
+	}
+	events := []confirmEvent{}
+	for _, h := range []uint64{1, 5, 3, 2, 6, 4} {
+		events = append(events, confirmEvent{
+			position: types.Position{Height: h}})
+	}
+	s.Require().NotEmpty(s.diverge(events, faulty))
+	s.Require().Empty(s.diverge(events, s.runBlockChain))
+	// Only confirmations up to height 3 are necessary to reproduce.
+	minimized := s.minimize(events, faulty)
+	s.Require().Len(minimized, 3)
+	for i, h := range []uint64{1, 3, 2} {
+		s.Require().Equal(h, minimized[i].position.Height)
+	}
+}
+
+func TestBlockChainDifferential(t *testing.T) {
+	suite.Run(t, new(BlockChainDifferentialTestSuite))
+}
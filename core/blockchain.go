@@ -40,18 +40,82 @@ var (
 	ErrIncorrectParentHash      = errors.New("incorrect parent hash")
 	ErrInvalidBlockHeight       = errors.New("invalid block height")
 	ErrInvalidRoundID           = errors.New("invalid round id")
-	ErrInvalidTimestamp         = errors.New("invalid timestamp")
+	ErrBlockIntervalTooShort    = errors.New("block interval too short")
+	ErrBlockIntervalTooLong     = errors.New("block interval too long")
 	ErrNotFollowTipPosition     = errors.New("not follow tip position")
 	ErrDuplicatedPendingBlock   = errors.New("duplicated pending block")
 	ErrRetrySanityCheckLater    = errors.New("retry sanity check later")
 	ErrRoundNotSwitch           = errors.New("round not switch")
 	ErrIncorrectAgreementResult = errors.New(
 		"incorrect block randomness result")
-	ErrMissingRandomness = errors.New("missing block randomness")
+	ErrMissingRandomness      = errors.New("missing block randomness")
+	ErrSystemMessageTooLarge  = errors.New("system message too large")
+	ErrTooManySystemMessages  = errors.New("too many system messages")
+	ErrMissingProtocolParams  = errors.New("missing protocol parameters hash")
+	ErrProtocolParamsMismatch = errors.New(
+		"mismatched protocol parameters hash")
+	ErrIncorrectWitness = errors.New("incorrect witness")
 )
 
+// ErrInvalidTimestamp is returned by sanity check when a block is proposed
+// too early.
+//
+// Deprecated: use ErrBlockIntervalTooShort, blocks proposed too late are
+// reported by ErrBlockIntervalTooLong.
+var ErrInvalidTimestamp = ErrBlockIntervalTooShort
+
+// sanityCheckFailureNames are names of reasons of sanity check failures
+// reported to metrics.
+var sanityCheckFailureNames = map[error]string{
+	ErrNotGenesisBlock:              "not-genesis-block",
+	ErrIsGenesisBlock:               "is-genesis-block",
+	ErrIncorrectParentHash:          "incorrect-parent-hash",
+	ErrInvalidBlockHeight:           "invalid-block-height",
+	ErrInvalidRoundID:               "invalid-round-id",
+	ErrBlockIntervalTooShort:        "block-interval-too-short",
+	ErrBlockIntervalTooLong:         "block-interval-too-long",
+	ErrRoundNotSwitch:               "round-not-switch",
+	ErrProtocolParamsMismatch:       "protocol-params-mismatch",
+	ErrMissingProtocolParams:        "missing-protocol-params",
+	ErrIncorrectWitness:             "incorrect-witness",
+	utils.ErrIncorrectHash:          "incorrect-hash",
+	utils.ErrIncorrectSignature:     "incorrect-signature",
+	utils.ErrSystemMessagesTooLarge: "system-messages-too-large",
+	utils.ErrTooManySystemMessages:  "too-many-system-messages",
+}
+
+// sanityCheckFailureCounter returns the name of the counter of a sanity check
+// failure.
+func sanityCheckFailureCounter(err error) string {
+	name, exist := sanityCheckFailureNames[err]
+	if !exist {
+		name = "other"
+	}
+	return "sanity-check-failures-" + name
+}
+
 const notReadyHeight uint64 = math.MaxUint64
 
+// BacklogAges are ages of the oldest blocks waiting in each stage of the
+// pipeline, an age is zero when no block is waiting in that stage.
+type BacklogAges struct {
+	// Undelivered is the age of the oldest block confirmed but not delivered
+	// yet, ex. waiting for its randomness.
+	Undelivered time.Duration
+	// Unwitnessed is the age of the oldest block delivered but not acked by
+	// the witness of any confirmed block yet.
+	Unwitnessed time.Duration
+}
+
+type deliveredRecord struct {
+	height uint64
+	time   time.Time
+}
+
+// maxPendingSystemMessages is the maximum count of system messages waiting to
+// be carried by blocks proposed by this node.
+const maxPendingSystemMessages = 64
+
 type pendingBlockRecord struct {
 	position types.Position
 	block    *types.Block
@@ -111,13 +175,44 @@ type blockChainConfig struct {
 	utils.RoundBasedConfig
 
 	minBlockInterval time.Duration
+	maxBlockInterval time.Duration
+	paramsHash       common.Hash
 }
 
 func (c *blockChainConfig) fromConfig(round uint64, config *types.Config) {
 	c.minBlockInterval = config.MinBlockInterval
+	c.maxBlockInterval = config.MaxBlockInterval
+	c.paramsHash = NewProtocolParams(config).Hash()
 	c.SetupRoundBasedFields(round, config)
 }
 
+// checkBlockInterval checks if the timestamp of a block follows its parent's
+// within the range of block interval.
+func (c *blockChainConfig) checkBlockInterval(parent, t time.Time) error {
+	if t.Before(parent.Add(c.minBlockInterval)) {
+		return ErrBlockIntervalTooShort
+	}
+	if c.maxBlockInterval > 0 && t.After(parent.Add(c.maxBlockInterval)) {
+		return ErrBlockIntervalTooLong
+	}
+	return nil
+}
+
+// adjustTimestamp returns the nearest timestamp to the proposing time which
+// follows the range of block interval.
+func (c *blockChainConfig) adjustTimestamp(
+	parent, proposeTime time.Time) time.Time {
+	if minT := parent.Add(c.minBlockInterval); proposeTime.Before(minT) {
+		return minT
+	}
+	if c.maxBlockInterval > 0 {
+		if maxT := parent.Add(c.maxBlockInterval); proposeTime.After(maxT) {
+			return maxT
+		}
+	}
+	return proposeTime
+}
+
 func newBlockChainConfig(prev blockChainConfig, config *types.Config) (
 	c blockChainConfig) {
 	c = blockChainConfig{}
@@ -145,6 +240,13 @@ type blockChain struct {
 	pendingBlocks       pendingBlockRecords
 	confirmedBlocks     types.BlocksByPosition
 	dMoment             time.Time
+	witnessVetoer       WitnessVetoer
+	registry            utils.NodeIdentityRegistry
+	opts                *options
+	deliveredTimes      map[uint64]time.Time
+	pendingSysMsgs      [][]byte
+	confirmedTimes      map[types.Position]time.Time
+	unwitnessed         []deliveredRecord
 
 	// Do not access this variable besides processAgreementResult.
 	lastPosition types.Position
@@ -164,7 +266,18 @@ func newBlockChain(nID types.NodeID, dMoment time.Time, initBlock *types.Block,
 		dMoment:       dMoment,
 		pendingRandomnesses: make(
 			map[types.Position][]byte),
+		deliveredTimes: make(map[uint64]time.Time),
+		confirmedTimes: make(map[types.Position]time.Time),
+		opts:           newOptions(nil),
+	}
+}
+
+// preparePayload prepares the payload of a block proposed by this node.
+func (bc *blockChain) preparePayload(position types.Position) ([]byte, error) {
+	if bc.opts.payloadSource != nil {
+		return bc.opts.payloadSource(position)
 	}
+	return bc.app.PreparePayload(position)
 }
 
 func (bc *blockChain) notifyRoundEvents(evts []utils.RoundEventParam) error {
@@ -237,6 +350,23 @@ func (bc *blockChain) extractBlocks() (ret []*types.Block) {
 		c, bc.confirmedBlocks = bc.confirmedBlocks[0], bc.confirmedBlocks[1:]
 		ret = append(ret, c)
 		bc.lastDelivered = c
+		delete(bc.confirmedTimes, c.Position)
+		if bc.lastConfirmed.Witness.Height < c.Position.Height {
+			bc.unwitnessed = append(bc.unwitnessed,
+				deliveredRecord{height: c.Position.Height, time: time.Now()})
+		}
+	}
+	if bc.witnessVetoer != nil && len(ret) > 0 {
+		now := time.Now()
+		delay := bc.witnessVetoer.WitnessAckDelay()
+		for h, t := range bc.deliveredTimes {
+			if now.Sub(t) >= delay {
+				delete(bc.deliveredTimes, h)
+			}
+		}
+		for _, b := range ret {
+			bc.deliveredTimes[b.Position.Height] = now
+		}
 	}
 	return
 }
@@ -250,9 +380,12 @@ func (bc *blockChain) sanityCheck(b *types.Block) error {
 			return ErrNotGenesisBlock
 		}
 		if b.Timestamp.Before(bc.dMoment.Add(bc.configs[0].minBlockInterval)) {
-			return ErrInvalidTimestamp
+			return ErrBlockIntervalTooShort
 		}
-		return nil
+		if err := bc.checkWitness(b, nil); err != nil {
+			return err
+		}
+		return bc.checkProtocolParams(b, bc.configs[0])
 	}
 	if b.IsGenesis() {
 		return ErrIsGenesisBlock
@@ -272,20 +405,71 @@ func (bc *blockChain) sanityCheck(b *types.Block) error {
 		if b.Position.Round != bc.lastConfirmed.Position.Round {
 			return ErrInvalidRoundID
 		}
+		if (b.ProtocolParamsHash != common.Hash{}) {
+			return ErrProtocolParamsMismatch
+		}
 	}
 	if !b.ParentHash.Equal(bc.lastConfirmed.Hash) {
 		return ErrIncorrectParentHash
 	}
-	if b.Timestamp.Before(bc.lastConfirmed.Timestamp.Add(
-		tipConfig.minBlockInterval)) {
-		return ErrInvalidTimestamp
+	if err := tipConfig.checkBlockInterval(
+		bc.lastConfirmed.Timestamp, b.Timestamp); err != nil {
+		return err
+	}
+	if tipConfig.IsLastBlock(bc.lastConfirmed) {
+		// The parameters of the next round are verifiable only when its
+		// config is known.
+		if len(bc.configs) < 2 {
+			return ErrRetrySanityCheckLater
+		}
+		if err := bc.checkProtocolParams(b, bc.configs[1]); err != nil {
+			return err
+		}
+	}
+	if err := bc.checkWitness(b, bc.lastConfirmed); err != nil {
+		return err
 	}
-	if err := utils.VerifyBlockSignature(b); err != nil {
+	if err := utils.VerifyBlockSignature(b, bc.registry); err != nil {
 		return err
 	}
 	return nil
 }
 
+// checkWitness verifies the witness newly acked by a block, witnesses carried
+// over from its parent are verified already.
+func (bc *blockChain) checkWitness(b, parent *types.Block) error {
+	if parent == nil {
+		if b.Witness.Height == 0 && len(b.Witness.Data) == 0 {
+			return nil
+		}
+	} else if b.Witness.Height == parent.Witness.Height &&
+		bytes.Equal(b.Witness.Data, parent.Witness.Data) {
+		return nil
+	}
+	if err := utils.VerifyWitness(&b.Witness); err != nil {
+		bc.logger.Warn("Incorrect witness",
+			"block", b,
+			"error", err)
+		return ErrIncorrectWitness
+	}
+	return nil
+}
+
+// checkProtocolParams checks the hash of protocol parameters carried by the
+// first block of a round, which is required for proposals. Blocks in old
+// encodings without that hash are only added when syncing, without sanity
+// check.
+func (bc *blockChain) checkProtocolParams(
+	b *types.Block, config blockChainConfig) error {
+	if (b.ProtocolParamsHash == common.Hash{}) {
+		return ErrMissingProtocolParams
+	}
+	if b.ProtocolParamsHash != config.paramsHash {
+		return ErrProtocolParamsMismatch
+	}
+	return nil
+}
+
 // addEmptyBlock is called when an empty block is confirmed by BA.
 func (bc *blockChain) addEmptyBlock(position types.Position) (
 	*types.Block, error) {
@@ -378,6 +562,26 @@ func (bc *blockChain) confirmed(h uint64) bool {
 	return r.block != nil
 }
 
+// unconfirmedPositions returns at most count positions following the last
+// confirmed block, up to the limit height. Positions of rounds whose configs
+// are not ready yet are not returned.
+func (bc *blockChain) unconfirmedPositions(
+	limit uint64, count int) (positions []types.Position) {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	h := types.GenesisHeight
+	if bc.lastConfirmed != nil {
+		h = bc.lastConfirmed.Position.Height + 1
+	}
+	for _, c := range bc.configs {
+		for ; h <= limit && len(positions) < count && c.Contains(h); h++ {
+			positions = append(positions,
+				types.Position{Round: c.RoundID(), Height: h})
+		}
+	}
+	return
+}
+
 func (bc *blockChain) nextBlock() (uint64, time.Time) {
 	bc.lock.RLock()
 	defer bc.lock.RUnlock()
@@ -387,13 +591,33 @@ func (bc *blockChain) nextBlock() (uint64, time.Time) {
 	if tip == nil {
 		return types.GenesisHeight, bc.dMoment
 	}
-	if tip != bc.lastDelivered {
+	if tip != bc.lastDelivered && !bc.pipelinable(tip) {
 		// If tip is not delivered, we should not proceed to next block.
 		return notReadyHeight, time.Time{}
 	}
 	return tip.Position.Height + 1, tip.Timestamp.Add(config.minBlockInterval)
 }
 
+// pipelinable checks if BA could proceed to the height after the undelivered
+// tip, which is allowed when it's the only confirmed block not delivered yet.
+func (bc *blockChain) pipelinable(tip *types.Block) bool {
+	if !bc.opts.pipelinedBA {
+		return false
+	}
+	if bc.lastDelivered == nil {
+		return tip.IsGenesis()
+	}
+	return bc.lastDelivered.Position.Height+1 == tip.Position.Height
+}
+
+// suggestTimestamp returns a timestamp for the next block which is closest
+// to 'now' and follows the range of block interval from current tip.
+func (bc *blockChain) suggestTimestamp(now time.Time) time.Time {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	return bc.suggestTimestampNoLock(now)
+}
+
 func (bc *blockChain) pendingBlocksWithoutRandomness() []*types.Block {
 	bc.lock.RLock()
 	defer bc.lock.RUnlock()
@@ -419,12 +643,42 @@ func (bc *blockChain) pendingBlocksWithoutRandomness() []*types.Block {
 	return blocks
 }
 
+// backlogAges returns ages of the oldest blocks waiting in the pipeline, both
+// ages are taken from the same snapshot.
+func (bc *blockChain) backlogAges(now time.Time) (ages BacklogAges) {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	if len(bc.confirmedBlocks) > 0 {
+		if t, exist := bc.confirmedTimes[bc.confirmedBlocks[0].Position]; exist {
+			ages.Undelivered = now.Sub(t)
+		}
+	}
+	if len(bc.unwitnessed) > 0 {
+		ages.Unwitnessed = now.Sub(bc.unwitnessed[0].time)
+	}
+	return
+}
+
+// backlogSizes returns the count of pending blocks not confirmed yet, and the
+// count of blocks confirmed but not delivered.
+func (bc *blockChain) backlogSizes() (pending, undelivered int) {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	return len(bc.pendingBlocks), len(bc.confirmedBlocks)
+}
+
 func (bc *blockChain) lastDeliveredBlock() *types.Block {
 	bc.lock.RLock()
 	defer bc.lock.RUnlock()
 	return bc.lastDelivered
 }
 
+func (bc *blockChain) lastConfirmedBlock() *types.Block {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	return bc.lastConfirmed
+}
+
 func (bc *blockChain) lastPendingBlock() *types.Block {
 	bc.lock.RLock()
 	defer bc.lock.RUnlock()
@@ -502,6 +756,19 @@ func (bc *blockChain) purgeConfig() {
 	}
 }
 
+func (bc *blockChain) suggestTimestampNoLock(now time.Time) time.Time {
+	if bc.lastConfirmed == nil {
+		// The range of block interval is not applicable to genesis block.
+		if minT := bc.dMoment.Add(
+			bc.configs[0].minBlockInterval); now.Before(minT) {
+			return minT
+		}
+		return now
+	}
+	tipConfig := bc.tipConfig()
+	return tipConfig.adjustTimestamp(bc.lastConfirmed.Timestamp, now)
+}
+
 func (bc *blockChain) verifyRandomness(
 	blockHash common.Hash, round uint64, randomness []byte) (bool, error) {
 	if round < DKGDelayRound {
@@ -540,7 +807,7 @@ func (bc *blockChain) prepareBlock(position types.Position,
 			b.Timestamp = minExpectedTime
 		} else {
 			bc.logger.Debug("Calling genesis Application.PreparePayload")
-			if b.Payload, err = bc.app.PreparePayload(b.Position); err != nil {
+			if b.Payload, err = bc.preparePayload(b.Position); err != nil {
 				b = nil
 				return
 			}
@@ -549,9 +816,10 @@ func (bc *blockChain) prepareBlock(position types.Position,
 				b = nil
 				return
 			}
-			if proposeTime.Before(minExpectedTime) {
-				b.Timestamp = minExpectedTime
+			if !bc.witnessAckable(b.Witness) {
+				b.Witness = types.Witness{}
 			}
+			b.Timestamp = bc.suggestTimestampNoLock(proposeTime)
 		}
 	} else {
 		tipConfig := bc.tipConfig()
@@ -570,12 +838,12 @@ func (bc *blockChain) prepareBlock(position types.Position,
 				return
 			}
 		}
-		minExpectedTime := tip.Timestamp.Add(bc.configs[0].minBlockInterval)
+		minExpectedTime := tip.Timestamp.Add(tipConfig.minBlockInterval)
 		b.ParentHash = tip.Hash
 		if !empty {
 			bc.logger.Debug("Calling Application.PreparePayload",
 				"position", b.Position)
-			if b.Payload, err = bc.app.PreparePayload(b.Position); err != nil {
+			if b.Payload, err = bc.preparePayload(b.Position); err != nil {
 				b = nil
 				return
 			}
@@ -586,9 +854,14 @@ func (bc *blockChain) prepareBlock(position types.Position,
 				b = nil
 				return
 			}
-			if b.Timestamp.Before(minExpectedTime) {
-				b.Timestamp = minExpectedTime
+			if !bc.witnessAckable(b.Witness) {
+				bc.logger.Debug("Withholding the ack of witness",
+					"witness-height", b.Witness.Height,
+					"position", b.Position)
+				b.Witness.Height = tip.Witness.Height
+				b.Witness.Data = common.CopyBytes(tip.Witness.Data)
 			}
+			b.Timestamp = bc.suggestTimestampNoLock(proposeTime)
 		} else {
 			b.Witness.Height = tip.Witness.Height
 			b.Witness.Data = make([]byte, len(tip.Witness.Data))
@@ -602,6 +875,13 @@ func (bc *blockChain) prepareBlock(position types.Position,
 			return
 		}
 	} else {
+		b.SystemMessages = bc.systemMessagesToPropose()
+		if tip == nil {
+			b.ProtocolParamsHash = bc.configs[0].paramsHash
+		} else if tipConfig := bc.tipConfig(); tipConfig.IsLastBlock(tip) &&
+			len(bc.configs) > 1 {
+			b.ProtocolParamsHash = bc.configs[1].paramsHash
+		}
 		if err = bc.signer.SignBlock(b); err != nil {
 			b = nil
 			return
@@ -610,6 +890,72 @@ func (bc *blockChain) prepareBlock(position types.Position,
 	return
 }
 
+// proposeSystemMessage queues a system message to be carried by blocks
+// proposed by this node, until one of them is confirmed.
+func (bc *blockChain) proposeSystemMessage(msg []byte) error {
+	if len(msg) > types.MaxSystemMessagesSize {
+		return ErrSystemMessageTooLarge
+	}
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+	if len(bc.pendingSysMsgs) >= maxPendingSystemMessages {
+		return ErrTooManySystemMessages
+	}
+	bc.pendingSysMsgs = append(bc.pendingSysMsgs, common.CopyBytes(msg))
+	return nil
+}
+
+// hasPendingSystemMessages checks if any system message is waiting to be
+// carried by blocks.
+func (bc *blockChain) hasPendingSystemMessages() bool {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	return len(bc.pendingSysMsgs) > 0
+}
+
+// systemMessagesToPropose returns pending system messages, in the order they
+// are proposed, within the size and count limits of a block. The lock should
+// be held.
+func (bc *blockChain) systemMessagesToPropose() (msgs [][]byte) {
+	size := 0
+	for _, msg := range bc.pendingSysMsgs {
+		if size+len(msg) > types.MaxSystemMessagesSize ||
+			len(msgs) >= types.MaxSystemMessagesCount {
+			break
+		}
+		size += len(msg)
+		msgs = append(msgs, common.CopyBytes(msg))
+	}
+	return
+}
+
+// removeSystemMessages removes pending system messages carried by a confirmed
+// block. The lock should be held.
+func (bc *blockChain) removeSystemMessages(msgs [][]byte) {
+	for _, msg := range msgs {
+		for i, pending := range bc.pendingSysMsgs {
+			if bytes.Equal(msg, pending) {
+				bc.pendingSysMsgs = append(
+					bc.pendingSysMsgs[:i], bc.pendingSysMsgs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// witnessAckable checks if a witness could be acked in a proposed block, the
+// lock should be held.
+func (bc *blockChain) witnessAckable(w types.Witness) bool {
+	if bc.witnessVetoer == nil || w.Height < types.GenesisHeight {
+		return true
+	}
+	if t, exist := bc.deliveredTimes[w.Height]; exist &&
+		time.Since(t) < bc.witnessVetoer.WitnessAckDelay() {
+		return false
+	}
+	return !bc.witnessVetoer.VetoWitness(w)
+}
+
 func (bc *blockChain) tipConfig() blockChainConfig {
 	if bc.lastConfirmed == nil {
 		panic(fmt.Errorf("attempting to access config without tip"))
@@ -629,8 +975,18 @@ func (bc *blockChain) confirmBlock(b *types.Block) {
 	}
 	bc.logger.Debug("Calling Application.BlockConfirmed", "block", b)
 	bc.app.BlockConfirmed(*b)
+	if b.ProposerID == bc.ID {
+		bc.removeSystemMessages(b.SystemMessages)
+	}
 	bc.lastConfirmed = b
 	bc.confirmedBlocks = append(bc.confirmedBlocks, b)
+	now := time.Now()
+	bc.confirmedTimes[b.Position] = now
+	for len(bc.unwitnessed) > 0 &&
+		bc.unwitnessed[0].height <= b.Witness.Height {
+		bc.opts.observe("witness-ack-wait", now.Sub(bc.unwitnessed[0].time))
+		bc.unwitnessed = bc.unwitnessed[1:]
+	}
 	bc.purgeConfig()
 }
 
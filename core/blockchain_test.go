@@ -18,6 +18,7 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"testing"
@@ -47,6 +48,38 @@ func (t *testTSigVerifierGetter) UpdateAndGet(round uint64) (
 
 func (t *testTSigVerifierGetter) Purge(_ uint64) {}
 
+// vetoApp is an Application instance withholding witness acks.
+type vetoApp struct {
+	*test.App
+	witness types.Witness
+	delay   time.Duration
+	veto    bool
+}
+
+func (app *vetoApp) PrepareWitness(_ uint64) (types.Witness, error) {
+	return app.witness, nil
+}
+
+func (app *vetoApp) WitnessAckDelay() time.Duration {
+	return app.delay
+}
+
+func (app *vetoApp) VetoWitness(_ types.Witness) bool {
+	return app.veto
+}
+
+// rejectingWitnessHasher rejects all witness data.
+type rejectingWitnessHasher struct{}
+
+func (h *rejectingWitnessHasher) HashWitness(
+	witness *types.Witness) (common.Hash, error) {
+	return crypto.Keccak256Hash(witness.Data), nil
+}
+
+func (h *rejectingWitnessHasher) VerifyWitness(_ *types.Witness) error {
+	return errors.New("rejected")
+}
+
 type BlockChainTestSuite struct {
 	suite.Suite
 
@@ -54,6 +87,7 @@ type BlockChainTestSuite struct {
 	signer        *utils.Signer
 	dMoment       time.Time
 	blockInterval time.Duration
+	forger        *test.BlockForger
 }
 
 func (s *BlockChainTestSuite) SetupSuite() {
@@ -63,6 +97,7 @@ func (s *BlockChainTestSuite) SetupSuite() {
 	s.signer = utils.NewSigner(prvKeys[0])
 	s.dMoment = time.Now().UTC()
 	s.blockInterval = 1 * time.Millisecond
+	s.forger = test.NewBlockForger(prvKeys[0], s.blockInterval, 1024)
 }
 
 func (s *BlockChainTestSuite) newBlocks(c uint64, initBlock *types.Block) (
@@ -131,6 +166,19 @@ func (s *BlockChainTestSuite) newBlock(parent *types.Block, round uint64,
 	return b
 }
 
+// withProtocolParams carries the hash of protocol parameters expected by a
+// block chain on the first block of a round, and signs the block again.
+func (s *BlockChainTestSuite) withProtocolParams(
+	bc *blockChain, b *types.Block) *types.Block {
+	if b.IsGenesis() {
+		b.ProtocolParamsHash = bc.configs[0].paramsHash
+	} else {
+		b.ProtocolParamsHash = bc.configs[1].paramsHash
+	}
+	s.Require().NoError(s.signer.SignBlock(b))
+	return b
+}
+
 func (s *BlockChainTestSuite) newRandomnessFromBlock(
 	b *types.Block) *types.AgreementResult {
 	return &types.AgreementResult{
@@ -306,8 +354,12 @@ func (s *BlockChainTestSuite) TestConcurrentAccess() {
 
 func (s *BlockChainTestSuite) TestSanityCheck() {
 	bc := s.newBlockChain(nil, 4)
-	blocks := s.newBlocks(3, nil)
-	b0, b1, b2 := blocks[0], blocks[1], blocks[2]
+	// Genesis block should carry the hash of protocol parameters.
+	b0 := s.newBlocks(1, nil)[0]
+	s.Require().Equal(ErrMissingProtocolParams, bc.sanityCheck(b0))
+	b0 = s.withProtocolParams(bc, b0)
+	blocks := s.newBlocks(2, b0)
+	b1, b2 := blocks[0], blocks[1]
 	// ErrNotGenesisBlock
 	s.Require().Equal(ErrNotGenesisBlock.Error(), bc.sanityCheck(b1).Error())
 	// Genesis block should pass sanity check.
@@ -334,6 +386,19 @@ func (s *BlockChainTestSuite) TestSanityCheck() {
 	s.Require().Equal(
 		ErrRoundNotSwitch.Error(),
 		bc.sanityCheck(s.newBlock(b3, 0, 1*time.Second)).Error())
+	// The first block of the next round is verifiable once its config is
+	// known.
+	s.Require().Equal(ErrRetrySanityCheckLater,
+		bc.sanityCheck(s.newBlock(b3, 1, 1*time.Second)))
+	s.Require().NoError(bc.notifyRoundEvents([]utils.RoundEventParam{
+		utils.RoundEventParam{
+			Round:       1,
+			Reset:       0,
+			BeginHeight: types.GenesisHeight + 4,
+			Config: &types.Config{
+				MinBlockInterval: s.blockInterval,
+				RoundLength:      4,
+			}}}))
 	b4 := &types.Block{
 		ParentHash: b2.Hash,
 		Position: types.Position{
@@ -346,14 +411,51 @@ func (s *BlockChainTestSuite) TestSanityCheck() {
 	// ErrIncorrectParentHash
 	s.Require().EqualError(ErrIncorrectParentHash, bc.sanityCheck(b4).Error())
 	b4.ParentHash = b3.Hash
-	// ErrInvalidTimestamp
-	s.Require().EqualError(ErrInvalidTimestamp, bc.sanityCheck(b4).Error())
+	// ErrBlockIntervalTooShort, which is still ErrInvalidTimestamp.
+	s.Require().EqualError(
+		ErrBlockIntervalTooShort, bc.sanityCheck(b4).Error())
+	s.Require().Equal(ErrInvalidTimestamp, bc.sanityCheck(b4))
 	b4.Timestamp = b3.Timestamp.Add(1 * time.Second)
 	// There is no valid signature attached.
 	s.Require().Error(bc.sanityCheck(b4))
-	// OK case.
+	// ErrMissingProtocolParams
 	s.Require().NoError(s.signer.SignBlock(b4))
-	s.Require().NoError(bc.sanityCheck(b4))
+	s.Require().Equal(ErrMissingProtocolParams, bc.sanityCheck(b4))
+	// OK case.
+	s.Require().NoError(bc.sanityCheck(s.withProtocolParams(bc, b4)))
+}
+
+func (s *BlockChainTestSuite) TestSanityCheckForgedBlocks() {
+	bc := s.newBlockChain(nil, 100)
+	blocks := s.newBlocks(2, nil)
+	s.Require().NoError(bc.addBlock(blocks[0]))
+	s.Require().NoError(bc.addBlock(blocks[1]))
+	crs := common.NewRandomHash()
+	for _, t := range []struct {
+		defect test.BlockDefect
+		err    error
+	}{
+		{test.BlockDefectNone, nil},
+		{test.BlockDefectWrongParent, ErrIncorrectParentHash},
+		{test.BlockDefectWrongHeight, ErrRetrySanityCheckLater},
+		{test.BlockDefectWrongRound, ErrInvalidRoundID},
+		{test.BlockDefectEarlyTimestamp, ErrBlockIntervalTooShort},
+		{test.BlockDefectPayloadHashMismatch, utils.ErrIncorrectHash},
+		{test.BlockDefectBadSignature, utils.ErrIncorrectSignature},
+	} {
+		b, err := s.forger.Forge(blocks[1], crs, t.defect)
+		s.Require().NoError(err)
+		s.Require().Equal(t.err, bc.sanityCheck(b), t.defect.String())
+		// The CRS signature of blocks not forging it should be valid.
+		if b.Position.Round < DKGDelayRound {
+			s.Require().True(utils.VerifyCRSSignature(b, crs, nil))
+		}
+	}
+	// The forged CRS signature would be caught.
+	b, err := s.forger.Forge(blocks[1], crs, test.BlockDefectForgedCRSSignature)
+	s.Require().NoError(err)
+	s.Require().NoError(bc.sanityCheck(b))
+	s.Require().False(utils.VerifyCRSSignature(b, crs, nil))
 }
 
 func (s *BlockChainTestSuite) TestNotifyRoundEvents() {
@@ -428,11 +530,32 @@ func (s *BlockChainTestSuite) TestNextBlockAndTipRound() {
 	// Add a block, which is the last block of this round.
 	b3 := s.newBlock(blocks[2], 1, 1*time.Second)
 	s.Require().NoError(bc.addBlock(blocks[1]))
-	s.Require().NoError(bc.sanityCheck(b3))
+	s.Require().NoError(bc.sanityCheck(s.withProtocolParams(bc, b3)))
 	s.Require().NoError(bc.addBlock(b3))
 	s.Require().Equal(bc.tipRound(), uint64(1))
 }
 
+func (s *BlockChainTestSuite) TestNextBlockPipelined() {
+	bc := s.newBlockChain(nil, 10)
+	bc.opts = newOptions([]Option{WithPipelinedBA()})
+	blocks := s.newBlocks(3, nil)
+	// Genesis block is confirmed but not delivered, BA could proceed.
+	s.Require().NoError(bc.addBlock(blocks[0]))
+	nextH, nextT := bc.nextBlock()
+	s.Require().Equal(uint64(2), nextH)
+	s.Require().Equal(
+		blocks[0].Timestamp.Add(bc.configs[0].minBlockInterval), nextT)
+	// Two blocks are not delivered, BA should wait.
+	s.Require().NoError(bc.addBlock(blocks[1]))
+	nextH, _ = bc.nextBlock()
+	s.Require().Equal(notReadyHeight, nextH)
+	// Deliver confirmed blocks, BA could proceed once the next one is added.
+	s.Require().Len(bc.extractBlocks(), 2)
+	s.Require().NoError(bc.addBlock(blocks[2]))
+	nextH, _ = bc.nextBlock()
+	s.Require().Equal(uint64(4), nextH)
+}
+
 func (s *BlockChainTestSuite) TestPendingBlocksWithoutRandomness() {
 	initBlock := s.newRoundOneInitBlock()
 	bc := s.newBlockChain(initBlock, 10)
@@ -625,6 +748,390 @@ func (s *BlockChainTestSuite) TestPrepareBlock() {
 	prepare2(true)
 }
 
+func (s *BlockChainTestSuite) TestWitnessVeto() {
+	bc := s.newBlockChain(nil, 100)
+	app := &vetoApp{App: test.NewApp(0, nil, nil), delay: time.Hour}
+	bc.app, bc.witnessVetoer = app, app
+	blocks := s.newBlocks(2, nil)
+	blocks[1].Witness = types.Witness{Height: 0, Data: []byte("w0")}
+	s.Require().NoError(bc.addBlock(blocks[0]))
+	s.Require().NoError(bc.addBlock(blocks[1]))
+	s.Require().Len(bc.extractBlocks(), 2)
+	app.witness = types.Witness{
+		Height: blocks[1].Position.Height,
+		Data:   []byte("w2"),
+	}
+	pos := types.Position{Height: blocks[1].Position.Height + 1}
+	propose := func() types.Witness {
+		b, err := bc.proposeBlock(pos, time.Now().UTC(), false)
+		s.Require().NoError(err)
+		return b.Witness
+	}
+	// Witness is withheld within the delay.
+	s.Require().Equal(blocks[1].Witness, propose())
+	// Witness is withheld when vetoed.
+	app.delay, app.veto = 0, true
+	s.Require().Equal(blocks[1].Witness, propose())
+	// Witness is acked.
+	app.veto = false
+	s.Require().Equal(app.witness, propose())
+	// Delivery records older than the delay are purged.
+	b2 := s.newBlock(blocks[1], 0, s.blockInterval)
+	s.Require().NoError(bc.addBlock(b2))
+	s.Require().Len(bc.extractBlocks(), 1)
+	s.Require().Len(bc.deliveredTimes, 1)
+}
+
+func (s *BlockChainTestSuite) TestWitnessHasher() {
+	defer utils.SetWitnessHasher(nil)
+	bc := s.newBlockChain(nil, 100)
+	blocks := s.newBlocks(1, nil)
+	s.Require().NoError(bc.addBlock(blocks[0]))
+	b1 := s.newBlock(blocks[0], 0, s.blockInterval)
+	b1.Witness = types.Witness{Height: 1, Data: []byte("w1")}
+	s.Require().NoError(s.signer.SignBlock(b1))
+	s.Require().NoError(bc.sanityCheck(b1))
+	// Newly acked witness is verified by the hasher.
+	utils.SetWitnessHasher(&rejectingWitnessHasher{})
+	s.Require().NoError(s.signer.SignBlock(b1))
+	s.Require().Equal(ErrIncorrectWitness, bc.sanityCheck(b1))
+	// Witness carried over from parent is not verified again.
+	b1.Witness = blocks[0].Witness
+	s.Require().NoError(s.signer.SignBlock(b1))
+	s.Require().NoError(bc.sanityCheck(b1))
+}
+
+func (s *BlockChainTestSuite) TestSystemMessages() {
+	bc := s.newBlockChain(nil, 100)
+	s.Require().Equal(ErrSystemMessageTooLarge, bc.proposeSystemMessage(
+		make([]byte, types.MaxSystemMessagesSize+1)))
+	msg0 := make([]byte, types.MaxSystemMessagesSize/2)
+	msg1 := []byte("msg1")
+	msg2 := make([]byte, types.MaxSystemMessagesSize/2)
+	s.Require().NoError(bc.proposeSystemMessage(msg0))
+	s.Require().NoError(bc.proposeSystemMessage(msg1))
+	s.Require().NoError(bc.proposeSystemMessage(msg2))
+	// Messages exceeding the size limit are left to later blocks.
+	b0, err := bc.proposeBlock(
+		types.Position{Height: types.GenesisHeight}, s.dMoment, false)
+	s.Require().NoError(err)
+	s.Require().Equal([][]byte{msg0, msg1}, b0.SystemMessages)
+	s.Require().NoError(utils.VerifyBlockSignature(b0, nil))
+	// Messages are not carried by empty blocks.
+	empty0, err := bc.proposeBlock(
+		types.Position{Height: types.GenesisHeight}, s.dMoment, true)
+	s.Require().NoError(err)
+	s.Require().Empty(empty0.SystemMessages)
+	// Forged system messages are detected.
+	forged := b0.Clone()
+	forged.SystemMessages[1] = []byte("forged")
+	s.Require().Equal(utils.ErrIncorrectHash,
+		utils.VerifyBlockSignature(forged, nil))
+	// Messages are removed once confirmed.
+	s.Require().NoError(bc.addBlock(b0))
+	s.Require().Equal([][]byte{msg2}, bc.pendingSysMsgs)
+	b1, err := bc.proposeBlock(
+		types.Position{Height: types.GenesisHeight + 1}, s.dMoment, false)
+	s.Require().NoError(err)
+	s.Require().Equal([][]byte{msg2}, b1.SystemMessages)
+	// The count of pending messages is limited.
+	for i := 1; i < maxPendingSystemMessages; i++ {
+		s.Require().NoError(bc.proposeSystemMessage(msg1))
+	}
+	s.Require().Equal(ErrTooManySystemMessages, bc.proposeSystemMessage(msg1))
+	// Messages exceeding the count limit are left to later blocks.
+	b2, err := bc.proposeBlock(
+		types.Position{Height: types.GenesisHeight + 1}, s.dMoment, false)
+	s.Require().NoError(err)
+	s.Require().Len(b2.SystemMessages, types.MaxSystemMessagesCount)
+	s.Require().NoError(utils.VerifyBlockSignature(b2, nil))
+	// Blocks carrying too many messages are rejected.
+	b2.SystemMessages = append(b2.SystemMessages, msg1)
+	s.Require().NoError(bc.signer.SignBlock(b2))
+	s.Require().Equal(utils.ErrTooManySystemMessages,
+		utils.VerifyBlockSignature(b2, nil))
+}
+
+func (s *BlockChainTestSuite) TestBacklogAges() {
+	bc := s.newBlockChain(nil, 100)
+	metrics := &fakeMetrics{
+		durations: make(map[string]int),
+		counters:  make(map[string]uint64),
+	}
+	bc.opts = newOptions([]Option{WithMetrics(metrics)})
+	later := func() time.Time { return time.Now().Add(time.Second) }
+	s.Require().Equal(BacklogAges{}, bc.backlogAges(later()))
+	b0 := s.newBlocks(1, nil)[0]
+	s.Require().NoError(bc.addBlock(b0))
+	ages := bc.backlogAges(later())
+	s.Require().True(ages.Undelivered >= time.Second)
+	s.Require().Zero(ages.Unwitnessed)
+	pending, undelivered := bc.backlogSizes()
+	s.Require().Zero(pending)
+	s.Require().Equal(1, undelivered)
+	// Delivered but not witnessed.
+	s.Require().Len(bc.extractBlocks(), 1)
+	ages = bc.backlogAges(later())
+	s.Require().Zero(ages.Undelivered)
+	s.Require().True(ages.Unwitnessed >= time.Second)
+	// Witnessed by the next confirmed block.
+	b1 := &types.Block{
+		ParentHash: b0.Hash,
+		Position:   types.Position{Height: b0.Position.Height + 1},
+		Timestamp:  b0.Timestamp.Add(s.blockInterval),
+		Witness:    types.Witness{Height: b0.Position.Height},
+		Randomness: NoRand,
+	}
+	s.Require().NoError(s.signer.SignBlock(b1))
+	s.Require().NoError(bc.addBlock(b1))
+	ages = bc.backlogAges(later())
+	s.Require().True(ages.Undelivered >= time.Second)
+	s.Require().Zero(ages.Unwitnessed)
+	s.Require().Equal(1, metrics.durations["witness-ack-wait"])
+	s.Require().Len(bc.extractBlocks(), 1)
+	s.Require().Empty(bc.confirmedTimes)
+	s.Require().Len(bc.unwitnessed, 1)
+}
+
+func (s *BlockChainTestSuite) TestSanityCheckFailureCounter() {
+	s.Require().Equal("sanity-check-failures-incorrect-parent-hash",
+		sanityCheckFailureCounter(ErrIncorrectParentHash))
+	s.Require().Equal("sanity-check-failures-incorrect-signature",
+		sanityCheckFailureCounter(utils.ErrIncorrectSignature))
+	s.Require().Equal("sanity-check-failures-other",
+		sanityCheckFailureCounter(errors.New("unknown")))
+}
+
+func (s *BlockChainTestSuite) TestUnconfirmedPositions() {
+	var roundLength uint64 = 3
+	bc := s.newBlockChain(nil, roundLength)
+	s.Require().NoError(bc.notifyRoundEvents([]utils.RoundEventParam{
+		utils.RoundEventParam{
+			Round:       1,
+			Reset:       0,
+			BeginHeight: types.GenesisHeight + roundLength,
+			Config: &types.Config{
+				MinBlockInterval: s.blockInterval,
+				RoundLength:      roundLength,
+			},
+		}}))
+	positions := bc.unconfirmedPositions(100, 4)
+	s.Require().Equal([]types.Position{
+		{Round: 0, Height: types.GenesisHeight},
+		{Round: 0, Height: types.GenesisHeight + 1},
+		{Round: 0, Height: types.GenesisHeight + 2},
+		{Round: 1, Height: types.GenesisHeight + 3},
+	}, positions)
+	// Limited by height.
+	s.Require().Len(bc.unconfirmedPositions(types.GenesisHeight, 4), 1)
+	// Limited by configs ready.
+	blocks := s.newBlocks(2, nil)
+	for _, b := range blocks {
+		s.Require().NoError(bc.addBlock(b))
+	}
+	positions = bc.unconfirmedPositions(100, 10)
+	s.Require().Len(positions, 4)
+	s.Require().Equal(blocks[1].Position.Height+1, positions[0].Height)
+}
+
+func (s *BlockChainTestSuite) TestBlockInterval() {
+	roundLength := uint64(2)
+	bc := newBlockChain(s.nID, s.dMoment, nil, test.NewApp(0, nil, nil),
+		&testTSigVerifierGetter{}, s.signer, &common.NullLogger{})
+	s.Require().NoError(bc.notifyRoundEvents([]utils.RoundEventParam{
+		utils.RoundEventParam{
+			Round:       0,
+			BeginHeight: types.GenesisHeight,
+			Config: &types.Config{
+				MinBlockInterval: 1 * time.Second,
+				MaxBlockInterval: 3 * time.Second,
+				RoundLength:      roundLength,
+			}},
+		utils.RoundEventParam{
+			Round:       1,
+			BeginHeight: types.GenesisHeight + roundLength,
+			Config: &types.Config{
+				MinBlockInterval: 2 * time.Second,
+				MaxBlockInterval: 5 * time.Second,
+				RoundLength:      roundLength,
+			}}}))
+	b0, err := bc.prepareBlock(
+		types.Position{Height: types.GenesisHeight}, s.dMoment, false)
+	s.Require().NoError(err)
+	s.Require().NoError(bc.sanityCheck(b0))
+	s.Require().NoError(bc.addBlock(b0))
+	// Both bounds of block interval should be checked.
+	s.Require().Equal(ErrBlockIntervalTooShort.Error(),
+		bc.sanityCheck(s.newBlock(b0, 0, 500*time.Millisecond)).Error())
+	s.Require().Equal(ErrBlockIntervalTooLong.Error(),
+		bc.sanityCheck(s.newBlock(b0, 0, 4*time.Second)).Error())
+	b1 := s.newBlock(b0, 0, 3*time.Second)
+	s.Require().NoError(bc.sanityCheck(b1))
+	s.Require().NoError(bc.addBlock(b1))
+	// The block interval across round boundary should follow the config of
+	// the previous round.
+	s.Require().Equal(ErrBlockIntervalTooLong.Error(),
+		bc.sanityCheck(s.newBlock(b1, 1, 4*time.Second)).Error())
+	s.Require().NoError(bc.sanityCheck(
+		s.withProtocolParams(bc, s.newBlock(b1, 1, 1*time.Second))))
+	// The timestamp of a proposed block should be adjusted to follow the
+	// range of block interval.
+	pos := types.Position{Round: 1, Height: types.GenesisHeight + 2}
+	b2, err := bc.prepareBlock(pos, b1.Timestamp.Add(time.Hour), false)
+	s.Require().NoError(err)
+	s.Require().True(b2.Timestamp.Equal(b1.Timestamp.Add(3 * time.Second)))
+	s.Require().NoError(bc.sanityCheck(b2))
+	b2, err = bc.prepareBlock(pos, b1.Timestamp, false)
+	s.Require().NoError(err)
+	s.Require().True(b2.Timestamp.Equal(b1.Timestamp.Add(1 * time.Second)))
+	b2.Randomness = common.GenerateRandomBytes()
+	s.Require().NoError(bc.addBlock(b2))
+	// Once round switched, the config of new round should be used.
+	s.Require().Equal(ErrBlockIntervalTooShort.Error(),
+		bc.sanityCheck(s.newBlock(b2, 1, 1500*time.Millisecond)).Error())
+	s.Require().NoError(bc.sanityCheck(s.newBlock(b2, 1, 5*time.Second)))
+}
+
+func (s *BlockChainTestSuite) TestSuggestTimestampWithDriftingClocks() {
+	bc := s.newBlockChain(nil, 100)
+	bc.configs[0].maxBlockInterval = 10 * s.blockInterval
+	// Clocks of proposers are away from the real time in both directions,
+	// and drift apart as time goes.
+	offsets := []time.Duration{
+		-3 * s.blockInterval, 0, s.blockInterval / 2, 4 * s.blockInterval}
+	drifts := []float64{-0.01, 0, 0.005, 0.02}
+	now := s.dMoment
+	var tip *types.Block
+	for i := 0; i < 40; i++ {
+		now = now.Add(s.blockInterval)
+		j := i % len(offsets)
+		clock := now.Add(offsets[j] +
+			time.Duration(float64(now.Sub(s.dMoment))*drifts[j]))
+		pos := types.Position{Height: types.GenesisHeight + uint64(i)}
+		b, err := bc.prepareBlock(pos, bc.suggestTimestamp(clock), false)
+		s.Require().NoError(err)
+		// Timestamps should always be monotone and keep the minimum block
+		// interval, no matter how clocks drift.
+		if tip != nil {
+			s.Require().False(
+				b.Timestamp.Before(tip.Timestamp.Add(s.blockInterval)))
+			s.Require().False(
+				b.Timestamp.After(tip.Timestamp.Add(10 * s.blockInterval)))
+		}
+		s.Require().NoError(bc.sanityCheck(b))
+		s.Require().NoError(bc.addBlock(b))
+		tip = b
+	}
+}
+
+func (s *BlockChainTestSuite) TestSuggestTimestamp() {
+	bc := s.newBlockChain(nil, 10)
+	bc.configs[0].maxBlockInterval = 10 * s.blockInterval
+	// Before genesis block, the timestamp should not precede dMoment.
+	s.Require().True(bc.suggestTimestamp(s.dMoment.Add(-time.Second)).Equal(
+		s.dMoment.Add(s.blockInterval)))
+	now := s.dMoment.Add(time.Hour)
+	s.Require().True(bc.suggestTimestamp(now).Equal(now))
+	blocks := s.newBlocks(1, nil)
+	s.Require().NoError(bc.addBlock(blocks[0]))
+	tipT := blocks[0].Timestamp
+	// The suggested timestamp should be monotone to the tip.
+	s.Require().True(bc.suggestTimestamp(tipT.Add(-time.Second)).Equal(
+		tipT.Add(s.blockInterval)))
+	s.Require().True(bc.suggestTimestamp(tipT.Add(5 * s.blockInterval)).Equal(
+		tipT.Add(5 * s.blockInterval)))
+	s.Require().True(bc.suggestTimestamp(tipT.Add(time.Hour)).Equal(
+		tipT.Add(10 * s.blockInterval)))
+}
+
+func (s *BlockChainTestSuite) TestProtocolParams() {
+	var roundLength uint64 = 2
+	bc := s.newBlockChain(nil, roundLength)
+	config1 := &types.Config{
+		MinBlockInterval: s.blockInterval,
+		RoundLength:      roundLength,
+	}
+	s.Require().NoError(bc.notifyRoundEvents([]utils.RoundEventParam{
+		utils.RoundEventParam{
+			Round:       1,
+			Reset:       0,
+			BeginHeight: types.GenesisHeight + roundLength,
+			Config:      config1,
+		}}))
+	// The genesis block carries the hash of protocol parameters.
+	b0, err := bc.prepareBlock(
+		types.Position{Height: types.GenesisHeight}, s.dMoment, false)
+	s.Require().NoError(err)
+	s.Require().Equal(bc.configs[0].paramsHash, b0.ProtocolParamsHash)
+	s.Require().NoError(bc.sanityCheck(b0))
+	s.Require().NoError(bc.addBlock(b0))
+	// Other blocks in a round should not carry it.
+	b1, err := bc.prepareBlock(types.Position{Height: b0.Position.Height + 1},
+		b0.Timestamp.Add(s.blockInterval), false)
+	s.Require().NoError(err)
+	s.Require().Equal(common.Hash{}, b1.ProtocolParamsHash)
+	b1.ProtocolParamsHash = bc.configs[0].paramsHash
+	s.Require().NoError(s.signer.SignBlock(b1))
+	s.Require().Equal(ErrProtocolParamsMismatch, bc.sanityCheck(b1))
+	b1.ProtocolParamsHash = common.Hash{}
+	s.Require().NoError(s.signer.SignBlock(b1))
+	s.Require().NoError(bc.sanityCheck(b1))
+	s.Require().NoError(bc.addBlock(b1))
+	// The first block of the next round carries the hash of its parameters.
+	b2, err := bc.prepareBlock(
+		types.Position{Round: 1, Height: b1.Position.Height + 1},
+		b1.Timestamp.Add(s.blockInterval), false)
+	s.Require().NoError(err)
+	s.Require().Equal(NewProtocolParams(config1).Hash(), b2.ProtocolParamsHash)
+	s.Require().NoError(bc.sanityCheck(b2))
+	// Blocks proposed with different parameters should be rejected.
+	config1.LambdaBA = time.Second
+	b2.ProtocolParamsHash = NewProtocolParams(config1).Hash()
+	s.Require().NoError(s.signer.SignBlock(b2))
+	s.Require().Equal(ErrProtocolParamsMismatch, bc.sanityCheck(b2))
+}
+
+func (s *BlockChainTestSuite) TestStaleGovernance() {
+	var roundLength uint64 = 2
+	// The governance of one node returns the configuration of the previous
+	// round after the round changes.
+	config0 := &types.Config{
+		MinBlockInterval: s.blockInterval,
+		RoundLength:      roundLength,
+	}
+	config1 := config0.Clone()
+	config1.LambdaBA = time.Second
+	bc := s.newBlockChain(nil, roundLength)
+	staleBC := s.newBlockChain(nil, roundLength)
+	for _, pair := range []struct {
+		bc     *blockChain
+		config *types.Config
+	}{{bc, config1}, {staleBC, config0}} {
+		s.Require().NoError(pair.bc.notifyRoundEvents([]utils.RoundEventParam{
+			utils.RoundEventParam{
+				Round:       1,
+				Reset:       0,
+				BeginHeight: types.GenesisHeight + roundLength,
+				Config:      pair.config,
+			}}))
+	}
+	blocks := s.newBlocks(2, nil)
+	for _, b := range blocks {
+		s.Require().NoError(bc.addBlock(b))
+		s.Require().NoError(staleBC.addBlock(b))
+	}
+	pos := types.Position{Round: 1, Height: blocks[1].Position.Height + 1}
+	proposeTime := blocks[1].Timestamp.Add(s.blockInterval)
+	b, err := bc.prepareBlock(pos, proposeTime, false)
+	s.Require().NoError(err)
+	staleB, err := staleBC.prepareBlock(pos, proposeTime, false)
+	s.Require().NoError(err)
+	// Both sides detect the inconsistency instead of confirming either one.
+	s.Require().NoError(bc.sanityCheck(b))
+	s.Require().Equal(ErrProtocolParamsMismatch, bc.sanityCheck(staleB))
+	s.Require().Equal(ErrProtocolParamsMismatch, staleBC.sanityCheck(b))
+}
+
 func TestBlockChain(t *testing.T) {
 	suite.Run(t, new(BlockChainTestSuite))
 }
@@ -0,0 +1,125 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// bootstrapBarrierTimeoutFactor decides how long the first DKG would wait for
+// its participants at most, in the unit of LambdaDKG.
+const bootstrapBarrierTimeoutFactor = 4
+
+// readinessInterval is the interval to broadcast readiness before the first
+// DKG, peers started later would miss readiness broadcasted earlier.
+const readinessInterval = time.Second
+
+// bootstrapBarrier makes the first DKG wait for its participants. Nodes
+// announce their readiness by signed messages before the first DKG, and the
+// registration of the first DKG is started when a quorum of the DKG set is
+// seen, or the wait expires.
+type bootstrapBarrier struct {
+	lock     sync.RWMutex
+	seen     map[types.NodeID]struct{}
+	done     bool
+	registry utils.NodeIdentityRegistry
+}
+
+func newBootstrapBarrier(
+	registry utils.NodeIdentityRegistry) *bootstrapBarrier {
+	return &bootstrapBarrier{
+		seen:     make(map[types.NodeID]struct{}),
+		registry: registry,
+	}
+}
+
+// needed checks if readiness from a node is still interesting.
+func (b *bootstrapBarrier) needed(nID types.NodeID) bool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if b.done {
+		return false
+	}
+	_, exist := b.seen[nID]
+	return !exist
+}
+
+func (b *bootstrapBarrier) announce(nID types.NodeID) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.done {
+		return
+	}
+	b.seen[nID] = struct{}{}
+}
+
+// announceByReadiness takes a readiness as the announcement from its proposer.
+// Readiness of nodes already seen is not verified again.
+func (b *bootstrapBarrier) announceByReadiness(r *types.Readiness) error {
+	if r.Round != DKGDelayRound || !b.needed(r.ProposerID) {
+		return nil
+	}
+	ok, err := utils.VerifyReadinessSignature(r, b.registry)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrIncorrectReadinessSignature
+	}
+	b.announce(r.ProposerID)
+	return nil
+}
+
+func (b *bootstrapBarrier) countIn(nodes map[types.NodeID]struct{}) (cnt int) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for nID := range nodes {
+		if _, exist := b.seen[nID]; exist {
+			cnt++
+		}
+	}
+	return
+}
+
+// wait blocks until a quorum of nodes are seen, timeout, or expired returns
+// true. The barrier is done after waited, announcements are no longer
+// collected.
+func (b *bootstrapBarrier) wait(parentCtx context.Context,
+	nodes map[types.NodeID]struct{}, quorum int, timeout time.Duration,
+	expired func() bool) (ready bool) {
+	defer func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		b.done = true
+		b.seen = nil
+	}()
+	ctx, cancel := context.WithTimeout(parentCtx, timeout)
+	defer cancel()
+	checkWithCancel(ctx, 100*time.Millisecond, func() bool {
+		if ready = b.countIn(nodes) >= quorum; ready {
+			return true
+		}
+		return expired()
+	})
+	return
+}
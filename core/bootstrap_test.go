@@ -0,0 +1,119 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+type BootstrapTestSuite struct {
+	suite.Suite
+}
+
+func (s *BootstrapTestSuite) TestBarrier() {
+	var (
+		req     = s.Require()
+		prvKeys = test.GenerateRandomPrivateKeys(4)
+		nodes   = make(map[types.NodeID]struct{})
+		b       = newBootstrapBarrier(nil)
+	)
+	for _, k := range prvKeys {
+		nodes[types.NewNodeID(k.PublicKey())] = struct{}{}
+	}
+	// Announce by valid readiness.
+	for _, k := range prvKeys[:2] {
+		r := &types.Readiness{Round: DKGDelayRound}
+		req.NoError(utils.NewSigner(k).SignReadiness(r))
+		req.NoError(b.announceByReadiness(r))
+	}
+	// Readiness with invalid signatures are rejected.
+	r := &types.Readiness{Round: DKGDelayRound}
+	req.NoError(utils.NewSigner(prvKeys[2]).SignReadiness(r))
+	r.ProposerID = types.NewNodeID(prvKeys[3].PublicKey())
+	req.Equal(ErrIncorrectReadinessSignature, b.announceByReadiness(r))
+	// Readiness of other rounds are ignored.
+	r = &types.Readiness{Round: DKGDelayRound + 1}
+	req.NoError(utils.NewSigner(prvKeys[2]).SignReadiness(r))
+	req.NoError(b.announceByReadiness(r))
+	req.Equal(2, b.countIn(nodes))
+	// Quorum is not reached, wait until timeout.
+	now := time.Now()
+	req.False(b.wait(context.Background(), nodes, 3, 200*time.Millisecond,
+		func() bool { return false }))
+	req.True(time.Since(now) >= 200*time.Millisecond)
+	// The barrier is done.
+	b.announce(types.NewNodeID(prvKeys[3].PublicKey()))
+	req.False(b.needed(types.NewNodeID(prvKeys[3].PublicKey())))
+}
+
+func (s *BootstrapTestSuite) TestBarrierQuorum() {
+	var (
+		req     = s.Require()
+		prvKeys = test.GenerateRandomPrivateKeys(4)
+		nodes   = make(map[types.NodeID]struct{})
+		b       = newBootstrapBarrier(nil)
+	)
+	for _, k := range prvKeys {
+		nodes[types.NewNodeID(k.PublicKey())] = struct{}{}
+	}
+	// Nodes outside the set are not counted.
+	b.announce(types.NodeID{Hash: common.NewRandomHash()})
+	go func() {
+		for _, k := range prvKeys[:3] {
+			time.Sleep(50 * time.Millisecond)
+			b.announce(types.NewNodeID(k.PublicKey()))
+		}
+	}()
+	req.True(b.wait(context.Background(), nodes, 3, 10*time.Second,
+		func() bool { return false }))
+}
+
+func (s *BootstrapTestSuite) TestBarrierExpired() {
+	var (
+		req     = s.Require()
+		prvKeys = test.GenerateRandomPrivateKeys(4)
+		nodes   = make(map[types.NodeID]struct{})
+		b       = newBootstrapBarrier(nil)
+		expired int32
+	)
+	for _, k := range prvKeys {
+		nodes[types.NewNodeID(k.PublicKey())] = struct{}{}
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		atomic.StoreInt32(&expired, 1)
+	}()
+	now := time.Now()
+	req.False(b.wait(context.Background(), nodes, 3, 10*time.Second,
+		func() bool { return atomic.LoadInt32(&expired) == 1 }))
+	req.True(time.Since(now) < 10*time.Second)
+}
+
+func TestBootstrap(t *testing.T) {
+	suite.Run(t, new(BootstrapTestSuite))
+}
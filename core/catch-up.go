@@ -0,0 +1,122 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+const (
+	// catchUpInterval is the interval to check the lag and pull agreement
+	// results when catching up.
+	catchUpInterval = time.Second
+	// catchUpPullCount is the count of positions pulled in one interval.
+	catchUpPullCount = 16
+)
+
+// isCatchingUp checks if this node stops participating BA to catch up.
+func (con *Consensus) isCatchingUp() bool {
+	return atomic.LoadInt32(&con.catchingUp) == 1
+}
+
+// IsCatchingUp checks if this node stops participating BA to catch up with
+// the network, see WithCatchUp.
+func (con *Consensus) IsCatchingUp() bool {
+	return con.isCatchingUp()
+}
+
+// checkCatchUp switches between participating BA and catching up by the lag
+// to the network frontier, it returns true when switched. Nothing is switched
+// until at least f+1 notaries of the current round reported watermarks.
+func (con *Consensus) checkCatchUp(now time.Time) bool {
+	frontier, count := con.frontier(now)
+	if count == 0 {
+		return false
+	}
+	var delivered uint64
+	if b := con.bcModule.lastDeliveredBlock(); b != nil {
+		delivered = b.Position.Height
+	}
+	var lag uint64
+	if frontier.Height > delivered {
+		lag = frontier.Height - delivered
+	}
+	switch {
+	case !con.isCatchingUp() && lag > con.opts.catchUpLag:
+		atomic.StoreInt32(&con.catchingUp, 1)
+		con.logger.Warn("Start catching up",
+			"delivered", delivered,
+			"frontier", frontier)
+		con.opts.incCounter("catch-up-started", 1)
+		con.events.publish(Event{Type: EventCatchUpStarted, Position: frontier})
+	case con.isCatchingUp() && lag <= con.opts.catchUpLag/2:
+		atomic.StoreInt32(&con.catchingUp, 0)
+		con.logger.Info("Finish catching up",
+			"delivered", delivered,
+			"frontier", frontier)
+		con.events.publish(Event{Type: EventCatchUpFinished, Position: frontier})
+	default:
+		return false
+	}
+	return true
+}
+
+// pullUnconfirmed pulls agreement results of positions between the last
+// confirmed block and the network frontier.
+func (con *Consensus) pullUnconfirmed(frontier types.Position) {
+	positions := con.bcModule.unconfirmedPositions(
+		frontier.Height, catchUpPullCount)
+	if len(positions) == 0 {
+		return
+	}
+	if puller, ok := con.network.(AgreementResultPuller); ok {
+		con.logger.Debug("Calling Network.PullAgreementResults for catching up",
+			"from", positions[0],
+			"count", len(positions))
+		puller.PullAgreementResults(positions)
+		return
+	}
+	con.logger.Debug("Calling Network.PullVotes for catching up",
+		"position", positions[0])
+	con.network.PullVotes(positions[0])
+}
+
+// catchUp checks the lag periodically, and pulls agreement results when
+// catching up.
+func (con *Consensus) catchUp() {
+	defer con.waitGroup.Done()
+	ticker := time.NewTicker(catchUpInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-con.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		con.checkCatchUp(time.Now())
+		if !con.isCatchingUp() {
+			continue
+		}
+		if frontier, ok := con.NetworkFrontier(); ok {
+			con.pullUnconfirmed(frontier)
+		}
+	}
+}
@@ -43,8 +43,65 @@ var (
 		"skip but no error")
 	ErrDKGAborted = fmt.Errorf(
 		"DKG is aborted")
+	ErrIncorrectDKGArtifacts = fmt.Errorf(
+		"incorrect DKG artifacts")
+	ErrDKGRoundSkipped = fmt.Errorf(
+		"DKG of round before joining is skipped")
 )
 
+// ErrDKGPhaseWindow is reported when a DKG message is received out of the
+// window of its phase.
+type ErrDKGPhaseWindow struct {
+	Message      string
+	Round, Reset uint64
+	Step         int
+}
+
+func (e ErrDKGPhaseWindow) Error() string {
+	return fmt.Sprintf("%s out of phase window: round(%d %d) step %d",
+		e.Message, e.Round, e.Reset, e.Step)
+}
+
+// Indexes of steps in dkgRunPhases where windows of DKG phases end, DKG
+// messages are useless to others after the step of their phase is done.
+const (
+	// Master public keys and MPK-ready are registered until MPKs are ready.
+	dkgStepWaitMPKReady = 0
+	// Private shares, complaints and finalize are exchanged until DKG
+	// finalize is proposed.
+	dkgStepProposeFinalize = 5
+)
+
+// dkgStateRetention is the count of rounds, before the latest round whose DKG
+// result is known, to keep DKG states in memory. Results of older rounds are
+// recovered from governance and database when requested again.
+const dkgStateRetention = 2
+
+// dkgPhaseHeights returns the height offsets of steps in dkgRunPhases from
+// the beginning of DKG, the last one is where the DKG ends.
+func dkgPhaseHeights(cfg *types.Config) []uint64 {
+	toHeight := func(d time.Duration) uint64 {
+		return uint64(d.Nanoseconds() / cfg.MinBlockInterval.Nanoseconds())
+	}
+	register, complaint, finalize := cfg.DKGPhaseDurations()
+	steps := []time.Duration{
+		// Phase 1: wait for MPKs registered.
+		register,
+		// Phase 2 ~ 8: exchange shares, complain and propose finalize.
+		complaint, complaint, complaint, complaint, complaint,
+		// Phase 9: wait for DKG finalized.
+		finalize,
+	}
+	heights := make([]uint64, 0, len(steps)+1)
+	heights = append(heights, 0)
+	var offset time.Duration
+	for _, d := range steps {
+		offset += d
+		heights = append(heights, toHeight(offset))
+	}
+	return heights
+}
+
 // ErrMismatchDKG represent an attempt to run DKG protocol is failed because
 // the register DKG protocol is mismatched, interms of round and resetCount.
 type ErrMismatchDKG struct {
@@ -86,6 +143,17 @@ type configurationChain struct {
 	dkgCtx       context.Context
 	dkgCtxCancel context.CancelFunc
 	dkgRunning   bool
+	// DKG of rounds before joinRound are never run by this node, they are
+	// verified by group public keys published in governance.
+	joinRound uint64
+	// DKG artifacts pulled from peers, indexed by their hashes.
+	artifactsPuller   DKGArtifactsPuller
+	artifactsDigester DKGArtifactDigester
+	artifactsLock     sync.RWMutex
+	pulledMPKs        map[uint64]map[common.Hash]*typesDKG.MasterPublicKey
+	pulledComplaints  map[uint64]map[common.Hash]*typesDKG.Complaint
+	// retention is the count of rounds to keep DKG states in memory.
+	retention uint64
 }
 
 func newConfigurationChain(
@@ -108,11 +176,22 @@ func newConfigurationChain(
 		cache:       cache,
 		db:          dbInst,
 		pendingPsig: make(map[common.Hash][]*typesDKG.PartialSignature),
+		pulledMPKs: make(
+			map[uint64]map[common.Hash]*typesDKG.MasterPublicKey),
+		pulledComplaints: make(
+			map[uint64]map[common.Hash]*typesDKG.Complaint),
+		retention: dkgStateRetention,
 	}
 	configurationChain.initDKGPhasesFunc()
 	return configurationChain
 }
 
+// skipRoundsBefore makes DKG of rounds before a round skipped, it should be
+// called before any DKG message is processed.
+func (cc *configurationChain) skipRoundsBefore(round uint64) {
+	cc.joinRound = round
+}
+
 func (cc *configurationChain) abortDKG(
 	parentCtx context.Context,
 	round, reset uint64) bool {
@@ -203,10 +282,19 @@ func (cc *configurationChain) registerDKG(
 	cc.notarySet = notarySet
 	cc.pendingPrvShare = make(map[types.NodeID]*typesDKG.PrivateShare)
 	cc.mpkReady = false
-	cc.dkg, err = recoverDKGProtocol(cc.ID, cc.recv, round, reset, cc.db)
+	cc.purgeDKGArtifacts(round)
+	err = retryDB(func() (err error) {
+		cc.dkg, err = recoverDKGProtocol(cc.ID, cc.recv, round, reset, cc.db)
+		return
+	})
 	cc.dkgCtx, cc.dkgCtxCancel = context.WithCancel(parentCtx)
 	if err != nil {
-		panic(err)
+		// This node would miss this DKG, and rejoin DKG of later rounds.
+		cc.logger.Error("Failed to recover DKG protocol",
+			"round", round,
+			"reset", reset,
+			"error", err)
+		return
 	}
 	if cc.dkg == nil {
 		cc.dkg = newDKGProtocol(
@@ -223,6 +311,7 @@ func (cc *configurationChain) registerDKG(
 			return
 		}
 	}
+	cc.dkg.registry = cc.cache.Registry()
 
 	go func() {
 		ticker := newTicker(cc.gov, round, TickerDKG)
@@ -231,7 +320,9 @@ func (cc *configurationChain) registerDKG(
 		cc.dkgLock.Lock()
 		defer cc.dkgLock.Unlock()
 		if cc.dkg != nil && cc.dkg.round == round && cc.dkg.reset == reset {
-			cc.dkg.proposeMPKReady()
+			if err := cc.dkg.proposeMPKReady(); err != nil {
+				cc.logger.Warn("Skip proposing MPK ready", "error", err)
+			}
 		}
 	}()
 }
@@ -264,6 +355,9 @@ func (cc *configurationChain) runDKGPhaseOne(round uint64, reset uint64) error {
 		}
 		cc.dkgLock.Lock()
 	}
+	if err == nil && len(cc.masterPublicKeys(round)) < len(cc.notarySet) {
+		cc.pullDKGArtifacts(round)
+	}
 	return err
 }
 
@@ -271,7 +365,7 @@ func (cc *configurationChain) runDKGPhaseTwoAndThree(
 	round uint64, reset uint64) error {
 	// Check if this node successfully join the protocol.
 	cc.logger.Debug("Calling Governance.DKGMasterPublicKeys", "round", round)
-	mpks := cc.gov.DKGMasterPublicKeys(round)
+	mpks := cc.masterPublicKeys(round)
 	inProtocol := false
 	for _, mpk := range mpks {
 		if mpk.ProposerID == cc.ID {
@@ -316,13 +410,17 @@ func (cc *configurationChain) runDKGPhaseTwoAndThree(
 
 func (cc *configurationChain) runDKGPhaseFour() {
 	// Phase 4(T = λ): Propose nack complaints.
-	cc.dkg.proposeNackComplaints()
+	if err := cc.dkg.proposeNackComplaints(); err != nil {
+		cc.logger.Warn("Skip proposing nack complaints", "error", err)
+	}
+	// Complaints are collected in next phase, catch up those missed.
+	cc.pullDKGArtifacts(cc.dkg.round)
 }
 
 func (cc *configurationChain) runDKGPhaseFiveAndSix(round uint64, reset uint64) {
 	// Phase 5(T = 2λ): Propose Anti nack complaint.
 	cc.logger.Debug("Calling Governance.DKGComplaints", "round", round)
-	cc.complaints = cc.gov.DKGComplaints(round)
+	cc.complaints = cc.dkgComplaints(round)
 	if err := cc.dkg.processNackComplaints(cc.complaints); err != nil {
 		cc.logger.Error("Failed to process NackComplaint",
 			"round", round,
@@ -336,13 +434,17 @@ func (cc *configurationChain) runDKGPhaseFiveAndSix(round uint64, reset uint64)
 
 func (cc *configurationChain) runDKGPhaseSeven() {
 	// Phase 7(T = 4λ): Enforce complaints and nack complaints.
-	cc.dkg.enforceNackComplaints(cc.complaints)
+	if err := cc.dkg.enforceNackComplaints(cc.complaints); err != nil {
+		cc.logger.Warn("Skip enforcing nack complaints", "error", err)
+	}
 	// Enforce complaint is done in `processPrivateShare`.
 }
 
 func (cc *configurationChain) runDKGPhaseEight() {
 	// Phase 8(T = 5λ): DKG finalize.
-	cc.dkg.proposeFinalize()
+	if err := cc.dkg.proposeFinalize(); err != nil {
+		cc.logger.Warn("Skip proposing DKG finalize", "error", err)
+	}
 }
 
 func (cc *configurationChain) runDKGPhaseNine(round uint64, reset uint64) error {
@@ -370,8 +472,8 @@ func (cc *configurationChain) runDKGPhaseNine(round uint64, reset uint64) error
 	cc.logger.Debug("Calling Governance.DKGMasterPublicKeys", "round", round)
 	cc.logger.Debug("Calling Governance.DKGComplaints", "round", round)
 	npks, err := typesDKG.NewNodePublicKeys(round,
-		cc.gov.DKGMasterPublicKeys(round),
-		cc.gov.DKGComplaints(round),
+		cc.masterPublicKeys(round),
+		cc.dkgComplaints(round),
 		cc.dkg.threshold)
 	if err != nil {
 		return err
@@ -402,10 +504,14 @@ func (cc *configurationChain) runDKGPhaseNine(round uint64, reset uint64) error
 		return err
 	}
 	cc.dkg.proposeSuccess()
-	cc.dkgResult.Lock()
-	defer cc.dkgResult.Unlock()
-	cc.dkgSigner[round] = signer
-	cc.npks[round] = npks
+	func() {
+		cc.dkgResult.Lock()
+		defer cc.dkgResult.Unlock()
+		cc.dkgSigner[round] = signer
+		cc.npks[round] = npks
+	}()
+	// The private key is persisted, states of stale rounds are useless.
+	cc.purgeStaleDKGStates(round)
 	return nil
 }
 
@@ -447,9 +553,14 @@ func (cc *configurationChain) runDKG(
 		return ErrSkipButNoError
 	}
 	cfg := utils.GetConfigWithPanic(cc.gov, round, cc.logger)
-	phaseHeight := uint64(
-		cfg.LambdaDKG.Nanoseconds() / cfg.MinBlockInterval.Nanoseconds())
-	skipPhase := int(dkgHeight / phaseHeight)
+	phaseHeights := dkgPhaseHeights(cfg)
+	skipPhase := -1
+	for _, h := range phaseHeights {
+		if h > dkgHeight {
+			break
+		}
+		skipPhase++
+	}
 	cc.logger.Info("Skipping DKG phase", "phase", skipPhase)
 	cc.dkgLock.Lock()
 	defer cc.dkgLock.Unlock()
@@ -484,7 +595,7 @@ func (cc *configurationChain) runDKG(
 	cc.dkg.step = skipPhase
 	for i := skipPhase; i < len(cc.dkgRunPhases); i++ {
 		wg.Add(1)
-		event.RegisterHeight(dkgBeginHeight+phaseHeight*uint64(i), func(uint64) {
+		event.RegisterHeight(dkgBeginHeight+phaseHeights[i], func(uint64) {
 			go func() {
 				defer wg.Done()
 				cc.dkgLock.Lock()
@@ -555,6 +666,10 @@ func (cc *configurationChain) getDKGInfo(
 	}
 	npks, signer := getFromCache()
 	if npks == nil || (!ignoreSigner && signer == nil) {
+		if !ignoreSigner && round < cc.joinRound {
+			// This node never holds private shares of these rounds.
+			return nil, nil, ErrDKGRoundSkipped
+		}
 		if err := cc.recoverDKGInfo(round, ignoreSigner); err != nil {
 			return nil, nil, err
 		}
@@ -586,10 +701,10 @@ func (cc *configurationChain) recoverDKGInfo(
 		utils.GetConfigWithPanic(cc.gov, round, cc.logger))
 	cc.logger.Debug("Calling Governance.DKGMasterPublicKeys for recoverDKGInfo",
 		"round", round)
-	mpk := cc.gov.DKGMasterPublicKeys(round)
+	mpk := cc.masterPublicKeys(round)
 	cc.logger.Debug("Calling Governance.DKGComplaints for recoverDKGInfo",
 		"round", round)
-	comps := cc.gov.DKGComplaints(round)
+	comps := cc.dkgComplaints(round)
 	qualifies, _, err := typesDKG.CalcQualifyNodes(mpk, comps, threshold)
 	if err != nil {
 		return err
@@ -602,8 +717,8 @@ func (cc *configurationChain) recoverDKGInfo(
 
 	if !npksExists {
 		npks, err := typesDKG.NewNodePublicKeys(round,
-			cc.gov.DKGMasterPublicKeys(round),
-			cc.gov.DKGComplaints(round),
+			cc.masterPublicKeys(round),
+			cc.dkgComplaints(round),
 			threshold)
 		if err != nil {
 			cc.logger.Warn("Failed to create DKGNodePublicKeys",
@@ -615,6 +730,7 @@ func (cc *configurationChain) recoverDKGInfo(
 			defer cc.dkgResult.Unlock()
 			cc.npks[round] = npks
 		}()
+		cc.purgeStaleDKGStates(round)
 	}
 	if !signerExists && !ignoreSigner {
 		reset := cc.gov.DKGResetCount(round)
@@ -700,6 +816,7 @@ func (cc *configurationChain) runTSig(
 		return crypto.Signature{}, ErrTSigAlreadyRunning
 	}
 	cc.tsig[hash] = newTSigProtocol(npks, hash)
+	cc.tsig[hash].registry = cc.cache.Registry()
 	pendingPsig := cc.pendingPsig[hash]
 	delete(cc.pendingPsig, hash)
 	go func() {
@@ -749,6 +866,9 @@ func (cc *configurationChain) runCRSTSig(
 
 func (cc *configurationChain) processPrivateShare(
 	prvShare *typesDKG.PrivateShare) error {
+	if prvShare.Round < cc.joinRound {
+		return nil
+	}
 	cc.dkgLock.Lock()
 	defer cc.dkgLock.Unlock()
 	if cc.dkg == nil {
@@ -757,9 +877,14 @@ func (cc *configurationChain) processPrivateShare(
 	if _, exist := cc.notarySet[prvShare.ProposerID]; !exist {
 		return ErrNotDKGParticipant
 	}
+	if err := cc.dkg.checkPhaseWindow(
+		"private share", dkgStepProposeFinalize); err != nil {
+		return err
+	}
 	if !cc.mpkReady {
 		// TODO(jimmy-dexon): remove duplicated signature check in dkg module.
-		ok, err := utils.VerifyDKGPrivateShareSignature(prvShare)
+		ok, err := utils.VerifyDKGPrivateShareSignature(
+			prvShare, cc.cache.Registry())
 		if err != nil {
 			return err
 		}
@@ -772,12 +897,233 @@ func (cc *configurationChain) processPrivateShare(
 	return cc.dkg.processPrivateShare(prvShare)
 }
 
+// masterPublicKeys returns master public keys of a round recorded by
+// governance. When governance knows only the hash of some of them, copies
+// pulled from peers with matched hashes are filled in.
+func (cc *configurationChain) masterPublicKeys(
+	round uint64) []*typesDKG.MasterPublicKey {
+	mpks := cc.gov.DKGMasterPublicKeys(round)
+	if cc.artifactsDigester == nil {
+		return mpks
+	}
+	cc.artifactsLock.RLock()
+	defer cc.artifactsLock.RUnlock()
+	pulled := cc.pulledMPKs[round]
+	if len(pulled) == 0 {
+		return mpks
+	}
+	exist := make(map[common.Hash]struct{}, len(mpks))
+	for _, mpk := range mpks {
+		exist[utils.HashDKGMasterPublicKey(mpk)] = struct{}{}
+	}
+	for _, h := range cc.artifactsDigester.DKGMasterPublicKeyHashes(round) {
+		if _, e := exist[h]; e {
+			continue
+		}
+		if mpk, e := pulled[h]; e {
+			mpks = append(mpks, mpk)
+		}
+	}
+	return mpks
+}
+
+// dkgComplaints returns complaints of a round recorded by governance. When
+// governance knows only the hash of some of them, copies pulled from peers
+// with matched hashes are filled in.
+func (cc *configurationChain) dkgComplaints(
+	round uint64) []*typesDKG.Complaint {
+	comps := cc.gov.DKGComplaints(round)
+	if cc.artifactsDigester == nil {
+		return comps
+	}
+	cc.artifactsLock.RLock()
+	defer cc.artifactsLock.RUnlock()
+	pulled := cc.pulledComplaints[round]
+	if len(pulled) == 0 {
+		return comps
+	}
+	exist := make(map[common.Hash]struct{}, len(comps))
+	for _, comp := range comps {
+		exist[utils.HashDKGComplaint(comp)] = struct{}{}
+	}
+	for _, h := range cc.artifactsDigester.DKGComplaintHashes(round) {
+		if _, e := exist[h]; e {
+			continue
+		}
+		if comp, e := pulled[h]; e {
+			comps = append(comps, comp)
+		}
+	}
+	return comps
+}
+
+// dkgArtifacts returns DKG artifacts of a round known by this node.
+func (cc *configurationChain) dkgArtifacts(round uint64) *typesDKG.Artifacts {
+	return &typesDKG.Artifacts{
+		Round:            round,
+		Reset:            cc.gov.DKGResetCount(round),
+		MasterPublicKeys: cc.masterPublicKeys(round),
+		Complaints:       cc.dkgComplaints(round),
+	}
+}
+
+func (cc *configurationChain) pullDKGArtifacts(round uint64) {
+	if cc.artifactsPuller == nil || cc.artifactsDigester == nil {
+		return
+	}
+	cc.logger.Debug("Calling Network.GetDKGArtifacts", "round", round)
+	cc.artifactsPuller.GetDKGArtifacts(round)
+}
+
+// purgeDKGArtifacts removes artifacts pulled for rounds before a round.
+func (cc *configurationChain) purgeDKGArtifacts(round uint64) {
+	cc.artifactsLock.Lock()
+	defer cc.artifactsLock.Unlock()
+	for r := range cc.pulledMPKs {
+		if r < round {
+			delete(cc.pulledMPKs, r)
+		}
+	}
+	for r := range cc.pulledComplaints {
+		if r < round {
+			delete(cc.pulledComplaints, r)
+		}
+	}
+}
+
+// purgeStaleDKGStates removes DKG states of rounds older than the retention
+// window before a round whose DKG result is known.
+func (cc *configurationChain) purgeStaleDKGStates(round uint64) {
+	if round <= cc.retention {
+		return
+	}
+	cc.purgeDKGStates(round - cc.retention)
+}
+
+// purgeDKGStates removes DKG results, pending partial signatures and pulled
+// artifacts of rounds before a round.
+func (cc *configurationChain) purgeDKGStates(round uint64) {
+	func() {
+		cc.dkgResult.Lock()
+		defer cc.dkgResult.Unlock()
+		for r := range cc.npks {
+			if r < round {
+				delete(cc.npks, r)
+			}
+		}
+		for r := range cc.dkgSigner {
+			if r < round {
+				delete(cc.dkgSigner, r)
+			}
+		}
+	}()
+	func() {
+		cc.tsigReady.L.Lock()
+		defer cc.tsigReady.L.Unlock()
+		for hash, psigs := range cc.pendingPsig {
+			kept := psigs[:0]
+			for _, psig := range psigs {
+				if psig.Round >= round {
+					kept = append(kept, psig)
+				}
+			}
+			if len(kept) == 0 {
+				delete(cc.pendingPsig, hash)
+			} else {
+				cc.pendingPsig[hash] = kept
+			}
+		}
+	}()
+	cc.purgeDKGArtifacts(round)
+}
+
+// processDKGArtifacts verifies artifacts pulled from peers and keeps those
+// recorded by governance. Artifacts of a stale reset are ignored.
+func (cc *configurationChain) processDKGArtifacts(
+	artifacts *typesDKG.Artifacts) error {
+	round := artifacts.Round
+	if cc.artifactsDigester == nil || round < cc.joinRound ||
+		artifacts.Reset != cc.gov.DKGResetCount(round) {
+		return nil
+	}
+	notarySet, err := cc.cache.GetNotarySet(round)
+	if err != nil {
+		return err
+	}
+	for _, mpk := range artifacts.MasterPublicKeys {
+		if mpk.Round != round || mpk.Reset != artifacts.Reset {
+			return ErrIncorrectDKGArtifacts
+		}
+		if _, exist := notarySet[mpk.ProposerID]; !exist {
+			return ErrNotDKGParticipant
+		}
+		ok, err := utils.VerifyDKGMasterPublicKeySignature(
+			mpk, cc.cache.Registry())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrIncorrectDKGArtifacts
+		}
+	}
+	for _, comp := range artifacts.Complaints {
+		if comp.Round != round || comp.Reset != artifacts.Reset {
+			return ErrIncorrectDKGArtifacts
+		}
+		if _, exist := notarySet[comp.ProposerID]; !exist {
+			return ErrNotDKGParticipant
+		}
+		ok, err := utils.VerifyDKGComplaintSignature(
+			comp, cc.cache.Registry())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrIncorrectDKGArtifacts
+		}
+	}
+	// Only artifacts recorded by governance are kept, others are never used.
+	recorded := make(map[common.Hash]struct{})
+	for _, h := range cc.artifactsDigester.DKGMasterPublicKeyHashes(round) {
+		recorded[h] = struct{}{}
+	}
+	for _, h := range cc.artifactsDigester.DKGComplaintHashes(round) {
+		recorded[h] = struct{}{}
+	}
+	cc.artifactsLock.Lock()
+	defer cc.artifactsLock.Unlock()
+	if _, exist := cc.pulledMPKs[round]; !exist {
+		cc.pulledMPKs[round] =
+			make(map[common.Hash]*typesDKG.MasterPublicKey)
+	}
+	if _, exist := cc.pulledComplaints[round]; !exist {
+		cc.pulledComplaints[round] = make(map[common.Hash]*typesDKG.Complaint)
+	}
+	for _, mpk := range artifacts.MasterPublicKeys {
+		h := utils.HashDKGMasterPublicKey(mpk)
+		if _, exist := recorded[h]; exist {
+			cc.pulledMPKs[round][h] = mpk
+		}
+	}
+	for _, comp := range artifacts.Complaints {
+		h := utils.HashDKGComplaint(comp)
+		if _, exist := recorded[h]; exist {
+			cc.pulledComplaints[round][h] = comp
+		}
+	}
+	return nil
+}
+
 func (cc *configurationChain) processPartialSignature(
 	psig *typesDKG.PartialSignature) error {
+	if psig.Round < cc.joinRound {
+		return nil
+	}
 	cc.tsigReady.L.Lock()
 	defer cc.tsigReady.L.Unlock()
 	if _, exist := cc.tsig[psig.Hash]; !exist {
-		ok, err := utils.VerifyDKGPartialSignatureSignature(psig)
+		ok, err := utils.VerifyDKGPartialSignatureSignature(
+			psig, cc.cache.Registry())
 		if err != nil {
 			return err
 		}
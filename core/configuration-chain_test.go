@@ -661,6 +661,51 @@ func (s *ConfigurationChainTestSuite) TestDKGSignerRecoverFromDB() {
 	}
 }
 
+func (s *ConfigurationChainTestSuite) TestPurgeStaleDKGStates() {
+	var (
+		req    = s.Require()
+		round  = DKGDelayRound
+		hash   = crypto.Keccak256Hash([]byte("Hash1"))
+		rounds = uint64(100)
+	)
+	var cc *configurationChain
+	for _, c := range s.runDKG(2, 4, round, 0) {
+		cc = c
+		break
+	}
+	psig, err := cc.preparePartialSignature(round, hash)
+	req.NoError(err)
+	npks, signer, err := cc.getDKGInfo(round, false)
+	req.NoError(err)
+	// Simulate DKG states accumulated in later rounds.
+	for r := round + 1; r <= round+rounds; r++ {
+		func() {
+			cc.dkgResult.Lock()
+			defer cc.dkgResult.Unlock()
+			cc.npks[r] = npks
+			cc.dkgSigner[r] = signer
+		}()
+		pending := &typesDKG.PartialSignature{
+			Round: r,
+			Hash:  common.NewRandomHash(),
+		}
+		cc.pendingPsig[pending.Hash] = append(
+			cc.pendingPsig[pending.Hash], pending)
+		cc.pulledComplaints[r] = make(map[common.Hash]*typesDKG.Complaint)
+		cc.purgeStaleDKGStates(r)
+		req.True(uint64(len(cc.npks)) <= cc.retention+1)
+		req.True(uint64(len(cc.dkgSigner)) <= cc.retention+1)
+		req.True(uint64(len(cc.pendingPsig)) <= cc.retention+1)
+		req.True(uint64(len(cc.pulledComplaints)) <= cc.retention+1)
+	}
+	_, exist := cc.npks[round]
+	req.False(exist)
+	// Purged results are recovered when requested again.
+	recovered, err := cc.preparePartialSignature(round, hash)
+	req.NoError(err)
+	req.Equal(psig.PartialSignature, recovered.PartialSignature)
+}
+
 func (s *ConfigurationChainTestSuite) TestDKGPhasesSnapShot() {
 	k := 2
 	n := 7
@@ -674,6 +719,204 @@ func (s *ConfigurationChainTestSuite) TestDKGPhasesSnapShot() {
 	}
 }
 
+func (s *ConfigurationChainTestSuite) TestDKGPhaseHeights() {
+	cfg := &types.Config{
+		LambdaDKG:        10 * time.Second,
+		MinBlockInterval: time.Second,
+	}
+	// The default is one LambdaDKG for each phase.
+	s.Require().Equal([]uint64{0, 10, 20, 30, 40, 50, 60, 70},
+		dkgPhaseHeights(cfg))
+	cfg.DKGRegisterDuration = 30 * time.Second
+	cfg.DKGComplaintDuration = 5 * time.Second
+	cfg.DKGFinalizeDuration = 20 * time.Second
+	s.Require().Equal([]uint64{0, 30, 35, 40, 45, 50, 55, 75},
+		dkgPhaseHeights(cfg))
+	s.Require().Equal(uint64(75), cfg.DKGLength())
+	// DKG is prepared earlier when phases can't be done before reset.
+	e := utils.RoundEventParam{BeginHeight: 1, Config: cfg}
+	cfg.RoundLength = 1000
+	s.Require().Equal(uint64(667), e.NextDKGPreparationHeight())
+	cfg.RoundLength = 300
+	s.Require().Equal(uint64(181), e.NextDKGPreparationHeight())
+	s.Require().True(
+		e.NextDKGPreparationHeight() >= e.NextDKGRegisterHeight())
+	s.Require().True(e.NextDKGPreparationHeight()+cfg.DKGLength() <=
+		e.NextDKGResetHeight())
+}
+
+func (s *ConfigurationChainTestSuite) TestDKGPhaseWindowOfProposals() {
+	recv := newTestCCGlobalReceiver(s)
+	d := newDKGProtocol(types.NodeID{}, recv, DKGDelayRound, 0, 1)
+	s.Require().NoError(d.proposeMPKReady())
+	s.Require().NoError(d.proposeFinalize())
+	// MPK ready is useless once MPKs are ready.
+	d.step = dkgStepWaitMPKReady + 1
+	s.Require().IsType(ErrDKGPhaseWindow{}, d.proposeMPKReady())
+	s.Require().NoError(d.proposeFinalize())
+	d.step = dkgStepProposeFinalize + 1
+	s.Require().IsType(ErrDKGPhaseWindow{}, d.proposeFinalize())
+	s.Require().IsType(ErrDKGPhaseWindow{}, d.proposeNackComplaints())
+	s.Require().IsType(ErrDKGPhaseWindow{}, d.enforceNackComplaints(nil))
+}
+
+func (s *ConfigurationChainTestSuite) TestDKGPhaseWindow() {
+	n := 4
+	s.setupNodes(n)
+	nID := s.nIDs[0]
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		s.pubKeys, 100*time.Millisecond, &common.NullLogger{}, true,
+	), ConfigRoundShift)
+	s.Require().NoError(err)
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	cc := newConfigurationChain(nID, newTestCCGlobalReceiver(s), gov,
+		utils.NewNodeSetCache(gov), dbInst, &common.NullLogger{})
+	cc.registerDKG(context.Background(), DKGDelayRound, 0, 1)
+	prvShare := &typesDKG.PrivateShare{
+		ProposerID: s.nIDs[1],
+		Round:      DKGDelayRound,
+	}
+	cc.dkg.step = dkgStepProposeFinalize + 1
+	err = cc.processPrivateShare(prvShare)
+	s.Require().IsType(ErrDKGPhaseWindow{}, err)
+}
+
+type testDKGArtifactDigester struct {
+	mpkHashes  common.Hashes
+	compHashes common.Hashes
+}
+
+func (d *testDKGArtifactDigester) DKGMasterPublicKeyHashes(
+	round uint64) common.Hashes {
+	return d.mpkHashes
+}
+
+func (d *testDKGArtifactDigester) DKGComplaintHashes(
+	round uint64) common.Hashes {
+	return d.compHashes
+}
+
+func (s *ConfigurationChainTestSuite) TestDKGArtifacts() {
+	var (
+		req   = s.Require()
+		n     = 4
+		round = DKGDelayRound
+	)
+	s.setupNodes(n)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		s.pubKeys, 100*time.Millisecond, &common.NullLogger{}, true,
+	), ConfigRoundShift)
+	req.NoError(err)
+	dbInst, err := db.NewMemBackedDB()
+	req.NoError(err)
+	cc := newConfigurationChain(s.nIDs[0], newTestCCGlobalReceiver(s), gov,
+		utils.NewNodeSetCache(gov), dbInst, &common.NullLogger{})
+	newMPK := func(nID types.NodeID) *typesDKG.MasterPublicKey {
+		_, pubShares := dkg.NewPrivateKeyShares(utils.GetDKGThreshold(
+			gov.Configuration(round)))
+		mpk := &typesDKG.MasterPublicKey{
+			Round:           round,
+			DKGID:           typesDKG.NewID(nID),
+			PublicKeyShares: *pubShares.Move(),
+		}
+		req.NoError(s.signers[nID].SignDKGMasterPublicKey(mpk))
+		return mpk
+	}
+	// Half of master public keys are in governance, governance knows only the
+	// hash of another one, and the last one is never recorded.
+	digester := &testDKGArtifactDigester{}
+	artifacts := &typesDKG.Artifacts{Round: round}
+	for i, nID := range s.nIDs {
+		mpk := newMPK(nID)
+		if i < n/2 {
+			gov.AddDKGMasterPublicKey(mpk)
+		}
+		if i < n-1 {
+			digester.mpkHashes = append(digester.mpkHashes,
+				utils.HashDKGMasterPublicKey(mpk))
+		}
+		artifacts.MasterPublicKeys = append(artifacts.MasterPublicKeys, mpk)
+	}
+	comp := &typesDKG.Complaint{
+		Round: round,
+		PrivateShare: typesDKG.PrivateShare{
+			ProposerID: s.nIDs[1],
+			Round:      round,
+		},
+	}
+	req.NoError(s.signers[s.nIDs[2]].SignDKGComplaint(comp))
+	artifacts.Complaints = append(artifacts.Complaints, comp)
+	digester.compHashes = append(digester.compHashes,
+		utils.HashDKGComplaint(comp))
+	// Pulled artifacts are never used without a digester.
+	req.NoError(cc.processDKGArtifacts(artifacts))
+	req.Len(cc.masterPublicKeys(round), n/2)
+	req.Empty(cc.dkgComplaints(round))
+	// Only those recorded by governance are filled in.
+	cc.artifactsDigester = digester
+	req.NoError(cc.processDKGArtifacts(artifacts))
+	req.Len(cc.masterPublicKeys(round), n-1)
+	req.Len(cc.dkgComplaints(round), 1)
+	req.NoError(cc.processDKGArtifacts(artifacts))
+	served := cc.dkgArtifacts(round)
+	req.Len(served.MasterPublicKeys, n-1)
+	req.Len(served.Complaints, 1)
+	for _, mpk := range served.MasterPublicKeys {
+		req.NotEqual(s.nIDs[n-1], mpk.ProposerID)
+	}
+	// Artifacts with invalid signatures are rejected.
+	forged := newMPK(s.nIDs[3])
+	forged.Signature = comp.Signature
+	req.Equal(ErrIncorrectDKGArtifacts, cc.processDKGArtifacts(
+		&typesDKG.Artifacts{
+			Round:            round,
+			MasterPublicKeys: []*typesDKG.MasterPublicKey{forged},
+		}))
+	// Artifacts of stale reset are ignored, pulled artifacts are purged.
+	req.NoError(cc.processDKGArtifacts(&typesDKG.Artifacts{
+		Round: round,
+		Reset: 1,
+	}))
+	cc.purgeDKGArtifacts(round + 1)
+	req.Len(cc.masterPublicKeys(round), n/2)
+}
+
+func (s *ConfigurationChainTestSuite) TestSkipRoundsBeforeJoin() {
+	var (
+		req       = s.Require()
+		joinRound = uint64(5)
+	)
+	s.setupNodes(4)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		s.pubKeys, 100*time.Millisecond, &common.NullLogger{}, true,
+	), ConfigRoundShift)
+	req.NoError(err)
+	dbInst, err := db.NewMemBackedDB()
+	req.NoError(err)
+	cc := newConfigurationChain(s.nIDs[0], newTestCCGlobalReceiver(s), gov,
+		utils.NewNodeSetCache(gov), dbInst, &common.NullLogger{})
+	cc.skipRoundsBefore(joinRound)
+	// Signers of rounds before joining are never available.
+	_, _, err = cc.getDKGInfo(joinRound-1, false)
+	req.Equal(ErrDKGRoundSkipped, err)
+	_, _, err = cc.getDKGInfo(joinRound, false)
+	req.NotEqual(ErrDKGRoundSkipped, err)
+	// Messages of rounds before joining are ignored.
+	prvShare := &typesDKG.PrivateShare{
+		ProposerID: types.NodeID{Hash: common.NewRandomHash()},
+		Round:      joinRound - 1,
+	}
+	req.NoError(cc.processPrivateShare(prvShare))
+	psig := &typesDKG.PartialSignature{
+		ProposerID: s.nIDs[1],
+		Round:      joinRound - 1,
+		Hash:       common.NewRandomHash(),
+	}
+	req.NoError(cc.processPartialSignature(psig))
+	req.Empty(cc.pendingPsig)
+}
+
 func (s *ConfigurationChainTestSuite) TestDKGAbort() {
 	n := 4
 	k := 1
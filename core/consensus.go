@@ -21,7 +21,9 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
@@ -55,8 +57,37 @@ var (
 		"randomness of block is incorrect")
 	ErrCannotVerifyBlockRandomness = fmt.Errorf(
 		"cannot verify block randomness")
+	ErrIncorrectWatermarkSignature = fmt.Errorf(
+		"signature of watermark is incorrect")
+	ErrIncorrectPingSignature = fmt.Errorf(
+		"signature of ping is incorrect")
+	ErrIncorrectReadinessSignature = fmt.Errorf(
+		"signature of readiness is incorrect")
+	ErrJoinedAfterDKGRegistration = fmt.Errorf(
+		"joined after DKG registration")
+	ErrDKGRegistrationTooLate = fmt.Errorf(
+		"DKG registration is too late")
+	ErrProtocolVersionOutdated = fmt.Errorf(
+		"protocol version is outdated")
+	ErrNoBlocksDelivered = fmt.Errorf(
+		"no blocks delivered for too long")
 )
 
+// agreementResultRetention is the count of heights that stored agreement
+// results are kept for peers catching up.
+const agreementResultRetention = 1024
+
+// logSampleInterval is the interval to sample logs in hot paths, see
+// common.SampledLogger.
+const logSampleInterval = 10 * time.Second
+
+// backlogSampleInterval is the interval to sample ages of backlogs as gauges.
+const backlogSampleInterval = time.Second
+
+// drainCheckInterval is the interval to check if received messages are
+// processed when shutting down.
+const drainCheckInterval = 10 * time.Millisecond
+
 type selfAgreementResult types.AgreementResult
 
 // consensusBAReceiver implements agreementReceiver.
@@ -132,8 +163,24 @@ func (recv *consensusBAReceiver) VerifyPartialSignature(vote *types.Vote) (
 	return len(vote.PartialSignature.Signature) == 0, true
 }
 
+// nonSigner checks if a vote requires a partial signature which this node
+// could not sign, ex. missing DKG of that round.
+func (recv *consensusBAReceiver) nonSigner(vote *types.Vote) bool {
+	if recv.psigSigner != nil || vote.Position.Round < DKGDelayRound ||
+		vote.BlockHash == types.SkipBlockHash {
+		return false
+	}
+	return vote.Type == types.VoteCom || vote.Type == types.VoteFastCom
+}
+
 func (recv *consensusBAReceiver) ProposeVote(vote *types.Vote) {
-	if !recv.isNotary {
+	if !recv.isNotary || recv.consensus.isCatchingUp() {
+		return
+	}
+	if recv.nonSigner(vote) {
+		// Votes without partial signatures would be rejected by others.
+		recv.consensus.sampledLogger.Debug(
+			"Skip signed vote as non-signer", "vote", vote)
 		return
 	}
 	if recv.psigSigner != nil &&
@@ -158,6 +205,19 @@ func (recv *consensusBAReceiver) ProposeVote(vote *types.Vote) {
 		recv.consensus.logger.Error("Failed to prepare vote", "error", err)
 		return
 	}
+	vote, state := recv.agreementModule.recordSentVote(vote)
+	if state != nil {
+		if err := recv.persistAgreementState(state); err != nil {
+			// The vote is not sent, or it might be equivocated after restart.
+			recv.consensus.logger.Error("Failed to persist agreement state",
+				"vote", vote,
+				"error", err)
+			recv.agreementModule.forgetSentVote(vote)
+			return
+		}
+	} else {
+		recv.consensus.logger.Debug("Broadcast sent vote again", "vote", vote)
+	}
 	go func() {
 		if err := recv.agreementModule.processVote(vote); err != nil {
 			recv.consensus.logger.Error("Failed to process self vote",
@@ -168,11 +228,45 @@ func (recv *consensusBAReceiver) ProposeVote(vote *types.Vote) {
 		recv.consensus.logger.Debug("Calling Network.BroadcastVote",
 			"vote", vote)
 		recv.consensus.network.BroadcastVote(vote)
+		recv.consensus.rebroadcaster.addVote(vote)
 	}()
 }
 
+// persistAgreementState writes the agreement state before votes in it are
+// broadcasted, it's restored when BA restarts at the same position.
+func (recv *consensusBAReceiver) persistAgreementState(
+	state *db.AgreementState) error {
+	store, ok := recv.consensus.db.(db.AgreementStateStore)
+	if !ok {
+		return nil
+	}
+	return store.PutAgreementState(*state)
+}
+
+func (recv *consensusBAReceiver) ProposeNullBlock() bool {
+	if !recv.isNotary || recv.consensus.isCatchingUp() ||
+		!recv.consensus.opts.nullProposal {
+		return false
+	}
+	checker, ok := recv.consensus.app.(PayloadChecker)
+	if !ok {
+		return false
+	}
+	if recv.consensus.bcModule.hasPendingSystemMessages() {
+		return false
+	}
+	pos := recv.agreementModule.agreementID()
+	recv.consensus.logger.Debug("Calling Application.HasPayload",
+		"position", &pos)
+	if checker.HasPayload(pos) {
+		return false
+	}
+	recv.consensus.opts.incCounter("null-block-proposals", 1)
+	return true
+}
+
 func (recv *consensusBAReceiver) ProposeBlock() common.Hash {
-	if !recv.isNotary {
+	if !recv.isNotary || recv.consensus.isCatchingUp() {
 		return common.Hash{}
 	}
 	block, err := recv.consensus.proposeBlock(recv.agreementModule.agreementID())
@@ -181,13 +275,24 @@ func (recv *consensusBAReceiver) ProposeBlock() common.Hash {
 		return types.NullBlockHash
 	}
 	go func() {
+		// The block might be confirmed before it's processed when this node
+		// forms the notary set alone, nobody else would send it back.
+		recv.consensus.resolveBAConfirmedBlock(block)
 		if err := recv.consensus.preProcessBlock(block); err != nil {
 			recv.consensus.logger.Error("Failed to pre-process block", "error", err)
 			return
 		}
+		if delay := recv.consensus.proposeDelay(block); delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-recv.consensus.ctx.Done():
+				return
+			}
+		}
 		recv.consensus.logger.Debug("Calling Network.BroadcastBlock",
 			"block", block)
 		recv.consensus.network.BroadcastBlock(block)
+		recv.consensus.rebroadcaster.addBlock(block)
 	}()
 	return block.Hash
 }
@@ -257,6 +362,11 @@ func (recv *consensusBAReceiver) ConfirmBlock(
 		}
 	}
 
+	recv.consensus.events.publish(Event{
+		Type:      EventBADecided,
+		Position:  block.Position,
+		BlockHash: block.Hash,
+	})
 	if len(votes) == 0 && len(block.Randomness) == 0 {
 		recv.consensus.logger.Error("No votes to recover randomness",
 			"block", block)
@@ -264,10 +374,17 @@ func (recv *consensusBAReceiver) ConfirmBlock(
 		voteList := make([]types.Vote, 0, len(votes))
 		IDs := make(cryptoDKG.IDs, 0, len(votes))
 		psigs := make([]cryptoDKG.PartialSignature, 0, len(votes))
+		meta := BlockConfirmMeta{
+			Duration: time.Since(recv.agreementModule.restartTimeNoLock()),
+		}
+		signers := make([]types.NodeID, 0, len(votes))
 		for _, vote := range votes {
 			if vote.BlockHash != hash {
 				continue
 			}
+			meta.Period = vote.Period
+			meta.VoterCount++
+			signers = append(signers, vote.ProposerID)
 			if block.Position.Round >= DKGDelayRound {
 				ID, exist := recv.npks.IDMap[vote.ProposerID]
 				if !exist {
@@ -291,6 +408,17 @@ func (recv *consensusBAReceiver) ConfirmBlock(
 		} else {
 			block.Randomness = NoRand
 		}
+		if len(block.Randomness) > 0 {
+			recv.consensus.audit.recordProvenance(block.Hash, block.Position,
+				FinalizationSourceBA, meta.Period, signers)
+		}
+		if recv.consensus.metaApp != nil {
+			recv.consensus.logger.Debug(
+				"Calling Application.BlockConfirmedWithMeta",
+				"block", block,
+				"meta", meta)
+			recv.consensus.metaApp.BlockConfirmedWithMeta(block.Hash, meta)
+		}
 
 		if recv.isNotary {
 			result := &types.AgreementResult{
@@ -394,6 +522,19 @@ func (recv *consensusBAReceiver) PullBlocks(hashes common.Hashes) {
 
 func (recv *consensusBAReceiver) ReportForkVote(v1, v2 *types.Vote) {
 	recv.consensus.gov.ReportForkVote(v1, v2)
+	reporter := recv.consensus.govExt.forkReporter
+	if reporter == nil {
+		return
+	}
+	evidence := types.NewVoteEvidence(v1, v2)
+	if err := recv.consensus.signer.SignVoteEvidence(evidence); err != nil {
+		recv.consensus.logger.Error("Failed to sign vote evidence",
+			"error", err)
+		return
+	}
+	recv.consensus.logger.Warn("Report vote equivocation",
+		"evidence", evidence)
+	reporter.ReportForkEvidence(evidence)
 }
 
 func (recv *consensusBAReceiver) ReportForkBlock(b1, b2 *types.Block) {
@@ -516,35 +657,69 @@ type Consensus struct {
 	// BA.
 	baMgr            *agreementMgr
 	baConfirmedBlock map[common.Hash]chan<- *types.Block
+	proposer         *blockProposer
+	rebroadcaster    *selfRebroadcaster
 
 	// DKG.
-	dkgRunning int32
-	dkgReady   *sync.Cond
-	cfgModule  *configurationChain
+	dkgRunning  int32
+	dkgReady    *sync.Cond
+	cfgModule   *configurationChain
+	dkgVerifier *dkgMsgVerifier
 
 	// Interfaces.
-	db       db.Database
-	app      Application
-	debugApp Debug
-	gov      Governance
-	network  Network
+	db           db.Database
+	app          Application
+	debugApp     *debugHub
+	metaApp      BlockConfirmMetaReceiver
+	batchApp     BatchDeliveryReceiver
+	batchMetaApp BatchDeliveryMetaReceiver
+	sysMsgApp    SystemMessageReceiver
+	crsApp       CRSFallbackReceiver
+	dkgApp       DKGMissReceiver
+	unknownApp   UnknownMessageReceiver
+	notaryApp    NotarySetChangeReceiver
+	versionApp   VersionOutdatedReceiver
+	fatalApp     FatalErrorReceiver
+	gov          Governance
+	govExt       governanceExtensions
+	network      Network
 
 	// Misc.
 	bcModule                 *blockChain
+	opts                     *options
+	audit                    *finalizationAudit
+	watermarks               *watermarkTracker
+	latencies                *latencyTracker
+	quarantine               *msgQuarantine
+	feed                     *blockFeed
+	events                   *eventFeed
+	revealDelay              uint64
+	unrevealed               []*types.Block
+	deliveredBatches         uint64
+	bootstrap                *bootstrapBarrier
 	dMoment                  time.Time
 	nodeSetCache             *utils.NodeSetCache
 	tsigVerifierCache        *TSigVerifierCache
 	lock                     sync.RWMutex
 	ctx                      context.Context
 	ctxCancel                context.CancelFunc
+	stopRecv                 chan struct{}
+	stopRecvOnce             sync.Once
+	fatalOnce                sync.Once
+	catchingUp               int32
+	inFlight                 int32
+	archiveNext              uint64
+	seen                     *seenCache
 	event                    *common.Event
 	roundEvent               *utils.RoundEvent
 	logger                   common.Logger
+	sampledLogger            *common.SampledLogger
 	resetDeliveryGuardTicker chan struct{}
 	msgChan                  chan types.Msg
 	priorityMsgChan          chan interface{}
 	waitGroup                sync.WaitGroup
 	processBlockChan         chan *types.Block
+	deliverSignal            chan struct{}
 
 	// Context of Dummy receiver during switching from syncer.
 	dummyCancel    context.CancelFunc
@@ -552,7 +727,8 @@ type Consensus struct {
 	dummyMsgBuffer []types.Msg
 }
 
-// NewConsensus construct an Consensus instance.
+// NewConsensus construct an Consensus instance, it panics when the
+// configuration of the bootstrap round is invalid.
 func NewConsensus(
 	dMoment time.Time,
 	app Application,
@@ -560,9 +736,30 @@ func NewConsensus(
 	db db.Database,
 	network Network,
 	prv crypto.PrivateKey,
-	logger common.Logger) *Consensus {
+	logger common.Logger,
+	opts ...Option) *Consensus {
+	con, err := newConsensusForRound(
+		nil, dMoment, app, gov, db, network, prv, logger, true, opts)
+	if err != nil {
+		panic(err)
+	}
+	return con
+}
+
+// NewCheckedConsensus constructs an Consensus instance like NewConsensus, but
+// returns the error instead of panicking when the configuration of the
+// bootstrap round is invalid.
+func NewCheckedConsensus(
+	dMoment time.Time,
+	app Application,
+	gov Governance,
+	db db.Database,
+	network Network,
+	prv crypto.PrivateKey,
+	logger common.Logger,
+	opts ...Option) (*Consensus, error) {
 	return newConsensusForRound(
-		nil, dMoment, app, gov, db, network, prv, logger, true)
+		nil, dMoment, app, gov, db, network, prv, logger, true, opts)
 }
 
 // NewConsensusForSimulation creates an instance of Consensus for simulation,
@@ -574,9 +771,14 @@ func NewConsensusForSimulation(
 	db db.Database,
 	network Network,
 	prv crypto.PrivateKey,
-	logger common.Logger) *Consensus {
-	return newConsensusForRound(
-		nil, dMoment, app, gov, db, network, prv, logger, false)
+	logger common.Logger,
+	opts ...Option) *Consensus {
+	con, err := newConsensusForRound(
+		nil, dMoment, app, gov, db, network, prv, logger, false, opts)
+	if err != nil {
+		panic(err)
+	}
+	return con
 }
 
 // NewConsensusFromSyncer constructs an Consensus instance from information
@@ -599,10 +801,14 @@ func NewConsensusFromSyncer(
 	prv crypto.PrivateKey,
 	confirmedBlocks []*types.Block,
 	cachedMessages []types.Msg,
-	logger common.Logger) (*Consensus, error) {
+	logger common.Logger,
+	opts ...Option) (*Consensus, error) {
 	// Setup Consensus instance.
-	con := newConsensusForRound(initBlock, dMoment, app, gov, db,
-		networkModule, prv, logger, true)
+	con, err := newConsensusForRound(initBlock, dMoment, app, gov, db,
+		networkModule, prv, logger, true, opts)
+	if err != nil {
+		return nil, err
+	}
 	// Launch a dummy receiver before we start receiving from network module.
 	con.dummyMsgBuffer = cachedMessages
 	con.dummyCancel, con.dummyFinished = utils.LaunchDummyReceiver(
@@ -637,6 +843,32 @@ func NewConsensusFromSyncer(
 	return con, nil
 }
 
+// governanceExtensions are optional interfaces implemented by governance, they
+// are detected once on the governance passed in. Governance might be
+// decorated later, ex. by tickerGovernance, which hides these interfaces.
+type governanceExtensions struct {
+	registry     utils.NodeIdentityRegistry
+	entropy      CRSEntropySource
+	versionGov   ProtocolVersionGovernance
+	missReporter LeaderMissReporter
+	forkReporter ForkEvidenceReporter
+	vrfGov       VRFLeaderGovernance
+	digester     DKGArtifactDigester
+	crsGov       CRSFallbackGovernance
+}
+
+func newGovernanceExtensions(gov Governance) (ext governanceExtensions) {
+	ext.registry, _ = gov.(utils.NodeIdentityRegistry)
+	ext.entropy, _ = gov.(CRSEntropySource)
+	ext.versionGov, _ = gov.(ProtocolVersionGovernance)
+	ext.missReporter, _ = gov.(LeaderMissReporter)
+	ext.forkReporter, _ = gov.(ForkEvidenceReporter)
+	ext.vrfGov, _ = gov.(VRFLeaderGovernance)
+	ext.digester, _ = gov.(DKGArtifactDigester)
+	ext.crsGov, _ = gov.(CRSFallbackGovernance)
+	return
+}
+
 // newConsensusForRound creates a Consensus instance.
 func newConsensusForRound(
 	initBlock *types.Block,
@@ -647,9 +879,17 @@ func newConsensusForRound(
 	network Network,
 	prv crypto.PrivateKey,
 	logger common.Logger,
-	usingNonBlocking bool) *Consensus {
+	usingNonBlocking bool,
+	opts []Option) (*Consensus, error) {
+	o := newOptions(opts)
+	// Optional interfaces of governance should be detected before decorated.
+	govExt := newGovernanceExtensions(gov)
+	registry := govExt.registry
+	if o.newTicker != nil {
+		gov = &tickerGovernance{Governance: gov, newTicker: o.newTicker}
+	}
 	// TODO(w): load latest blockHeight from DB, and use config at that height.
-	nodeSetCache := utils.NewNodeSetCache(gov)
+	nodeSetCache := utils.NewNodeSetCacheWithRegistry(gov, registry)
 	// Setup signer module.
 	signer := utils.NewSigner(prv)
 	// Check if the application implement Debug interface.
@@ -665,8 +905,14 @@ func newConsensusForRound(
 	if initBlock != nil {
 		initPos = initBlock.Position
 	}
+	// Misconfiguration should fail fast before any module is launched.
+	if err := utils.GetConfigWithPanic(
+		gov, initPos.Round, logger).Validate(); err != nil {
+		return nil, err
+	}
 	// Init configuration chain.
-	ID := types.NewNodeID(prv.PublicKey())
+	ID := utils.NodeIdentity(registry, initPos.Round, prv.PublicKey())
+	signer.SetNodeID(ID)
 	recv := &consensusDKGReceiver{
 		ID:           ID,
 		gov:          gov,
@@ -677,6 +923,10 @@ func newConsensusForRound(
 	}
 	cfgModule := newConfigurationChain(ID, recv, gov, nodeSetCache, db, logger)
 	recv.cfgModule = cfgModule
+	if puller, ok := network.(DKGArtifactsPuller); ok {
+		cfgModule.artifactsPuller = puller
+	}
+	cfgModule.artifactsDigester = govExt.digester
 	signer.SetBLSSigner(
 		func(round uint64, hash common.Hash) (crypto.Signature, error) {
 			_, signer, err := cfgModule.getDKGInfo(round, false)
@@ -689,15 +939,54 @@ func newConsensusForRound(
 	if usingNonBlocking {
 		appModule = newNonBlocking(app, debugApp)
 	}
+	crsApp, _ := app.(CRSFallbackReceiver)
+	dkgApp, _ := app.(DKGMissReceiver)
+	unknownApp, _ := app.(UnknownMessageReceiver)
+	notaryApp, _ := app.(NotarySetChangeReceiver)
+	versionApp, _ := app.(VersionOutdatedReceiver)
+	fatalApp, _ := app.(FatalErrorReceiver)
+	var metaApp BlockConfirmMetaReceiver
+	if _, ok := app.(BlockConfirmMetaReceiver); ok {
+		metaApp = appModule.(BlockConfirmMetaReceiver)
+	}
+	var batchApp BatchDeliveryReceiver
+	if _, ok := app.(BatchDeliveryReceiver); ok {
+		batchApp = appModule.(BatchDeliveryReceiver)
+	}
+	var batchMetaApp BatchDeliveryMetaReceiver
+	if _, ok := app.(BatchDeliveryMetaReceiver); ok {
+		batchMetaApp = appModule.(BatchDeliveryMetaReceiver)
+	}
+	var sysMsgApp SystemMessageReceiver
+	if _, ok := app.(SystemMessageReceiver); ok {
+		sysMsgApp = appModule.(SystemMessageReceiver)
+	}
 	tsigVerifierCache := NewTSigVerifierCache(gov, 7)
 	bcModule := newBlockChain(ID, dMoment, initBlock, appModule,
 		tsigVerifierCache, signer, logger)
+	if vetoer, ok := app.(WitnessVetoer); ok {
+		bcModule.witnessVetoer = vetoer
+	}
+	bcModule.registry = registry
+	bcModule.opts = o
 	// Construct Consensus instance.
 	con := &Consensus{
 		ID:                       ID,
 		app:                      appModule,
-		debugApp:                 debugApp,
+		debugApp:                 newDebugHub(),
+		metaApp:                  metaApp,
+		batchApp:                 batchApp,
+		batchMetaApp:             batchMetaApp,
+		sysMsgApp:                sysMsgApp,
+		crsApp:                   crsApp,
+		dkgApp:                   dkgApp,
+		unknownApp:               unknownApp,
+		notaryApp:                notaryApp,
+		versionApp:               versionApp,
+		fatalApp:                 fatalApp,
 		gov:                      gov,
+		govExt:                   govExt,
+		opts:                     o,
 		db:                       db,
 		network:                  network,
 		baConfirmedBlock:         make(map[common.Hash]chan<- *types.Block),
@@ -710,12 +999,35 @@ func newConsensusForRound(
 		signer:                   signer,
 		event:                    common.NewEvent(),
 		logger:                   logger,
+		sampledLogger:            common.NewSampledLogger(logger, logSampleInterval),
 		resetDeliveryGuardTicker: make(chan struct{}),
 		msgChan:                  make(chan types.Msg, 1024),
 		priorityMsgChan:          make(chan interface{}, 1024),
 		processBlockChan:         make(chan *types.Block, 1024),
-	}
+		deliverSignal:            make(chan struct{}, 1),
+		stopRecv:                 make(chan struct{}),
+	}
+	con.proposer = newBlockProposer(con.prepareBlock)
+	con.audit = newFinalizationAudit()
+	con.watermarks = newWatermarkTracker()
+	con.latencies = newLatencyTracker()
+	con.rebroadcaster = newSelfRebroadcaster(con.rebroadcast)
+	con.bootstrap = newBootstrapBarrier(registry)
+	con.bootstrap.announce(ID)
 	con.ctx, con.ctxCancel = context.WithCancel(context.Background())
+	con.feed = newBlockFeed(con.ctx, db)
+	con.events = newEventFeed()
+	con.seen = newSeenCache(seenCacheSize)
+	if debugApp != nil {
+		con.debugApp.attach(debugApp)
+	}
+	if configurer, ok := app.(CallbackConfigurer); ok {
+		con.revealDelay = configurer.CallbackConfig().RandomnessRevealDelay
+	}
+	con.dkgVerifier = newDKGMsgVerifier(
+		con.ctx, con.opts.verifierWorkers, registry, con.processDKGMsg)
+	con.quarantine = newMsgQuarantine(
+		con.opts.quarantineSize, con.opts.alertThreshold)
 	var err error
 	con.roundEvent, err = utils.NewRoundEvent(con.ctx, gov, logger, initPos,
 		ConfigRoundShift)
@@ -728,7 +1040,7 @@ func newConsensusForRound(
 	if err = con.prepare(initBlock); err != nil {
 		panic(err)
 	}
-	return con
+	return con, nil
 }
 
 // prepare the Consensus instance to be ready for blocks after 'initBlock'.
@@ -749,13 +1061,20 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 			panic("not implemented yet")
 		}
 	}
+	if _, err = con.checkProtocolVersion(initRound); err != nil {
+		return
+	}
+	// Nodes joining at a later round would not run DKG of previous rounds.
+	con.cfgModule.skipRoundsBefore(initRound)
 	// Measure time elapse for each handler of round events.
 	elapse := func(what string, lastE utils.RoundEventParam) func() {
 		start := time.Now()
+		observe := con.opts.observeDuration("round-event-" + what)
 		con.logger.Info("Handle round event",
 			"what", what,
 			"event", lastE)
 		return func() {
+			observe()
 			con.logger.Info("Finish round event",
 				"what", what,
 				"event", lastE,
@@ -775,6 +1094,37 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 			con.tsigVerifierCache.Purge(e.Round + 1)
 		}
 	})
+	// Register round event handler to halt before participating rounds
+	// requiring a newer protocol version, it should be taken before BA
+	// modules are notified.
+	con.roundEvent.Register(func(evts []utils.RoundEventParam) {
+		if con.govExt.versionGov == nil {
+			return
+		}
+		e := evts[len(evts)-1]
+		defer elapse("check-version", e)()
+		if e.Reset != 0 {
+			return
+		}
+		if required, err := con.checkProtocolVersion(e.Round); err != nil {
+			con.haltOutdated(e.Round, required)
+			return
+		}
+		if _, err := con.checkProtocolVersion(e.Round + 1); err != nil {
+			con.logger.Warn("Protocol version is outdated for next round",
+				"round", e.Round+1,
+				"version", ProtocolVersion)
+		}
+	})
+	// Register round event handler to emit events of rounds.
+	con.roundEvent.Register(func(evts []utils.RoundEventParam) {
+		for _, e := range evts {
+			if e.Reset != 0 {
+				continue
+			}
+			con.events.publish(Event{Type: EventRoundAdvanced, Round: e.Round})
+		}
+	})
 	// Register round event handler to abort previous running DKG if any.
 	con.roundEvent.Register(func(evts []utils.RoundEventParam) {
 		e := evts[len(evts)-1]
@@ -795,10 +1145,11 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 		// Always updates newer configs to the later modules first in the data
 		// flow.
 		if err := con.bcModule.notifyRoundEvents(evts); err != nil {
-			panic(err)
+			con.fatal(err)
+			return
 		}
 		if err := con.baMgr.notifyRoundEvents(evts); err != nil {
-			panic(err)
+			con.fatal(err)
 		}
 	})
 	// Register round event handler to reset DKG if the DKG set for next round
@@ -876,6 +1227,20 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 			}()
 		})
 	})
+	// Register round event handler to notify application the rotation of
+	// notary set.
+	con.roundEvent.Register(func(evts []utils.RoundEventParam) {
+		if con.notaryApp == nil {
+			return
+		}
+		defer elapse("notify-notary-set", evts[len(evts)-1])()
+		for _, e := range evts {
+			if e.Reset != 0 || e.Round == 0 {
+				continue
+			}
+			con.notifyNotarySetChange(e.Round)
+		}
+	})
 	con.roundEvent.Register(func(evts []utils.RoundEventParam) {
 		e := evts[len(evts)-1]
 		if e.Reset != 0 {
@@ -942,6 +1307,22 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 		// Register a routine to trigger round events.
 		con.event.RegisterHeight(e.NextRoundValidationHeight(),
 			utils.RoundEventRetryHandlerGenerator(con.roundEvent, con.event))
+		// Register a routine to propose fallback CRS to governance when it
+		// fails to publish one in time.
+		con.event.RegisterHeight(e.NextCRSDeadlineHeight(), func(uint64) {
+			nextRound := e.Round + 1
+			crs, proposed := proposeCRSFallback(
+				con.gov, con.govExt.crsGov, nextRound)
+			if !proposed {
+				return
+			}
+			con.logger.Warn("CRS is not ready before deadline, propose fallback",
+				"round", nextRound,
+				"crs", crs)
+			if con.crsApp != nil {
+				go con.crsApp.CRSFallback(nextRound, crs)
+			}
+		})
 		// Register a routine to register next DKG.
 		con.event.RegisterHeight(e.NextDKGRegisterHeight(), func(uint64) {
 			nextRound := e.Round + 1
@@ -951,6 +1332,12 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 					"reset", e.Reset)
 				return
 			}
+			if con.opts.withoutDKG {
+				con.logger.Info("Skip runDKG for round, DKG is disabled",
+					"round", nextRound,
+					"reset", e.Reset)
+				return
+			}
 			go func() {
 				// Normally, gov.CRS would return non-nil. Use this for in case
 				// of unexpected network fluctuation and ensure the robustness.
@@ -978,8 +1365,30 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 				con.logger.Info("Selected as notary set",
 					"round", nextRound,
 					"reset", e.Reset)
+				if con.joinedTooLate(nextRound) {
+					con.missDKG(nextRound, e.Reset,
+						ErrJoinedAfterDKGRegistration)
+					return
+				}
 				nextConfig := utils.GetConfigWithPanic(con.gov, nextRound,
 					con.logger)
+				if _, ok := con.network.(ReadinessAnnouncer); ok &&
+					nextRound == DKGDelayRound && e.Reset == 0 {
+					ready := con.bootstrap.wait(con.ctx, nextNotarySet,
+						utils.GetDKGThreshold(nextConfig),
+						nextConfig.LambdaDKG*bootstrapBarrierTimeoutFactor,
+						con.bootstrapExpired(e))
+					if con.ctx.Err() != nil {
+						return
+					}
+					con.logger.Info("Bootstrap barrier passed",
+						"round", nextRound,
+						"ready", ready)
+				}
+				if con.registeredTooLate(e) {
+					con.missDKG(nextRound, e.Reset, ErrDKGRegistrationTooLate)
+					return
+				}
 				con.cfgModule.registerDKG(con.ctx, nextRound, e.Reset,
 					utils.GetDKGThreshold(nextConfig))
 				con.event.RegisterHeight(e.NextDKGPreparationHeight(),
@@ -1006,8 +1415,71 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 	return
 }
 
+// joinedTooLate checks if this node joins after master public keys of DKG
+// of a round are all registered, a node recovered from a crash would find its
+// own master public key registered.
+func (con *Consensus) joinedTooLate(round uint64) bool {
+	if !con.gov.IsDKGMPKReady(round) {
+		return false
+	}
+	for _, mpk := range con.gov.DKGMasterPublicKeys(round) {
+		if mpk.ProposerID == con.ID {
+			return false
+		}
+	}
+	return true
+}
+
+// bootstrapExpired returns a checker telling if the first DKG should stop
+// waiting for its participants. The wait expires when half of the blocks
+// before the DKG preparation height are delivered, the registration should
+// be done well before registeredTooLate holds.
+func (con *Consensus) bootstrapExpired(e utils.RoundEventParam) func() bool {
+	delivered := func() (height uint64) {
+		if b := con.bcModule.lastDeliveredBlock(); b != nil {
+			height = b.Position.Height
+		}
+		return
+	}
+	deadline := e.NextDKGPreparationHeight()
+	var margin uint64
+	if h := delivered(); h < deadline {
+		margin = (deadline - h) / 2
+	}
+	return func() bool {
+		return delivered()+margin >= deadline
+	}
+}
+
+// registeredTooLate checks if the DKG of next round is already prepared by
+// others when this node is about to register it, ex. delayed by waiting for
+// CRS. The master public key of this node could not be proposed in time.
+func (con *Consensus) registeredTooLate(e utils.RoundEventParam) bool {
+	b := con.bcModule.lastDeliveredBlock()
+	return b != nil && b.Position.Height >= e.NextDKGPreparationHeight()
+}
+
+// missDKG alerts that this node is selected as notary of a round but fails to
+// join its DKG. The node would be keyless in that round and participates BA
+// as a non-signer, DKG of later rounds is joined as usual when selected.
+func (con *Consensus) missDKG(round, reset uint64, reason error) {
+	con.logger.Error("Missed DKG, participate as non-signer",
+		"round", round,
+		"reset", reset,
+		"rejoin-round", round+1,
+		"reason", reason)
+	con.opts.incCounter("dkg-missed", 1)
+	if con.dkgApp != nil {
+		go con.dkgApp.DKGMissed(round, reset, reason)
+	}
+}
+
 // Run starts running DEXON Consensus.
 func (con *Consensus) Run() {
+	if nsNetwork, ok := con.network.(NamespacedNetwork); ok {
+		con.logger.Info("Running consensus in namespace",
+			"namespace", nsNetwork.Namespace())
+	}
 	// There may have emptys block in blockchain added by force sync.
 	blocksWithoutRandomness := con.bcModule.pendingBlocksWithoutRandomness()
 	// Launch BA routines.
@@ -1018,6 +1490,42 @@ func (con *Consensus) Run() {
 	go con.deliverNetworkMsg()
 	con.waitGroup.Add(1)
 	go con.processMsg()
+	con.waitGroup.Add(1)
+	go func() {
+		defer con.waitGroup.Done()
+		con.dkgVerifier.run()
+	}()
+	con.waitGroup.Add(1)
+	go func() {
+		defer con.waitGroup.Done()
+		con.rebroadcaster.run(con.ctx)
+	}()
+	if gossiper, ok := con.network.(WatermarkGossiper); ok {
+		con.waitGroup.Add(1)
+		go con.gossipWatermark(gossiper)
+	}
+	if prober, ok := con.network.(LatencyProber); ok {
+		con.waitGroup.Add(1)
+		go con.probeLatency(prober)
+	}
+	if announcer, ok := con.network.(ReadinessAnnouncer); ok {
+		con.waitGroup.Add(1)
+		go con.announceReadiness(announcer)
+	}
+	if gauges := con.opts.gaugeMetrics(); gauges != nil {
+		con.waitGroup.Add(1)
+		go con.sampleBacklogAges(gauges)
+	}
+	if con.opts.catchUpLag > 0 {
+		con.waitGroup.Add(1)
+		go con.catchUp()
+	}
+	if con.opts.pipelinedBA {
+		con.waitGroup.Add(1)
+		go con.deliverBlockLoop()
+	}
+	con.startArchive()
+	con.waitGroup.Add(1)
 	go con.processBlockLoop()
 	// Stop dummy receiver if launched.
 	if con.dummyCancel != nil {
@@ -1114,6 +1622,8 @@ func (con *Consensus) generateBlockRandomness(blocks []*types.Block) {
 					Randomness: sig.Signature[:],
 				}
 				con.bcModule.addBlockRandomness(block.Position, sig.Signature[:])
+				con.audit.recordProvenance(block.Hash, block.Position,
+					FinalizationSourceTSig, 0, nil)
 				con.logger.Debug("Broadcast BlockRandomness",
 					"block", block,
 					"result", result)
@@ -1148,11 +1658,88 @@ func (con *Consensus) runDKG(
 				round, reset,
 				con.event, dkgBeginHeight, dkgHeight); err != nil {
 			con.logger.Error("Failed to runDKG", "error", err)
+		} else {
+			con.events.publish(Event{
+				Type:  EventDKGFinished,
+				Round: round,
+				Reset: reset,
+			})
 		}
 	}()
 }
 
+// fatal halts all routines for an unrecoverable error, and leaves the decision
+// to application. It panics when application doesn't receive fatal errors.
+func (con *Consensus) fatal(err error) {
+	con.logger.Error("Halt for fatal error", "error", err)
+	con.opts.incCounter("fatal-errors", 1)
+	if con.fatalApp == nil {
+		panic(err)
+	}
+	con.ctxCancel()
+	con.fatalOnce.Do(func() {
+		go con.fatalApp.FatalError(err)
+	})
+}
+
+// checkProtocolVersion checks if ProtocolVersion meets the requirement of a
+// round, and returns the required version.
+func (con *Consensus) checkProtocolVersion(round uint64) (uint32, error) {
+	if con.govExt.versionGov == nil {
+		return 0, nil
+	}
+	required := con.govExt.versionGov.MinProtocolVersion(round)
+	if required > ProtocolVersion {
+		return required, ErrProtocolVersionOutdated
+	}
+	return required, nil
+}
+
+// haltOutdated halts all routines since ProtocolVersion is outdated for a
+// round, and notifies application to stop this instance.
+func (con *Consensus) haltOutdated(round uint64, required uint32) {
+	con.logger.Error("Halt for outdated protocol version",
+		"round", round,
+		"required", required,
+		"version", ProtocolVersion)
+	con.ctxCancel()
+	if con.versionApp != nil {
+		go con.versionApp.ProtocolVersionOutdated(round, required)
+	}
+}
+
+// notifyNotarySetChange notifies application the difference between notary
+// sets of round and its previous round.
+func (con *Consensus) notifyNotarySetChange(round uint64) {
+	prevSet, err := con.nodeSetCache.GetNotarySet(round - 1)
+	if err != nil {
+		con.logger.Error("Failed to get notary set",
+			"round", round-1,
+			"error", err)
+		return
+	}
+	curSet, err := con.nodeSetCache.GetNotarySet(round)
+	if err != nil {
+		con.logger.Error("Failed to get notary set",
+			"round", round,
+			"error", err)
+		return
+	}
+	added, removed := diffNodeSets(prevSet, curSet)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	con.logger.Info("Notary set changed",
+		"round", round,
+		"added", len(added),
+		"removed", len(removed))
+	go con.notaryApp.NotarySetChanged(round, added, removed)
+}
+
 func (con *Consensus) runCRS(round uint64, hash common.Hash, reset bool) {
+	if con.govExt.entropy != nil {
+		hash = MixCRSEntropy(hash, con.govExt.entropy.CRSEntropy(round+1))
+	}
 	// Start running next round CRS.
 	psig, err := con.cfgModule.preparePartialSignature(round, hash)
 	if err != nil {
@@ -1196,6 +1783,54 @@ func (con *Consensus) Stop() {
 	if nbApp, ok := con.app.(*nonBlocking); ok {
 		nbApp.wait()
 	}
+	con.sampledLogger.Flush()
+	con.events.close()
+}
+
+// Shutdown stops the consensus gracefully. Messages from network are not
+// received anymore, and those received, including DKG messages being
+// verified, are processed along with confirmed blocks before stopping
+// routines as Stop does. It returns the error of ctx
+// when ctx is done before all routines exit, the stopping would continue in
+// background.
+func (con *Consensus) Shutdown(ctx context.Context) error {
+	con.stopRecvOnce.Do(func() { close(con.stopRecv) })
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+	// A message is counted right after taken from the channel, the draining
+	// is confirmed by two consecutive checks to cover that gap.
+	for idle := 0; idle < 2; {
+		select {
+		case <-ctx.Done():
+			go con.Stop()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if con.drained() {
+			idle++
+		} else {
+			idle = 0
+		}
+	}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		con.Stop()
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drained checks if there is no message or block waiting in, or taken from,
+// internal channels and the DKG verifier.
+func (con *Consensus) drained() bool {
+	return len(con.msgChan) == 0 && len(con.priorityMsgChan) == 0 &&
+		len(con.processBlockChan) == 0 && len(con.deliverSignal) == 0 &&
+		atomic.LoadInt32(&con.inFlight) == 0 && con.dkgVerifier.drained()
 }
 
 func (con *Consensus) deliverNetworkMsg() {
@@ -1205,9 +1840,13 @@ func (con *Consensus) deliverNetworkMsg() {
 		select {
 		case <-con.ctx.Done():
 			return
+		case <-con.stopRecv:
+			return
 		default:
 		}
 		select {
+		case <-con.stopRecv:
+			return
 		case msg := <-recv:
 		innerLoop:
 			for {
@@ -1227,8 +1866,10 @@ func (con *Consensus) deliverNetworkMsg() {
 
 func (con *Consensus) processMsg() {
 	defer con.waitGroup.Done()
+	// Each iteration either returns or takes one message, which is no more in
+	// flight once the iteration is done.
 MessageLoop:
-	for {
+	for ; ; atomic.AddInt32(&con.inFlight, -1) {
 		select {
 		case <-con.ctx.Done():
 			return
@@ -1248,16 +1889,18 @@ MessageLoop:
 				return
 			}
 		}
+		atomic.AddInt32(&con.inFlight, 1)
 		switch val := msg.(type) {
 		case *selfAgreementResult:
 			con.baMgr.touchAgreementResult((*types.AgreementResult)(val))
+			con.storeAgreementResult((*types.AgreementResult)(val))
 		case *types.Block:
-			if ch, exist := func() (chan<- *types.Block, bool) {
+			if func() bool {
 				con.lock.RLock()
 				defer con.lock.RUnlock()
-				ch, e := con.baConfirmedBlock[val.Hash]
-				return ch, e
-			}(); exist {
+				_, exist := con.baConfirmedBlock[val.Hash]
+				return exist
+			}() {
 				if val.IsEmpty() {
 					hash, err := utils.HashBlock(val)
 					if err != nil {
@@ -1303,7 +1946,8 @@ MessageLoop:
 						con.network.ReportBadPeerChan() <- peer
 						continue MessageLoop
 					}
-					if err := utils.VerifyBlockSignature(val); err != nil {
+					if err := utils.VerifyBlockSignature(
+						val, con.nodeSetCache.Registry()); err != nil {
 						con.logger.Error("VerifyBlockSignature failed",
 							"block", val,
 							"error", err)
@@ -1311,64 +1955,182 @@ MessageLoop:
 						continue MessageLoop
 					}
 				}
-				func() {
-					con.lock.Lock()
-					defer con.lock.Unlock()
-					// In case of multiple delivered block.
-					if _, exist := con.baConfirmedBlock[val.Hash]; !exist {
-						return
-					}
-					delete(con.baConfirmedBlock, val.Hash)
-					ch <- val
-				}()
+				con.resolveBAConfirmedBlock(val)
+			} else if con.isStale(val.Position) {
+				con.opts.incCounter("stale-blocks", 1)
+			} else if con.isDuplicated(val, "blocks") {
+				// The same block is processed recently, drop it before
+				// verifying its signature again.
 			} else if val.IsFinalized() {
 				if err := con.processFinalizedBlock(val); err != nil {
-					con.logger.Error("Failed to process finalized block",
+					con.sampledLogger.Error("Failed to process finalized block",
 						"block", val,
 						"error", err)
 					con.network.ReportBadPeerChan() <- peer
+				} else {
+					con.seen.mark(val)
 				}
 			} else {
 				if err := con.preProcessBlock(val); err != nil {
-					con.logger.Error("Failed to pre process block",
+					con.sampledLogger.Error("Failed to pre process block",
 						"block", val,
 						"error", err)
 					con.network.ReportBadPeerChan() <- peer
+				} else {
+					con.seen.mark(val)
 				}
 			}
 		case *types.Vote:
+			if con.isDuplicated(val, "votes") {
+				continue MessageLoop
+			}
 			if err := con.ProcessVote(val); err != nil {
-				con.logger.Error("Failed to process vote",
+				con.sampledLogger.Error("Failed to process vote",
 					"vote", val,
 					"error", err)
 				con.network.ReportBadPeerChan() <- peer
+			} else {
+				con.seen.mark(val)
 			}
 		case *types.AgreementResult:
 			if err := con.ProcessAgreementResult(val); err != nil {
-				con.logger.Error("Failed to process agreement result",
+				con.sampledLogger.Error("Failed to process agreement result",
 					"result", val,
 					"error", err)
 				con.network.ReportBadPeerChan() <- peer
 			}
-		case *typesDKG.PrivateShare:
-			if err := con.cfgModule.processPrivateShare(val); err != nil {
-				con.logger.Error("Failed to process private share",
+		case *typesDKG.PrivateShare, *typesDKG.PartialSignature:
+			// DKG messages are verified and processed out of this loop.
+			con.dkgVerifier.submit(val, peer)
+		case *typesDKG.Artifacts:
+			if err := con.cfgModule.processDKGArtifacts(val); err != nil {
+				con.sampledLogger.Error("Failed to process DKG artifacts",
+					"artifacts", val,
 					"error", err)
 				con.network.ReportBadPeerChan() <- peer
 			}
-
-		case *typesDKG.PartialSignature:
-			if err := con.cfgModule.processPartialSignature(val); err != nil {
-				con.logger.Error("Failed to process partial signature",
+		case *types.Watermark:
+			if err := con.ProcessWatermark(val); err != nil {
+				con.sampledLogger.Error("Failed to process watermark",
+					"watermark", val,
 					"error", err)
 				con.network.ReportBadPeerChan() <- peer
 			}
+		case *types.Ping:
+			if err := con.ProcessPing(val); err != nil {
+				con.sampledLogger.Error("Failed to process ping",
+					"ping", val,
+					"error", err)
+				con.network.ReportBadPeerChan() <- peer
+			}
+		case *types.Readiness:
+			if err := con.ProcessReadiness(val); err != nil {
+				con.sampledLogger.Error("Failed to process readiness",
+					"readiness", val,
+					"error", err)
+				con.network.ReportBadPeerChan() <- peer
+			}
+		default:
+			con.processUnknownMsg(msg, peer)
+		}
+	}
+}
+
+// resolveBAConfirmedBlock hands a block to the routine waiting for it since
+// it's confirmed by BA before received.
+func (con *Consensus) resolveBAConfirmedBlock(b *types.Block) {
+	con.lock.Lock()
+	defer con.lock.Unlock()
+	// In case of multiple delivered block.
+	ch, exist := con.baConfirmedBlock[b.Hash]
+	if !exist {
+		return
+	}
+	delete(con.baConfirmedBlock, b.Hash)
+	ch <- b
+}
+
+// isDuplicated checks if a vote or block received is processed recently,
+// counters of received and duplicated messages of that kind are increased.
+func (con *Consensus) isDuplicated(msg interface{}, kind string) bool {
+	con.opts.incCounter("received-"+kind, 1)
+	if !con.seen.seen(msg) {
+		return false
+	}
+	con.opts.incCounter("duplicated-"+kind, 1)
+	return true
+}
+
+// isStale checks if a position is too far behind the last delivered block
+// to be useful, by the cutoff set by WithStaleCutoff. It's not measured from
+// the last confirmed block, finalized blocks above the delivered tip are still
+// needed to catch up.
+func (con *Consensus) isStale(pos types.Position) bool {
+	if con.opts.staleCutoff == 0 {
+		return false
+	}
+	tip := con.bcModule.lastDeliveredBlock()
+	if tip == nil {
+		return false
+	}
+	return pos.Height+con.opts.staleCutoff < tip.Position.Height
+}
+
+// processUnknownMsg counts and quarantines a message of unknown type, peers
+// sending it are not punished since they might run a newer protocol.
+func (con *Consensus) processUnknownMsg(msg, peer interface{}) {
+	con.opts.incCounter("unknown-messages", 1)
+	con.sampledLogger.Warn("Unknown message",
+		"type", fmt.Sprintf("%T", msg),
+		"peer", peer)
+	count, alert := con.quarantine.add(msg, peer, time.Now())
+	if !alert {
+		return
+	}
+	con.logger.Error("Unknown messages spiked",
+		"count", count,
+		"window", unknownMsgAlertWindow)
+	if con.unknownApp != nil {
+		go con.unknownApp.UnknownMessagesSpiked(count, unknownMsgAlertWindow)
+	}
+}
+
+// processDKGMsg is called, in order of arrival, when signatures of DKG
+// messages are verified.
+func (con *Consensus) processDKGMsg(msg, peer interface{}, err error) {
+	switch val := msg.(type) {
+	case *typesDKG.PrivateShare:
+		if err == nil {
+			err = con.cfgModule.processPrivateShare(val)
+		}
+		if err != nil {
+			if _, ok := err.(ErrDKGPhaseWindow); ok {
+				// Honest peers might be slow, don't punish them.
+				con.logger.Debug("Late private share", "error", err)
+				return
+			}
+			con.sampledLogger.Error("Failed to process private share",
+				"error", err)
+			con.network.ReportBadPeerChan() <- peer
+		}
+	case *typesDKG.PartialSignature:
+		if err == nil {
+			err = con.cfgModule.processPartialSignature(val)
+		}
+		if err != nil {
+			con.sampledLogger.Error("Failed to process partial signature",
+				"error", err)
+			con.network.ReportBadPeerChan() <- peer
 		}
 	}
 }
 
 // ProcessVote is the entry point to submit ont vote to a Consensus instance.
 func (con *Consensus) ProcessVote(vote *types.Vote) (err error) {
+	if con.isStale(vote.Position) {
+		con.opts.incCounter("stale-votes", 1)
+		return
+	}
 	err = con.baMgr.processVote(vote)
 	return
 }
@@ -1387,16 +2149,26 @@ func (con *Consensus) ProcessAgreementResult(
 	if err := con.bcModule.processAgreementResult(rand); err != nil {
 		con.baMgr.untouchAgreementResult(rand)
 		if err == ErrSkipButNoError {
+			// The position is delivered, the result might be pulled to
+			// backfill the archive.
+			con.archiveAgreementResult(rand)
 			return nil
 		}
 		return err
 	}
+	signers := make([]types.NodeID, 0, len(rand.Votes))
+	for _, vote := range rand.Votes {
+		signers = append(signers, vote.ProposerID)
+	}
+	con.audit.recordProvenance(rand.BlockHash, rand.Position,
+		FinalizationSourceAgreementResult, 0, signers)
 	// Syncing BA Module.
 	if err := con.baMgr.processAgreementResult(rand); err != nil {
 		con.baMgr.untouchAgreementResult(rand)
 		return err
 	}
 
+	con.storeAgreementResult(rand)
 	con.logger.Debug("Rebroadcast AgreementResult",
 		"result", rand)
 	con.network.BroadcastAgreementResult(rand)
@@ -1404,10 +2176,249 @@ func (con *Consensus) ProcessAgreementResult(
 	return con.deliverFinalizedBlocks()
 }
 
+// ProcessWatermark processes the watermark gossiped by other nodes.
+func (con *Consensus) ProcessWatermark(w *types.Watermark) error {
+	ok, err := utils.VerifyWatermarkSignature(w, con.nodeSetCache.Registry())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrIncorrectWatermarkSignature
+	}
+	// Only nodes known in the current round are trusted, a node far ahead
+	// might report rounds unknown to this node.
+	nodeSet, err := con.nodeSetCache.GetNodeSet(con.bcModule.tipRound())
+	if err != nil {
+		return err
+	}
+	if _, exist := nodeSet.IDs[w.ProposerID]; !exist {
+		return nil
+	}
+	now := time.Now().UTC()
+	if !con.watermarks.update(w, now) {
+		return nil
+	}
+	notarySet, err := con.nodeSetCache.GetNotarySet(con.bcModule.tipRound())
+	if err != nil {
+		return err
+	}
+	var delivered uint64
+	if b := con.bcModule.lastDeliveredBlock(); b != nil {
+		delivered = b.Position.Height
+	}
+	if started, frontier := con.watermarks.checkBehind(
+		delivered, now, notarySet); started {
+		con.logger.Warn("Falling behind the network",
+			"delivered", delivered,
+			"frontier", frontier)
+	}
+	return nil
+}
+
+// ProcessReadiness processes the readiness announced by other nodes before the
+// first DKG.
+func (con *Consensus) ProcessReadiness(r *types.Readiness) error {
+	return con.bootstrap.announceByReadiness(r)
+}
+
+// ProcessPing processes pings and pongs sent by other nodes. A ping from a
+// notary set member is answered by a pong, and a pong is matched with the
+// ping it answers to measure the round-trip latency.
+func (con *Consensus) ProcessPing(p *types.Ping) error {
+	ok, err := utils.VerifyPingSignature(p, con.nodeSetCache.Registry())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrIncorrectPingSignature
+	}
+	if p.TargetID != con.ID {
+		return nil
+	}
+	if p.IsPong {
+		if rtt, ok := con.latencies.pong(
+			p.ProposerID, p.Nonce, time.Now()); ok {
+			con.opts.observe("ping-rtt", rtt)
+		}
+		return nil
+	}
+	prober, ok := con.network.(LatencyProber)
+	if !ok {
+		return nil
+	}
+	// Only pings from notary set members are answered, or anyone could make
+	// this node send pongs to it.
+	notarySet, err := con.nodeSetCache.GetNotarySet(con.bcModule.tipRound())
+	if err != nil {
+		return err
+	}
+	if _, exist := notarySet[p.ProposerID]; !exist {
+		return nil
+	}
+	pong := &types.Ping{
+		TargetID: p.ProposerID,
+		Round:    p.Round,
+		Nonce:    p.Nonce,
+		IsPong:   true,
+	}
+	if err := con.signer.SignPing(pong); err != nil {
+		return err
+	}
+	prober.SendPing(p.ProposerID, pong)
+	return nil
+}
+
+// PeerLatencies returns percentiles of recent round-trip latencies to other
+// notary set members, measured by pings.
+func (con *Consensus) PeerLatencies() map[types.NodeID]PeerLatency {
+	return con.latencies.latencies()
+}
+
+// SubscribeBlocks subscribes blocks delivered from a height. Blocks lower
+// than the latest delivered one are replayed from database first, then blocks
+// are streamed as they are delivered.
+func (con *Consensus) SubscribeBlocks(from uint64) *BlockSubscription {
+	return con.feed.subscribe(from)
+}
+
+// AttachDebug attaches a Debug consumer at run-time, which is called along
+// with the application when it implements Debug interface. The returned
+// handle is used to detach it.
+func (con *Consensus) AttachDebug(debug Debug) *DebugHandle {
+	return con.debugApp.attach(debug)
+}
+
+// Subscribe subscribes events of given types emitted by consensus, all types
+// are subscribed when none is given. Events are buffered for subscribers, and
+// a subscriber lagging too much is closed by ErrSubscriptionLagging.
+func (con *Consensus) Subscribe(eventTypes ...EventType) *EventSubscription {
+	return con.events.subscribe(eventTypes...)
+}
+
+// NetworkFrontier returns the delivered position of the network estimated by
+// watermarks gossiped recently by notaries of the current round, ok is false
+// when not enough notaries reported.
+func (con *Consensus) NetworkFrontier() (pos types.Position, ok bool) {
+	pos, count := con.frontier(time.Now().UTC())
+	ok = count > 0
+	return
+}
+
+// frontier estimates the network frontier by watermarks from the notary set
+// of the current round.
+func (con *Consensus) frontier(now time.Time) (types.Position, int) {
+	notarySet, err := con.nodeSetCache.GetNotarySet(con.bcModule.tipRound())
+	if err != nil {
+		con.logger.Warn("Unable to get notary set to estimate frontier",
+			"error", err)
+		return types.Position{}, 0
+	}
+	return con.watermarks.frontier(now, notarySet)
+}
+
+// gossipWatermark broadcasts the watermark of this node periodically.
+func (con *Consensus) gossipWatermark(gossiper WatermarkGossiper) {
+	defer con.waitGroup.Done()
+	ticker := time.NewTicker(watermarkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-con.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		w := &types.Watermark{Timestamp: time.Now().UTC()}
+		if b := con.bcModule.lastConfirmedBlock(); b != nil {
+			w.Confirmed = b.Position
+		}
+		if b := con.bcModule.lastDeliveredBlock(); b != nil {
+			w.Delivered = b.Position
+		}
+		if err := con.signer.SignWatermark(w); err != nil {
+			con.logger.Error("Failed to sign watermark", "error", err)
+			continue
+		}
+		con.logger.Trace("Calling Network.BroadcastWatermark",
+			"watermark", w)
+		gossiper.BroadcastWatermark(w)
+	}
+}
+
+// announceReadiness broadcasts the readiness of this node periodically until
+// the first DKG is started.
+func (con *Consensus) announceReadiness(announcer ReadinessAnnouncer) {
+	defer con.waitGroup.Done()
+	ticker := time.NewTicker(readinessInterval)
+	defer ticker.Stop()
+	for con.bcModule.tipRound() < DKGDelayRound {
+		r := &types.Readiness{Round: DKGDelayRound}
+		if err := con.signer.SignReadiness(r); err != nil {
+			con.logger.Error("Failed to sign readiness", "error", err)
+			return
+		}
+		con.logger.Trace("Calling Network.BroadcastReadiness",
+			"readiness", r)
+		announcer.BroadcastReadiness(r)
+		select {
+		case <-con.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeLatency pings other notary set members periodically, and publishes
+// percentiles of round-trip latencies to each of them as gauges.
+func (con *Consensus) probeLatency(prober LatencyProber) {
+	defer con.waitGroup.Done()
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-con.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		con.latencies.expire(time.Now())
+		round := con.bcModule.tipRound()
+		notarySet, err := con.nodeSetCache.GetNotarySet(round)
+		if err != nil {
+			con.logger.Debug("Unable to get notary set to ping",
+				"round", round,
+				"error", err)
+			continue
+		}
+		con.latencies.retain(notarySet)
+		if _, exist := notarySet[con.ID]; exist {
+			for nID := range notarySet {
+				if nID == con.ID {
+					continue
+				}
+				p := &types.Ping{TargetID: nID, Round: round}
+				p.Nonce = con.latencies.ping(nID, time.Now())
+				if err := con.signer.SignPing(p); err != nil {
+					con.logger.Error("Failed to sign ping", "error", err)
+					continue
+				}
+				prober.SendPing(nID, p)
+			}
+		}
+		gauges := con.opts.gaugeMetrics()
+		if gauges == nil {
+			continue
+		}
+		for nID, l := range con.latencies.latencies() {
+			gauges.SetGauge("peer-latency-p50:"+nID.String(), l.P50.Seconds())
+			gauges.SetGauge("peer-latency-p90:"+nID.String(), l.P90.Seconds())
+			gauges.SetGauge("peer-latency-p99:"+nID.String(), l.P99.Seconds())
+		}
+	}
+}
+
 // preProcessBlock performs Byzantine Agreement on the block.
 func (con *Consensus) preProcessBlock(b *types.Block) (err error) {
 	err = con.baMgr.processBlock(b)
-	if err == nil && con.debugApp != nil {
+	if err == nil {
 		con.debugApp.BlockReceived(b.Hash)
 	}
 	return
@@ -1417,7 +2428,8 @@ func (con *Consensus) processFinalizedBlock(b *types.Block) (err error) {
 	if b.Position.Round < DKGDelayRound {
 		return
 	}
-	if err = utils.VerifyBlockSignature(b); err != nil {
+	if err = utils.VerifyBlockSignature(
+		b, con.nodeSetCache.Registry()); err != nil {
 		return
 	}
 	verifier, ok, err := con.tsigVerifierCache.UpdateAndGet(b.Position.Round)
@@ -1436,7 +2448,9 @@ func (con *Consensus) processFinalizedBlock(b *types.Block) (err error) {
 		return
 	}
 	err = con.baMgr.processFinalizedBlock(b)
-	if err == nil && con.debugApp != nil {
+	if err == nil {
+		con.audit.recordProvenance(b.Hash, b.Position,
+			FinalizationSourceFinalizedBlock, 0, nil)
 		con.debugApp.BlockReceived(b.Hash)
 	}
 	return
@@ -1464,30 +2478,291 @@ func (con *Consensus) deliveryGuard() {
 			return
 		case <-con.resetDeliveryGuardTicker:
 		case <-time.After(60 * time.Second):
+			var delivered uint64
+			if b := con.bcModule.lastDeliveredBlock(); b != nil {
+				delivered = b.Position.Height
+			}
+			// When peers are not progressing either, the whole network is
+			// stalled and there is nothing to recover by this node alone.
+			if frontier, ok := con.NetworkFrontier(); ok &&
+				frontier.Height <= delivered {
+				con.logger.Error("Network stalled",
+					"ID", con.ID,
+					"delivered", delivered,
+					"frontier", frontier)
+				continue
+			}
 			con.logger.Error("No blocks delivered for too long", "ID", con.ID)
-			panic(fmt.Errorf("No blocks delivered for too long"))
+			con.fatal(ErrNoBlocksDelivered)
+			return
 		}
 	}
 }
 
-// deliverBlock deliver a block to application layer.
-func (con *Consensus) deliverBlock(b *types.Block) {
+// sampleBacklogAges reports ages of backlogs as gauges periodically, the ages
+// keep growing when the pipeline is stalled without any event.
+func (con *Consensus) sampleBacklogAges(gauges GaugeMetrics) {
+	defer con.waitGroup.Done()
+	ticker := time.NewTicker(backlogSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-con.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ages := con.BacklogAges()
+		gauges.SetGauge("undelivered-age", ages.Undelivered.Seconds())
+		gauges.SetGauge("unwitnessed-age", ages.Unwitnessed.Seconds())
+		pending, undelivered := con.bcModule.backlogSizes()
+		gauges.SetGauge("pending-blocks", float64(pending))
+		gauges.SetGauge("undelivered-blocks", float64(undelivered))
+	}
+}
+
+// BacklogAges returns ages of the oldest block confirmed but not delivered,
+// and the oldest block delivered but not witnessed. They are the earliest
+// signals of stalls in generating randomness or acking witnesses.
+func (con *Consensus) BacklogAges() BacklogAges {
+	return con.bcModule.backlogAges(time.Now())
+}
+
+// storeAgreementResult persists an agreement result when the database
+// supports it.
+func (con *Consensus) storeAgreementResult(result *types.AgreementResult) {
+	store, ok := con.db.(db.AgreementResultStore)
+	if !ok {
+		return
+	}
+	if err := store.PutAgreementResult(*result); err != nil {
+		con.logger.Error("Failed to store agreement result",
+			"result", result,
+			"error", err)
+	}
+}
+
+// AgreementResult returns the stored agreement result of a position, which
+// could be used to serve peers catching up.
+func (con *Consensus) AgreementResult(pos types.Position) (
+	*types.AgreementResult, error) {
+	store, ok := con.db.(db.AgreementResultStore)
+	if !ok {
+		return nil, db.ErrNotImplemented
+	}
+	result, err := store.GetAgreementResult(pos)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ProtocolParams exports protocol parameters active in a round, the hash of
+// its canonical JSON document is carried by the first block of that round.
+func (con *Consensus) ProtocolParams(round uint64) (*ProtocolParams, error) {
+	config := con.gov.Configuration(round)
+	if config == nil {
+		return nil, ErrConfigurationNotReady
+	}
+	return NewProtocolParams(config), nil
+}
+
+// VoteThresholds returns counts of votes required by BA in a round, derived
+// from the notary set actually selected in that round.
+func (con *Consensus) VoteThresholds(round uint64) (VoteThresholds, error) {
+	notarySet, err := con.nodeSetCache.GetNotarySet(round)
+	if err != nil {
+		return VoteThresholds{}, err
+	}
+	return NewVoteThresholds(len(notarySet)), nil
+}
+
+// TimestampProof returns the proof of consensus timestamp of a delivered
+// block, which could be verified by utils.VerifyTimestampProof with the group
+// public key of that round.
+func (con *Consensus) TimestampProof(hash common.Hash) (
+	*types.TimestampProof, error) {
+	b, err := con.db.GetBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	return utils.NewTimestampProof(&b)
+}
+
+// DeliveryProof returns the proof of the position where a delivered block is
+// delivered. Blocks in rounds before DKG is ready are provable only when their
+// agreement results are still stored.
+func (con *Consensus) DeliveryProof(hash common.Hash) (
+	*types.DeliveryProof, error) {
+	b, err := con.db.GetBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	var result *types.AgreementResult
+	if b.Position.Round < DKGDelayRound {
+		if result, err = con.AgreementResult(b.Position); err != nil {
+			return nil, err
+		}
+	}
+	return utils.NewDeliveryProof(&b, result)
+}
+
+// proposeDelay returns the phase offset of this node to broadcast a proposal
+// when propose jitter is enabled.
+func (con *Consensus) proposeDelay(b *types.Block) time.Duration {
+	if con.opts.proposeJitter <= 0 {
+		return 0
+	}
+	config := con.baMgr.config(b.Position.Round)
+	if config == nil {
+		return 0
+	}
+	window := time.Duration(
+		float64(config.lambdaBA) * con.opts.proposeJitter)
+	return proposeOffset(config.crs, con.ID, window)
+}
+
+// rebroadcast broadcasts own messages again for selfRebroadcaster.
+func (con *Consensus) rebroadcast(msg interface{}) {
+	switch val := msg.(type) {
+	case *types.Block:
+		con.logger.Debug("Rebroadcast block", "block", val)
+		con.network.BroadcastBlock(val)
+	case *types.Vote:
+		con.logger.Debug("Rebroadcast vote", "vote", val)
+		con.network.BroadcastVote(val)
+	}
+}
+
+// deliverBlocks delivers blocks to application layer, they would be delivered
+// in one batch when the application implements BatchDeliveryReceiver.
+func (con *Consensus) deliverBlocks(blocks []*types.Block) {
+	if len(blocks) == 0 {
+		return
+	}
 	select {
 	case con.resetDeliveryGuardTicker <- struct{}{}:
 	default:
 	}
-	if err := con.db.PutBlock(*b); err != nil {
-		panic(err)
+	for _, b := range blocks {
+		if err := retryDB(func() error {
+			return con.db.PutBlock(*b)
+		}); err != nil {
+			con.fatal(err)
+			return
+		}
+		if err := retryDB(func() error {
+			return con.db.PutCompactionChainTipInfo(b.Hash, b.Position.Height)
+		}); err != nil {
+			con.fatal(err)
+			return
+		}
+		if err := con.audit.deliver(b); err != nil {
+			con.logger.Error("Failed to audit finalization",
+				"block", b,
+				"error", err)
+		}
 	}
-	if err := con.db.PutCompactionChainTipInfo(b.Hash,
-		b.Position.Height); err != nil {
-		panic(err)
+	con.opts.incCounter("delivered-blocks", uint64(len(blocks)))
+	published := make([]*types.Block, 0, len(blocks))
+	for _, b := range blocks {
+		published = append(published, b.Clone())
+	}
+	con.feed.publish(published)
+	for _, b := range blocks {
+		con.events.publish(Event{
+			Type:      EventBlockFinalized,
+			Position:  b.Position,
+			BlockHash: b.Hash,
+		})
+	}
+	revealed := con.revealBlocks(blocks)
+	if con.sysMsgApp != nil {
+		for _, b := range revealed {
+			if len(b.SystemMessages) == 0 {
+				continue
+			}
+			con.logger.Debug("Calling Application.SystemMessagesDelivered",
+				"block", b,
+				"count", len(b.SystemMessages))
+			con.sysMsgApp.SystemMessagesDelivered(
+				b.Hash, b.Position, b.Clone().SystemMessages)
+		}
 	}
-	con.logger.Debug("Calling Application.BlockDelivered", "block", b)
-	con.app.BlockDelivered(b.Hash, b.Position, common.CopyBytes(b.Randomness))
-	if con.debugApp != nil {
+	if con.batchMetaApp != nil && len(revealed) > 0 {
+		batch := make([]*types.Block, 0, len(revealed))
+		for _, b := range revealed {
+			batch = append(batch, b.Clone())
+		}
+		meta := DeliveryMeta{
+			DeliveryMetaBatchSeq: con.deliveredBatches,
+			DeliveryMetaOrdering: DeliveryOrderingHeight,
+			DeliveryMetaWithheld: uint64(len(con.unrevealed)),
+		}
+		con.deliveredBatches++
+		con.logger.Debug("Calling Application.BlocksDeliveredWithMeta",
+			"count", len(batch),
+			"last", batch[len(batch)-1],
+			"meta", meta)
+		con.batchMetaApp.BlocksDeliveredWithMeta(batch, meta)
+	} else if con.batchApp != nil && len(revealed) > 0 {
+		batch := make([]*types.Block, 0, len(revealed))
+		for _, b := range revealed {
+			batch = append(batch, b.Clone())
+		}
+		con.logger.Debug("Calling Application.BlocksDelivered",
+			"count", len(batch),
+			"last", batch[len(batch)-1])
+		con.batchApp.BlocksDelivered(batch)
+	} else {
+		for _, b := range revealed {
+			con.logger.Debug("Calling Application.BlockDelivered", "block", b)
+			con.app.BlockDelivered(
+				b.Hash, b.Position, common.CopyBytes(b.Randomness))
+		}
+	}
+	for _, b := range blocks {
 		con.debugApp.BlockReady(b.Hash)
 	}
+	con.purgeAgreementResults(blocks[len(blocks)-1].Position.Height)
+}
+
+// revealBlocks returns blocks ready to be delivered to the application. When
+// a reveal delay is configured, the latest finalized blocks are withheld
+// until enough blocks are finalized after them. Blocks withheld when the
+// consensus core is stopped are not delivered.
+func (con *Consensus) revealBlocks(blocks []*types.Block) []*types.Block {
+	if con.revealDelay == 0 {
+		return blocks
+	}
+	con.unrevealed = append(con.unrevealed, blocks...)
+	if uint64(len(con.unrevealed)) <= con.revealDelay {
+		return nil
+	}
+	count := uint64(len(con.unrevealed)) - con.revealDelay
+	revealed := con.unrevealed[:count]
+	con.unrevealed = append([]*types.Block(nil), con.unrevealed[count:]...)
+	return revealed
+}
+
+// purgeAgreementResults removes stored agreement results older than the
+// retention window, results since the begin of the archive range are kept.
+func (con *Consensus) purgeAgreementResults(height uint64) {
+	store, ok := con.db.(db.AgreementResultStore)
+	if !ok || height <= agreementResultRetention {
+		return
+	}
+	height -= agreementResultRetention
+	if con.opts.archive && height > con.opts.archiveBegin {
+		height = con.opts.archiveBegin
+	}
+	if height <= types.GenesisHeight {
+		return
+	}
+	if err := store.PurgeAgreementResults(height); err != nil {
+		con.logger.Error("Failed to purge agreement results",
+			"height", height,
+			"error", err)
+	}
 }
 
 // deliverFinalizedBlocks extracts and delivers finalized blocks to application
@@ -1503,14 +2778,19 @@ func (con *Consensus) deliverFinalizedBlocksWithoutLock() (err error) {
 	con.logger.Debug("Last blocks in compaction chain",
 		"delivered", con.bcModule.lastDeliveredBlock(),
 		"pending", con.bcModule.lastPendingBlock())
+	con.deliverBlocks(deliveredBlocks)
 	for _, b := range deliveredBlocks {
-		con.deliverBlock(b)
 		con.event.NotifyHeight(b.Position.Height)
 	}
+	if len(deliveredBlocks) > 0 {
+		con.rebroadcaster.confirm(
+			deliveredBlocks[len(deliveredBlocks)-1].Position)
+	}
 	return
 }
 
 func (con *Consensus) processBlockLoop() {
+	defer con.waitGroup.Done()
 	for {
 		select {
 		case <-con.ctx.Done():
@@ -1521,17 +2801,54 @@ func (con *Consensus) processBlockLoop() {
 		case <-con.ctx.Done():
 			return
 		case block := <-con.processBlockChan:
+			atomic.AddInt32(&con.inFlight, 1)
 			if err := con.processBlock(block); err != nil {
 				con.logger.Error("Error processing block",
 					"block", block,
 					"error", err)
 			}
+			atomic.AddInt32(&con.inFlight, -1)
+		}
+	}
+}
+
+// deliverBlockLoop delivers blocks added by processBlock when BA is pipelined,
+// thus adding blocks is not blocked by delivering previous ones.
+func (con *Consensus) deliverBlockLoop() {
+	defer con.waitGroup.Done()
+	for {
+		select {
+		case <-con.ctx.Done():
+			return
+		case <-con.deliverSignal:
+			atomic.AddInt32(&con.inFlight, 1)
+			if err := func() error {
+				con.lock.Lock()
+				defer con.lock.Unlock()
+				return con.deliverFinalizedBlocksWithoutLock()
+			}(); err != nil {
+				con.logger.Error("Error delivering blocks", "error", err)
+			}
+			atomic.AddInt32(&con.inFlight, -1)
 		}
 	}
 }
 
 // processBlock is the entry point to submit one block to a Consensus instance.
 func (con *Consensus) processBlock(block *types.Block) (err error) {
+	if con.opts.pipelinedBA {
+		// blockChain is guarded by its own lock, the block is confirmed
+		// right away and BA could move on while blocks are delivered by
+		// deliverBlockLoop.
+		if err = con.bcModule.addBlock(block); err != nil {
+			return
+		}
+		select {
+		case con.deliverSignal <- struct{}{}:
+		default:
+		}
+		return
+	}
 	// Block processed by blockChain can be out-of-order. But the output from
 	// blockChain (deliveredBlocks) cannot, thus we need to protect the part
 	// below with writer lock.
@@ -1546,10 +2863,17 @@ func (con *Consensus) processBlock(block *types.Block) (err error) {
 	return
 }
 
-// PrepareBlock would setup header fields of block based on its ProposerID.
+// proposeBlock would return the block to propose at that position.
 func (con *Consensus) proposeBlock(position types.Position) (
 	*types.Block, error) {
-	b, err := con.bcModule.proposeBlock(position, time.Now().UTC(), false)
+	return con.proposer.propose(
+		position, con.bcModule.suggestTimestamp(con.opts.clock().UTC()))
+}
+
+// prepareBlock would setup header fields of block based on its ProposerID.
+func (con *Consensus) prepareBlock(position types.Position,
+	proposeTime time.Time) (*types.Block, error) {
+	b, err := con.bcModule.proposeBlock(position, proposeTime, false)
 	if err != nil {
 		return nil, err
 	}
@@ -1565,3 +2889,87 @@ func (con *Consensus) proposeBlock(position types.Position) (
 	}
 	return b, nil
 }
+
+// ProposeSystemMessage queues a system message to be carried by blocks
+// proposed by this node, separately from the payload. The message is kept
+// until a block of this node carrying it is confirmed, and is delivered by
+// SystemMessageReceiver.
+func (con *Consensus) ProposeSystemMessage(msg []byte) error {
+	return con.bcModule.proposeSystemMessage(msg)
+}
+
+// ProposerStats returns the statistics of block proposing of this node.
+func (con *Consensus) ProposerStats() ProposerStats {
+	return con.proposer.getStats()
+}
+
+// AgreementStatus returns votes received per period and type by BA at its
+// current position, along with notaries not voting yet.
+func (con *Consensus) AgreementStatus() AgreementStatus {
+	return con.baMgr.baModule.status()
+}
+
+// Metrics returns the snapshot of BA metrics, including periods taken to
+// confirm positions, votes received by type and candidate blocks.
+func (con *Consensus) Metrics() AgreementMetrics {
+	return con.baMgr.baModule.metrics.snapshot()
+}
+
+// FinalizationRecord returns the provenance of the block delivered at a
+// height, only recent records are kept.
+func (con *Consensus) FinalizationRecord(height uint64) (
+	FinalizationRecord, bool) {
+	return con.audit.record(height)
+}
+
+// DKGArtifacts returns DKG artifacts of a round known by this node, it's
+// used to serve peers pulling them.
+func (con *Consensus) DKGArtifacts(round uint64) *typesDKG.Artifacts {
+	return con.cfgModule.dkgArtifacts(round)
+}
+
+// DeadLetters returns application callbacks failed by panicking. It's always
+// empty when callbacks are not made non-blocking.
+func (con *Consensus) DeadLetters() []DeadLetter {
+	nb, ok := con.app.(*nonBlocking)
+	if !ok {
+		return nil
+	}
+	return nb.getDeadLetters()
+}
+
+// QuarantinedMessages returns latest messages of unknown types received from
+// network, the oldest one comes first. It's always empty unless
+// WithQuarantine is provided.
+func (con *Consensus) QuarantinedMessages() []QuarantinedMessage {
+	return con.quarantine.messages()
+}
+
+// RetryDeadLetters dispatches failed application callbacks again, and returns
+// the count of retried ones.
+func (con *Consensus) RetryDeadLetters() int {
+	nb, ok := con.app.(*nonBlocking)
+	if !ok {
+		return 0
+	}
+	return nb.retryDeadLetters()
+}
+
+// diffNodeSets returns sorted nodes in cur but not in prev, and nodes in prev
+// but not in cur.
+func diffNodeSets(prev, cur map[types.NodeID]struct{}) (
+	added, removed types.NodeIDs) {
+	for nID := range cur {
+		if _, exist := prev[nID]; !exist {
+			added = append(added, nID)
+		}
+	}
+	for nID := range prev {
+		if _, exist := cur[nID]; !exist {
+			removed = append(removed, nID)
+		}
+	}
+	sort.Sort(added)
+	sort.Sort(removed)
+	return
+}
@@ -20,6 +20,7 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"sort"
 	"sync"
 	"testing"
 	"time"
@@ -69,6 +70,11 @@ func (n *network) BroadcastAgreementResult(
 	n.conn.broadcast(n.nID, randRequest)
 }
 
+// SendPing sends a ping or a pong to a node.
+func (n *network) SendPing(to types.NodeID, ping *types.Ping) {
+	n.conn.send(n.nID, to, ping)
+}
+
 // SendDKGPrivateShare sends PrivateShare to a DKG participant.
 func (n *network) SendDKGPrivateShare(
 	recv crypto.PublicKey, prvShare *typesDKG.PrivateShare) {
@@ -169,6 +175,8 @@ func (nc *networkConnection) setCon(nID types.NodeID, con *Consensus) {
 				err = con.ProcessVote(val)
 			case *types.AgreementResult:
 				err = con.ProcessAgreementResult(val)
+			case *types.Ping:
+				err = con.ProcessPing(val)
 			case *typesDKG.PrivateShare:
 				err = con.cfgModule.processPrivateShare(val)
 			case *typesDKG.PartialSignature:
@@ -236,19 +244,34 @@ func (s *ConsensusTestSuite) prepareConsensus(
 
 func (s *ConsensusTestSuite) prepareConsensusWithDB(
 	dMoment time.Time,
-	gov *test.Governance,
+	gov Governance,
 	prvKey crypto.PrivateKey,
 	conn *networkConnection,
-	dbInst db.Database) (
+	dbInst db.Database,
+	opts ...Option) (
 	*test.App, *Consensus) {
 
 	app := test.NewApp(0, nil, nil)
+	con := s.prepareConsensusWithApp(
+		dMoment, app, gov, prvKey, conn, dbInst, opts...)
+	return app, con
+}
+
+func (s *ConsensusTestSuite) prepareConsensusWithApp(
+	dMoment time.Time,
+	app Application,
+	gov Governance,
+	prvKey crypto.PrivateKey,
+	conn *networkConnection,
+	dbInst db.Database,
+	opts ...Option) *Consensus {
+
 	nID := types.NewNodeID(prvKey.PublicKey())
 	network := conn.newNetwork(nID)
-	con := NewConsensus(
-		dMoment, app, gov, dbInst, network, prvKey, &common.NullLogger{})
+	con := NewConsensus(dMoment, app, gov, dbInst, network, prvKey,
+		&common.NullLogger{}, opts...)
 	conn.setCon(nID, con)
-	return app, con
+	return con
 }
 
 func (s *ConsensusTestSuite) TestRegisteredDKGRecover() {
@@ -385,6 +408,27 @@ func (s *ConsensusTestSuite) TestSyncBA() {
 	// Negative cases are moved to TestVerifyAgreementResult in utils_test.go.
 }
 
+func (s *ConsensusTestSuite) TestShutdown() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	_, con := s.prepareConsensus(time.Now().UTC(), gov, prvKeys[0], conn)
+	go con.Run()
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 100; i++ {
+		con.msgChan <- types.Msg{Payload: &types.Vote{}}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s.Require().NoError(con.Shutdown(ctx))
+	// Received messages are processed.
+	s.Require().Len(con.msgChan, 0)
+	s.Require().Equal(context.Canceled, con.ctx.Err())
+}
+
 func (s *ConsensusTestSuite) TestInitialHeightEventTriggered() {
 	// Initial block is the last block of corresponding round, in this case,
 	// we should make sure all height event handlers could be triggered after
@@ -433,6 +477,582 @@ func (s *ConsensusTestSuite) TestInitialHeightEventTriggered() {
 	s.Require().Equal(con.bcModule.configs[0].RoundEndHeight(), uint64(301))
 }
 
+type revealDelayApp struct {
+	*test.App
+
+	delay uint64
+}
+
+func (app *revealDelayApp) CallbackConfig() CallbackConfig {
+	return CallbackConfig{RandomnessRevealDelay: app.delay}
+}
+
+func (s *ConsensusTestSuite) TestRandomnessRevealDelay() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	app := &revealDelayApp{App: test.NewApp(0, nil, nil), delay: 2}
+	con := s.prepareConsensusWithApp(
+		time.Now().UTC(), app, gov, prvKeys[0], conn, dbInst)
+	s.Require().Equal(uint64(2), con.revealDelay)
+	blocks := make([]*types.Block, 5)
+	for i := range blocks {
+		blocks[i] = &types.Block{
+			Hash:     common.NewRandomHash(),
+			Position: types.Position{Height: uint64(i) + 1},
+		}
+	}
+	s.Require().Empty(con.revealBlocks(blocks[:1]))
+	s.Require().Empty(con.revealBlocks(blocks[1:2]))
+	s.Require().Equal(blocks[:1], con.revealBlocks(blocks[2:3]))
+	s.Require().Equal(blocks[1:3], con.revealBlocks(blocks[3:5]))
+	s.Require().Equal(blocks[3:], con.unrevealed)
+	// No delay by default.
+	con.revealDelay = 0
+	s.Require().Equal(blocks[:2], con.revealBlocks(blocks[:2]))
+}
+
+type dkgMissApp struct {
+	*test.App
+
+	missed chan uint64
+}
+
+func (app *dkgMissApp) DKGMissed(round, reset uint64, reason error) {
+	app.missed <- round
+}
+
+func (s *ConsensusTestSuite) TestDKGMiss() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	app := &dkgMissApp{App: test.NewApp(0, nil, nil), missed: make(chan uint64)}
+	con := s.prepareConsensusWithApp(
+		time.Now().UTC(), app, gov, prvKeys[0], conn, dbInst)
+	defer con.Stop()
+	// Registering before DKG preparation is in time.
+	e := utils.RoundEventParam{
+		Round:       1,
+		BeginHeight: types.GenesisHeight,
+		Config:      &types.Config{RoundLength: 30},
+	}
+	s.Require().False(con.registeredTooLate(e))
+	con.bcModule.lastDelivered = &types.Block{
+		Position: types.Position{Height: e.NextDKGPreparationHeight() - 1}}
+	s.Require().False(con.registeredTooLate(e))
+	con.bcModule.lastDelivered = &types.Block{
+		Position: types.Position{Height: e.NextDKGPreparationHeight()}}
+	s.Require().True(con.registeredTooLate(e))
+	// The application is alerted.
+	con.missDKG(2, 0, ErrDKGRegistrationTooLate)
+	select {
+	case round := <-app.missed:
+		s.Require().Equal(uint64(2), round)
+	case <-time.After(time.Second):
+		s.FailNow("not alerted")
+	}
+	// Votes requiring partial signatures are skipped by non-signers.
+	recv := &consensusBAReceiver{consensus: con}
+	hash := common.NewRandomHash()
+	newVote := func(t types.VoteType, hash common.Hash,
+		round uint64) *types.Vote {
+		vote := types.NewVote(t, hash, 0)
+		vote.Position.Round = round
+		return vote
+	}
+	s.Require().True(recv.nonSigner(newVote(types.VoteCom, hash, 2)))
+	s.Require().True(recv.nonSigner(newVote(types.VoteFastCom, hash, 2)))
+	s.Require().False(recv.nonSigner(newVote(types.VotePreCom, hash, 2)))
+	s.Require().False(recv.nonSigner(
+		newVote(types.VoteCom, types.SkipBlockHash, 2)))
+	s.Require().False(recv.nonSigner(
+		newVote(types.VoteCom, hash, DKGDelayRound-1)))
+}
+
+func (s *ConsensusTestSuite) TestStaleCutoff() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	metrics := &fakeMetrics{
+		durations: make(map[string]int),
+		counters:  make(map[string]uint64),
+	}
+	_, con := s.prepareConsensusWithDB(time.Now().UTC(), gov, prvKeys[0], conn,
+		dbInst, WithStaleCutoff(2), WithMetrics(metrics))
+	// Nothing is stale before any block is delivered.
+	s.Require().False(con.isStale(types.Position{Height: 1}))
+	// Finalized blocks above the delivered tip are needed to catch up, even
+	// if they are far behind the confirmed tip.
+	con.bcModule.lastConfirmed = &types.Block{
+		Position: types.Position{Height: 10}}
+	s.Require().False(con.isStale(types.Position{Height: 7}))
+	con.bcModule.lastDelivered = &types.Block{
+		Position: types.Position{Height: 10}}
+	s.Require().False(con.isStale(types.Position{Height: 8}))
+	s.Require().True(con.isStale(types.Position{Height: 7}))
+	// Stale votes are dropped without verifying signatures.
+	vote := types.NewVote(types.VoteCom, common.NewRandomHash(), 0)
+	vote.Position.Height = 7
+	s.Require().NoError(con.ProcessVote(vote))
+	s.Require().Equal(uint64(1), metrics.counters["stale-votes"])
+	// Disabled by default.
+	con.opts.staleCutoff = 0
+	s.Require().False(con.isStale(types.Position{Height: 1}))
+}
+
+func (s *ConsensusTestSuite) TestValidateNextRound() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	_, con := s.prepareConsensus(time.Now().UTC(), gov, prvKeys[0], conn)
+	report := con.ValidateNextRound()
+	s.Require().Equal(uint64(1), report.Round)
+	s.Require().NotEqual(common.Hash{}, report.ProtocolParamsHash)
+	s.Require().Equal(NewProtocolParams(gov.Configuration(1)).Hash(),
+		report.ProtocolParamsHash)
+	// No DKG is run for round 1 yet.
+	s.Require().False(report.OK())
+	s.Require().Equal([]error{ErrNextDKGNotReady}, report.Problems)
+}
+
+type notaryApp struct {
+	*test.App
+}
+
+func (app *notaryApp) NotarySetChanged(uint64, types.NodeIDs, types.NodeIDs) {}
+
+func (s *ConsensusTestSuite) TestNotarySetChanged() {
+	_, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	nIDs := make(types.NodeIDs, 0, len(pubKeys))
+	for _, k := range pubKeys {
+		nIDs = append(nIDs, types.NewNodeID(k))
+	}
+	toSet := func(ids ...types.NodeID) map[types.NodeID]struct{} {
+		set := make(map[types.NodeID]struct{})
+		for _, nID := range ids {
+			set[nID] = struct{}{}
+		}
+		return set
+	}
+	added, removed := diffNodeSets(
+		toSet(nIDs[0], nIDs[1], nIDs[2]), toSet(nIDs[1], nIDs[2], nIDs[3]))
+	s.Require().Equal(types.NodeIDs{nIDs[3]}, added)
+	s.Require().Equal(types.NodeIDs{nIDs[0]}, removed)
+	added, removed = diffNodeSets(toSet(nIDs...), toSet(nIDs...))
+	s.Require().Empty(added)
+	s.Require().Empty(removed)
+	added, removed = diffNodeSets(toSet(), toSet(nIDs...))
+	s.Require().Len(added, len(nIDs))
+	s.Require().True(sort.IsSorted(added))
+	s.Require().Empty(removed)
+	// The receiver is detected from application.
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	con := s.prepareConsensusWithApp(time.Now().UTC(),
+		&notaryApp{test.NewApp(0, nil, nil)}, gov, prvKeys[0], conn, dbInst)
+	s.Require().NotNil(con.notaryApp)
+}
+
+func (s *ConsensusTestSuite) TestBlockCertificate() {
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	nID := types.NewNodeID(prvKeys[0].PublicKey())
+	conn := s.newNetworkConnection()
+	_, con := s.prepareConsensusWithDB(
+		time.Now().UTC(), gov, prvKeys[0], conn, dbInst)
+	// Unfinalized blocks have no certificate.
+	b := &types.Block{
+		ProposerID: nID,
+		Position:   types.Position{Height: 1},
+		Hash:       common.NewRandomHash(),
+	}
+	s.Require().NoError(dbInst.PutBlock(*b))
+	_, err = con.BlockCertificate(b.Hash)
+	s.Require().Equal(utils.ErrBlockNotFinalized, err)
+	// No votes without agreement result.
+	b.Randomness = common.GenerateRandomBytes()
+	s.Require().NoError(dbInst.UpdateBlock(*b))
+	cert, err := con.BlockCertificate(b.Hash)
+	s.Require().NoError(err)
+	s.Require().Equal(nID, cert.ProposerID)
+	s.Require().Equal(b.Randomness, cert.Randomness)
+	s.Require().Empty(cert.Votes)
+	// Voters are extracted from the stored agreement result.
+	result := types.AgreementResult{
+		BlockHash: b.Hash,
+		Position:  b.Position,
+	}
+	for _, prvKey := range prvKeys {
+		vote := types.NewVote(types.VoteCom, b.Hash, 0)
+		vote.ProposerID = types.NewNodeID(prvKey.PublicKey())
+		vote.Position = b.Position
+		result.Votes = append(result.Votes, *vote)
+	}
+	con.storeAgreementResult(&result)
+	cert, err = con.BlockCertificate(b.Hash)
+	s.Require().NoError(err)
+	s.Require().Len(cert.Votes, len(prvKeys))
+	s.Require().Len(cert.Voters, len(prvKeys))
+	s.Require().True(sort.IsSorted(cert.Voters))
+	// Unknown blocks.
+	_, err = con.BlockCertificate(common.NewRandomHash())
+	s.Require().Equal(db.ErrBlockDoesNotExist, err)
+}
+
+// versionGov requires a minimum protocol version since a round.
+type versionGov struct {
+	*test.Governance
+
+	since    uint64
+	required uint32
+}
+
+func (g *versionGov) MinProtocolVersion(round uint64) uint32 {
+	if round < g.since {
+		return 0
+	}
+	return g.required
+}
+
+type versionApp struct {
+	*test.App
+
+	outdated chan uint64
+}
+
+func (app *versionApp) ProtocolVersionOutdated(round uint64, _ uint32) {
+	app.outdated <- round
+}
+
+func (s *ConsensusTestSuite) TestProtocolVersionOutdated() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	newConsensus := func(gov Governance, app Application) *Consensus {
+		dbInst, err := db.NewMemBackedDB()
+		s.Require().NoError(err)
+		return s.prepareConsensusWithApp(
+			time.Now().UTC(), app, gov, prvKeys[0], conn, dbInst)
+	}
+	// Refuse to start when the initial round requires a newer version.
+	s.Require().Panics(func() {
+		newConsensus(&versionGov{Governance: gov, required: ProtocolVersion + 1},
+			test.NewApp(0, nil, nil))
+	})
+	// Halt when the round requiring a newer version begins.
+	app := &versionApp{
+		App:      test.NewApp(0, nil, nil),
+		outdated: make(chan uint64),
+	}
+	con := newConsensus(&versionGov{
+		Governance: gov,
+		since:      2,
+		required:   ProtocolVersion + 1,
+	}, app)
+	_, err = con.checkProtocolVersion(1)
+	s.Require().NoError(err)
+	required, err := con.checkProtocolVersion(2)
+	s.Require().Equal(ErrProtocolVersionOutdated, err)
+	s.Require().Equal(ProtocolVersion+1, required)
+	con.haltOutdated(2, required)
+	select {
+	case round := <-app.outdated:
+		s.Require().Equal(uint64(2), round)
+	case <-time.After(time.Second):
+		s.FailNow("not notified")
+	}
+	s.Require().Error(con.ctx.Err())
+}
+
+// reporterGov receives reports only available on undecorated governance.
+type reporterGov struct {
+	*test.Governance
+
+	misses    chan map[types.NodeID]uint64
+	evidences chan *types.VoteEvidence
+}
+
+func (g *reporterGov) ReportLeaderMiss(
+	round uint64, misses map[types.NodeID]uint64) {
+	g.misses <- misses
+}
+
+func (g *reporterGov) ReportForkEvidence(evidence *types.VoteEvidence) {
+	g.evidences <- evidence
+}
+
+func (s *ConsensusTestSuite) TestReportLeaderMiss() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	rGov := &reporterGov{
+		Governance: gov,
+		misses:     make(chan map[types.NodeID]uint64, 1),
+		evidences:  make(chan *types.VoteEvidence, 1),
+	}
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	_, con := s.prepareConsensusWithDB(
+		time.Now().UTC(), rGov, prvKeys[0], conn, dbInst)
+	leader := types.NewNodeID(pubKeys[1])
+	now := time.Now()
+	s.Require().True(con.baMgr.leaderMisses.check(
+		types.Position{Round: 1, Height: 10}, leader, now, time.Time{},
+		time.Second))
+	con.baMgr.reportLeaderMisses(1)
+	select {
+	case misses := <-rGov.misses:
+		s.Require().Equal(uint64(1), misses[leader])
+	case <-time.After(time.Second):
+		s.FailNow("not reported")
+	}
+}
+
+func (s *ConsensusTestSuite) TestReportForkEvidence() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	rGov := &reporterGov{
+		Governance: gov,
+		evidences:  make(chan *types.VoteEvidence, 1),
+	}
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	_, con := s.prepareConsensusWithDB(
+		time.Now().UTC(), rGov, prvKeys[0], conn, dbInst)
+	accused := utils.NewSigner(prvKeys[1])
+	v1 := types.NewVote(types.VoteCom, common.NewRandomHash(), 1)
+	v2 := types.NewVote(types.VoteCom, common.NewRandomHash(), 1)
+	s.Require().NoError(accused.SignVote(v1))
+	s.Require().NoError(accused.SignVote(v2))
+	recv := &consensusBAReceiver{consensus: con}
+	recv.ReportForkVote(v1, v2)
+	select {
+	case evidence := <-rGov.evidences:
+		s.Require().Equal(con.ID, evidence.ReporterID)
+	case <-time.After(time.Second):
+		s.FailNow("not reported")
+	}
+}
+
+type fatalApp struct {
+	*test.App
+
+	errs chan error
+}
+
+func (app *fatalApp) FatalError(err error) {
+	app.errs <- err
+}
+
+func (s *ConsensusTestSuite) TestFatalError() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	newConsensus := func(app Application) *Consensus {
+		dbInst, err := db.NewMemBackedDB()
+		s.Require().NoError(err)
+		return s.prepareConsensusWithApp(
+			time.Now().UTC(), app, gov, prvKeys[0], conn, dbInst)
+	}
+	// Panic when application doesn't receive fatal errors.
+	con := newConsensus(test.NewApp(0, nil, nil))
+	s.Require().Panics(func() { con.fatal(ErrNoBlocksDelivered) })
+	// Halt and notify application once.
+	app := &fatalApp{App: test.NewApp(0, nil, nil), errs: make(chan error, 2)}
+	con = newConsensus(app)
+	con.fatal(ErrNoBlocksDelivered)
+	con.fatal(ErrNoBlocksDelivered)
+	select {
+	case err := <-app.errs:
+		s.Require().Equal(ErrNoBlocksDelivered, err)
+	case <-time.After(time.Second):
+		s.FailNow("not notified")
+	}
+	s.Require().Error(con.ctx.Err())
+	select {
+	case <-app.errs:
+		s.FailNow("notified twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (s *ConsensusTestSuite) TestCatchUp() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	_, con := s.prepareConsensusWithDB(time.Now().UTC(), gov, prvKeys[0], conn,
+		dbInst, WithCatchUp(10))
+	sub := con.Subscribe(EventCatchUpStarted, EventCatchUpFinished)
+	now := time.Now().UTC()
+	// No watermark is received.
+	s.Require().False(con.checkCatchUp(now))
+	// A single notary reporting a far-ahead height is not trusted.
+	con.watermarks.update(&types.Watermark{
+		ProposerID: types.NewNodeID(pubKeys[0]),
+		Timestamp:  now,
+		Delivered:  types.Position{Height: 1000},
+	}, now)
+	s.Require().False(con.checkCatchUp(now))
+	s.Require().False(con.IsCatchingUp())
+	for _, k := range pubKeys[1:] {
+		con.watermarks.update(&types.Watermark{
+			ProposerID: types.NewNodeID(k),
+			Timestamp:  now,
+			Delivered:  types.Position{Height: 50},
+		}, now)
+	}
+	s.Require().True(con.checkCatchUp(now))
+	s.Require().True(con.IsCatchingUp())
+	s.Require().Equal(EventCatchUpStarted, (<-sub.Events()).Type)
+	// Not switched back until the lag is within half of the threshold.
+	con.bcModule.lastDelivered = &types.Block{
+		Position: types.Position{Height: 44}}
+	s.Require().False(con.checkCatchUp(now))
+	s.Require().True(con.IsCatchingUp())
+	con.bcModule.lastDelivered = &types.Block{
+		Position: types.Position{Height: 45}}
+	s.Require().True(con.checkCatchUp(now))
+	s.Require().False(con.IsCatchingUp())
+	e := <-sub.Events()
+	s.Require().Equal(EventCatchUpFinished, e.Type)
+	s.Require().Equal(uint64(50), e.Position.Height)
+}
+
+func (s *ConsensusTestSuite) TestArchive() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	_, con := s.prepareConsensusWithDB(time.Now().UTC(), gov, prvKeys[0], conn,
+		dbInst, WithArchive(5, 10))
+	con.archiveNext = 5
+	con.bcModule.lastDelivered = &types.Block{
+		Position: types.Position{Height: 2000}}
+	newResult := func(height uint64) *types.AgreementResult {
+		b := types.Block{
+			Hash:     common.NewRandomHash(),
+			Position: types.Position{Height: height},
+		}
+		s.Require().NoError(dbInst.PutBlock(b))
+		return &types.AgreementResult{
+			BlockHash: b.Hash,
+			Position:  b.Position,
+		}
+	}
+	for _, h := range []uint64{1, 5, 6, 8} {
+		con.storeAgreementResult(newResult(h))
+	}
+	// Results since the begin of the archive range are not purged.
+	con.purgeAgreementResults(2000)
+	_, err = dbInst.GetAgreementResult(types.Position{Height: 1})
+	s.Require().Equal(db.ErrAgreementResultDoesNotExist, err)
+	_, err = dbInst.GetAgreementResult(types.Position{Height: 5})
+	s.Require().NoError(err)
+	s.Require().Equal([]types.Position{{Height: 7}, {Height: 9}},
+		con.missingArchivePositions(dbInst, archivePullCount))
+	s.Require().Equal(uint64(7), con.archiveNext)
+	s.Require().False(con.IsArchiveComplete())
+	// Results out of the archive range are not backfilled.
+	con.archiveAgreementResult(newResult(12))
+	_, err = dbInst.GetAgreementResult(types.Position{Height: 12})
+	s.Require().Equal(db.ErrAgreementResultDoesNotExist, err)
+	// Results of blocks at other heights are not archived.
+	replayed := newResult(11)
+	replayed.Position = types.Position{Height: 7}
+	con.archiveAgreementResult(replayed)
+	_, err = dbInst.GetAgreementResult(types.Position{Height: 7})
+	s.Require().Equal(db.ErrAgreementResultDoesNotExist, err)
+	con.archiveAgreementResult(newResult(7))
+	con.archiveAgreementResult(newResult(9))
+	s.Require().Empty(con.missingArchivePositions(dbInst, archivePullCount))
+	s.Require().True(con.IsArchiveComplete())
+}
+
+func (s *ConsensusTestSuite) TestPing() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	dMoment := time.Now().UTC()
+	_, conA := s.prepareConsensus(dMoment, gov, prvKeys[0], conn)
+	_, conB := s.prepareConsensus(dMoment, gov, prvKeys[1], conn)
+	newPing := func() *types.Ping {
+		p := &types.Ping{TargetID: conB.ID}
+		p.Nonce = conA.latencies.ping(conB.ID, time.Now())
+		s.Require().NoError(conA.signer.SignPing(p))
+		return p
+	}
+	// Forged ping.
+	p := newPing()
+	p.Nonce++
+	s.Require().Equal(ErrIncorrectPingSignature, conB.ProcessPing(p))
+	// The pong is sent back to conA asynchronously.
+	s.Require().NoError(conB.ProcessPing(newPing()))
+	for i := 0; i < 100; i++ {
+		if len(conA.PeerLatencies()) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	latencies := conA.PeerLatencies()
+	s.Require().Len(latencies, 1)
+	s.Require().Equal(1, latencies[conB.ID].Samples)
+}
+
 func TestConsensus(t *testing.T) {
 	suite.Run(t, new(ConsensusTestSuite))
 }
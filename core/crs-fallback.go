@@ -0,0 +1,66 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+)
+
+const (
+	crsFallbackPrefix = "dexon-consensus-crs-fallback"
+	crsEntropyPrefix  = "dexon-consensus-crs-entropy"
+)
+
+// FallbackCRS derives the CRS of a round from the CRS of its previous round.
+// It's used when governance fails to publish CRS before the deadline.
+func FallbackCRS(prevCRS common.Hash) common.Hash {
+	return crypto.Keccak256Hash([]byte(crsFallbackPrefix), prevCRS[:])
+}
+
+// MixCRSEntropy derives the hash to be signed for CRS of next round from the
+// CRS of current round and external entropy. The CRS is returned untouched
+// when there is no entropy.
+func MixCRSEntropy(crs common.Hash, entropy []byte) common.Hash {
+	if len(entropy) == 0 {
+		return crs
+	}
+	return crypto.Keccak256Hash([]byte(crsEntropyPrefix), crs[:], entropy)
+}
+
+// proposeCRSFallback is called when the deadline of CRS for a round is
+// reached. When governance doesn't publish CRS for that round, the fallback
+// CRS derived from CRS of previous round is proposed to governance, it's used
+// only after recorded by governance.
+func proposeCRSFallback(gov Governance, fallbackGov CRSFallbackGovernance,
+	round uint64) (crs common.Hash, proposed bool) {
+	if fallbackGov == nil || round == 0 {
+		return
+	}
+	if (gov.CRS(round) != common.Hash{}) {
+		return
+	}
+	prevCRS := gov.CRS(round - 1)
+	if (prevCRS == common.Hash{}) {
+		return
+	}
+	crs = FallbackCRS(prevCRS)
+	fallbackGov.ProposeCRSFallback(round, crs)
+	proposed = true
+	return
+}
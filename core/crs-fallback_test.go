@@ -0,0 +1,86 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+)
+
+type CRSFallbackTestSuite struct {
+	suite.Suite
+}
+
+func (s *CRSFallbackTestSuite) TestProposeCRSFallback() {
+	req := s.Require()
+	_, pubKeys, err := test.NewKeys(4)
+	req.NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, 100*time.Millisecond, &common.NullLogger{}, true),
+		ConfigRoundShift)
+	req.NoError(err)
+	// Nothing is proposed when governance doesn't record fallbacks.
+	_, proposed := proposeCRSFallback(gov, nil, 2)
+	req.False(proposed)
+	// CRS of genesis rounds are ready, no fallback is needed.
+	_, proposed = proposeCRSFallback(gov, gov, 1)
+	req.False(proposed)
+	// Unable to derive fallback without CRS of previous round.
+	_, proposed = proposeCRSFallback(gov, gov, 3)
+	req.False(proposed)
+	// Governance fails to publish CRS for round 2, the fallback is used
+	// once it's recorded by governance.
+	req.Equal(common.Hash{}, gov.CRS(2))
+	crs, proposed := proposeCRSFallback(gov, gov, 2)
+	req.True(proposed)
+	req.Equal(FallbackCRS(gov.CRS(1)), crs)
+	req.Equal(crs, gov.CRS(2))
+	// CRS proposed later doesn't replace the recorded fallback.
+	gov.ProposeCRS(2, []byte("late crs"))
+	req.Equal(crs, gov.CRS(2))
+	_, proposed = proposeCRSFallback(gov, gov, 2)
+	req.False(proposed)
+	// Fallbacks are chained through rounds missing CRS.
+	crs3, proposed := proposeCRSFallback(gov, gov, 3)
+	req.True(proposed)
+	req.Equal(FallbackCRS(crs), crs3)
+	req.Equal(crs3, gov.CRS(3))
+}
+
+func (s *CRSFallbackTestSuite) TestMixCRSEntropy() {
+	req := s.Require()
+	crs := common.NewRandomHash()
+	// Nothing is mixed without entropy.
+	req.Equal(crs, MixCRSEntropy(crs, nil))
+	req.Equal(crs, MixCRSEntropy(crs, []byte{}))
+	mixed := MixCRSEntropy(crs, []byte("vdf output"))
+	req.NotEqual(crs, mixed)
+	req.Equal(mixed, MixCRSEntropy(crs, []byte("vdf output")))
+	req.NotEqual(mixed, MixCRSEntropy(crs, []byte("vdf output 2")))
+	req.NotEqual(mixed, MixCRSEntropy(common.NewRandomHash(),
+		[]byte("vdf output")))
+}
+
+func TestCRSFallback(t *testing.T) {
+	suite.Run(t, new(CRSFallbackTestSuite))
+}
@@ -18,6 +18,7 @@
 package dkg
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -40,6 +41,10 @@ var (
 	// ErrShareNotFound is reported when the private key share of id is not found
 	// when recovering private key.
 	ErrShareNotFound = fmt.Errorf("share not found")
+	// ErrInvalidPublicKeySharesBytes is reported when decoding public key
+	// shares from malformed bytes.
+	ErrInvalidPublicKeySharesBytes = fmt.Errorf(
+		"invalid public key shares bytes")
 )
 
 const cryptoType = "bls"
@@ -311,6 +316,108 @@ func (pubs *PublicKeyShares) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalBinary implements encoding.BinaryMarshaler. Unlike RLP encoding,
+// shares already evaluated for IDs are encoded as well, thus they are not
+// evaluated again once decoded.
+func (pubs *PublicKeyShares) MarshalBinary() ([]byte, error) {
+	cache := pubs.cache.Load().(*publicKeySharesCache)
+	ids := make([]ID, len(cache.share))
+	for id, idx := range cache.index {
+		ids[idx] = id
+	}
+	b := make([]byte, 0,
+		(len(pubs.masterPublicKey)+len(ids))*publicKeyLength)
+	b = appendUvarint(b, uint64(len(pubs.masterPublicKey)))
+	for _, m := range pubs.masterPublicKey {
+		b = append(b, m.Serialize()...)
+	}
+	b = appendUvarint(b, uint64(len(ids)))
+	for idx, id := range ids {
+		idBytes := id.GetLittleEndian()
+		b = appendUvarint(b, uint64(len(idBytes)))
+		b = append(b, idBytes...)
+		b = append(b, cache.share[idx].publicKey.Serialize()...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. Evaluated shares
+// are trusted without verification, bytes should only be decoded from
+// trusted sources, ex. local storage.
+func (pubs *PublicKeyShares) UnmarshalBinary(b []byte) error {
+	readUvarint := func() (uint64, error) {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return 0, ErrInvalidPublicKeySharesBytes
+		}
+		b = b[n:]
+		return v, nil
+	}
+	readBytes := func(length uint64) ([]byte, error) {
+		if uint64(len(b)) < length {
+			return nil, ErrInvalidPublicKeySharesBytes
+		}
+		ret := b[:length]
+		b = b[length:]
+		return ret, nil
+	}
+	readPublicKey := func() (key bls.PublicKey, err error) {
+		keyBytes, err := readBytes(uint64(publicKeyLength))
+		if err != nil {
+			return
+		}
+		err = key.Deserialize(keyBytes)
+		return
+	}
+	ps := NewEmptyPublicKeyShares()
+	count, err := readUvarint()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		key, err := readPublicKey()
+		if err != nil {
+			return err
+		}
+		ps.masterPublicKey = append(ps.masterPublicKey, key)
+	}
+	if count, err = readUvarint(); err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		length, err := readUvarint()
+		if err != nil {
+			return err
+		}
+		idBytes, err := readBytes(length)
+		if err != nil {
+			return err
+		}
+		var id ID
+		if err = id.SetLittleEndian(idBytes); err != nil {
+			return err
+		}
+		var share PublicKey
+		if share.publicKey, err = readPublicKey(); err != nil {
+			return err
+		}
+		if err = ps.AddShare(id, &share); err != nil {
+			return err
+		}
+	}
+	if len(b) != 0 {
+		return ErrInvalidPublicKeySharesBytes
+	}
+	pubs.masterPublicKey = ps.masterPublicKey
+	pubs.cache.Store(ps.cache.Load())
+	return nil
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return append(b, buf[:binary.PutUvarint(buf, v)]...)
+}
+
 // Clone clones every fields of PublicKeyShares. This method is mainly
 // for testing purpose thus would panic when error.
 func (pubs *PublicKeyShares) Clone() *PublicKeyShares {
@@ -366,6 +473,28 @@ func NewPrivateKeyShares(t int) (*PrivateKeyShares, *PublicKeyShares) {
 	}, pubShare
 }
 
+// NewPrivateKeySharesFromSeed creates a DKG private key shares of threshold t,
+// whose polynomial is derived from seed. It's for reproducible test vectors,
+// keys derived from a seed known by others are not secret.
+func NewPrivateKeySharesFromSeed(t int, seed []byte) (
+	*PrivateKeyShares, *PublicKeyShares) {
+	msk := make([]bls.SecretKey, t)
+	for i := range msk {
+		idx := make([]byte, 8)
+		binary.LittleEndian.PutUint64(idx, uint64(i))
+		coef := crypto.Keccak256Hash(seed, idx)
+		// #nosec G104
+		msk[i].SetLittleEndian(coef[:])
+	}
+	mpk := bls.GetMasterPublicKey(msk)
+	pubShare := NewEmptyPublicKeyShares()
+	pubShare.masterPublicKey = mpk
+	return &PrivateKeyShares{
+		masterPrivateKey: msk,
+		shareIndex:       make(map[ID]int),
+	}, pubShare
+}
+
 // NewEmptyPrivateKeyShares creates an empty private key shares.
 func NewEmptyPrivateKeyShares() *PrivateKeyShares {
 	return &PrivateKeyShares{
@@ -505,24 +634,27 @@ func (pubs *PublicKeyShares) AddShare(shareID ID, share *PublicKey) error {
 	return nil
 }
 
-// VerifyPrvShare verifies if the private key shares is valid.
+// VerifyPrvShare verifies if the private key shares is valid. The share
+// evaluated for the ID is cached, verifying shares for the same ID again,
+// ex. when checking complaints, would not evaluate it again.
 func (pubs *PublicKeyShares) VerifyPrvShare(ID ID, share *PrivateKey) (
 	bool, error) {
-	var pk bls.PublicKey
-	if err := pk.Set(pubs.masterPublicKey, &ID); err != nil {
+	pk, err := pubs.Share(ID)
+	if err != nil {
 		return false, err
 	}
-	return pk.IsEqual(share.privateKey.GetPublicKey()), nil
+	return pk.publicKey.IsEqual(share.privateKey.GetPublicKey()), nil
 }
 
-// VerifyPubShare verifies if the public key shares is valid.
+// VerifyPubShare verifies if the public key shares is valid. The share
+// evaluated for the ID is cached.
 func (pubs *PublicKeyShares) VerifyPubShare(ID ID, share *PublicKey) (
 	bool, error) {
-	var pk bls.PublicKey
-	if err := pk.Set(pubs.masterPublicKey, &ID); err != nil {
+	pk, err := pubs.Share(ID)
+	if err != nil {
 		return false, err
 	}
-	return pk.IsEqual(&share.publicKey), nil
+	return pk.publicKey.IsEqual(&share.publicKey), nil
 }
 
 // RecoverPublicKey recovers private key from the shares.
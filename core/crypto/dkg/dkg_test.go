@@ -327,6 +327,46 @@ func (s *DKGTestSuite) TestPublicKeySharesRLPEncodeDecode() {
 	s.Require().True(reflect.DeepEqual(b, bb))
 }
 
+func (s *DKGTestSuite) TestPublicKeySharesBinaryEncodeDecode() {
+	prvShares, pubShares := NewPrivateKeyShares(3)
+	ids := s.genID(5)
+	prvShares.SetParticipants(ids)
+	// Evaluate shares for some IDs.
+	for _, id := range ids[:3] {
+		_, err := pubShares.Share(id)
+		s.Require().NoError(err)
+	}
+	b, err := pubShares.MarshalBinary()
+	s.Require().NoError(err)
+	decoded := NewEmptyPublicKeyShares()
+	s.Require().NoError(decoded.UnmarshalBinary(b))
+	s.Require().True(pubShares.Equal(decoded))
+	s.Require().Equal(pubShares.MasterKeyBytes(), decoded.MasterKeyBytes())
+	// Evaluated shares are decoded as well.
+	s.Require().Len(decoded.cache.Load().(*publicKeySharesCache).share, 3)
+	for _, id := range ids {
+		prvShare, ok := prvShares.Share(id)
+		s.Require().True(ok)
+		valid, err := decoded.VerifyPrvShare(id, prvShare)
+		s.Require().NoError(err)
+		s.Require().True(valid)
+	}
+	// It's also decodable without evaluated shares.
+	_, pubShares = NewPrivateKeyShares(3)
+	b, err = pubShares.MarshalBinary()
+	s.Require().NoError(err)
+	decoded = NewEmptyPublicKeyShares()
+	s.Require().NoError(decoded.UnmarshalBinary(b))
+	s.Require().True(pubShares.Equal(decoded))
+	// Malformed bytes.
+	s.Require().Equal(ErrInvalidPublicKeySharesBytes,
+		decoded.UnmarshalBinary(b[:len(b)-1]))
+	s.Require().Equal(ErrInvalidPublicKeySharesBytes,
+		decoded.UnmarshalBinary(append(b, 0)))
+	s.Require().Equal(ErrInvalidPublicKeySharesBytes,
+		decoded.UnmarshalBinary(nil))
+}
+
 func (s *DKGTestSuite) TestPrivateKeySharesRLPEncodeDecode() {
 	privShares, _ := NewPrivateKeyShares(10)
 	privShares.shares = append(privShares.shares, PrivateKey{})
@@ -446,6 +486,26 @@ func (s *DKGTestSuite) TestPublicKeySharesClone() {
 	s.Require().True(pubShares1.Equal(pubShares2))
 }
 
+func (s *DKGTestSuite) TestPrivateKeySharesFromSeed() {
+	ids := s.genID(5)
+	prvShares1, pubShares1 := NewPrivateKeySharesFromSeed(3, []byte("seed"))
+	prvShares2, pubShares2 := NewPrivateKeySharesFromSeed(3, []byte("seed"))
+	s.Require().Equal(pubShares1.MasterKeyBytes(), pubShares2.MasterKeyBytes())
+	prvShares1.SetParticipants(ids)
+	prvShares2.SetParticipants(ids)
+	s.Require().True(prvShares1.Equal(prvShares2))
+	for _, id := range ids {
+		prvShare, ok := prvShares1.Share(id)
+		s.Require().True(ok)
+		valid, err := pubShares1.VerifyPrvShare(id, prvShare)
+		s.Require().NoError(err)
+		s.Require().True(valid)
+	}
+	_, pubShares3 := NewPrivateKeySharesFromSeed(3, []byte("another seed"))
+	s.Require().NotEqual(
+		pubShares1.MasterKeyBytes(), pubShares3.MasterKeyBytes())
+}
+
 func TestDKG(t *testing.T) {
 	suite.Run(t, new(DKGTestSuite))
 }
@@ -659,3 +719,41 @@ func benchmarkGPKAddShare(b *testing.B, t, n int) {
 		}
 	}
 }
+
+func BenchmarkVerifyPrvShares81_121(b *testing.B) {
+	benchmarkVerifyPrvShares(b, 81, 121, true)
+}
+
+func BenchmarkVerifyPrvSharesNoCache81_121(b *testing.B) {
+	benchmarkVerifyPrvShares(b, 81, 121, false)
+}
+
+// benchmarkVerifyPrvShares verifies private shares of n receivers against the
+// same public key shares, as checking complaints of a DKG round does.
+func benchmarkVerifyPrvShares(b *testing.B, t, n int, cached bool) {
+	prvShares, pubShares := NewPrivateKeyShares(t)
+	IDs := make(IDs, n)
+	for i := range IDs {
+		id := common.NewRandomHash()
+		IDs[i] = NewID(id[:])
+	}
+	prvShares.SetParticipants(IDs)
+	shares := make([]*PrivateKey, n)
+	for i, id := range IDs {
+		shares[i], _ = prvShares.Share(id)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !cached {
+			b.StopTimer()
+			pubShares = pubShares.Clone()
+			b.StartTimer()
+		}
+		for j, id := range IDs {
+			if ok, err := pubShares.VerifyPrvShare(id, shares[j]); !ok ||
+				err != nil {
+				b.Fatalf("failed to verify share: %v", err)
+			}
+		}
+	}
+}
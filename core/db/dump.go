@@ -0,0 +1,178 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+// Errors for block dump.
+var (
+	// ErrInvalidDumpHeader means the stream is not a block dump.
+	ErrInvalidDumpHeader = errors.New("invalid dump header")
+	// ErrDumpChecksumMismatch means a frame in block dump is corrupted.
+	ErrDumpChecksumMismatch = errors.New("dump checksum mismatch")
+	// ErrDumpFrameTooLarge means the size of a frame exceeds the limit.
+	ErrDumpFrameTooLarge = errors.New("dump frame too large")
+)
+
+// dumpMagic is the header of block dump, the last byte is the version of
+// the format.
+var dumpMagic = []byte{'d', 'x', 'b', 'd', 1}
+
+const maxDumpFrameSize = 64 * 1024 * 1024
+
+// Export writes blocks with height in [fromHeight, toHeight] to a stream in
+// the order iterated from database. Each block is encoded in a frame, which is
+// the length of RLP encoded block in 4 bytes big endian, RLP encoded block,
+// and then its CRC32 checksum in 4 bytes big endian.
+func Export(r Reader, w io.Writer, fromHeight, toHeight uint64) (
+	count int, err error) {
+	iter, err := r.GetAllBlocks()
+	if err != nil {
+		return
+	}
+	if _, err = w.Write(dumpMagic); err != nil {
+		return
+	}
+	buf := make([]byte, 4)
+	for {
+		var b types.Block
+		if b, err = iter.NextBlock(); err != nil {
+			if err == ErrIterationFinished {
+				err = nil
+			}
+			return
+		}
+		if b.Position.Height < fromHeight || b.Position.Height > toHeight {
+			continue
+		}
+		var encoded []byte
+		if encoded, err = rlp.EncodeToBytes(&b); err != nil {
+			return
+		}
+		binary.BigEndian.PutUint32(buf, uint32(len(encoded)))
+		if _, err = w.Write(buf); err != nil {
+			return
+		}
+		if _, err = w.Write(encoded); err != nil {
+			return
+		}
+		binary.BigEndian.PutUint32(buf, crc32.ChecksumIEEE(encoded))
+		if _, err = w.Write(buf); err != nil {
+			return
+		}
+		count++
+	}
+}
+
+// Import reads blocks exported by Export from a stream and puts them into
+// database. Hashes and signatures of blocks are verified against the registry
+// before written, only hashes are verified for empty blocks. Blocks already
+// existed in database are skipped. The tip of compaction chain is moved
+// forward by finalized blocks following it.
+func Import(db Database, r io.Reader, registry utils.NodeIdentityRegistry) (
+	count int, err error) {
+	header := make([]byte, len(dumpMagic))
+	if _, err = io.ReadFull(r, header); err != nil {
+		return
+	}
+	if !bytes.Equal(header, dumpMagic) {
+		err = ErrInvalidDumpHeader
+		return
+	}
+	// Blocks are not ordered in dump, finalized ones higher than the next
+	// height of compaction chain are kept until the gap is filled.
+	_, tipHeight := db.GetCompactionChainTipInfo()
+	pending := make(map[uint64]common.Hash)
+	buf := make([]byte, 4)
+	for {
+		if _, err = io.ReadFull(r, buf); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+		size := binary.BigEndian.Uint32(buf)
+		if size > maxDumpFrameSize {
+			err = ErrDumpFrameTooLarge
+			return
+		}
+		encoded := make([]byte, size)
+		if _, err = io.ReadFull(r, encoded); err != nil {
+			return
+		}
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return
+		}
+		if binary.BigEndian.Uint32(buf) != crc32.ChecksumIEEE(encoded) {
+			err = ErrDumpChecksumMismatch
+			return
+		}
+		var b types.Block
+		if err = rlp.DecodeBytes(encoded, &b); err != nil {
+			return
+		}
+		if b.IsEmpty() {
+			// Empty blocks are neither signed nor carrying payloads.
+			var hash common.Hash
+			if hash, err = utils.HashBlock(&b); err != nil {
+				return
+			}
+			if hash != b.Hash {
+				err = utils.ErrIncorrectHash
+				return
+			}
+		} else if err = utils.VerifyBlockSignature(&b, registry); err != nil {
+			return
+		}
+		if err = db.PutBlock(b); err != nil {
+			if err != ErrBlockExists {
+				return
+			}
+			err = nil
+		} else {
+			count++
+		}
+		if !b.IsFinalized() || b.Position.Height <= tipHeight {
+			continue
+		}
+		pending[b.Position.Height] = b.Hash
+		for {
+			hash, exists := pending[tipHeight+1]
+			if !exists {
+				break
+			}
+			if err = db.PutCompactionChainTipInfo(
+				hash, tipHeight+1); err != nil {
+				return
+			}
+			delete(pending, tipHeight+1)
+			tipHeight++
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+type DumpTestSuite struct {
+	suite.Suite
+}
+
+func (s *DumpTestSuite) newBlocks(count uint64) (blocks []types.Block) {
+	prvKey, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	signer := utils.NewSigner(prvKey)
+	parentHash := common.Hash{}
+	for i := uint64(0); i < count; i++ {
+		b := types.Block{
+			ParentHash: parentHash,
+			Position:   types.Position{Height: types.GenesisHeight + i},
+			Timestamp:  time.Now().UTC(),
+			Payload:    []byte(fmt.Sprintf("payload-%d", i)),
+			Randomness: common.GenerateRandomBytes(),
+		}
+		s.Require().NoError(signer.SignBlock(&b))
+		blocks = append(blocks, b)
+		parentHash = b.Hash
+	}
+	return
+}
+
+func (s *DumpTestSuite) TestExportImport() {
+	src, err := NewMemBackedDB()
+	s.Require().NoError(err)
+	blocks := s.newBlocks(5)
+	for _, b := range blocks {
+		s.Require().NoError(src.PutBlock(b))
+	}
+	buf := &bytes.Buffer{}
+	count, err := Export(src, buf, blocks[0].Position.Height,
+		blocks[2].Position.Height)
+	s.Require().NoError(err)
+	s.Require().Equal(3, count)
+	dbName := fmt.Sprintf("test-db-%v-import.db", time.Now().UTC())
+	dst, err := NewLevelDBBackedDB(dbName)
+	s.Require().NoError(err)
+	defer func(dbName string) {
+		s.NoError(dst.Close())
+		s.NoError(os.RemoveAll(dbName))
+	}(dbName)
+	// Blocks already existed should be skipped.
+	s.Require().NoError(dst.PutBlock(blocks[1]))
+	count, err = Import(dst, bytes.NewReader(buf.Bytes()), nil)
+	s.Require().NoError(err)
+	s.Require().Equal(2, count)
+	for _, b := range blocks[:3] {
+		imported, err := dst.GetBlock(b.Hash)
+		s.Require().NoError(err)
+		s.Require().Equal(b.Payload, imported.Payload)
+		s.Require().Equal(b.Randomness, imported.Randomness)
+	}
+	s.Require().False(dst.HasBlock(blocks[3].Hash))
+	s.Require().False(dst.HasBlock(blocks[4].Hash))
+	// The tip of compaction chain should be moved to the last imported block.
+	tipHash, tipHeight := dst.GetCompactionChainTipInfo()
+	s.Require().Equal(blocks[2].Hash, tipHash)
+	s.Require().Equal(blocks[2].Position.Height, tipHeight)
+	// Blocks not following the tip of compaction chain should not move it.
+	buf.Reset()
+	count, err = Export(src, buf, blocks[4].Position.Height,
+		blocks[4].Position.Height)
+	s.Require().NoError(err)
+	s.Require().Equal(1, count)
+	count, err = Import(dst, bytes.NewReader(buf.Bytes()), nil)
+	s.Require().NoError(err)
+	s.Require().Equal(1, count)
+	tipHash, tipHeight = dst.GetCompactionChainTipInfo()
+	s.Require().Equal(blocks[2].Hash, tipHash)
+	s.Require().Equal(blocks[2].Position.Height, tipHeight)
+	// Export from leveldb should get the same blocks.
+	buf.Reset()
+	count, err = Export(dst, buf, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Equal(4, count)
+	mem, err := NewMemBackedDB()
+	s.Require().NoError(err)
+	count, err = Import(mem, bytes.NewReader(buf.Bytes()), nil)
+	s.Require().NoError(err)
+	s.Require().Equal(4, count)
+	s.Require().False(mem.HasBlock(blocks[3].Hash))
+	tipHash, tipHeight = mem.GetCompactionChainTipInfo()
+	s.Require().Equal(blocks[2].Hash, tipHash)
+	s.Require().Equal(blocks[2].Position.Height, tipHeight)
+}
+
+func (s *DumpTestSuite) TestEmptyBlock() {
+	src, err := NewMemBackedDB()
+	s.Require().NoError(err)
+	blocks := s.newBlocks(2)
+	parent := blocks[len(blocks)-1]
+	empty := types.Block{
+		ParentHash: parent.Hash,
+		Position:   types.Position{Height: parent.Position.Height + 1},
+		Timestamp:  parent.Timestamp.Add(time.Second),
+		Randomness: common.GenerateRandomBytes(),
+	}
+	empty.Hash, err = utils.HashBlock(&empty)
+	s.Require().NoError(err)
+	s.Require().True(empty.IsEmpty())
+	for _, b := range append(blocks, empty) {
+		s.Require().NoError(src.PutBlock(b))
+	}
+	buf := &bytes.Buffer{}
+	count, err := Export(src, buf, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Equal(3, count)
+	dst, err := NewMemBackedDB()
+	s.Require().NoError(err)
+	count, err = Import(dst, bytes.NewReader(buf.Bytes()), nil)
+	s.Require().NoError(err)
+	s.Require().Equal(3, count)
+	tipHash, tipHeight := dst.GetCompactionChainTipInfo()
+	s.Require().Equal(empty.Hash, tipHash)
+	s.Require().Equal(empty.Position.Height, tipHeight)
+	// Empty blocks with incorrect hash are rejected.
+	forged := empty
+	forged.Timestamp = forged.Timestamp.Add(time.Second)
+	forgedDB, err := NewMemBackedDB()
+	s.Require().NoError(err)
+	s.Require().NoError(forgedDB.PutBlock(forged))
+	buf.Reset()
+	_, err = Export(forgedDB, buf, 0, 10)
+	s.Require().NoError(err)
+	dst, err = NewMemBackedDB()
+	s.Require().NoError(err)
+	_, err = Import(dst, bytes.NewReader(buf.Bytes()), nil)
+	s.Require().Equal(utils.ErrIncorrectHash, err)
+}
+
+func (s *DumpTestSuite) TestCorruptedDump() {
+	src, err := NewMemBackedDB()
+	s.Require().NoError(err)
+	for _, b := range s.newBlocks(2) {
+		s.Require().NoError(src.PutBlock(b))
+	}
+	buf := &bytes.Buffer{}
+	_, err = Export(src, buf, 0, 1)
+	s.Require().NoError(err)
+	dumped := buf.Bytes()
+	dst, err := NewMemBackedDB()
+	s.Require().NoError(err)
+	// Invalid header.
+	corrupted := append([]byte{}, dumped...)
+	corrupted[0]++
+	_, err = Import(dst, bytes.NewReader(corrupted), nil)
+	s.Require().Equal(ErrInvalidDumpHeader, err)
+	// Checksum mismatch.
+	corrupted = append([]byte{}, dumped...)
+	corrupted[len(dumpMagic)+4]++
+	_, err = Import(dst, bytes.NewReader(corrupted), nil)
+	s.Require().Equal(ErrDumpChecksumMismatch, err)
+	// Truncated dump.
+	_, err = Import(dst, bytes.NewReader(dumped[:len(dumped)-1]), nil)
+	s.Require().Error(err)
+	// Blocks with incorrect hash.
+	forged := s.newBlocks(1)[0]
+	forged.Payload = []byte("forged")
+	forgedDB, err := NewMemBackedDB()
+	s.Require().NoError(err)
+	s.Require().NoError(forgedDB.PutBlock(forged))
+	buf.Reset()
+	_, err = Export(forgedDB, buf, 0, 1)
+	s.Require().NoError(err)
+	_, err = Import(dst, bytes.NewReader(buf.Bytes()), nil)
+	s.Require().Equal(utils.ErrIncorrectHash, err)
+	s.Require().False(dst.HasBlock(forged.Hash))
+}
+
+func TestDump(t *testing.T) {
+	suite.Run(t, new(DumpTestSuite))
+}
@@ -0,0 +1,116 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+var (
+	// encryptionCheckKey keeps a known value sealed by the key of an
+	// encrypted database, which is used to verify the key when opened.
+	encryptionCheckKey   = []byte("encryption-check")
+	encryptionCheckValue = []byte("dexon-consensus")
+
+	errSealedValueTooShort = errors.New("sealed value too short")
+)
+
+// newValueCipher creates an AES-GCM cipher from a key of 16, 24 or 32 bytes.
+func newValueCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealValue encrypts the value of an entry, the entry key is authenticated
+// along with it so sealed values could not be swapped between entries. The
+// random nonce is prepended to the returned ciphertext.
+func sealValue(aead cipher.AEAD, key, value []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(),
+		aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, value, key), nil
+}
+
+// openValue decrypts a value sealed by sealValue.
+func openValue(aead cipher.AEAD, key, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errSealedValueTooShort
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, key)
+}
+
+// levelDBEmpty checks if nothing other than the schema version is written in
+// a leveldb database.
+func levelDBEmpty(db *leveldb.DB) (bool, error) {
+	iter := db.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		if !bytes.Equal(iter.Key(), schemaVersionKey) {
+			return false, nil
+		}
+	}
+	return true, iter.Error()
+}
+
+// checkLevelDBEncryption makes sure a leveldb database is opened with the key
+// it's encrypted by. A newly created database is marked as encrypted when a
+// cipher is given.
+func checkLevelDBEncryption(db *leveldb.DB, aead cipher.AEAD) error {
+	sealed, err := db.Get(encryptionCheckKey, nil)
+	switch err {
+	case nil:
+		if aead == nil {
+			return ErrDatabaseEncrypted
+		}
+		value, err := openValue(aead, encryptionCheckKey, sealed)
+		if err != nil || !bytes.Equal(value, encryptionCheckValue) {
+			return ErrIncorrectEncryptionKey
+		}
+		return nil
+	case leveldb.ErrNotFound:
+	default:
+		return err
+	}
+	if aead == nil {
+		return nil
+	}
+	empty, err := levelDBEmpty(db)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return ErrDatabaseNotEncrypted
+	}
+	if sealed, err = sealValue(
+		aead, encryptionCheckKey, encryptionCheckValue); err != nil {
+		return err
+	}
+	return db.Put(encryptionCheckKey, sealed, nil)
+}
@@ -56,6 +56,29 @@ var (
 	// ErrDKGProtocolDoesNotExist raised when the DKG protocol of the
 	// requested round does not exists.
 	ErrDKGProtocolDoesNotExist = errors.New("dkg protocol does not exists")
+	// ErrAgreementResultDoesNotExist raised when the agreement result of the
+	// requested position does not exist.
+	ErrAgreementResultDoesNotExist = errors.New(
+		"agreement result does not exist")
+	// ErrAgreementStateDoesNotExist raised when no agreement state is
+	// written in database.
+	ErrAgreementStateDoesNotExist = errors.New(
+		"agreement state does not exist")
+	// ErrSchemaVersionDoesNotExist raised when no schema version is written
+	// in database.
+	ErrSchemaVersionDoesNotExist = errors.New("schema version does not exist")
+	// ErrUnsupportedSchemaVersion raised when the data is written in a
+	// format newer than this implementation supports.
+	ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
+	// ErrIncorrectEncryptionKey raised when an encrypted database is opened
+	// with a key other than the one it's encrypted by.
+	ErrIncorrectEncryptionKey = errors.New("incorrect encryption key")
+	// ErrDatabaseEncrypted raised when an encrypted database is opened
+	// without a key.
+	ErrDatabaseEncrypted = errors.New("database is encrypted")
+	// ErrDatabaseNotEncrypted raised when a database not encrypted is opened
+	// with a key, its existing data would not be encrypted.
+	ErrDatabaseNotEncrypted = errors.New("database is not encrypted")
 )
 
 // Database is the interface for a Database.
@@ -93,6 +116,75 @@ type Writer interface {
 	PutOrUpdateDKGProtocol(dkgProtocol DKGProtocolInfo) error
 }
 
+// Snapshot is a consistent read-only view of a database, later writes to that
+// database are not visible to it.
+type Snapshot interface {
+	Reader
+
+	// Release allows database implementation to release resources held by
+	// this snapshot.
+	Release()
+}
+
+// Snapshotter is implemented by databases supporting read-only snapshots,
+// which allows heavy queries not to block writes.
+type Snapshotter interface {
+	Snapshot() (Snapshot, error)
+}
+
+// AgreementResultStore is implemented by databases persisting agreement
+// results, which certify the block confirmed at each position. Peers catching
+// up could be served by stored results without collecting votes again.
+type AgreementResultStore interface {
+	PutAgreementResult(result types.AgreementResult) error
+	GetAgreementResult(pos types.Position) (types.AgreementResult, error)
+
+	// PurgeAgreementResults removes results of heights lower than the given
+	// one.
+	PurgeAgreementResults(height uint64) error
+}
+
+// AgreementState is the state of BA of this node at a position, votes are
+// those proposed by this node.
+type AgreementState struct {
+	Position  types.Position
+	Period    uint64
+	LockValue common.Hash
+	LockIter  uint64
+	Votes     []types.Vote
+}
+
+// AgreementStateStore is implemented by databases persisting the latest
+// agreement state, a node restarted in the middle of BA restores its locked
+// value and votes from it instead of proposing conflicting votes.
+type AgreementStateStore interface {
+	PutAgreementState(state AgreementState) error
+	GetAgreementState() (AgreementState, error)
+}
+
+// NewSnapshot takes a snapshot of a database if supported.
+func NewSnapshot(db Reader) (Snapshot, error) {
+	s, ok := db.(Snapshotter)
+	if !ok {
+		return nil, ErrNotImplemented
+	}
+	return s.Snapshot()
+}
+
+// readOnlyView hides writer methods of an underlying database instance.
+type readOnlyView struct {
+	Reader
+
+	release func()
+}
+
+// Release implements Snapshot.Release method.
+func (v *readOnlyView) Release() {
+	if v.release != nil {
+		v.release()
+	}
+}
+
 // BlockIterator defines an iterator on blocks hold
 // in a DB.
 type BlockIterator interface {
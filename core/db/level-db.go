@@ -18,10 +18,14 @@
 package db
 
 import (
+	"crypto/cipher"
 	"encoding/binary"
 	"io"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
@@ -34,6 +38,8 @@ var (
 	compactionChainTipInfoKey = []byte("cc-tip")
 	dkgPrivateKeyKeyPrefix    = []byte("dkg-prvs")
 	dkgProtocolInfoKeyPrefix  = []byte("dkg-protocol-info")
+	agreementResultKeyPrefix  = []byte("ar-")
+	agreementStateKey         = []byte("agreement-state")
 )
 
 type compactionChainTipInfo struct {
@@ -341,23 +347,104 @@ func (m *NodeIDToDKGID) DecodeRLP(s *rlp.Stream) error {
 	return nil
 }
 
+// levelDBReader is the common read interface of leveldb.DB and
+// leveldb.Snapshot.
+type levelDBReader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	Has(key []byte, ro *opt.ReadOptions) (bool, error)
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+type levelDBBlockIterator struct {
+	iter iterator.Iterator
+	lvl  *LevelDBBackedDB
+}
+
+// NextBlock implemenets BlockIterator.NextBlock method.
+func (it *levelDBBlockIterator) NextBlock() (block types.Block, err error) {
+	if it.iter == nil {
+		err = ErrIterationFinished
+		return
+	}
+	if !it.iter.Next() {
+		err = it.iter.Error()
+		it.iter.Release()
+		it.iter = nil
+		if err == nil {
+			err = ErrIterationFinished
+		}
+		return
+	}
+	value, err := it.lvl.openValue(it.iter.Key(), it.iter.Value())
+	if err != nil {
+		return
+	}
+	err = rlp.DecodeBytes(value, &block)
+	return
+}
+
 // LevelDBBackedDB is a leveldb backed DB implementation.
 type LevelDBBackedDB struct {
-	db *leveldb.DB
+	db     *leveldb.DB
+	reader levelDBReader
+	aead   cipher.AEAD
 }
 
 // NewLevelDBBackedDB initialize a leveldb-backed database.
 func NewLevelDBBackedDB(
 	path string) (lvl *LevelDBBackedDB, err error) {
+	return openLevelDB(path, nil)
+}
+
+// NewEncryptedLevelDBBackedDB initialize a leveldb-backed database whose
+// values are encrypted at rest by AES-GCM. The key should be 16, 24 or 32
+// bytes, ex. fetched from a KMS or keystore by operators, and the same key is
+// required to open the database again. Keys of entries, ex. block hashes, are
+// not encrypted.
+func NewEncryptedLevelDBBackedDB(
+	path string, key []byte) (lvl *LevelDBBackedDB, err error) {
+	aead, err := newValueCipher(key)
+	if err != nil {
+		return
+	}
+	return openLevelDB(path, aead)
+}
 
+func openLevelDB(
+	path string, aead cipher.AEAD) (lvl *LevelDBBackedDB, err error) {
 	dbInst, err := leveldb.OpenFile(path, nil)
 	if err != nil {
 		return
 	}
-	lvl = &LevelDBBackedDB{db: dbInst}
+	if err = migrateLevelDB(dbInst); err != nil {
+		dbInst.Close()
+		return
+	}
+	if err = checkLevelDBEncryption(dbInst, aead); err != nil {
+		dbInst.Close()
+		return
+	}
+	lvl = &LevelDBBackedDB{db: dbInst, reader: dbInst, aead: aead}
 	return
 }
 
+// SchemaVersion implements SchemaVersioner.SchemaVersion method.
+func (lvl *LevelDBBackedDB) SchemaVersion() (uint32, error) {
+	return levelDBSchemaVersion(lvl.reader)
+}
+
+// Snapshot implements Snapshotter.Snapshot method.
+func (lvl *LevelDBBackedDB) Snapshot() (Snapshot, error) {
+	snap, err := lvl.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &readOnlyView{
+		Reader:  &LevelDBBackedDB{reader: snap, aead: lvl.aead},
+		release: snap.Release,
+	}, nil
+}
+
 // Close implement Closer interface, which would release allocated resource.
 func (lvl *LevelDBBackedDB) Close() error {
 	return lvl.db.Close()
@@ -373,13 +460,13 @@ func (lvl *LevelDBBackedDB) HasBlock(hash common.Hash) bool {
 }
 
 func (lvl *LevelDBBackedDB) internalHasBlock(key []byte) (bool, error) {
-	return lvl.db.Has(key, nil)
+	return lvl.reader.Has(key, nil)
 }
 
 // GetBlock implements the Reader.GetBlock method.
 func (lvl *LevelDBBackedDB) GetBlock(
 	hash common.Hash) (block types.Block, err error) {
-	queried, err := lvl.db.Get(lvl.getBlockKey(hash), nil)
+	queried, err := lvl.get(lvl.getBlockKey(hash))
 	if err != nil {
 		if err == leveldb.ErrNotFound {
 			err = ErrBlockDoesNotExist
@@ -407,7 +494,7 @@ func (lvl *LevelDBBackedDB) UpdateBlock(block types.Block) (err error) {
 		err = ErrBlockDoesNotExist
 		return
 	}
-	err = lvl.db.Put(blockKey, marshaled, nil)
+	err = lvl.put(blockKey, marshaled)
 	return
 }
 
@@ -426,14 +513,18 @@ func (lvl *LevelDBBackedDB) PutBlock(block types.Block) (err error) {
 		err = ErrBlockExists
 		return
 	}
-	err = lvl.db.Put(blockKey, marshaled, nil)
+	err = lvl.put(blockKey, marshaled)
 	return
 }
 
 // GetAllBlocks implements Reader.GetAllBlocks method, which allows callers
-// to retrieve all blocks in DB.
+// to retrieve all blocks in DB. The iterator would be released once the
+// iteration is finished.
 func (lvl *LevelDBBackedDB) GetAllBlocks() (BlockIterator, error) {
-	return nil, ErrNotImplemented
+	return &levelDBBlockIterator{
+		iter: lvl.reader.NewIterator(util.BytesPrefix(blockKeyPrefix), nil),
+		lvl:  lvl,
+	}, nil
 }
 
 // PutCompactionChainTipInfo saves tip of compaction chain into the database.
@@ -455,12 +546,12 @@ func (lvl *LevelDBBackedDB) PutCompactionChainTipInfo(
 	if info.Height+1 != height {
 		return ErrInvalidCompactionChainTipHeight
 	}
-	return lvl.db.Put(compactionChainTipInfoKey, marshaled, nil)
+	return lvl.put(compactionChainTipInfoKey, marshaled)
 }
 
 func (lvl *LevelDBBackedDB) internalGetCompactionChainTipInfo() (
 	info compactionChainTipInfo, err error) {
-	queried, err := lvl.db.Get(compactionChainTipInfoKey, nil)
+	queried, err := lvl.get(compactionChainTipInfoKey)
 	if err != nil {
 		if err == leveldb.ErrNotFound {
 			err = nil
@@ -486,7 +577,7 @@ func (lvl *LevelDBBackedDB) GetCompactionChainTipInfo() (
 // GetDKGPrivateKey get DKG private key of one round.
 func (lvl *LevelDBBackedDB) GetDKGPrivateKey(round, reset uint64) (
 	prv dkg.PrivateKey, err error) {
-	queried, err := lvl.db.Get(lvl.getDKGPrivateKeyKey(round), nil)
+	queried, err := lvl.get(lvl.getDKGPrivateKeyKey(round))
 	if err != nil {
 		if err == leveldb.ErrNotFound {
 			err = ErrDKGPrivateKeyDoesNotExist
@@ -522,14 +613,13 @@ func (lvl *LevelDBBackedDB) PutDKGPrivateKey(
 	if err != nil {
 		return err
 	}
-	return lvl.db.Put(
-		lvl.getDKGPrivateKeyKey(round), marshaled, nil)
+	return lvl.put(lvl.getDKGPrivateKeyKey(round), marshaled)
 }
 
 // GetDKGProtocol get DKG protocol.
 func (lvl *LevelDBBackedDB) GetDKGProtocol() (
 	info DKGProtocolInfo, err error) {
-	queried, err := lvl.db.Get(lvl.getDKGProtocolInfoKey(), nil)
+	queried, err := lvl.get(lvl.getDKGProtocolInfoKey())
 	if err != nil {
 		if err == leveldb.ErrNotFound {
 			err = ErrDKGProtocolDoesNotExist
@@ -547,7 +637,118 @@ func (lvl *LevelDBBackedDB) PutOrUpdateDKGProtocol(info DKGProtocolInfo) error {
 	if err != nil {
 		return err
 	}
-	return lvl.db.Put(lvl.getDKGProtocolInfoKey(), marshaled, nil)
+	return lvl.put(lvl.getDKGProtocolInfoKey(), marshaled)
+}
+
+// PutAgreementResult implements AgreementResultStore.PutAgreementResult
+// method, the result would overwrite the existing one of the same height.
+func (lvl *LevelDBBackedDB) PutAgreementResult(
+	result types.AgreementResult) error {
+	marshaled, err := rlp.EncodeToBytes(&result)
+	if err != nil {
+		return err
+	}
+	return lvl.put(
+		lvl.getAgreementResultKey(result.Position.Height), marshaled)
+}
+
+// GetAgreementResult implements AgreementResultStore.GetAgreementResult
+// method.
+func (lvl *LevelDBBackedDB) GetAgreementResult(pos types.Position) (
+	result types.AgreementResult, err error) {
+	queried, err := lvl.get(lvl.getAgreementResultKey(pos.Height))
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			err = ErrAgreementResultDoesNotExist
+		}
+		return
+	}
+	if err = rlp.DecodeBytes(queried, &result); err != nil {
+		return
+	}
+	if result.Position != pos {
+		result = types.AgreementResult{}
+		err = ErrAgreementResultDoesNotExist
+	}
+	return
+}
+
+// PurgeAgreementResults implements AgreementResultStore.PurgeAgreementResults
+// method.
+func (lvl *LevelDBBackedDB) PurgeAgreementResults(height uint64) error {
+	iter := lvl.db.NewIterator(&util.Range{
+		Start: agreementResultKeyPrefix,
+		Limit: lvl.getAgreementResultKey(height),
+	}, nil)
+	defer iter.Release()
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return lvl.db.Write(batch, nil)
+}
+
+// PutAgreementState implements AgreementStateStore.PutAgreementState method,
+// only the latest state is kept.
+func (lvl *LevelDBBackedDB) PutAgreementState(state AgreementState) error {
+	marshaled, err := rlp.EncodeToBytes(&state)
+	if err != nil {
+		return err
+	}
+	if lvl.aead != nil {
+		if marshaled, err = sealValue(
+			lvl.aead, agreementStateKey, marshaled); err != nil {
+			return err
+		}
+	}
+	return lvl.db.Put(agreementStateKey, marshaled, &opt.WriteOptions{
+		Sync: true,
+	})
+}
+
+// GetAgreementState implements AgreementStateStore.GetAgreementState method.
+func (lvl *LevelDBBackedDB) GetAgreementState() (
+	state AgreementState, err error) {
+	queried, err := lvl.get(agreementStateKey)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			err = ErrAgreementStateDoesNotExist
+		}
+		return
+	}
+	err = rlp.DecodeBytes(queried, &state)
+	return
+}
+
+// get reads the value of a key, which is decrypted when the database is
+// encrypted.
+func (lvl *LevelDBBackedDB) get(key []byte) ([]byte, error) {
+	queried, err := lvl.reader.Get(key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return lvl.openValue(key, queried)
+}
+
+// put writes the value of a key, which is encrypted when the database is
+// encrypted.
+func (lvl *LevelDBBackedDB) put(key, value []byte) (err error) {
+	if lvl.aead != nil {
+		if value, err = sealValue(lvl.aead, key, value); err != nil {
+			return
+		}
+	}
+	return lvl.db.Put(key, value, nil)
+}
+
+func (lvl *LevelDBBackedDB) openValue(key, value []byte) ([]byte, error) {
+	if lvl.aead == nil {
+		return value, nil
+	}
+	return openValue(lvl.aead, key, value)
 }
 
 func (lvl *LevelDBBackedDB) getBlockKey(hash common.Hash) (ret []byte) {
@@ -566,6 +767,16 @@ func (lvl *LevelDBBackedDB) getDKGPrivateKeyKey(
 	return
 }
 
+// getAgreementResultKey encodes height in big endian, which keeps keys of
+// agreement results sorted by height.
+func (lvl *LevelDBBackedDB) getAgreementResultKey(
+	height uint64) (ret []byte) {
+	ret = make([]byte, len(agreementResultKeyPrefix)+8)
+	copy(ret, agreementResultKeyPrefix)
+	binary.BigEndian.PutUint64(ret[len(agreementResultKeyPrefix):], height)
+	return
+}
+
 func (lvl *LevelDBBackedDB) getDKGProtocolInfoKey() (ret []byte) {
 	ret = make([]byte, len(dkgProtocolInfoKeyPrefix)+8)
 	copy(ret, dkgProtocolInfoKeyPrefix)
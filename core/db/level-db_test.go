@@ -32,6 +32,7 @@ import (
 	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	"github.com/dexon-foundation/dexon/rlp"
+	"github.com/syndtr/goleveldb/leveldb"
 )
 
 type LevelDBTestSuite struct {
@@ -131,6 +132,39 @@ func (s *LevelDBTestSuite) TestSyncIndex() {
 	}
 }
 
+func (s *LevelDBTestSuite) TestSnapshot() {
+	dbName := fmt.Sprintf("test-db-%v-snapshot.db", time.Now().UTC())
+	dbInst, err := NewLevelDBBackedDB(dbName)
+	s.Require().NoError(err)
+	defer func(dbName string) {
+		err = dbInst.Close()
+		s.NoError(err)
+		err = os.RemoveAll(dbName)
+		s.NoError(err)
+	}(dbName)
+	block1 := types.Block{
+		ProposerID: types.NodeID{Hash: common.NewRandomHash()},
+		Hash:       common.NewRandomHash(),
+		Position:   types.Position{Height: 1},
+	}
+	s.Require().NoError(dbInst.PutBlock(block1))
+	snap, err := NewSnapshot(dbInst)
+	s.Require().NoError(err)
+	defer snap.Release()
+	// Writes after snapshot should not be visible to it.
+	block2 := block1
+	block2.Hash = common.NewRandomHash()
+	s.Require().NoError(dbInst.PutBlock(block2))
+	s.Require().NoError(dbInst.PutCompactionChainTipInfo(block1.Hash, 1))
+	s.Require().True(snap.HasBlock(block1.Hash))
+	s.Require().False(snap.HasBlock(block2.Hash))
+	_, err = snap.GetBlock(block2.Hash)
+	s.Require().Equal(ErrBlockDoesNotExist, err)
+	_, height := snap.GetCompactionChainTipInfo()
+	s.Require().Equal(uint64(0), height)
+	s.Require().True(dbInst.HasBlock(block2.Hash))
+}
+
 func (s *LevelDBTestSuite) TestCompactionChainTipInfo() {
 	dbName := fmt.Sprintf("test-db-%v-cc-tip.db", time.Now().UTC())
 	dbInst, err := NewLevelDBBackedDB(dbName)
@@ -213,6 +247,81 @@ func (s *LevelDBTestSuite) TestDKGProtocol() {
 	s.Require().NoError(dbInst.PutOrUpdateDKGProtocol(DKGProtocolInfo{}))
 }
 
+func (s *LevelDBTestSuite) TestAgreementResult() {
+	dbName := fmt.Sprintf("test-db-%v-agreement-result.db", time.Now().UTC())
+	dbInst, err := NewLevelDBBackedDB(dbName)
+	s.Require().NoError(err)
+	defer func(dbName string) {
+		err = dbInst.Close()
+		s.NoError(err)
+		err = os.RemoveAll(dbName)
+		s.NoError(err)
+	}(dbName)
+	results := []types.AgreementResult{}
+	for h := uint64(1); h <= 300; h++ {
+		result := types.AgreementResult{
+			BlockHash: common.NewRandomHash(),
+			Position:  types.Position{Round: 1, Height: h},
+			Votes: []types.Vote{
+				*types.NewVote(types.VoteCom, common.NewRandomHash(), 1),
+			},
+			Randomness: []byte{1, 2, 3},
+		}
+		s.Require().NoError(dbInst.PutAgreementResult(result))
+		results = append(results, result)
+	}
+	result, err := dbInst.GetAgreementResult(results[1].Position)
+	s.Require().NoError(err)
+	s.Require().Equal(results[1].BlockHash, result.BlockHash)
+	s.Require().Len(result.Votes, 1)
+	s.Require().Equal(results[1].Votes[0].BlockHash, result.Votes[0].BlockHash)
+	// Positions with the same height but different round should not match.
+	_, err = dbInst.GetAgreementResult(types.Position{Round: 2, Height: 2})
+	s.Require().Equal(ErrAgreementResultDoesNotExist, err)
+	// Purge results lower than height 257, big endian keys should make sure
+	// results of height 256 are purged and 257 are kept.
+	s.Require().NoError(dbInst.PurgeAgreementResults(257))
+	_, err = dbInst.GetAgreementResult(results[255].Position)
+	s.Require().Equal(ErrAgreementResultDoesNotExist, err)
+	_, err = dbInst.GetAgreementResult(results[256].Position)
+	s.Require().NoError(err)
+	_, err = dbInst.GetAgreementResult(results[299].Position)
+	s.Require().NoError(err)
+}
+
+func (s *LevelDBTestSuite) TestAgreementState() {
+	dbName := fmt.Sprintf("test-db-%v-agreement-state.db", time.Now().UTC())
+	dbInst, err := NewLevelDBBackedDB(dbName)
+	s.Require().NoError(err)
+	defer func(dbName string) {
+		err = dbInst.Close()
+		s.NoError(err)
+		err = os.RemoveAll(dbName)
+		s.NoError(err)
+	}(dbName)
+	_, err = dbInst.GetAgreementState()
+	s.Require().Equal(ErrAgreementStateDoesNotExist, err)
+	for h := uint64(1); h <= 2; h++ {
+		s.Require().NoError(dbInst.PutAgreementState(AgreementState{
+			Position:  types.Position{Round: 1, Height: h},
+			Period:    h + 2,
+			LockValue: common.NewRandomHash(),
+			LockIter:  h,
+			Votes: []types.Vote{
+				*types.NewVote(types.VotePreCom, common.NewRandomHash(), h),
+			},
+		}))
+	}
+	// Only the latest state is kept.
+	state, err := dbInst.GetAgreementState()
+	s.Require().NoError(err)
+	s.Require().Equal(types.Position{Round: 1, Height: 2}, state.Position)
+	s.Require().Equal(uint64(4), state.Period)
+	s.Require().Equal(uint64(2), state.LockIter)
+	s.Require().Len(state.Votes, 1)
+	s.Require().Equal(types.VotePreCom, state.Votes[0].Type)
+}
+
 func (s *LevelDBTestSuite) TestDKGProtocolInfoRLPEncodeDecode() {
 	protocol := DKGProtocolInfo{
 		ID:        types.NodeID{Hash: common.Hash{0x11}},
@@ -321,6 +430,164 @@ func (s *LevelDBTestSuite) TestNodeIDToDKGIDRLPEncodeDecode() {
 	s.Require().True(reflect.DeepEqual(m, newM))
 }
 
+func (s *LevelDBTestSuite) TestSchemaVersion() {
+	dbName := fmt.Sprintf("test-db-%v-schema-version.db", time.Now().UTC())
+	defer func(dbName string) {
+		s.NoError(os.RemoveAll(dbName))
+	}(dbName)
+	// A newly created database is written in the latest version.
+	dbInst, err := NewLevelDBBackedDB(dbName)
+	s.Require().NoError(err)
+	version, err := dbInst.SchemaVersion()
+	s.Require().NoError(err)
+	s.Require().Equal(SchemaVersion, version)
+	s.Require().NoError(dbInst.Close())
+	// Remove the version marker to simulate a database created before schema
+	// versioning, it should be migrated on open.
+	raw, err := leveldb.OpenFile(dbName, nil)
+	s.Require().NoError(err)
+	s.Require().NoError(raw.Delete(schemaVersionKey, nil))
+	s.Require().NoError(raw.Put([]byte("legacy"), []byte("data"), nil))
+	s.Require().NoError(raw.Close())
+	dbInst, err = NewLevelDBBackedDB(dbName)
+	s.Require().NoError(err)
+	version, err = dbInst.SchemaVersion()
+	s.Require().NoError(err)
+	s.Require().Equal(SchemaVersion, version)
+	s.Require().NoError(dbInst.Close())
+	// A database written by newer version should not be opened.
+	raw, err = leveldb.OpenFile(dbName, nil)
+	s.Require().NoError(err)
+	s.Require().NoError(raw.Put(
+		schemaVersionKey, encodeSchemaVersion(SchemaVersion+1), nil))
+	s.Require().NoError(raw.Close())
+	_, err = NewLevelDBBackedDB(dbName)
+	s.Require().Equal(ErrUnsupportedSchemaVersion, err)
+	s.Require().Len(levelDBMigrations, int(SchemaVersion))
+}
+
+func (s *LevelDBTestSuite) TestEncryption() {
+	dbName := fmt.Sprintf("test-db-%v-encryption.db", time.Now().UTC())
+	defer func(dbName string) {
+		s.NoError(os.RemoveAll(dbName))
+	}(dbName)
+	key := common.NewRandomHash()
+	dbInst, err := NewEncryptedLevelDBBackedDB(dbName, key[:])
+	s.Require().NoError(err)
+	block := types.Block{
+		ProposerID: types.NodeID{Hash: common.NewRandomHash()},
+		Hash:       common.NewRandomHash(),
+		Position:   types.Position{Height: 1},
+		Payload:    []byte("secret payload"),
+	}
+	s.Require().NoError(dbInst.PutBlock(block))
+	s.Require().NoError(dbInst.PutCompactionChainTipInfo(block.Hash, 1))
+	prvKey := dkg.NewPrivateKey()
+	s.Require().NoError(dbInst.PutDKGPrivateKey(1, 0, *prvKey))
+	s.Require().NoError(dbInst.Close())
+	// Values are not written in plain text.
+	raw, err := leveldb.OpenFile(dbName, nil)
+	s.Require().NoError(err)
+	iter := raw.NewIterator(nil, nil)
+	for iter.Next() {
+		s.Require().False(bytes.Contains(iter.Value(), block.Payload))
+		s.Require().False(bytes.Contains(iter.Value(), prvKey.Bytes()))
+	}
+	iter.Release()
+	s.Require().NoError(iter.Error())
+	s.Require().NoError(raw.Close())
+	// Opened without the key or with another key.
+	_, err = NewLevelDBBackedDB(dbName)
+	s.Require().Equal(ErrDatabaseEncrypted, err)
+	otherKey := common.NewRandomHash()
+	_, err = NewEncryptedLevelDBBackedDB(dbName, otherKey[:])
+	s.Require().Equal(ErrIncorrectEncryptionKey, err)
+	_, err = NewEncryptedLevelDBBackedDB(dbName, key[:7])
+	s.Require().Error(err)
+	// Opened with the same key.
+	dbInst, err = NewEncryptedLevelDBBackedDB(dbName, key[:])
+	s.Require().NoError(err)
+	queried, err := dbInst.GetBlock(block.Hash)
+	s.Require().NoError(err)
+	s.Require().Equal(block.Payload, queried.Payload)
+	hash, height := dbInst.GetCompactionChainTipInfo()
+	s.Require().Equal(block.Hash, hash)
+	s.Require().Equal(uint64(1), height)
+	queriedKey, err := dbInst.GetDKGPrivateKey(1, 0)
+	s.Require().NoError(err)
+	s.Require().Equal(*prvKey, queriedKey)
+	iterBlocks, err := dbInst.GetAllBlocks()
+	s.Require().NoError(err)
+	queried, err = iterBlocks.NextBlock()
+	s.Require().NoError(err)
+	s.Require().Equal(block.Hash, queried.Hash)
+	_, err = iterBlocks.NextBlock()
+	s.Require().Equal(ErrIterationFinished, err)
+	snap, err := NewSnapshot(dbInst)
+	s.Require().NoError(err)
+	queried, err = snap.GetBlock(block.Hash)
+	s.Require().NoError(err)
+	s.Require().Equal(block.Payload, queried.Payload)
+	snap.Release()
+	s.Require().NoError(dbInst.Close())
+	// Existing data of a database not encrypted would not be encrypted.
+	plainName := dbName + "-plain"
+	defer func(dbName string) {
+		s.NoError(os.RemoveAll(dbName))
+	}(plainName)
+	dbInst, err = NewLevelDBBackedDB(plainName)
+	s.Require().NoError(err)
+	s.Require().NoError(dbInst.PutBlock(block))
+	s.Require().NoError(dbInst.Close())
+	_, err = NewEncryptedLevelDBBackedDB(plainName, key[:])
+	s.Require().Equal(ErrDatabaseNotEncrypted, err)
+}
+
 func TestLevelDB(t *testing.T) {
 	suite.Run(t, new(LevelDBTestSuite))
 }
+
+func benchmarkLevelDBGetBlock(b *testing.B, key []byte) {
+	dbName := fmt.Sprintf("bench-db-%v.db", time.Now().UTC())
+	defer os.RemoveAll(dbName)
+	var (
+		dbInst *LevelDBBackedDB
+		err    error
+	)
+	if key == nil {
+		dbInst, err = NewLevelDBBackedDB(dbName)
+	} else {
+		dbInst, err = NewEncryptedLevelDBBackedDB(dbName, key)
+	}
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer dbInst.Close()
+	hashes := make(common.Hashes, 1000)
+	for i := range hashes {
+		block := types.Block{
+			Hash:     common.NewRandomHash(),
+			Position: types.Position{Height: uint64(i + 1)},
+			Payload:  make([]byte, 1024),
+		}
+		if err = dbInst.PutBlock(block); err != nil {
+			b.Fatal(err)
+		}
+		hashes[i] = block.Hash
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = dbInst.GetBlock(hashes[i%len(hashes)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLevelDBGetBlock(b *testing.B) {
+	benchmarkLevelDBGetBlock(b, nil)
+}
+
+func BenchmarkEncryptedLevelDBGetBlock(b *testing.B) {
+	key := common.NewRandomHash()
+	benchmarkLevelDBGetBlock(b, key[:])
+}
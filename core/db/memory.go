@@ -40,11 +40,19 @@ func (seq *blockSeqIterator) NextBlock() (types.Block, error) {
 	return seq.db.getBlockByIndex(curIdx)
 }
 
+// memBackedDBDump is the content of the persistent file of MemBackedDB.
+type memBackedDBDump struct {
+	Version  uint32
+	Sequence common.Hashes
+	ByHash   map[common.Hash]*types.Block
+}
+
 // MemBackedDB is a memory backed DB implementation.
 type MemBackedDB struct {
 	blocksLock               sync.RWMutex
 	blockHashSequence        common.Hashes
 	blocksByHash             map[common.Hash]*types.Block
+	blocksShared             bool
 	compactionChainTipLock   sync.RWMutex
 	compactionChainTipHash   common.Hash
 	compactionChainTipHeight uint64
@@ -52,6 +60,10 @@ type MemBackedDB struct {
 	dkgPrivateKeys           map[uint64]*dkgPrivateKey
 	dkgProtocolLock          sync.RWMutex
 	dkgProtocolInfo          *DKGProtocolInfo
+	agreementResultsLock     sync.RWMutex
+	agreementResults         map[uint64]types.AgreementResult
+	agreementStateLock       sync.RWMutex
+	agreementState           *AgreementState
 	persistantFilePath       string
 }
 
@@ -62,6 +74,7 @@ func NewMemBackedDB(persistantFilePath ...string) (
 		blockHashSequence: common.Hashes{},
 		blocksByHash:      make(map[common.Hash]*types.Block),
 		dkgPrivateKeys:    make(map[uint64]*dkgPrivateKey),
+		agreementResults:  make(map[uint64]types.AgreementResult),
 	}
 	if len(persistantFilePath) == 0 || len(persistantFilePath[0]) == 0 {
 		return
@@ -81,14 +94,14 @@ func NewMemBackedDB(persistantFilePath ...string) (
 
 	// Init this instance by file content, it's a temporary way
 	// to export those private field for JSON encoding.
-	toLoad := struct {
-		Sequence common.Hashes
-		ByHash   map[common.Hash]*types.Block
-	}{}
+	toLoad := memBackedDBDump{}
 	err = json.Unmarshal(buf, &toLoad)
 	if err != nil {
 		return
 	}
+	if err = migrateMemBackedDB(&toLoad); err != nil {
+		return
+	}
 	dbInst.blockHashSequence = toLoad.Sequence
 	dbInst.blocksByHash = toLoad.ByHash
 	return
@@ -128,6 +141,7 @@ func (m *MemBackedDB) PutBlock(block types.Block) error {
 	m.blocksLock.Lock()
 	defer m.blocksLock.Unlock()
 
+	m.unshareBlocks()
 	m.blockHashSequence = append(m.blockHashSequence, block.Hash)
 	m.blocksByHash[block.Hash] = &block
 	return nil
@@ -142,6 +156,7 @@ func (m *MemBackedDB) UpdateBlock(block types.Block) error {
 	m.blocksLock.Lock()
 	defer m.blocksLock.Unlock()
 
+	m.unshareBlocks()
 	m.blocksByHash[block.Hash] = &block
 	return nil
 }
@@ -214,6 +229,66 @@ func (m *MemBackedDB) PutOrUpdateDKGProtocol(dkgProtocol DKGProtocolInfo) error
 	return nil
 }
 
+// SchemaVersion implements SchemaVersioner.SchemaVersion method, data loaded
+// from the persistent file is always migrated to the latest version.
+func (m *MemBackedDB) SchemaVersion() (uint32, error) {
+	return SchemaVersion, nil
+}
+
+// PutAgreementResult implements AgreementResultStore.PutAgreementResult
+// method, the result would overwrite the existing one of the same height.
+func (m *MemBackedDB) PutAgreementResult(result types.AgreementResult) error {
+	m.agreementResultsLock.Lock()
+	defer m.agreementResultsLock.Unlock()
+	m.agreementResults[result.Position.Height] = result
+	return nil
+}
+
+// GetAgreementResult implements AgreementResultStore.GetAgreementResult
+// method.
+func (m *MemBackedDB) GetAgreementResult(pos types.Position) (
+	types.AgreementResult, error) {
+	m.agreementResultsLock.RLock()
+	defer m.agreementResultsLock.RUnlock()
+	result, exists := m.agreementResults[pos.Height]
+	if !exists || result.Position != pos {
+		return types.AgreementResult{}, ErrAgreementResultDoesNotExist
+	}
+	return result, nil
+}
+
+// PurgeAgreementResults implements AgreementResultStore.PurgeAgreementResults
+// method.
+func (m *MemBackedDB) PurgeAgreementResults(height uint64) error {
+	m.agreementResultsLock.Lock()
+	defer m.agreementResultsLock.Unlock()
+	for h := range m.agreementResults {
+		if h < height {
+			delete(m.agreementResults, h)
+		}
+	}
+	return nil
+}
+
+// PutAgreementState implements AgreementStateStore.PutAgreementState method,
+// only the latest state is kept.
+func (m *MemBackedDB) PutAgreementState(state AgreementState) error {
+	m.agreementStateLock.Lock()
+	defer m.agreementStateLock.Unlock()
+	m.agreementState = &state
+	return nil
+}
+
+// GetAgreementState implements AgreementStateStore.GetAgreementState method.
+func (m *MemBackedDB) GetAgreementState() (AgreementState, error) {
+	m.agreementStateLock.RLock()
+	defer m.agreementStateLock.RUnlock()
+	if m.agreementState == nil {
+		return AgreementState{}, ErrAgreementStateDoesNotExist
+	}
+	return *m.agreementState, nil
+}
+
 // Close implement Closer interface, which would release allocated resource.
 func (m *MemBackedDB) Close() (err error) {
 	// Save internal state to a pretty-print json file. It's a temporary way
@@ -225,10 +300,8 @@ func (m *MemBackedDB) Close() (err error) {
 	m.blocksLock.RLock()
 	defer m.blocksLock.RUnlock()
 
-	toDump := struct {
-		Sequence common.Hashes
-		ByHash   map[common.Hash]*types.Block
-	}{
+	toDump := memBackedDBDump{
+		Version:  SchemaVersion,
 		Sequence: m.blockHashSequence,
 		ByHash:   m.blocksByHash,
 	}
@@ -260,3 +333,45 @@ func (m *MemBackedDB) getBlockByIndex(idx int) (types.Block, error) {
 func (m *MemBackedDB) GetAllBlocks() (BlockIterator, error) {
 	return &blockSeqIterator{db: m}, nil
 }
+
+// Snapshot implements Snapshotter.Snapshot method. Blocks are shared with
+// the snapshot and copied on the next write.
+func (m *MemBackedDB) Snapshot() (Snapshot, error) {
+	view := &MemBackedDB{
+		dkgPrivateKeys: make(map[uint64]*dkgPrivateKey),
+	}
+	func() {
+		m.blocksLock.Lock()
+		defer m.blocksLock.Unlock()
+		view.blockHashSequence = m.blockHashSequence
+		view.blocksByHash = m.blocksByHash
+		m.blocksShared = true
+	}()
+	view.compactionChainTipHash, view.compactionChainTipHeight =
+		m.GetCompactionChainTipInfo()
+	func() {
+		m.dkgPrivateKeysLock.RLock()
+		defer m.dkgPrivateKeysLock.RUnlock()
+		for round, prv := range m.dkgPrivateKeys {
+			view.dkgPrivateKeys[round] = prv
+		}
+	}()
+	if info, err := m.GetDKGProtocol(); err == nil {
+		view.dkgProtocolInfo = &info
+	}
+	return &readOnlyView{Reader: view}, nil
+}
+
+// unshareBlocks makes sure the block map is not shared with any snapshot
+// before writing to it.
+func (m *MemBackedDB) unshareBlocks() {
+	if !m.blocksShared {
+		return
+	}
+	blocksByHash := make(map[common.Hash]*types.Block, len(m.blocksByHash))
+	for k, v := range m.blocksByHash {
+		blocksByHash[k] = v
+	}
+	m.blocksByHash = blocksByHash
+	m.blocksShared = false
+}
@@ -19,6 +19,8 @@ package db
 
 import (
 	"bytes"
+	"encoding/json"
+	"io/ioutil"
 	"os"
 	"testing"
 
@@ -128,6 +130,43 @@ func (s *MemBackedDBTestSuite) TestIteration() {
 	s.Contains(touched, s.b02.Hash)
 }
 
+func (s *MemBackedDBTestSuite) TestSnapshot() {
+	dbInst, err := NewMemBackedDB()
+	s.Require().NoError(err)
+	s.Require().NoError(dbInst.PutBlock(*s.b00))
+	s.Require().NoError(dbInst.PutCompactionChainTipInfo(s.b00.Hash, 1))
+	snap, err := NewSnapshot(dbInst)
+	s.Require().NoError(err)
+	defer snap.Release()
+	// Writes after snapshot should not be visible to it.
+	s.Require().NoError(dbInst.PutBlock(*s.b01))
+	updated := *s.b00
+	updated.Payload = []byte{1}
+	s.Require().NoError(dbInst.UpdateBlock(updated))
+	s.Require().NoError(dbInst.PutCompactionChainTipInfo(s.b01.Hash, 2))
+	s.Require().False(snap.HasBlock(s.b01.Hash))
+	b, err := snap.GetBlock(s.b00.Hash)
+	s.Require().NoError(err)
+	s.Require().Empty(b.Payload)
+	hash, height := snap.GetCompactionChainTipInfo()
+	s.Require().Equal(s.b00.Hash, hash)
+	s.Require().Equal(uint64(1), height)
+	iter, err := snap.GetAllBlocks()
+	s.Require().NoError(err)
+	_, err = iter.NextBlock()
+	s.Require().NoError(err)
+	_, err = iter.NextBlock()
+	s.Require().Equal(ErrIterationFinished, err)
+	// The database itself should be updated.
+	s.Require().True(dbInst.HasBlock(s.b01.Hash))
+	b, err = dbInst.GetBlock(s.b00.Hash)
+	s.Require().NoError(err)
+	s.Require().Equal([]byte{1}, b.Payload)
+	// The snapshot should be read-only.
+	_, ok := snap.(Writer)
+	s.Require().False(ok)
+}
+
 func (s *MemBackedDBTestSuite) TestCompactionChainTipInfo() {
 	dbInst, err := NewMemBackedDB()
 	s.Require().NoError(err)
@@ -181,6 +220,60 @@ func (s *MemBackedDBTestSuite) TestDKGPrivateKey() {
 	s.Require().NotEqual(bytes.Compare(p2.Bytes(), p.Bytes()), 0)
 }
 
+func (s *MemBackedDBTestSuite) TestAgreementResult() {
+	dbInst, err := NewMemBackedDB()
+	s.Require().NoError(err)
+	for h := uint64(1); h <= 3; h++ {
+		s.Require().NoError(dbInst.PutAgreementResult(types.AgreementResult{
+			BlockHash: common.NewRandomHash(),
+			Position:  types.Position{Round: 1, Height: h},
+		}))
+	}
+	result, err := dbInst.GetAgreementResult(types.Position{Round: 1, Height: 2})
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(2), result.Position.Height)
+	// Positions with the same height but different round should not match.
+	_, err = dbInst.GetAgreementResult(types.Position{Round: 2, Height: 2})
+	s.Require().Equal(ErrAgreementResultDoesNotExist, err)
+	// Purge results lower than height 3.
+	s.Require().NoError(dbInst.PurgeAgreementResults(3))
+	_, err = dbInst.GetAgreementResult(types.Position{Round: 1, Height: 2})
+	s.Require().Equal(ErrAgreementResultDoesNotExist, err)
+	_, err = dbInst.GetAgreementResult(types.Position{Round: 1, Height: 3})
+	s.Require().NoError(err)
+}
+
+func (s *MemBackedDBTestSuite) TestSchemaVersion() {
+	dbPath := "test-schema-version.db"
+	defer func() {
+		s.NoError(os.Remove(dbPath))
+	}()
+	// Files dumped before schema versioning should be loaded.
+	buf, err := json.Marshal(struct {
+		Sequence common.Hashes
+		ByHash   map[common.Hash]*types.Block
+	}{
+		Sequence: common.Hashes{s.b00.Hash},
+		ByHash:   map[common.Hash]*types.Block{s.b00.Hash: s.b00},
+	})
+	s.Require().NoError(err)
+	s.Require().NoError(ioutil.WriteFile(dbPath, buf, 0644))
+	dbInst, err := NewMemBackedDB(dbPath)
+	s.Require().NoError(err)
+	s.True(dbInst.HasBlock(s.b00.Hash))
+	version, err := dbInst.SchemaVersion()
+	s.Require().NoError(err)
+	s.Require().Equal(SchemaVersion, version)
+	s.Require().NoError(dbInst.Close())
+	// Files dumped by newer version should not be loaded.
+	buf, err = json.Marshal(memBackedDBDump{Version: SchemaVersion + 1})
+	s.Require().NoError(err)
+	s.Require().NoError(ioutil.WriteFile(dbPath, buf, 0644))
+	_, err = NewMemBackedDB(dbPath)
+	s.Require().Equal(ErrUnsupportedSchemaVersion, err)
+	s.Require().Len(memBackedDBMigrations, int(SchemaVersion))
+}
+
 func TestMemBackedDB(t *testing.T) {
 	suite.Run(t, new(MemBackedDBTestSuite))
 }
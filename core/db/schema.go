@@ -0,0 +1,125 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"encoding/binary"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// SchemaVersion is the version of the persistent format written by databases
+// in this package. It should be increased with a migration registered for
+// each implementation whenever the format is changed.
+const SchemaVersion uint32 = 1
+
+// SchemaVersioner is implemented by databases persisting data across
+// restarts. Data written by older versions is migrated in place when opened.
+type SchemaVersioner interface {
+	SchemaVersion() (uint32, error)
+}
+
+var schemaVersionKey = []byte("schema-version")
+
+// levelDBMigrations[v] migrates a leveldb database from version v to v+1. A
+// migration might be interrupted and run again on next startup, thus it
+// should be idempotent.
+// Values of encrypted databases are sealed, see NewEncryptedLevelDBBackedDB.
+var levelDBMigrations = []func(*leveldb.DB) error{
+	// Version 0 is databases created before schema versioning, its format is
+	// identical to version 1.
+	func(*leveldb.DB) error { return nil },
+}
+
+// memBackedDBMigrations[v] migrates data loaded from the persistent file of
+// MemBackedDB from version v to v+1.
+var memBackedDBMigrations = []func(*memBackedDBDump) error{
+	// Version 0 is files dumped before schema versioning, its format is
+	// identical to version 1.
+	func(*memBackedDBDump) error { return nil },
+}
+
+func encodeSchemaVersion(version uint32) []byte {
+	ret := make([]byte, 4)
+	binary.BigEndian.PutUint32(ret, version)
+	return ret
+}
+
+// migrateLevelDB upgrades the schema of a leveldb database to SchemaVersion.
+func migrateLevelDB(db *leveldb.DB) error {
+	version, err := levelDBSchemaVersion(db)
+	switch err {
+	case nil:
+	case ErrSchemaVersionDoesNotExist:
+		// A newly created database is written in the latest format.
+		iter := db.NewIterator(nil, nil)
+		empty := !iter.First()
+		iter.Release()
+		if err = iter.Error(); err != nil {
+			return err
+		}
+		if empty {
+			return db.Put(
+				schemaVersionKey, encodeSchemaVersion(SchemaVersion), nil)
+		}
+		version = 0
+	default:
+		return err
+	}
+	if version > SchemaVersion {
+		return ErrUnsupportedSchemaVersion
+	}
+	for ; version < SchemaVersion; version++ {
+		if err = levelDBMigrations[version](db); err != nil {
+			return err
+		}
+		if err = db.Put(
+			schemaVersionKey, encodeSchemaVersion(version+1), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func levelDBSchemaVersion(reader levelDBReader) (uint32, error) {
+	queried, err := reader.Get(schemaVersionKey, nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			err = ErrSchemaVersionDoesNotExist
+		}
+		return 0, err
+	}
+	if len(queried) != 4 {
+		return 0, ErrUnsupportedSchemaVersion
+	}
+	return binary.BigEndian.Uint32(queried), nil
+}
+
+// migrateMemBackedDB upgrades data loaded from the persistent file of
+// MemBackedDB to SchemaVersion.
+func migrateMemBackedDB(dump *memBackedDBDump) error {
+	if dump.Version > SchemaVersion {
+		return ErrUnsupportedSchemaVersion
+	}
+	for ; dump.Version < SchemaVersion; dump.Version++ {
+		if err := memBackedDBMigrations[dump.Version](dump); err != nil {
+			return err
+		}
+	}
+	return nil
+}
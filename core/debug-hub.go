@@ -0,0 +1,96 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+)
+
+// DebugHandle is returned when a Debug consumer is attached to consensus, and
+// is used to detach it.
+type DebugHandle struct {
+	hub *debugHub
+	id  uint64
+}
+
+// Detach stops calling the attached Debug consumer, it's safe to be called
+// more than once, or inside callbacks of that consumer.
+func (h *DebugHandle) Detach() {
+	h.hub.detach(h.id)
+}
+
+type debugConsumer struct {
+	id    uint64
+	debug Debug
+}
+
+// debugHub dispatches Debug callbacks to consumers attached at run-time.
+type debugHub struct {
+	lock      sync.RWMutex
+	lastID    uint64
+	consumers []debugConsumer
+}
+
+func newDebugHub() *debugHub {
+	return &debugHub{}
+}
+
+func (h *debugHub) attach(debug Debug) *DebugHandle {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.lastID++
+	// Copy on write, thus callbacks could be made without holding the lock.
+	consumers := make([]debugConsumer, 0, len(h.consumers)+1)
+	consumers = append(consumers, h.consumers...)
+	h.consumers = append(consumers, debugConsumer{id: h.lastID, debug: debug})
+	return &DebugHandle{hub: h, id: h.lastID}
+}
+
+func (h *debugHub) detach(id uint64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	consumers := make([]debugConsumer, 0, len(h.consumers))
+	for _, c := range h.consumers {
+		if c.id != id {
+			consumers = append(consumers, c)
+		}
+	}
+	h.consumers = consumers
+}
+
+func (h *debugHub) snapshot() []debugConsumer {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.consumers
+}
+
+// BlockReceived implements Debug interface.
+func (h *debugHub) BlockReceived(hash common.Hash) {
+	for _, c := range h.snapshot() {
+		c.debug.BlockReceived(hash)
+	}
+}
+
+// BlockReady implements Debug interface.
+func (h *debugHub) BlockReady(hash common.Hash) {
+	for _, c := range h.snapshot() {
+		c.debug.BlockReady(hash)
+	}
+}
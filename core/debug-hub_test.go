@@ -0,0 +1,79 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+)
+
+type countingDebug struct {
+	received []common.Hash
+	ready    []common.Hash
+	onReady  func()
+}
+
+func (d *countingDebug) BlockReceived(hash common.Hash) {
+	d.received = append(d.received, hash)
+}
+
+func (d *countingDebug) BlockReady(hash common.Hash) {
+	d.ready = append(d.ready, hash)
+	if d.onReady != nil {
+		d.onReady()
+	}
+}
+
+type DebugHubTestSuite struct {
+	suite.Suite
+}
+
+func (s *DebugHubTestSuite) TestAttachDetach() {
+	h := newDebugHub()
+	// No consumer is attached.
+	h.BlockReceived(common.NewRandomHash())
+	d1, d2 := &countingDebug{}, &countingDebug{}
+	handle1 := h.attach(d1)
+	h.attach(d2)
+	hash := common.NewRandomHash()
+	h.BlockReceived(hash)
+	s.Require().Equal([]common.Hash{hash}, d1.received)
+	s.Require().Equal([]common.Hash{hash}, d2.received)
+	handle1.Detach()
+	handle1.Detach()
+	h.BlockReceived(common.NewRandomHash())
+	s.Require().Len(d1.received, 1)
+	s.Require().Len(d2.received, 2)
+}
+
+func (s *DebugHubTestSuite) TestDetachInCallback() {
+	h := newDebugHub()
+	d := &countingDebug{}
+	handle := h.attach(d)
+	d.onReady = handle.Detach
+	h.BlockReady(common.NewRandomHash())
+	h.BlockReady(common.NewRandomHash())
+	s.Require().Len(d.ready, 1)
+}
+
+func TestDebugHub(t *testing.T) {
+	suite.Run(t, new(DebugHubTestSuite))
+}
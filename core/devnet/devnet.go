@@ -0,0 +1,234 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package devnet runs a development network of a single node, which forms the
+// notary set and the DKG set alone. Application developers could integrate
+// against the consensus core locally without standing up a committee.
+package devnet
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+)
+
+// Config is the configuration of a development network, zero values are
+// replaced by defaults.
+type Config struct {
+	// BlockInterval is the minimum interval between blocks, it could be
+	// sub-second. Default is 400ms.
+	BlockInterval time.Duration
+	// RoundLength is the count of blocks in one round, default is 100. Rounds
+	// should be long enough for DKG of next round to finish.
+	RoundLength uint64
+	// PrivateKey is the key of the node, a new one is generated by default.
+	PrivateKey crypto.PrivateKey
+	// DB is the database of the node, default is an in-memory one.
+	DB db.Database
+	// Logger is the logger of the node, default is common.NullLogger.
+	Logger common.Logger
+	// DMoment is the time to start consensus, default is the time when the
+	// node is created.
+	DMoment time.Time
+	// Options are passed to the consensus instance.
+	Options []core.Option
+}
+
+func (c *Config) setDefaults() (err error) {
+	if c.BlockInterval == 0 {
+		c.BlockInterval = 400 * time.Millisecond
+	}
+	if c.RoundLength == 0 {
+		c.RoundLength = 100
+	}
+	if c.PrivateKey == nil {
+		if c.PrivateKey, err = ecdsa.NewPrivateKey(); err != nil {
+			return
+		}
+	}
+	if c.DB == nil {
+		if c.DB, err = db.NewMemBackedDB(); err != nil {
+			return
+		}
+	}
+	if c.Logger == nil {
+		c.Logger = &common.NullLogger{}
+	}
+	if c.DMoment.IsZero() {
+		c.DMoment = time.Now().UTC()
+	}
+	return
+}
+
+// Node is the only node of a development network.
+type Node struct {
+	ID  types.NodeID
+	Con *core.Consensus
+	Gov *test.Governance
+	DB  db.Database
+
+	lock    sync.Mutex
+	running bool
+	stopped chan struct{}
+}
+
+// New creates the node of a development network running the application.
+func New(app core.Application, config Config) (n *Node, err error) {
+	if err = config.setDefaults(); err != nil {
+		return
+	}
+	pubKey := config.PrivateKey.PublicKey()
+	// Lambda of BA is derived from the block interval, the same as the
+	// default of test.State.
+	gov, err := test.NewGovernance(test.NewState(core.DKGDelayRound,
+		[]crypto.PublicKey{pubKey}, config.BlockInterval/4,
+		&common.NullLogger{}, true), core.ConfigRoundShift)
+	if err != nil {
+		return
+	}
+	if err = gov.State().RequestChange(
+		test.StateChangeRoundLength, config.RoundLength); err != nil {
+		return
+	}
+	if err = gov.State().RequestChange(
+		test.StateChangeMinBlockInterval, config.BlockInterval); err != nil {
+		return
+	}
+	gov.NotifyRound(0, types.GenesisHeight)
+	n = &Node{
+		ID:      types.NewNodeID(pubKey),
+		Gov:     gov,
+		DB:      config.DB,
+		stopped: make(chan struct{}),
+	}
+	n.Con = core.NewConsensus(config.DMoment, app, gov, config.DB,
+		newNetwork(), config.PrivateKey, config.Logger, config.Options...)
+	return
+}
+
+// Run runs consensus of the node, it blocks until the node is stopped.
+func (n *Node) Run() {
+	if !func() bool {
+		n.lock.Lock()
+		defer n.lock.Unlock()
+		if n.running {
+			return false
+		}
+		n.running = true
+		return true
+	}() {
+		return
+	}
+	go n.notifyRounds()
+	n.Con.Run()
+}
+
+// Stop stops consensus of the node.
+func (n *Node) Stop() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	select {
+	case <-n.stopped:
+		return
+	default:
+	}
+	close(n.stopped)
+	if n.running {
+		n.Con.Stop()
+	}
+}
+
+// notifyRounds notifies governance when the first block of each round is
+// delivered, which is done by the governance contract in a real network.
+func (n *Node) notifyRounds() {
+	sub := n.Con.SubscribeBlocks(types.GenesisHeight)
+	defer sub.Unsubscribe()
+	round := uint64(1)
+	for {
+		select {
+		case b, ok := <-sub.Blocks():
+			if !ok {
+				return
+			}
+			if b.Position.Round == round {
+				n.Gov.NotifyRound(round, b.Position.Height)
+				round++
+			}
+		case <-n.stopped:
+			return
+		}
+	}
+}
+
+// network is a network without any peer, messages broadcasted are dropped
+// and there is nothing to pull.
+type network struct {
+	recv    chan types.Msg
+	badPeer chan interface{}
+}
+
+func newNetwork() *network {
+	return &network{
+		recv: make(chan types.Msg),
+		// Bad peers are only reported for messages received.
+		badPeer: make(chan interface{}, 1),
+	}
+}
+
+// PullBlocks implements core.Network interface.
+func (n *network) PullBlocks(common.Hashes) {}
+
+// PullVotes implements core.Network interface.
+func (n *network) PullVotes(types.Position) {}
+
+// BroadcastVote implements core.Network interface.
+func (n *network) BroadcastVote(*types.Vote) {}
+
+// BroadcastBlock implements core.Network interface.
+func (n *network) BroadcastBlock(*types.Block) {}
+
+// BroadcastAgreementResult implements core.Network interface.
+func (n *network) BroadcastAgreementResult(*types.AgreementResult) {}
+
+// SendDKGPrivateShare implements core.Network interface.
+func (n *network) SendDKGPrivateShare(
+	crypto.PublicKey, *typesDKG.PrivateShare) {
+}
+
+// BroadcastDKGPrivateShare implements core.Network interface.
+func (n *network) BroadcastDKGPrivateShare(*typesDKG.PrivateShare) {}
+
+// BroadcastDKGPartialSignature implements core.Network interface.
+func (n *network) BroadcastDKGPartialSignature(*typesDKG.PartialSignature) {}
+
+// ReceiveChan implements core.Network interface.
+func (n *network) ReceiveChan() <-chan types.Msg {
+	return n.recv
+}
+
+// ReportBadPeerChan implements core.Network interface.
+func (n *network) ReportBadPeerChan() chan<- interface{} {
+	return n.badPeer
+}
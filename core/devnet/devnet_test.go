@@ -0,0 +1,56 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package devnet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+)
+
+type DevnetTestSuite struct {
+	suite.Suite
+}
+
+func (s *DevnetTestSuite) TestRunRounds() {
+	app := test.NewApp(0, nil, nil)
+	n, err := New(app, Config{BlockInterval: 100 * time.Millisecond})
+	s.Require().NoError(err)
+	go n.Run()
+	defer n.Stop()
+	// Blocks in rounds after DKG is ready are delivered.
+	deadline := time.After(time.Minute)
+	for app.GetLatestDeliveredPosition().Round <= core.DKGDelayRound {
+		select {
+		case <-deadline:
+			s.FailNow("timeout", "delivered: %s",
+				app.GetLatestDeliveredPosition())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	n.Stop()
+	s.Require().NoError(app.Verify())
+}
+
+func TestDevnet(t *testing.T) {
+	suite.Run(t, new(DevnetTestSuite))
+}
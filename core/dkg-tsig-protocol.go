@@ -123,6 +123,8 @@ type dkgProtocol struct {
 	antiComplaintReceived map[types.NodeID]map[types.NodeID]struct{}
 	// The completed step in `runDKG`.
 	step int
+	// registry maps identities of participants signing private shares.
+	registry utils.NodeIdentityRegistry
 }
 
 func (d *dkgProtocol) convertFromInfo(info db.DKGProtocolInfo) {
@@ -218,6 +220,7 @@ type tsigProtocol struct {
 	hash           common.Hash
 	sigs           map[dkg.ID]dkg.PartialSignature
 	threshold      int
+	registry       utils.NodeIdentityRegistry
 }
 
 func newDKGProtocol(
@@ -337,7 +340,25 @@ func (d *dkgProtocol) verifySelfPrvShare() error {
 	return nil
 }
 
-func (d *dkgProtocol) proposeNackComplaints() {
+// checkPhaseWindow checks if DKG messages of a phase, whose window ends after
+// the step 'last' in dkgRunPhases, are still useful at current step.
+func (d *dkgProtocol) checkPhaseWindow(message string, last int) error {
+	if d.step <= last {
+		return nil
+	}
+	return ErrDKGPhaseWindow{
+		Message: message,
+		Round:   d.round,
+		Reset:   d.reset,
+		Step:    d.step,
+	}
+}
+
+func (d *dkgProtocol) proposeNackComplaints() error {
+	if err := d.checkPhaseWindow(
+		"nack complaint", dkgStepProposeFinalize); err != nil {
+		return err
+	}
 	for nID := range d.mpkMap {
 		if _, exist := d.prvSharesReceived[nID]; exist {
 			continue
@@ -352,6 +373,7 @@ func (d *dkgProtocol) proposeNackComplaints() {
 			},
 		})
 	}
+	return nil
 }
 
 func (d *dkgProtocol) processNackComplaints(complaints []*typesDKG.Complaint) (
@@ -390,7 +412,12 @@ func (d *dkgProtocol) processNackComplaints(complaints []*typesDKG.Complaint) (
 	return
 }
 
-func (d *dkgProtocol) enforceNackComplaints(complaints []*typesDKG.Complaint) {
+func (d *dkgProtocol) enforceNackComplaints(
+	complaints []*typesDKG.Complaint) error {
+	if err := d.checkPhaseWindow(
+		"complaint", dkgStepProposeFinalize); err != nil {
+		return err
+	}
 	complained := make(map[types.NodeID]struct{})
 	// Do not propose nack complaint to itself.
 	complained[d.ID] = struct{}{}
@@ -427,6 +454,7 @@ func (d *dkgProtocol) enforceNackComplaints(complaints []*typesDKG.Complaint) {
 			})
 		}
 	}
+	return nil
 }
 
 func (d *dkgProtocol) sanityCheck(prvShare *typesDKG.PrivateShare) error {
@@ -447,7 +475,7 @@ func (d *dkgProtocol) sanityCheck(prvShare *typesDKG.PrivateShare) error {
 	if _, exist := d.idMap[prvShare.ProposerID]; !exist {
 		return ErrNotDKGParticipant
 	}
-	ok, err := utils.VerifyDKGPrivateShareSignature(prvShare)
+	ok, err := utils.VerifyDKGPrivateShareSignature(prvShare, d.registry)
 	if err != nil {
 		return err
 	}
@@ -519,20 +547,30 @@ func (d *dkgProtocol) processPrivateShare(
 	return nil
 }
 
-func (d *dkgProtocol) proposeMPKReady() {
+func (d *dkgProtocol) proposeMPKReady() error {
+	if err := d.checkPhaseWindow(
+		"MPK ready", dkgStepWaitMPKReady); err != nil {
+		return err
+	}
 	d.recv.ProposeDKGMPKReady(&typesDKG.MPKReady{
 		ProposerID: d.ID,
 		Round:      d.round,
 		Reset:      d.reset,
 	})
+	return nil
 }
 
-func (d *dkgProtocol) proposeFinalize() {
+func (d *dkgProtocol) proposeFinalize() error {
+	if err := d.checkPhaseWindow(
+		"finalize", dkgStepProposeFinalize); err != nil {
+		return err
+	}
 	d.recv.ProposeDKGFinalize(&typesDKG.Finalize{
 		ProposerID: d.ID,
 		Round:      d.round,
 		Reset:      d.reset,
 	})
+	return nil
 }
 
 func (d *dkgProtocol) proposeSuccess() {
@@ -661,7 +699,7 @@ func (tsig *tsigProtocol) sanityCheck(psig *typesDKG.PartialSignature) error {
 	if !exist {
 		return ErrNotQualifyDKGParticipant
 	}
-	ok, err := utils.VerifyDKGPartialSignatureSignature(psig)
+	ok, err := utils.VerifyDKGPartialSignatureSignature(psig, tsig.registry)
 	if err != nil {
 		return err
 	}
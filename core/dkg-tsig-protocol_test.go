@@ -446,7 +446,7 @@ func (s *DKGTSIGProtocolTestSuite) TestNackComplaint() {
 		complaint, exist := recv.complaints[byzantineID]
 		s.True(complaint.IsNack())
 		s.Require().True(exist)
-		s.True(utils.VerifyDKGComplaintSignature(complaint))
+		s.True(utils.VerifyDKGComplaintSignature(complaint, nil))
 	}
 }
 
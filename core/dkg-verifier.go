@@ -0,0 +1,172 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// dkgVerifyQueueSize is the count of DKG messages allowed to be verified or
+// waiting for verification.
+const dkgVerifyQueueSize = 1024
+
+type dkgVerifyTask struct {
+	msg  interface{}
+	peer interface{}
+	err  error
+	done chan struct{}
+}
+
+// dkgMsgVerifier verifies signatures of DKG messages by a pool of workers,
+// and hands verified messages to the handler in the order they are submitted.
+// Therefore, bursts of DKG messages would not block the message loop.
+type dkgMsgVerifier struct {
+	// pending is the count of submitted tasks not handled yet, it's accessed
+	// atomically.
+	pending  int32
+	ctx      context.Context
+	tasks    chan *dkgVerifyTask
+	ordered  chan *dkgVerifyTask
+	workers  int
+	registry utils.NodeIdentityRegistry
+	handler  func(msg, peer interface{}, err error)
+}
+
+func newDKGMsgVerifier(ctx context.Context, workers int,
+	registry utils.NodeIdentityRegistry,
+	handler func(msg, peer interface{}, err error)) *dkgMsgVerifier {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &dkgMsgVerifier{
+		ctx:      ctx,
+		tasks:    make(chan *dkgVerifyTask, dkgVerifyQueueSize),
+		ordered:  make(chan *dkgVerifyTask, dkgVerifyQueueSize),
+		workers:  workers,
+		registry: registry,
+		handler:  handler,
+	}
+}
+
+// run launches workers and the handling routine, it blocks until the context
+// is done.
+func (v *dkgMsgVerifier) run() {
+	wg := sync.WaitGroup{}
+	for i := 0; i < v.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v.verifyLoop()
+		}()
+	}
+	v.handleLoop()
+	wg.Wait()
+}
+
+// submit queues a DKG message, it returns false when the verifier is stopped
+// or the message is not verifiable by this module.
+func (v *dkgMsgVerifier) submit(msg, peer interface{}) bool {
+	switch msg.(type) {
+	case *typesDKG.PrivateShare, *typesDKG.PartialSignature:
+	default:
+		return false
+	}
+	select {
+	case <-v.ctx.Done():
+		return false
+	default:
+	}
+	task := &dkgVerifyTask{msg: msg, peer: peer, done: make(chan struct{})}
+	atomic.AddInt32(&v.pending, 1)
+	// Reserve the slot in order before the task is verified.
+	select {
+	case v.ordered <- task:
+	case <-v.ctx.Done():
+		atomic.AddInt32(&v.pending, -1)
+		return false
+	}
+	select {
+	case v.tasks <- task:
+	case <-v.ctx.Done():
+		return false
+	}
+	return true
+}
+
+// drained checks if all submitted messages are handled.
+func (v *dkgMsgVerifier) drained() bool {
+	return atomic.LoadInt32(&v.pending) == 0
+}
+
+func (v *dkgMsgVerifier) verifyLoop() {
+	for {
+		select {
+		case task := <-v.tasks:
+			task.err = verifyDKGMsg(task.msg, v.registry)
+			close(task.done)
+		case <-v.ctx.Done():
+			return
+		}
+	}
+}
+
+func (v *dkgMsgVerifier) handleLoop() {
+	for {
+		select {
+		case task := <-v.ordered:
+			select {
+			case <-task.done:
+			case <-v.ctx.Done():
+				return
+			}
+			v.handler(task.msg, task.peer, task.err)
+			atomic.AddInt32(&v.pending, -1)
+		case <-v.ctx.Done():
+			return
+		}
+	}
+}
+
+func verifyDKGMsg(
+	msg interface{}, registry utils.NodeIdentityRegistry) error {
+	switch val := msg.(type) {
+	case *typesDKG.PrivateShare:
+		ok, err := utils.VerifyDKGPrivateShareSignature(val, registry)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrIncorrectPrivateShareSignature
+		}
+	case *typesDKG.PartialSignature:
+		ok, err := utils.VerifyDKGPartialSignatureSignature(val, registry)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrIncorrectPartialSignatureSignature
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+type DKGVerifierTestSuite struct {
+	suite.Suite
+}
+
+func (s *DKGVerifierTestSuite) TestOrderedCompletion() {
+	var (
+		req     = s.Require()
+		prvKeys = test.GenerateRandomPrivateKeys(4)
+		count   = 100
+		handled = make(chan *typesDKG.PartialSignature, count)
+		invalid = make(map[int]struct{})
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v := newDKGMsgVerifier(ctx, 4, nil,
+		func(msg, peer interface{}, err error) {
+			psig := msg.(*typesDKG.PartialSignature)
+			_, shouldFail := invalid[peer.(int)]
+			if shouldFail {
+				req.Equal(ErrIncorrectPartialSignatureSignature, err)
+			} else {
+				req.NoError(err)
+			}
+			handled <- psig
+		})
+	go v.run()
+	psigs := make([]*typesDKG.PartialSignature, 0, count)
+	for i := 0; i < count; i++ {
+		psig := &typesDKG.PartialSignature{
+			Round: uint64(i),
+			Hash:  common.NewRandomHash(),
+		}
+		req.NoError(utils.NewSigner(prvKeys[i%len(prvKeys)]).
+			SignDKGPartialSignature(psig))
+		if i%10 == 0 {
+			psig.Hash = common.NewRandomHash()
+			invalid[i] = struct{}{}
+		}
+		psigs = append(psigs, psig)
+	}
+	for i, psig := range psigs {
+		req.True(v.submit(psig, i))
+	}
+	// Messages are handled in the order they are submitted.
+	for _, psig := range psigs {
+		select {
+		case h := <-handled:
+			req.Equal(psig, h)
+		case <-time.After(10 * time.Second):
+			req.FailNow("timeout")
+		}
+	}
+	// It's drained once the last handler returns.
+	deadline := time.Now().Add(10 * time.Second)
+	for !v.drained() {
+		req.True(time.Now().Before(deadline), "timeout")
+		time.Sleep(time.Millisecond)
+	}
+	// Unknown messages are not accepted.
+	req.False(v.submit(struct{}{}, 0))
+	req.True(v.drained())
+	cancel()
+	req.False(v.submit(psigs[0], 0))
+}
+
+func TestDKGVerifier(t *testing.T) {
+	suite.Run(t, new(DKGVerifierTestSuite))
+}
@@ -0,0 +1,299 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package engine is a stable facade over the consensus core. The core is
+// reorganized from time to time, applications embedding consensus could
+// depend on this package instead, whose API only changes along with Version.
+//
+// Interfaces and types of this package are owned by it, the application passed
+// in is handed to package core as is, thus optional interfaces of it, ex.
+// core.BlockConfirmMetaReceiver, are still detected.
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Version is the version of the API of this package, it's increased when the
+// API is changed incompatibly.
+const Version = 1
+
+// Errors for engine.
+var (
+	ErrMissingApplication = errors.New("application is required")
+	ErrMissingGovernance  = errors.New("governance is required")
+	ErrMissingDB          = errors.New("db is required")
+	ErrMissingNetwork     = errors.New("network is required")
+	ErrMissingPrivateKey  = errors.New("private key is required")
+	ErrAlreadyStarted     = errors.New("engine is already started")
+	ErrNotStarted         = errors.New("engine is not started")
+	ErrStopped            = errors.New("engine is stopped")
+)
+
+// Config is the configuration of an engine, fields are required unless noted.
+type Config struct {
+	// DMoment is the time to start consensus.
+	DMoment     time.Time
+	Application Application
+	Governance  Governance
+	DB          db.Database
+	Network     Network
+	PrivateKey  crypto.PrivateKey
+	// Logger is optional, default is common.NullLogger.
+	Logger common.Logger
+	// Options are optional.
+	Options []Option
+}
+
+func (c *Config) validate() error {
+	switch {
+	case c.Application == nil:
+		return ErrMissingApplication
+	case c.Governance == nil:
+		return ErrMissingGovernance
+	case c.DB == nil:
+		return ErrMissingDB
+	case c.Network == nil:
+		return ErrMissingNetwork
+	case c.PrivateKey == nil:
+		return ErrMissingPrivateKey
+	}
+	return nil
+}
+
+// PayloadSource supplies payloads of blocks proposed by this node.
+type PayloadSource interface {
+	// Payload returns the payload of the block at the position, nil is
+	// returned when there is nothing to propose.
+	Payload(position types.Position) ([]byte, error)
+}
+
+// Status is the snapshot of an engine.
+type Status struct {
+	ID      types.NodeID
+	Running bool
+	// Position is the position BA is working on, and Period is the period of
+	// BA at that position.
+	Position types.Position
+	Period   uint64
+	// CatchingUp is true when this node stops participating BA to catch up
+	// with the network.
+	CatchingUp bool
+}
+
+// Block is a block delivered by consensus.
+type Block struct {
+	Hash           common.Hash
+	ParentHash     common.Hash
+	ProposerID     types.NodeID
+	Position       types.Position
+	Timestamp      time.Time
+	Payload        []byte
+	Randomness     []byte
+	SystemMessages [][]byte
+}
+
+func newBlock(b *types.Block) *Block {
+	return &Block{
+		Hash:           b.Hash,
+		ParentHash:     b.ParentHash,
+		ProposerID:     b.ProposerID,
+		Position:       b.Position,
+		Timestamp:      b.Timestamp,
+		Payload:        b.Payload,
+		Randomness:     b.Randomness,
+		SystemMessages: b.SystemMessages,
+	}
+}
+
+// Subscription is a stream of delivered blocks in ascending order of height,
+// without gaps or duplicates.
+type Subscription struct {
+	sub  *core.BlockSubscription
+	ch   chan *Block
+	done chan struct{}
+	once sync.Once
+}
+
+func newSubscription(sub *core.BlockSubscription) *Subscription {
+	s := &Subscription{
+		sub:  sub,
+		ch:   make(chan *Block),
+		done: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *Subscription) run() {
+	defer close(s.ch)
+	for b := range s.sub.Blocks() {
+		select {
+		case s.ch <- newBlock(b):
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Blocks returns the channel of delivered blocks, it's closed when the
+// subscription ends, and Err tells the reason.
+func (s *Subscription) Blocks() <-chan *Block {
+	return s.ch
+}
+
+// Err returns the reason why the subscription ends.
+func (s *Subscription) Err() error {
+	return s.sub.Err()
+}
+
+// Unsubscribe stops the subscription.
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	s.sub.Unsubscribe()
+}
+
+// Engine runs consensus of a node.
+type Engine struct {
+	id  types.NodeID
+	con *core.Consensus
+	app Application
+
+	sourceLock sync.RWMutex
+	source     PayloadSource
+
+	lock    sync.Mutex
+	running bool
+	stopped bool
+}
+
+// New creates an engine, it's not running until Start is called.
+func New(config Config) (*Engine, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if config.Logger == nil {
+		config.Logger = &common.NullLogger{}
+	}
+	e := &Engine{
+		id:  types.NewNodeID(config.PrivateKey.PublicKey()),
+		app: config.Application,
+	}
+	// Application is passed as is, thus its optional interfaces are kept.
+	opts := []core.Option{core.WithPayloadSource(e.preparePayload)}
+	for _, opt := range config.Options {
+		opts = append(opts, opt.opt)
+	}
+	con, err := core.NewCheckedConsensus(config.DMoment, config.Application,
+		config.Governance, config.DB, config.Network, config.PrivateKey,
+		config.Logger, opts...)
+	if err != nil {
+		return nil, err
+	}
+	e.con = con
+	return e, nil
+}
+
+// Start runs consensus in background.
+func (e *Engine) Start() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.stopped {
+		return ErrStopped
+	}
+	if e.running {
+		return ErrAlreadyStarted
+	}
+	e.running = true
+	go e.con.Run()
+	return nil
+}
+
+// Stop stops consensus gracefully, messages received and blocks confirmed
+// are processed before stopping. It returns the error of ctx when ctx is done
+// first, and the stopping continues in background. A stopped engine could
+// not be started again.
+func (e *Engine) Stop(ctx context.Context) error {
+	// The lock is not held when shutting down, Status could still be queried
+	// meanwhile.
+	if err := func() error {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		if e.stopped {
+			return ErrStopped
+		}
+		if !e.running {
+			return ErrNotStarted
+		}
+		e.stopped, e.running = true, false
+		return nil
+	}(); err != nil {
+		return err
+	}
+	return e.con.Shutdown(ctx)
+}
+
+// Status returns the snapshot of this engine.
+func (e *Engine) Status() Status {
+	e.lock.Lock()
+	running := e.running
+	e.lock.Unlock()
+	agr := e.con.AgreementStatus()
+	return Status{
+		ID:         e.id,
+		Running:    running,
+		Position:   agr.Position,
+		Period:     agr.Period,
+		CatchingUp: e.con.IsCatchingUp(),
+	}
+}
+
+// Subscribe subscribes blocks delivered from the height, blocks delivered
+// before subscribing are loaded from the database.
+func (e *Engine) Subscribe(from uint64) *Subscription {
+	return newSubscription(e.con.SubscribeBlocks(from))
+}
+
+// ProposePayloadSource replaces Application.PreparePayload by the source to
+// prepare payloads of blocks proposed afterward, nil restores it.
+func (e *Engine) ProposePayloadSource(src PayloadSource) {
+	e.sourceLock.Lock()
+	defer e.sourceLock.Unlock()
+	e.source = src
+}
+
+// preparePayload prepares payloads by the payload source when set.
+func (e *Engine) preparePayload(position types.Position) ([]byte, error) {
+	e.sourceLock.RLock()
+	src := e.source
+	e.sourceLock.RUnlock()
+	if src == nil {
+		return e.app.PreparePayload(position)
+	}
+	return src.Payload(position)
+}
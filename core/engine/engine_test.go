@@ -0,0 +1,180 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+)
+
+// network is a network without any peer.
+type network struct {
+	recv    chan types.Msg
+	badPeer chan interface{}
+}
+
+func (n *network) PullBlocks(common.Hashes)                                {}
+func (n *network) PullVotes(types.Position)                                {}
+func (n *network) BroadcastVote(*types.Vote)                               {}
+func (n *network) BroadcastBlock(*types.Block)                             {}
+func (n *network) BroadcastAgreementResult(*types.AgreementResult)         {}
+func (n *network) BroadcastDKGPrivateShare(*typesDKG.PrivateShare)         {}
+func (n *network) BroadcastDKGPartialSignature(*typesDKG.PartialSignature) {}
+func (n *network) SendDKGPrivateShare(
+	crypto.PublicKey, *typesDKG.PrivateShare) {
+}
+func (n *network) ReceiveChan() <-chan types.Msg         { return n.recv }
+func (n *network) ReportBadPeerChan() chan<- interface{} { return n.badPeer }
+
+type payloadSource struct{}
+
+func (payloadSource) Payload(position types.Position) ([]byte, error) {
+	return []byte("payload"), nil
+}
+
+// metaApp implements an optional interface of core.Application.
+type metaApp struct {
+	*test.App
+
+	confirmed chan common.Hash
+}
+
+func (app *metaApp) BlockConfirmedWithMeta(
+	hash common.Hash, _ core.BlockConfirmMeta) {
+	select {
+	case app.confirmed <- hash:
+	default:
+	}
+}
+
+// invalidGovernance returns configurations failing validation.
+type invalidGovernance struct {
+	Governance
+}
+
+func (g *invalidGovernance) Configuration(round uint64) *types.Config {
+	config := g.Governance.Configuration(round).Clone()
+	config.LambdaDKG = 0
+	return config
+}
+
+type EngineTestSuite struct {
+	suite.Suite
+}
+
+func (s *EngineTestSuite) newConfig() Config {
+	prvKeys, pubKeys, err := test.NewKeys(1)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(core.DKGDelayRound,
+		pubKeys, 25*time.Millisecond, &common.NullLogger{}, true),
+		core.ConfigRoundShift)
+	s.Require().NoError(err)
+	s.Require().NoError(gov.State().RequestChange(
+		test.StateChangeMinBlockInterval, 100*time.Millisecond))
+	gov.NotifyRound(0, types.GenesisHeight)
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	return Config{
+		DMoment:     time.Now().UTC(),
+		Application: test.NewApp(0, nil, nil),
+		Governance:  gov,
+		DB:          dbInst,
+		Network: &network{
+			recv:    make(chan types.Msg),
+			badPeer: make(chan interface{}, 1),
+		},
+		PrivateKey: prvKeys[0],
+		Options:    []Option{WithVerifierPool(1)},
+	}
+}
+
+func (s *EngineTestSuite) TestValidate() {
+	config := s.newConfig()
+	config.Network = nil
+	_, err := New(config)
+	s.Require().Equal(ErrMissingNetwork, err)
+	config = s.newConfig()
+	config.Application = nil
+	_, err = New(config)
+	s.Require().Equal(ErrMissingApplication, err)
+	// Invalid configurations are reported instead of panicking.
+	config = s.newConfig()
+	config.Governance = &invalidGovernance{Governance: config.Governance}
+	_, err = New(config)
+	s.Require().Error(err)
+}
+
+func (s *EngineTestSuite) TestLifecycle() {
+	e, err := New(s.newConfig())
+	s.Require().NoError(err)
+	s.Require().Equal(ErrNotStarted, e.Stop(context.Background()))
+	e.ProposePayloadSource(payloadSource{})
+	s.Require().NoError(e.Start())
+	s.Require().Equal(ErrAlreadyStarted, e.Start())
+	s.Require().True(e.Status().Running)
+	sub := e.Subscribe(types.GenesisHeight)
+	select {
+	case b := <-sub.Blocks():
+		s.Require().Equal(types.GenesisHeight, b.Position.Height)
+		s.Require().Equal([]byte("payload"), b.Payload)
+	case <-time.After(time.Minute):
+		s.FailNow("timeout")
+	}
+	sub.Unsubscribe()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s.Require().NoError(e.Stop(ctx))
+	s.Require().False(e.Status().Running)
+	s.Require().Equal(ErrStopped, e.Start())
+}
+
+func (s *EngineTestSuite) TestOptionalInterfaces() {
+	config := s.newConfig()
+	app := &metaApp{
+		App:       test.NewApp(0, nil, nil),
+		confirmed: make(chan common.Hash, 1),
+	}
+	config.Application = app
+	e, err := New(config)
+	s.Require().NoError(err)
+	e.ProposePayloadSource(payloadSource{})
+	s.Require().NoError(e.Start())
+	select {
+	case <-app.confirmed:
+	case <-time.After(time.Minute):
+		s.FailNow("optional interface is not detected")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s.Require().NoError(e.Stop(ctx))
+}
+
+func TestEngine(t *testing.T) {
+	suite.Run(t, new(EngineTestSuite))
+}
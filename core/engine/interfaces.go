@@ -0,0 +1,161 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+)
+
+// Interfaces of this package are kept as they are when those of package core
+// are changed, the compiler rejects the build when they are no longer
+// compatible.
+var (
+	_ core.Application = Application(nil)
+	_ core.Governance  = Governance(nil)
+	_ core.Network     = Network(nil)
+	_ core.Metrics     = Metrics(nil)
+)
+
+// Application is the interface for the application running on consensus.
+type Application interface {
+	// PreparePayload is called when preparing a block at the position.
+	PreparePayload(position types.Position) ([]byte, error)
+
+	// PrepareWitness returns the witness data no lower than consensusHeight.
+	PrepareWitness(consensusHeight uint64) (types.Witness, error)
+
+	// VerifyBlock verifies if the block is valid.
+	VerifyBlock(block *types.Block) types.BlockVerifyStatus
+
+	// BlockConfirmed is called when a block is confirmed.
+	BlockConfirmed(block types.Block)
+
+	// BlockDelivered is called when a block is delivered with randomness.
+	BlockDelivered(hash common.Hash, position types.Position, rand []byte)
+}
+
+// Governance is the interface for the governance contract, which keeps
+// configurations, node sets and DKG results of rounds.
+type Governance interface {
+	// Configuration returns the configuration at a given round.
+	Configuration(round uint64) *types.Config
+
+	// CRS returns the CRS for a given round.
+	CRS(round uint64) common.Hash
+
+	// ProposeCRS proposes the CRS of a round.
+	ProposeCRS(round uint64, signedCRS []byte)
+
+	// NodeSet returns the node set at a given round.
+	NodeSet(round uint64) []crypto.PublicKey
+
+	// GetRoundHeight returns the begin height of a round.
+	GetRoundHeight(round uint64) uint64
+
+	// AddDKGComplaint adds a DKG complaint.
+	AddDKGComplaint(complaint *typesDKG.Complaint)
+
+	// DKGComplaints gets all DKG complaints of a round.
+	DKGComplaints(round uint64) []*typesDKG.Complaint
+
+	// AddDKGMasterPublicKey adds a DKG master public key.
+	AddDKGMasterPublicKey(masterPublicKey *typesDKG.MasterPublicKey)
+
+	// DKGMasterPublicKeys gets all DKG master public keys of a round.
+	DKGMasterPublicKeys(round uint64) []*typesDKG.MasterPublicKey
+
+	// AddDKGMPKReady adds a DKG ready message.
+	AddDKGMPKReady(ready *typesDKG.MPKReady)
+
+	// IsDKGMPKReady checks if master public keys of DKG are ready.
+	IsDKGMPKReady(round uint64) bool
+
+	// AddDKGFinalize adds a DKG finalize message.
+	AddDKGFinalize(final *typesDKG.Finalize)
+
+	// IsDKGFinal checks if DKG is final.
+	IsDKGFinal(round uint64) bool
+
+	// AddDKGSuccess adds a DKG success message.
+	AddDKGSuccess(success *typesDKG.Success)
+
+	// IsDKGSuccess checks if DKG is success.
+	IsDKGSuccess(round uint64) bool
+
+	// ReportForkVote reports a node for forking votes.
+	ReportForkVote(vote1, vote2 *types.Vote)
+
+	// ReportForkBlock reports a node for forking blocks.
+	ReportForkBlock(block1, block2 *types.Block)
+
+	// ResetDKG resets latest DKG data and proposes a new CRS.
+	ResetDKG(newSignedCRS []byte)
+
+	// DKGResetCount returns the reset count for DKG of a round.
+	DKGResetCount(round uint64) uint64
+}
+
+// Network is the interface for the network carrying consensus messages.
+type Network interface {
+	// PullBlocks tries to pull blocks from peers.
+	PullBlocks(hashes common.Hashes)
+
+	// PullVotes tries to pull votes from peers.
+	PullVotes(position types.Position)
+
+	// BroadcastVote broadcasts a vote to all nodes.
+	BroadcastVote(vote *types.Vote)
+
+	// BroadcastBlock broadcasts a block to all nodes.
+	BroadcastBlock(block *types.Block)
+
+	// BroadcastAgreementResult broadcasts an agreement result to DKG set.
+	BroadcastAgreementResult(randRequest *types.AgreementResult)
+
+	// SendDKGPrivateShare sends a private share to a DKG participant.
+	SendDKGPrivateShare(pub crypto.PublicKey, prvShare *typesDKG.PrivateShare)
+
+	// BroadcastDKGPrivateShare broadcasts a private share to all DKG
+	// participants.
+	BroadcastDKGPrivateShare(prvShare *typesDKG.PrivateShare)
+
+	// BroadcastDKGPartialSignature broadcasts a partial signature to all DKG
+	// participants.
+	BroadcastDKGPartialSignature(psig *typesDKG.PartialSignature)
+
+	// ReceiveChan returns a channel to receive messages from peers.
+	ReceiveChan() <-chan types.Msg
+
+	// ReportBadPeerChan returns a channel to report bad peers.
+	ReportBadPeerChan() chan<- interface{}
+}
+
+// Metrics receives measurements of consensus.
+type Metrics interface {
+	// ObserveDuration records the time spent in a stage.
+	ObserveDuration(stage string, duration time.Duration)
+
+	// IncCounter increases a counter by delta.
+	IncCounter(name string, delta uint64)
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"github.com/dexon-foundation/dexon-consensus/core"
+)
+
+// Option customizes an engine.
+type Option struct {
+	opt core.Option
+}
+
+// WithMetrics reports measurements of consensus to metrics.
+func WithMetrics(metrics Metrics) Option {
+	return Option{opt: core.WithMetrics(metrics)}
+}
+
+// WithNullBlockProposal proposes the null block when there is nothing to
+// propose.
+func WithNullBlockProposal() Option {
+	return Option{opt: core.WithNullBlockProposal()}
+}
+
+// WithVerifierPool sets the count of workers verifying DKG messages, the
+// count of CPUs is used when workers is not positive.
+func WithVerifierPool(workers int) Option {
+	return Option{opt: core.WithVerifierPool(workers)}
+}
+
+// WithoutDKG stops the node from joining DKG even when selected.
+func WithoutDKG() Option {
+	return Option{opt: core.WithoutDKG()}
+}
+
+// WithProposeJitter spreads proposals of nodes in the window of ratio times
+// lambda BA, ratio should be in [0, 1).
+func WithProposeJitter(ratio float64) Option {
+	return Option{opt: core.WithProposeJitter(ratio)}
+}
+
+// WithStaleCutoff drops votes and blocks for positions more than cutoff
+// heights behind the last delivered block. Zero disables the cutoff.
+func WithStaleCutoff(cutoff uint64) Option {
+	return Option{opt: core.WithStaleCutoff(cutoff)}
+}
+
+// WithCatchUp stops participating BA to catch up when lagging behind the
+// network by more than lag heights. It's disabled by default.
+func WithCatchUp(lag uint64) Option {
+	return Option{opt: core.WithCatchUp(lag)}
+}
+
+// WithPipelinedBA lets BA start the next height as soon as the previous block
+// is confirmed.
+func WithPipelinedBA() Option {
+	return Option{opt: core.WithPipelinedBA()}
+}
+
+// WithArchive keeps agreement results of heights in [begin, end) instead of
+// purging them, the end is unbounded when zero.
+func WithArchive(begin, end uint64) Option {
+	return Option{opt: core.WithArchive(begin, end)}
+}
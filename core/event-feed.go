@@ -0,0 +1,197 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// maxEventBacklog is the count of events allowed to be buffered for a
+// subscriber, a subscriber lagging more than that is closed.
+const maxEventBacklog = 256
+
+// EventType is the type of events emitted by consensus.
+type EventType int
+
+// EventType enum.
+const (
+	// EventBADecided is emitted when BA confirms a block of a position.
+	EventBADecided EventType = iota
+	// EventRoundAdvanced is emitted when a round begins.
+	EventRoundAdvanced
+	// EventDKGFinished is emitted when DKG run by this node finishes.
+	EventDKGFinished
+	// EventBlockFinalized is emitted when a block is finalized and delivered
+	// by the compaction chain.
+	EventBlockFinalized
+	// EventCatchUpStarted is emitted when this node stops participating BA
+	// to catch up with the network, see WithCatchUp.
+	EventCatchUpStarted
+	// EventCatchUpFinished is emitted when this node rejoins BA.
+	EventCatchUpFinished
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventBADecided:
+		return "BADecided"
+	case EventRoundAdvanced:
+		return "RoundAdvanced"
+	case EventDKGFinished:
+		return "DKGFinished"
+	case EventBlockFinalized:
+		return "BlockFinalized"
+	case EventCatchUpStarted:
+		return "CatchUpStarted"
+	case EventCatchUpFinished:
+		return "CatchUpFinished"
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event is an event emitted by consensus, fields not related to its type are
+// left empty.
+type Event struct {
+	Type EventType
+	// Position and BlockHash are set for EventBADecided and
+	// EventBlockFinalized, Position is the network frontier for
+	// EventCatchUpStarted and EventCatchUpFinished.
+	Position  types.Position
+	BlockHash common.Hash
+	// Round is set for EventRoundAdvanced and EventDKGFinished, and Reset is
+	// set for EventDKGFinished.
+	Round uint64
+	Reset uint64
+}
+
+func (e Event) String() string {
+	switch e.Type {
+	case EventBADecided, EventBlockFinalized:
+		return fmt.Sprintf("Event{%s Block:%s Pos:%s}",
+			e.Type, e.BlockHash.String()[:6], e.Position)
+	case EventDKGFinished:
+		return fmt.Sprintf("Event{%s Round:%d Reset:%d}",
+			e.Type, e.Round, e.Reset)
+	case EventCatchUpStarted, EventCatchUpFinished:
+		return fmt.Sprintf("Event{%s Frontier:%s}", e.Type, e.Position)
+	}
+	return fmt.Sprintf("Event{%s Round:%d}", e.Type, e.Round)
+}
+
+// EventSubscription is a stream of events of subscribed types.
+type EventSubscription struct {
+	feed  *eventFeed
+	types map[EventType]struct{}
+	ch    chan Event
+	err   error
+}
+
+// Events returns the channel of events, it's closed when the subscription
+// ends, and Err tells the reason.
+func (s *EventSubscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Err returns the reason why the subscription ends.
+func (s *EventSubscription) Err() error {
+	s.feed.lock.Lock()
+	defer s.feed.lock.Unlock()
+	return s.err
+}
+
+// Unsubscribe stops the subscription.
+func (s *EventSubscription) Unsubscribe() {
+	s.feed.lock.Lock()
+	defer s.feed.lock.Unlock()
+	s.feed.removeNoLock(s, ErrSubscriptionCancelled)
+}
+
+// eventFeed dispatches events to subscribers without blocking emitters.
+type eventFeed struct {
+	lock   sync.Mutex
+	subs   map[*EventSubscription]struct{}
+	closed bool
+}
+
+func newEventFeed() *eventFeed {
+	return &eventFeed{subs: make(map[*EventSubscription]struct{})}
+}
+
+// subscribe subscribes events of given types, all types are subscribed when
+// none is given.
+func (f *eventFeed) subscribe(eventTypes ...EventType) *EventSubscription {
+	s := &EventSubscription{
+		feed:  f,
+		types: make(map[EventType]struct{}),
+		ch:    make(chan Event, maxEventBacklog),
+	}
+	for _, t := range eventTypes {
+		s.types[t] = struct{}{}
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.closed {
+		s.err = ErrSubscriptionCancelled
+		close(s.ch)
+		return s
+	}
+	f.subs[s] = struct{}{}
+	return s
+}
+
+func (f *eventFeed) removeNoLock(s *EventSubscription, err error) {
+	if _, exist := f.subs[s]; !exist {
+		return
+	}
+	delete(f.subs, s)
+	s.err = err
+	close(s.ch)
+}
+
+// publish dispatches an event to subscribers, subscribers whose buffer is
+// full are closed by ErrSubscriptionLagging.
+func (f *eventFeed) publish(e Event) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for s := range f.subs {
+		if len(s.types) > 0 {
+			if _, exist := s.types[e.Type]; !exist {
+				continue
+			}
+		}
+		select {
+		case s.ch <- e:
+		default:
+			f.removeNoLock(s, ErrSubscriptionLagging)
+		}
+	}
+}
+
+// close ends all subscriptions, later subscriptions end immediately.
+func (f *eventFeed) close() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for s := range f.subs {
+		f.removeNoLock(s, ErrSubscriptionCancelled)
+	}
+	f.closed = true
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type EventFeedTestSuite struct {
+	suite.Suite
+}
+
+func (s *EventFeedTestSuite) TestFilterByType() {
+	f := newEventFeed()
+	all := f.subscribe()
+	rounds := f.subscribe(EventRoundAdvanced, EventDKGFinished)
+	f.publish(Event{Type: EventBADecided, BlockHash: common.NewRandomHash()})
+	f.publish(Event{Type: EventRoundAdvanced, Round: 1})
+	f.publish(Event{Type: EventDKGFinished, Round: 2})
+	s.Require().Len(all.Events(), 3)
+	s.Require().Len(rounds.Events(), 2)
+	e := <-rounds.Events()
+	s.Require().Equal(EventRoundAdvanced, e.Type)
+	s.Require().Equal(uint64(1), e.Round)
+	e = <-rounds.Events()
+	s.Require().Equal(EventDKGFinished, e.Type)
+}
+
+func (s *EventFeedTestSuite) TestLagging() {
+	f := newEventFeed()
+	sub := f.subscribe(EventBlockFinalized)
+	for i := 0; i <= maxEventBacklog; i++ {
+		f.publish(Event{
+			Type:     EventBlockFinalized,
+			Position: types.Position{Height: uint64(i)},
+		})
+	}
+	count := 0
+	for range sub.Events() {
+		count++
+	}
+	s.Require().Equal(maxEventBacklog, count)
+	s.Require().Equal(ErrSubscriptionLagging, sub.Err())
+	// Publishing to closed subscribers is fine.
+	f.publish(Event{Type: EventBlockFinalized})
+}
+
+func (s *EventFeedTestSuite) TestUnsubscribe() {
+	f := newEventFeed()
+	sub := f.subscribe()
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	_, ok := <-sub.Events()
+	s.Require().False(ok)
+	s.Require().Equal(ErrSubscriptionCancelled, sub.Err())
+	// Subscriptions end when the feed is closed.
+	sub = f.subscribe()
+	f.close()
+	_, ok = <-sub.Events()
+	s.Require().False(ok)
+	sub = f.subscribe()
+	_, ok = <-sub.Events()
+	s.Require().False(ok)
+	s.Require().Equal(ErrSubscriptionCancelled, sub.Err())
+}
+
+func TestEventFeed(t *testing.T) {
+	suite.Run(t, new(EventFeedTestSuite))
+}
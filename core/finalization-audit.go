@@ -0,0 +1,181 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Errors for finalization audit.
+var (
+	ErrNonMonotonicFinalization = errors.New(
+		"finalized height is not monotonic")
+)
+
+// maxFinalizationRecords is the count of finalization records kept, older
+// ones would be dropped.
+const maxFinalizationRecords = 10000
+
+// FinalizationSource describes how a block is finalized by this node.
+type FinalizationSource int
+
+// FinalizationSource enum.
+const (
+	// FinalizationSourceUnknown means the block is delivered before any
+	// provenance is recorded, ex. blocks before DKGDelayRound confirmed by
+	// syncing.
+	FinalizationSourceUnknown FinalizationSource = iota
+	// FinalizationSourceBA means the block is confirmed by BA of this node,
+	// and the randomness is recovered from votes.
+	FinalizationSourceBA
+	// FinalizationSourceAgreementResult means the block is finalized by an
+	// agreement result from peers.
+	FinalizationSourceAgreementResult
+	// FinalizationSourceTSig means the randomness is generated by the TSIG
+	// protocol run by this node.
+	FinalizationSourceTSig
+	// FinalizationSourceFinalizedBlock means the block is received from peers
+	// with randomness.
+	FinalizationSourceFinalizedBlock
+)
+
+func (s FinalizationSource) String() string {
+	switch s {
+	case FinalizationSourceBA:
+		return "ba"
+	case FinalizationSourceAgreementResult:
+		return "agreement-result"
+	case FinalizationSourceTSig:
+		return "tsig"
+	case FinalizationSourceFinalizedBlock:
+		return "finalized-block"
+	}
+	return "unknown"
+}
+
+// FinalizationRecord records the provenance of a finalized block.
+type FinalizationRecord struct {
+	// Height is the height the block is delivered at.
+	Height uint64
+	// Hash is the hash of the block.
+	Hash common.Hash
+	// Position is the position of the block.
+	Position types.Position
+	// Proposer is the node proposing this block, empty for empty blocks.
+	Proposer types.NodeID
+	// WitnessHeight is the height acked by the witness of this block.
+	WitnessHeight uint64
+	// Source is how this block is finalized.
+	Source FinalizationSource
+	// Period is the period of BA when the block is confirmed, it's only
+	// available when the source is FinalizationSourceBA.
+	Period uint64
+	// Signers are nodes whose votes, or partial signatures of TSIG,
+	// contribute to the finalization. It's empty when unknown locally.
+	Signers []types.NodeID
+	// Time is when the block is delivered.
+	Time time.Time
+}
+
+type finalizationProvenance struct {
+	position types.Position
+	source   FinalizationSource
+	period   uint64
+	signers  []types.NodeID
+}
+
+// finalizationAudit keeps the provenance of delivered blocks, and makes sure
+// heights are assigned monotonically.
+type finalizationAudit struct {
+	lock        sync.RWMutex
+	provenances map[common.Hash]finalizationProvenance
+	records     map[uint64]FinalizationRecord
+	lastHeight  uint64
+}
+
+func newFinalizationAudit() *finalizationAudit {
+	return &finalizationAudit{
+		provenances: make(map[common.Hash]finalizationProvenance),
+		records:     make(map[uint64]FinalizationRecord),
+	}
+}
+
+// recordProvenance records how a block is finalized before it's delivered,
+// the first record of a block is kept.
+func (a *finalizationAudit) recordProvenance(hash common.Hash,
+	pos types.Position, source FinalizationSource, period uint64,
+	signers []types.NodeID) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if pos.Height <= a.lastHeight {
+		return
+	}
+	if _, exist := a.provenances[hash]; exist {
+		return
+	}
+	a.provenances[hash] = finalizationProvenance{
+		position: pos,
+		source:   source,
+		period:   period,
+		signers:  signers,
+	}
+}
+
+// deliver creates the finalization record of a delivered block.
+func (a *finalizationAudit) deliver(b *types.Block) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.lastHeight != 0 && b.Position.Height != a.lastHeight+1 {
+		return ErrNonMonotonicFinalization
+	}
+	a.lastHeight = b.Position.Height
+	rec := FinalizationRecord{
+		Height:        b.Position.Height,
+		Hash:          b.Hash,
+		Position:      b.Position,
+		Proposer:      b.ProposerID,
+		WitnessHeight: b.Witness.Height,
+		Time:          time.Now().UTC(),
+	}
+	if p, exist := a.provenances[b.Hash]; exist {
+		rec.Source = p.source
+		rec.Period = p.period
+		rec.Signers = p.signers
+	}
+	a.records[rec.Height] = rec
+	delete(a.records, rec.Height-maxFinalizationRecords)
+	for hash, p := range a.provenances {
+		if p.position.Height <= a.lastHeight {
+			delete(a.provenances, hash)
+		}
+	}
+	return nil
+}
+
+func (a *finalizationAudit) record(height uint64) (
+	rec FinalizationRecord, exist bool) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	rec, exist = a.records[height]
+	return
+}
@@ -0,0 +1,99 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type FinalizationAuditTestSuite struct {
+	suite.Suite
+}
+
+func (s *FinalizationAuditTestSuite) newBlock(height uint64) *types.Block {
+	return &types.Block{
+		Hash:       common.NewRandomHash(),
+		ProposerID: types.NodeID{Hash: common.NewRandomHash()},
+		Position:   types.Position{Height: height},
+		Witness:    types.Witness{Height: height - 1},
+	}
+}
+
+func (s *FinalizationAuditTestSuite) TestDeliver() {
+	audit := newFinalizationAudit()
+	b1, b2, b3 := s.newBlock(1), s.newBlock(2), s.newBlock(3)
+	signers := []types.NodeID{
+		types.NodeID{Hash: common.NewRandomHash()},
+		types.NodeID{Hash: common.NewRandomHash()},
+	}
+	audit.recordProvenance(
+		b1.Hash, b1.Position, FinalizationSourceBA, 2, signers)
+	// The first provenance is kept.
+	audit.recordProvenance(
+		b1.Hash, b1.Position, FinalizationSourceAgreementResult, 0, nil)
+	audit.recordProvenance(
+		b2.Hash, b2.Position, FinalizationSourceTSig, 0, nil)
+	s.Require().NoError(audit.deliver(b1))
+	rec, exist := audit.record(1)
+	s.Require().True(exist)
+	s.Equal(b1.Hash, rec.Hash)
+	s.Equal(b1.ProposerID, rec.Proposer)
+	s.Equal(uint64(0), rec.WitnessHeight)
+	s.Equal(FinalizationSourceBA, rec.Source)
+	s.Equal(uint64(2), rec.Period)
+	s.Equal(signers, rec.Signers)
+	// Heights should be assigned monotonically.
+	s.Equal(ErrNonMonotonicFinalization, audit.deliver(b3))
+	s.Require().NoError(audit.deliver(b2))
+	rec, exist = audit.record(2)
+	s.Require().True(exist)
+	s.Equal(FinalizationSourceTSig, rec.Source)
+	s.Equal("tsig", rec.Source.String())
+	s.Empty(rec.Signers)
+	s.Require().NoError(audit.deliver(b3))
+	rec, exist = audit.record(3)
+	s.Require().True(exist)
+	s.Equal(FinalizationSourceUnknown, rec.Source)
+	_, exist = audit.record(4)
+	s.False(exist)
+	// Provenances of delivered heights are ignored.
+	audit.recordProvenance(
+		b1.Hash, b1.Position, FinalizationSourceBA, 0, nil)
+	s.Empty(audit.provenances)
+}
+
+func (s *FinalizationAuditTestSuite) TestPurge() {
+	audit := newFinalizationAudit()
+	for h := uint64(1); h <= maxFinalizationRecords+10; h++ {
+		s.Require().NoError(audit.deliver(s.newBlock(h)))
+	}
+	s.Len(audit.records, maxFinalizationRecords)
+	_, exist := audit.record(10)
+	s.False(exist)
+	_, exist = audit.record(11)
+	s.True(exist)
+}
+
+func TestFinalizationAudit(t *testing.T) {
+	suite.Run(t, new(FinalizationAuditTestSuite))
+}
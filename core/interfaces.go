@@ -54,6 +54,178 @@ type Debug interface {
 	BlockReady(common.Hash)
 }
 
+// BlockConfirmMeta carries the information about how a block is confirmed by
+// BA.
+type BlockConfirmMeta struct {
+	// Period is the period of BA when the block is confirmed.
+	Period uint64
+	// Duration is the time elapsed since BA started at that position.
+	Duration time.Duration
+	// VoterCount is the count of votes for that block when confirmed.
+	VoterCount int
+}
+
+// BlockConfirmMetaReceiver is an optional interface for Application to
+// receive meta of blocks confirmed by BA of this node.
+type BlockConfirmMetaReceiver interface {
+	// BlockConfirmedWithMeta is called when a block is confirmed by BA.
+	BlockConfirmedWithMeta(hash common.Hash, meta BlockConfirmMeta)
+}
+
+// BatchDeliveryReceiver is an optional interface for Application to receive
+// delivered blocks in batches, ex. thousands of blocks might be delivered at
+// once after catching up. When implemented, BlocksDelivered is called instead
+// of BlockDelivered.
+type BatchDeliveryReceiver interface {
+	// BlocksDelivered is called when blocks are added to the compaction chain,
+	// blocks are sorted by height.
+	BlocksDelivered(blocks []*types.Block)
+}
+
+// Keys of DeliveryMeta.
+const (
+	// DeliveryMetaBatchSeq is the sequence number of a delivered batch as
+	// uint64, it starts from zero and is increased by one per batch delivered
+	// by this node. Batches differ between nodes, ex. a node catching up
+	// receives larger batches.
+	DeliveryMetaBatchSeq = "batch-seq"
+	// DeliveryMetaOrdering is the ordering mode of blocks in a batch as
+	// string.
+	DeliveryMetaOrdering = "ordering"
+	// DeliveryMetaWithheld is the count of finalized blocks withheld by the
+	// randomness reveal delay when this batch is delivered, as uint64.
+	DeliveryMetaWithheld = "withheld"
+)
+
+// DeliveryOrderingHeight is the ordering mode that blocks are sorted by
+// heights confirmed by BA, which is the same on all nodes.
+const DeliveryOrderingHeight = "height"
+
+// DeliveryMeta is the metadata of a delivered batch, keys are defined by
+// DeliveryMeta* constants. Keys might be added in later versions, unknown
+// keys should be ignored.
+type DeliveryMeta map[string]interface{}
+
+// BatchDeliveryMetaReceiver is an optional interface for Application to
+// receive delivered batches with their metadata, ex. to implement
+// deterministic ordering policies of transactions in a batch. When
+// implemented, BlocksDeliveredWithMeta is called instead of BlocksDelivered
+// and BlockDelivered.
+type BatchDeliveryMetaReceiver interface {
+	// BlocksDeliveredWithMeta is called when blocks are added to the
+	// compaction chain, blocks are sorted by height.
+	BlocksDeliveredWithMeta(blocks []*types.Block, meta DeliveryMeta)
+}
+
+// SystemMessageReceiver is an optional interface for Application to receive
+// system messages carried by delivered blocks, which are proposed by
+// Consensus.ProposeSystemMessage.
+type SystemMessageReceiver interface {
+	// SystemMessagesDelivered is called right before the block carrying these
+	// messages is delivered, messages are in the order they are proposed.
+	SystemMessagesDelivered(
+		hash common.Hash, position types.Position, msgs [][]byte)
+}
+
+// PayloadChecker is an optional interface for Application to tell if there is
+// anything to propose. When WithNullBlockProposal is enabled, the null block is
+// proposed instead of building a block when nothing is available.
+type PayloadChecker interface {
+	// HasPayload returns false when no payload would be prepared for the
+	// block at the position.
+	HasPayload(position types.Position) bool
+}
+
+// WitnessVetoer is an optional interface for Application to validate witness
+// data before acking it in blocks proposed by this node. When the ack of a
+// witness is withheld, the witness of the parent block is carried instead.
+type WitnessVetoer interface {
+	// WitnessAckDelay returns the duration a delivered block should wait
+	// before the witness referring to it could be acked.
+	WitnessAckDelay() time.Duration
+	// VetoWitness returns true to withhold the ack of a witness.
+	VetoWitness(witness types.Witness) bool
+}
+
+// CRSFallbackReceiver is an optional interface for Application to be
+// alerted when governance fails to publish CRS before the deadline and a
+// fallback CRS is proposed to governance.
+type CRSFallbackReceiver interface {
+	// CRSFallback is called when the fallback CRS of a round is proposed.
+	CRSFallback(round uint64, crs common.Hash)
+}
+
+// DKGMissReceiver is an optional interface for Application to be alerted
+// when this node is selected as notary of a round but fails to join its DKG.
+// The node participates BA of that round as a non-signer, and rejoins DKG of
+// later rounds when selected.
+type DKGMissReceiver interface {
+	// DKGMissed is called when the DKG of a round is missed.
+	DKGMissed(round, reset uint64, reason error)
+}
+
+// UnknownMessageReceiver is an optional interface for Application to be
+// alerted when messages of unknown types are received in a burst, ex. peers
+// upgraded to a newer protocol. The threshold is set by WithQuarantine.
+type UnknownMessageReceiver interface {
+	// UnknownMessagesSpiked is called when the count of unknown messages
+	// received in a window reaches the threshold.
+	UnknownMessagesSpiked(count int, window time.Duration)
+}
+
+// VersionOutdatedReceiver is an optional interface for Application to be
+// notified when this node halts since its ProtocolVersion is lower than the
+// one required by governance. Application should call Consensus.Stop and
+// upgrade the node.
+type VersionOutdatedReceiver interface {
+	// ProtocolVersionOutdated is called when the round requiring a newer
+	// protocol version begins.
+	ProtocolVersionOutdated(round uint64, required uint32)
+}
+
+// FatalErrorReceiver is an optional interface for Application to decide what
+// to do when consensus halts for an unrecoverable error, ex. failing to write
+// delivered blocks to database after retries. Consensus panics on such errors
+// when Application doesn't implement this interface.
+type FatalErrorReceiver interface {
+	// FatalError is called once consensus halts, Application should call
+	// Consensus.Stop and might restart this node.
+	FatalError(err error)
+}
+
+// NotarySetChangeReceiver is an optional interface for Application to be
+// notified when the notary set rotates at round boundaries, ex. to update
+// reward tables or validator-facing UI.
+type NotarySetChangeReceiver interface {
+	// NotarySetChanged is called when a round begins with the nodes joining
+	// and leaving the notary set compared to the previous round, both sorted.
+	// It's not called when the notary set is unchanged.
+	NotarySetChanged(round uint64, added, removed types.NodeIDs)
+}
+
+// CallbackConfig describes how callbacks to Application are dispatched.
+type CallbackConfig struct {
+	// ConfirmWorkers is the count of goroutines calling BlockConfirmed and
+	// BlockConfirmedWithMeta when they are made non-blocking. These methods
+	// would be called concurrently when more than one worker is configured.
+	// BlockDelivered is always called in order by a dedicated goroutine, after
+	// confirmations issued before it.
+	ConfirmWorkers int
+	// RandomnessRevealDelay is the count of blocks a finalized block waits
+	// before it's delivered with its randomness, ex. when it's 2, block at
+	// height 10 is delivered after block at height 12 is finalized. It's for
+	// commit-reveal use cases where the randomness of a block must not be
+	// available when proposing blocks right after it.
+	RandomnessRevealDelay uint64
+}
+
+// CallbackConfigurer is an optional interface for Application to configure
+// the dispatching of its callbacks.
+type CallbackConfigurer interface {
+	// CallbackConfig is called when the consensus core is constructed.
+	CallbackConfig() CallbackConfig
+}
+
 // Network describs the network interface that interacts with DEXON consensus
 // core.
 type Network interface {
@@ -89,6 +261,98 @@ type Network interface {
 	ReportBadPeerChan() chan<- interface{}
 }
 
+// NamespacedNetwork is an optional interface for Network implementations
+// hosting several consensus instances, ex. different shards. Each instance
+// should be given a Network of its own namespace, and messages from other
+// namespaces should never be delivered through its ReceiveChan.
+type NamespacedNetwork interface {
+	// Namespace returns the namespace of the consensus instance.
+	Namespace() string
+}
+
+// DKGArtifactsPuller is an optional interface for Network to pull DKG
+// artifacts from peers. A node whose governance knows hashes of some master
+// public keys or complaints but not their content, ex. a light client syncing
+// state lazily, could fill them in this way.
+//
+// Pulled artifacts should be delivered by ReceiveChan as
+// *typesDKG.Artifacts, and Consensus.DKGArtifacts could be used to serve
+// requests from peers. Pulled artifacts are only used when Governance
+// implements DKGArtifactDigester.
+type DKGArtifactsPuller interface {
+	// GetDKGArtifacts tries to pull DKG artifacts of a round from peers.
+	GetDKGArtifacts(round uint64)
+}
+
+// DKGArtifactDigester is an optional interface for Governance to report
+// hashes of DKG artifacts recorded for a round, ex. when the content of them
+// is not synced yet. Qualification and the group public key are always
+// derived from artifacts recorded by governance, a pulled artifact is used
+// only when its hash is reported here and governance misses its content.
+type DKGArtifactDigester interface {
+	// DKGMasterPublicKeyHashes returns hashes of master public keys of a
+	// round recorded by governance, hashed by utils.HashDKGMasterPublicKey.
+	DKGMasterPublicKeyHashes(round uint64) common.Hashes
+	// DKGComplaintHashes returns hashes of complaints of a round recorded by
+	// governance, hashed by utils.HashDKGComplaint.
+	DKGComplaintHashes(round uint64) common.Hashes
+}
+
+// AgreementResultPuller is an optional interface for Network to pull agreement
+// results from peers. A node lagging behind could confirm blocks by results
+// certified by others instead of collecting votes again.
+//
+// Pulled results should be delivered by ReceiveChan as
+// *types.AgreementResult, and Consensus.AgreementResult could be used to
+// serve requests from peers.
+type AgreementResultPuller interface {
+	// PullAgreementResults tries to pull agreement results of positions from
+	// peers.
+	PullAgreementResults(positions []types.Position)
+}
+
+// WatermarkGossiper is an optional interface for Network to gossip watermarks,
+// which lets nodes estimate the progress of the network. Watermarks from peers
+// should be delivered by ReceiveChan as *types.Watermark.
+type WatermarkGossiper interface {
+	// BroadcastWatermark broadcasts the watermark of this node.
+	BroadcastWatermark(watermark *types.Watermark)
+}
+
+// LatencyProber is an optional interface for Network to measure round-trip
+// latencies between notary set members by pings. Pings and pongs from peers
+// should be delivered by ReceiveChan as *types.Ping.
+type LatencyProber interface {
+	// SendPing sends a ping or a pong to a node.
+	SendPing(to types.NodeID, ping *types.Ping)
+}
+
+// ReadinessAnnouncer is an optional interface for Network to announce the
+// readiness of this node before the first DKG, which waits for a quorum of its
+// participants. Readiness from peers should be delivered by ReceiveChan as
+// *types.Readiness. The first DKG is started without waiting when Network
+// doesn't implement it.
+type ReadinessAnnouncer interface {
+	// BroadcastReadiness broadcasts the readiness of this node.
+	BroadcastReadiness(readiness *types.Readiness)
+}
+
+// Metrics receives measurements of the consensus pipeline.
+type Metrics interface {
+	// ObserveDuration records the time spent in a stage.
+	ObserveDuration(stage string, duration time.Duration)
+
+	// IncCounter increases a counter by delta.
+	IncCounter(name string, delta uint64)
+}
+
+// GaugeMetrics is an optional interface for Metrics to receive gauges, which
+// are sampled periodically.
+type GaugeMetrics interface {
+	// SetGauge sets the current value of a gauge.
+	SetGauge(name string, value float64)
+}
+
 // Governance interface specifies interface to control the governance contract.
 // Note that there are a lot more methods in the governance contract, that this
 // interface only define those that are required to run the consensus algorithm.
@@ -160,6 +424,68 @@ type Governance interface {
 	DKGResetCount(round uint64) uint64
 }
 
+// LeaderMissReporter is an optional interface for Governance to receive the
+// count of positions, in a round, that selected leaders failed to deliver
+// their proposals within lambda. Governance could rotate chronically offline
+// leaders out by these reports.
+type LeaderMissReporter interface {
+	// ReportLeaderMiss is called by notary set of a round when that round
+	// is finished by BA.
+	ReportLeaderMiss(round uint64, misses map[types.NodeID]uint64)
+}
+
+// ForkEvidenceReporter is an optional interface for Governance to receive
+// evidences of vote equivocations signed by this node, which could be
+// submitted on-chain to slash the offender. ReportForkVote is still called
+// for each equivocation.
+type ForkEvidenceReporter interface {
+	ReportForkEvidence(evidence *types.VoteEvidence)
+}
+
+// VRFLeaderGovernance is an optional interface for Governance to select
+// leaders by VRF. Leaders are always the proposals whose CRS signatures are
+// closest to CRS, and since DKGDelayRound a CRS signature is a unique
+// threshold signature that serves as the output of VRF. In rounds enabled by
+// this interface, the leader precomputed from CRS is not used, the leader of
+// a position is unknown until proposals are revealed. Fast BA and leader
+// misses are skipped in these rounds since no leader is known in advance.
+type VRFLeaderGovernance interface {
+	// VRFLeaderSelection returns true when the precomputed leader is not used
+	// in a round.
+	VRFLeaderSelection(round uint64) bool
+}
+
+// ProtocolVersionGovernance is an optional interface for Governance to
+// require a minimum ProtocolVersion for nodes participating each round.
+type ProtocolVersionGovernance interface {
+	// MinProtocolVersion returns the minimum ProtocolVersion required for a
+	// round, zero means no requirement.
+	MinProtocolVersion(round uint64) uint32
+}
+
+// CRSEntropySource is an optional interface for Governance to mix externally
+// supplied entropy, ex. the output of an application-layer VDF, into the CRS
+// of a round. The hash signed by notary set to derive CRS of that round is
+// MixCRSEntropy(CRS of previous round, entropy), governance should verify
+// signed CRS against the same hash.
+type CRSEntropySource interface {
+	// CRSEntropy returns the entropy to be mixed into the CRS of a round,
+	// nil or empty if there is none.
+	CRSEntropy(round uint64) []byte
+}
+
+// CRSFallbackGovernance is an optional interface for Governance to record a
+// fallback CRS for rounds whose CRS is not published before the deadline.
+// Governance should accept the proposal only when CRS of that round is still
+// missing and the proposed CRS is FallbackCRS(CRS of previous round), and
+// reject CRS of that round proposed later. Consensus never uses a fallback
+// CRS unless it's recorded by governance, thus all nodes, including syncing
+// ones, run that round with the same CRS.
+type CRSFallbackGovernance interface {
+	// ProposeCRSFallback proposes a fallback CRS of a round.
+	ProposeCRSFallback(round uint64, crs common.Hash)
+}
+
 // Ticker define the capability to tick by interval.
 type Ticker interface {
 	// Tick would return a channel, which would be triggered until next tick.
@@ -172,6 +498,15 @@ type Ticker interface {
 	Restart()
 }
 
+// IntervalTicker is an optional interface for Ticker to change its interval in
+// place when the lambda is changed by governance at round boundaries, rather
+// than being stopped and replaced by a new one.
+type IntervalTicker interface {
+	// SetInterval changes the interval of the ticker, it takes effect from
+	// the next Restart, thus ticks of the running period are not disturbed.
+	SetInterval(interval time.Duration)
+}
+
 // Recovery interface for interacting with recovery information.
 type Recovery interface {
 	// ProposeSkipBlock proposes a skip block.
@@ -0,0 +1,150 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+const (
+	// pingInterval is the interval to ping other notary set members.
+	pingInterval = 10 * time.Second
+	// pingTimeout is the duration to wait for a pong, pings not answered
+	// in time are dropped.
+	pingTimeout = 30 * time.Second
+	// latencySampleSize is the count of latest round-trip times kept for
+	// each peer.
+	latencySampleSize = 64
+)
+
+// PeerLatency is the percentiles of recent round-trip times to a peer.
+type PeerLatency struct {
+	P50     time.Duration
+	P90     time.Duration
+	P99     time.Duration
+	Samples int
+}
+
+type pendingPing struct {
+	target types.NodeID
+	sent   time.Time
+}
+
+// latencyTracker keeps pings waiting for pongs, and a window of round-trip
+// times to each peer.
+type latencyTracker struct {
+	lock    sync.Mutex
+	nonce   uint64
+	pending map[uint64]pendingPing
+	samples map[types.NodeID][]time.Duration
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		pending: make(map[uint64]pendingPing),
+		samples: make(map[types.NodeID][]time.Duration),
+	}
+}
+
+// ping records a ping sent to a peer and returns its nonce.
+func (t *latencyTracker) ping(target types.NodeID, now time.Time) uint64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.nonce++
+	t.pending[t.nonce] = pendingPing{target: target, sent: now}
+	return t.nonce
+}
+
+// pong matches a pong with the ping it answers, and records the round-trip
+// time. It returns false when no such ping is waiting.
+func (t *latencyTracker) pong(from types.NodeID, nonce uint64, now time.Time) (
+	time.Duration, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p, exist := t.pending[nonce]
+	if !exist || p.target != from {
+		return 0, false
+	}
+	delete(t.pending, nonce)
+	rtt := now.Sub(p.sent)
+	samples := append(t.samples[from], rtt)
+	if len(samples) > latencySampleSize {
+		copy(samples, samples[1:])
+		samples = samples[:latencySampleSize]
+	}
+	t.samples[from] = samples
+	return rtt, true
+}
+
+// expire drops pings not answered in time.
+func (t *latencyTracker) expire(now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for nonce, p := range t.pending {
+		if now.Sub(p.sent) > pingTimeout {
+			delete(t.pending, nonce)
+		}
+	}
+}
+
+// retain drops samples of peers not in the given set, ex. nodes leaving the
+// notary set.
+func (t *latencyTracker) retain(nodes map[types.NodeID]struct{}) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for nID := range t.samples {
+		if _, exist := nodes[nID]; !exist {
+			delete(t.samples, nID)
+		}
+	}
+}
+
+// latencies returns percentiles of round-trip times to each peer.
+func (t *latencyTracker) latencies() map[types.NodeID]PeerLatency {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	ret := make(map[types.NodeID]PeerLatency, len(t.samples))
+	for nID, samples := range t.samples {
+		sorted := append([]time.Duration(nil), samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		ret[nID] = PeerLatency{
+			P50:     percentile(sorted, 50),
+			P90:     percentile(sorted, 90),
+			P99:     percentile(sorted, 99),
+			Samples: len(sorted),
+		}
+	}
+	return ret
+}
+
+// percentile picks the p-th percentile from sorted durations by the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (len(sorted)*p + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,82 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type LatencyTrackerTestSuite struct {
+	suite.Suite
+}
+
+func (s *LatencyTrackerTestSuite) TestPercentiles() {
+	t := newLatencyTracker()
+	peer := types.NodeID{Hash: common.NewRandomHash()}
+	now := time.Now()
+	for i := 1; i <= 100; i++ {
+		nonce := t.ping(peer, now)
+		rtt, ok := t.pong(peer, nonce, now.Add(time.Duration(i)*time.Millisecond))
+		s.Require().True(ok)
+		s.Require().Equal(time.Duration(i)*time.Millisecond, rtt)
+	}
+	l := t.latencies()[peer]
+	// Only the latest samples are kept.
+	s.Require().Equal(latencySampleSize, l.Samples)
+	s.Require().Equal(68*time.Millisecond, l.P50)
+	s.Require().Equal(94*time.Millisecond, l.P90)
+	s.Require().Equal(100*time.Millisecond, l.P99)
+}
+
+func (s *LatencyTrackerTestSuite) TestPong() {
+	t := newLatencyTracker()
+	peer := types.NodeID{Hash: common.NewRandomHash()}
+	other := types.NodeID{Hash: common.NewRandomHash()}
+	now := time.Now()
+	nonce := t.ping(peer, now)
+	// Pong from other node.
+	_, ok := t.pong(other, nonce, now)
+	s.Require().False(ok)
+	// Unknown nonce.
+	_, ok = t.pong(peer, nonce+1, now)
+	s.Require().False(ok)
+	_, ok = t.pong(peer, nonce, now)
+	s.Require().True(ok)
+	// Answered twice.
+	_, ok = t.pong(peer, nonce, now)
+	s.Require().False(ok)
+	// Expired.
+	nonce = t.ping(peer, now)
+	t.expire(now.Add(pingTimeout + time.Second))
+	_, ok = t.pong(peer, nonce, now)
+	s.Require().False(ok)
+	// Peers leaving the notary set are dropped.
+	s.Require().Len(t.latencies(), 1)
+	t.retain(map[types.NodeID]struct{}{other: struct{}{}})
+	s.Require().Empty(t.latencies())
+}
+
+func TestLatencyTracker(t *testing.T) {
+	suite.Run(t, new(LatencyTrackerTestSuite))
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// leaderMissTracker aggregates, per round, the count of positions that the
+// selected leader failed to deliver its proposal within lambda.
+type leaderMissTracker struct {
+	lock    sync.Mutex
+	checked map[types.Position]struct{}
+	misses  map[uint64]map[types.NodeID]uint64
+}
+
+func newLeaderMissTracker() *leaderMissTracker {
+	return &leaderMissTracker{
+		checked: make(map[types.Position]struct{}),
+		misses:  make(map[uint64]map[types.NodeID]uint64),
+	}
+}
+
+// check records a miss for the leader of a position when its proposal is not
+// received within lambda since BA is restarted. Each position is only checked
+// once.
+func (t *leaderMissTracker) check(pos types.Position, leader types.NodeID,
+	restarted, received time.Time, lambda time.Duration) (missed bool) {
+	if isStop(pos) || (leader == types.NodeID{}) {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if _, exist := t.checked[pos]; exist {
+		return
+	}
+	t.checked[pos] = struct{}{}
+	if !received.IsZero() && received.Sub(restarted) <= lambda {
+		return
+	}
+	if _, exist := t.misses[pos.Round]; !exist {
+		t.misses[pos.Round] = make(map[types.NodeID]uint64)
+	}
+	t.misses[pos.Round][leader]++
+	missed = true
+	return
+}
+
+// purge returns misses aggregated for rounds no newer than the given round,
+// and removes them from the tracker.
+func (t *leaderMissTracker) purge(round uint64) (
+	misses map[uint64]map[types.NodeID]uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	misses = make(map[uint64]map[types.NodeID]uint64)
+	for r, m := range t.misses {
+		if r <= round {
+			misses[r] = m
+			delete(t.misses, r)
+		}
+	}
+	for pos := range t.checked {
+		if pos.Round <= round {
+			delete(t.checked, pos)
+		}
+	}
+	return
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type LeaderMissTestSuite struct {
+	suite.Suite
+}
+
+func (s *LeaderMissTestSuite) TestCheck() {
+	var (
+		req     = s.Require()
+		t       = newLeaderMissTracker()
+		lambda  = 250 * time.Millisecond
+		now     = time.Now().UTC()
+		leader1 = types.NodeID{Hash: common.NewRandomHash()}
+		leader2 = types.NodeID{Hash: common.NewRandomHash()}
+	)
+	// Proposal in time.
+	req.False(t.check(types.Position{Round: 1, Height: 10}, leader1,
+		now, now.Add(lambda), lambda))
+	// Proposal delivered too late.
+	req.True(t.check(types.Position{Round: 1, Height: 11}, leader1,
+		now, now.Add(2*lambda), lambda))
+	// No proposal at all.
+	req.True(t.check(types.Position{Round: 1, Height: 12}, leader1,
+		now, time.Time{}, lambda))
+	req.True(t.check(types.Position{Round: 2, Height: 20}, leader2,
+		now, time.Time{}, lambda))
+	// Each position is only checked once.
+	req.False(t.check(types.Position{Round: 1, Height: 12}, leader1,
+		now, time.Time{}, lambda))
+	// Stopped BA is not checked.
+	req.False(t.check(types.Position{Height: math.MaxUint64},
+		leader1, now, time.Time{}, lambda))
+	misses := t.purge(1)
+	req.Len(misses, 1)
+	req.Equal(map[types.NodeID]uint64{leader1: 2}, misses[1])
+	req.Empty(t.purge(1))
+	misses = t.purge(2)
+	req.Equal(map[types.NodeID]uint64{leader2: 1}, misses[2])
+}
+
+func TestLeaderMiss(t *testing.T) {
+	suite.Run(t, new(LeaderMissTestSuite))
+}
@@ -20,6 +20,7 @@ package core
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
@@ -29,81 +30,335 @@ type blockConfirmedEvent struct {
 	block *types.Block
 }
 
+type blockConfirmedWithMetaEvent struct {
+	blockHash common.Hash
+	meta      BlockConfirmMeta
+}
+
 type blockDeliveredEvent struct {
 	blockHash     common.Hash
 	blockPosition types.Position
 	rand          []byte
 }
 
+type blocksDeliveredEvent struct {
+	blocks []*types.Block
+	meta   DeliveryMeta
+}
+
+type systemMessagesDeliveredEvent struct {
+	blockHash     common.Hash
+	blockPosition types.Position
+	msgs          [][]byte
+}
+
+// maxDeadLetters is the maximum count of dead letters kept, older ones would
+// be dropped.
+const maxDeadLetters = 1000
+
+// DeadLetter is an application callback failed by panicking. When a
+// delivery fails, later deliveries are held back until it's retried
+// successfully, thus blocks are always delivered in order without gaps.
+type DeadLetter struct {
+	// Callback is the name of the failed method of Application.
+	Callback string
+	// Hash is the hash of the block passed to that callback, or the first
+	// block of the batch passed to BlocksDelivered.
+	Hash common.Hash
+	// Position is the position of that block, it's only available for
+	// BlockConfirmed, BlockDelivered, BlocksDelivered and
+	// SystemMessagesDelivered. For BlocksDelivered, it's the first block in
+	// that batch.
+	Position types.Position
+	// Err is the error recovered from the panic.
+	Err error
+	// Time is when the callback failed.
+	Time time.Time
+
+	event interface{}
+}
+
+func newDeadLetter(event interface{}, err error) DeadLetter {
+	letter := DeadLetter{Err: err, Time: time.Now().UTC(), event: event}
+	switch e := event.(type) {
+	case blockConfirmedEvent:
+		letter.Callback = "BlockConfirmed"
+		letter.Hash = e.block.Hash
+		letter.Position = e.block.Position
+	case blockConfirmedWithMetaEvent:
+		letter.Callback = "BlockConfirmedWithMeta"
+		letter.Hash = e.blockHash
+	case blockDeliveredEvent:
+		letter.Callback = "BlockDelivered"
+		letter.Hash = e.blockHash
+		letter.Position = e.blockPosition
+	case blocksDeliveredEvent:
+		letter.Callback = "BlocksDelivered"
+		if e.meta != nil {
+			letter.Callback = "BlocksDeliveredWithMeta"
+		}
+		if len(e.blocks) > 0 {
+			letter.Hash = e.blocks[0].Hash
+			letter.Position = e.blocks[0].Position
+		}
+	case systemMessagesDeliveredEvent:
+		letter.Callback = "SystemMessagesDelivered"
+		letter.Hash = e.blockHash
+		letter.Position = e.blockPosition
+	}
+	return letter
+}
+
+type confirmTask struct {
+	seq   uint64
+	event interface{}
+}
+
+type deliverTask struct {
+	// confirmSeq is the sequence of the next confirmation when this task is
+	// added, all confirmations before it should be done first.
+	confirmSeq uint64
+	event      interface{}
+}
+
 // nonBlocking implements these interfaces and is a decorator for
 // them that makes the methods to be non-blocking.
 //  - Application
 //  - Debug
 //  - It also provides nonblockig for db update.
+//
+// Confirmations are dispatched by a configurable count of workers, while
+// deliveries are dispatched by one worker to keep them ordered. The delivery
+// worker halts on a failed delivery until dead letters are retried.
 type nonBlocking struct {
 	app          Application
 	debug        Debug
-	eventChan    chan interface{}
-	events       []interface{}
+	metaApp      BlockConfirmMetaReceiver
+	batchApp     BatchDeliveryReceiver
+	batchMetaApp BatchDeliveryMetaReceiver
+	sysMsgApp    SystemMessageReceiver
+	confirms     []confirmTask
+	deliveries   []deliverTask
+	confirmSeq   uint64
+	confirming   map[uint64]struct{}
+	running      int
+	halted       bool
 	eventsChange *sync.Cond
-	running      sync.WaitGroup
+	deadLetters  []DeadLetter
+	deadLock     sync.Mutex
 }
 
 func newNonBlocking(app Application, debug Debug) *nonBlocking {
+	config := CallbackConfig{ConfirmWorkers: 1}
+	if configurer, ok := app.(CallbackConfigurer); ok {
+		config = configurer.CallbackConfig()
+	}
+	if config.ConfirmWorkers < 1 {
+		config.ConfirmWorkers = 1
+	}
 	nonBlockingModule := &nonBlocking{
 		app:          app,
 		debug:        debug,
-		eventChan:    make(chan interface{}, 6),
-		events:       make([]interface{}, 0, 100),
+		confirms:     make([]confirmTask, 0, 100),
+		deliveries:   make([]deliverTask, 0, 100),
+		confirming:   make(map[uint64]struct{}),
 		eventsChange: sync.NewCond(&sync.Mutex{}),
 	}
-	go nonBlockingModule.run()
+	if metaApp, ok := app.(BlockConfirmMetaReceiver); ok {
+		nonBlockingModule.metaApp = metaApp
+	}
+	if batchApp, ok := app.(BatchDeliveryReceiver); ok {
+		nonBlockingModule.batchApp = batchApp
+	}
+	if batchMetaApp, ok := app.(BatchDeliveryMetaReceiver); ok {
+		nonBlockingModule.batchMetaApp = batchMetaApp
+	}
+	if sysMsgApp, ok := app.(SystemMessageReceiver); ok {
+		nonBlockingModule.sysMsgApp = sysMsgApp
+	}
+	for i := 0; i < config.ConfirmWorkers; i++ {
+		go nonBlockingModule.runConfirm()
+	}
+	go nonBlockingModule.runDeliver()
 	return nonBlockingModule
 }
 
 func (nb *nonBlocking) addEvent(event interface{}) {
 	nb.eventsChange.L.Lock()
 	defer nb.eventsChange.L.Unlock()
-	nb.events = append(nb.events, event)
+	switch event.(type) {
+	case blockDeliveredEvent, blocksDeliveredEvent,
+		systemMessagesDeliveredEvent:
+		nb.deliveries = append(nb.deliveries, deliverTask{
+			confirmSeq: nb.confirmSeq,
+			event:      event,
+		})
+	default:
+		nb.confirms = append(nb.confirms, confirmTask{
+			seq:   nb.confirmSeq,
+			event: event,
+		})
+		nb.confirmSeq++
+	}
 	nb.eventsChange.Broadcast()
 }
 
-func (nb *nonBlocking) run() {
-	// This go routine consume the first event from events and call the
-	// corresponding methods of Application/Debug/db.
+// confirmedBefore checks if all confirmations before seq are done, the lock
+// should be held.
+func (nb *nonBlocking) confirmedBefore(seq uint64) bool {
+	if len(nb.confirms) > 0 && nb.confirms[0].seq < seq {
+		return false
+	}
+	for s := range nb.confirming {
+		if s < seq {
+			return false
+		}
+	}
+	return true
+}
+
+func (nb *nonBlocking) runConfirm() {
+	for {
+		var task confirmTask
+		func() {
+			nb.eventsChange.L.Lock()
+			defer nb.eventsChange.L.Unlock()
+			for len(nb.confirms) == 0 {
+				nb.eventsChange.Wait()
+			}
+			task = nb.confirms[0]
+			nb.confirms = nb.confirms[1:]
+			nb.confirming[task.seq] = struct{}{}
+			nb.running++
+		}()
+		nb.dispatch(task.event)
+		func() {
+			nb.eventsChange.L.Lock()
+			defer nb.eventsChange.L.Unlock()
+			delete(nb.confirming, task.seq)
+			nb.running--
+			nb.eventsChange.Broadcast()
+		}()
+	}
+}
+
+func (nb *nonBlocking) runDeliver() {
 	for {
-		var event interface{}
+		var task deliverTask
 		func() {
 			nb.eventsChange.L.Lock()
 			defer nb.eventsChange.L.Unlock()
-			for len(nb.events) == 0 {
+			for nb.halted || len(nb.deliveries) == 0 ||
+				!nb.confirmedBefore(nb.deliveries[0].confirmSeq) {
 				nb.eventsChange.Wait()
 			}
-			event = nb.events[0]
-			nb.events = nb.events[1:]
-			nb.running.Add(1)
+			task = nb.deliveries[0]
+			nb.running++
 		}()
-		switch e := event.(type) {
-		case blockConfirmedEvent:
-			nb.app.BlockConfirmed(*e.block)
-		case blockDeliveredEvent:
-			nb.app.BlockDelivered(e.blockHash, e.blockPosition, e.rand)
+		ok := nb.dispatch(task.event)
+		func() {
+			nb.eventsChange.L.Lock()
+			defer nb.eventsChange.L.Unlock()
+			// The failed delivery is kept at the head until retried.
+			if ok {
+				nb.deliveries = nb.deliveries[1:]
+			} else {
+				nb.halted = true
+			}
+			nb.running--
+			nb.eventsChange.Broadcast()
+		}()
+	}
+}
+
+// dispatch calls the corresponding methods of Application/Debug/db. Panics
+// from the application are recovered and the event is kept as a dead letter,
+// ok is false in that case.
+func (nb *nonBlocking) dispatch(event interface{}) (ok bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			ok = true
+			return
+		}
+		err, isErr := r.(error)
+		if !isErr {
+			err = fmt.Errorf("%v", r)
+		}
+		nb.addDeadLetter(newDeadLetter(event, err))
+	}()
+	switch e := event.(type) {
+	case blockConfirmedEvent:
+		nb.app.BlockConfirmed(*e.block)
+	case blockConfirmedWithMetaEvent:
+		nb.metaApp.BlockConfirmedWithMeta(e.blockHash, e.meta)
+	case blockDeliveredEvent:
+		nb.app.BlockDelivered(e.blockHash, e.blockPosition, e.rand)
+	case blocksDeliveredEvent:
+		if e.meta != nil {
+			nb.batchMetaApp.BlocksDeliveredWithMeta(e.blocks, e.meta)
+		} else {
+			nb.batchApp.BlocksDelivered(e.blocks)
+		}
+	case systemMessagesDeliveredEvent:
+		nb.sysMsgApp.SystemMessagesDelivered(
+			e.blockHash, e.blockPosition, e.msgs)
+	default:
+		fmt.Printf("Unknown event %v.", e)
+	}
+	return
+}
+
+func (nb *nonBlocking) addDeadLetter(letter DeadLetter) {
+	nb.deadLock.Lock()
+	defer nb.deadLock.Unlock()
+	nb.deadLetters = append(nb.deadLetters, letter)
+	if len(nb.deadLetters) > maxDeadLetters {
+		nb.deadLetters = nb.deadLetters[len(nb.deadLetters)-maxDeadLetters:]
+	}
+}
+
+// getDeadLetters returns a copy of dead letters, the oldest one comes first.
+func (nb *nonBlocking) getDeadLetters() []DeadLetter {
+	nb.deadLock.Lock()
+	defer nb.deadLock.Unlock()
+	return append([]DeadLetter(nil), nb.deadLetters...)
+}
+
+// retryDeadLetters dispatches dead letters again. The failed delivery is
+// still queued ahead of later deliveries, it's retried by resuming the
+// delivery worker.
+func (nb *nonBlocking) retryDeadLetters() int {
+	var letters []DeadLetter
+	func() {
+		nb.deadLock.Lock()
+		defer nb.deadLock.Unlock()
+		letters, nb.deadLetters = nb.deadLetters, nil
+	}()
+	for _, letter := range letters {
+		switch letter.event.(type) {
+		case blockDeliveredEvent, blocksDeliveredEvent,
+			systemMessagesDeliveredEvent:
 		default:
-			fmt.Printf("Unknown event %v.", e)
+			nb.addEvent(letter.event)
 		}
-		nb.running.Done()
-		nb.eventsChange.Broadcast()
 	}
+	nb.eventsChange.L.Lock()
+	defer nb.eventsChange.L.Unlock()
+	nb.halted = false
+	nb.eventsChange.Broadcast()
+	return len(letters)
 }
 
-// wait will wait for all event in events finishes.
+// wait will wait for all event in events finishes, deliveries held back by a
+// failed delivery are not waited.
 func (nb *nonBlocking) wait() {
 	nb.eventsChange.L.Lock()
 	defer nb.eventsChange.L.Unlock()
-	for len(nb.events) > 0 {
+	for len(nb.confirms) > 0 || nb.running > 0 ||
+		(len(nb.deliveries) > 0 && !nb.halted) {
 		nb.eventsChange.Wait()
 	}
-	nb.running.Wait()
 }
 
 // PreparePayload cannot be non-blocking.
@@ -126,6 +381,18 @@ func (nb *nonBlocking) BlockConfirmed(block types.Block) {
 	nb.addEvent(blockConfirmedEvent{&block})
 }
 
+// BlockConfirmedWithMeta is called when a block is confirmed by BA.
+func (nb *nonBlocking) BlockConfirmedWithMeta(
+	blockHash common.Hash, meta BlockConfirmMeta) {
+	if nb.metaApp == nil {
+		return
+	}
+	nb.addEvent(blockConfirmedWithMetaEvent{
+		blockHash: blockHash,
+		meta:      meta,
+	})
+}
+
 // BlockDelivered is called when a block is add to the compaction chain.
 func (nb *nonBlocking) BlockDelivered(blockHash common.Hash,
 	blockPosition types.Position, rand []byte) {
@@ -135,3 +402,39 @@ func (nb *nonBlocking) BlockDelivered(blockHash common.Hash,
 		rand:          rand,
 	})
 }
+
+// BlocksDelivered is called when blocks are added to the compaction chain.
+func (nb *nonBlocking) BlocksDelivered(blocks []*types.Block) {
+	if nb.batchApp == nil {
+		for _, b := range blocks {
+			nb.BlockDelivered(b.Hash, b.Position, b.Randomness)
+		}
+		return
+	}
+	nb.addEvent(blocksDeliveredEvent{blocks: blocks})
+}
+
+// BlocksDeliveredWithMeta is called when blocks are added to the compaction
+// chain.
+func (nb *nonBlocking) BlocksDeliveredWithMeta(
+	blocks []*types.Block, meta DeliveryMeta) {
+	if nb.batchMetaApp == nil {
+		nb.BlocksDelivered(blocks)
+		return
+	}
+	nb.addEvent(blocksDeliveredEvent{blocks: blocks, meta: meta})
+}
+
+// SystemMessagesDelivered is called before the block carrying these messages
+// is delivered.
+func (nb *nonBlocking) SystemMessagesDelivered(blockHash common.Hash,
+	blockPosition types.Position, msgs [][]byte) {
+	if nb.sysMsgApp == nil {
+		return
+	}
+	nb.addEvent(systemMessagesDeliveredEvent{
+		blockHash:     blockHash,
+		blockPosition: blockPosition,
+		msgs:          msgs,
+	})
+}
@@ -18,6 +18,7 @@
 package core
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -31,6 +32,7 @@ import (
 type slowApp struct {
 	sleep          time.Duration
 	blockConfirmed map[common.Hash]struct{}
+	blockMeta      map[common.Hash]BlockConfirmMeta
 	blockDelivered map[common.Hash]struct{}
 }
 
@@ -38,6 +40,7 @@ func newSlowApp(sleep time.Duration) *slowApp {
 	return &slowApp{
 		sleep:          sleep,
 		blockConfirmed: make(map[common.Hash]struct{}),
+		blockMeta:      make(map[common.Hash]BlockConfirmMeta),
 		blockDelivered: make(map[common.Hash]struct{}),
 	}
 }
@@ -59,6 +62,12 @@ func (app *slowApp) BlockConfirmed(block types.Block) {
 	app.blockConfirmed[block.Hash] = struct{}{}
 }
 
+func (app *slowApp) BlockConfirmedWithMeta(
+	blockHash common.Hash, meta BlockConfirmMeta) {
+	time.Sleep(app.sleep)
+	app.blockMeta[blockHash] = meta
+}
+
 func (app *slowApp) BlockDelivered(blockHash common.Hash,
 	blockPosition types.Position, _ []byte) {
 	time.Sleep(app.sleep)
@@ -104,6 +113,105 @@ func (app *noDebugApp) BlockDelivered(blockHash common.Hash,
 	app.blockDelivered[blockHash] = struct{}{}
 }
 
+// parallelApp is an Application instance configures several workers for
+// confirmations, and records the order of callbacks.
+type parallelApp struct {
+	noDebugApp
+	lock      sync.Mutex
+	workers   int
+	sleep     time.Duration
+	delivered common.Hashes
+	violated  bool
+}
+
+func (app *parallelApp) CallbackConfig() CallbackConfig {
+	return CallbackConfig{ConfirmWorkers: app.workers}
+}
+
+func (app *parallelApp) BlockConfirmed(block types.Block) {
+	time.Sleep(app.sleep)
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	app.blockConfirmed[block.Hash] = struct{}{}
+}
+
+func (app *parallelApp) BlockDelivered(blockHash common.Hash,
+	blockPosition types.Position, _ []byte) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if _, exist := app.blockConfirmed[blockHash]; !exist {
+		app.violated = true
+	}
+	app.delivered = append(app.delivered, blockHash)
+}
+
+// panicApp is an Application instance panics in callbacks until fixed.
+type panicApp struct {
+	noDebugApp
+	lock      sync.Mutex
+	fixed     bool
+	delivered common.Hashes
+}
+
+func (app *panicApp) BlockDelivered(blockHash common.Hash,
+	blockPosition types.Position, rand []byte) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if !app.fixed {
+		panic("not fixed")
+	}
+	app.noDebugApp.BlockDelivered(blockHash, blockPosition, rand)
+	app.delivered = append(app.delivered, blockHash)
+}
+
+// batchApp is an Application instance receives deliveries in batches.
+type batchApp struct {
+	noDebugApp
+	batches [][]*types.Block
+}
+
+func (app *batchApp) BlocksDelivered(blocks []*types.Block) {
+	for _, b := range blocks {
+		if _, exist := app.blockConfirmed[b.Hash]; !exist {
+			panic("delivered before confirmed")
+		}
+	}
+	app.batches = append(app.batches, blocks)
+}
+
+// batchMetaApp is an Application instance receives deliveries in batches
+// with their metadata.
+type batchMetaApp struct {
+	batchApp
+	metas []DeliveryMeta
+}
+
+func (app *batchMetaApp) BlocksDeliveredWithMeta(
+	blocks []*types.Block, meta DeliveryMeta) {
+	app.batchApp.BlocksDelivered(blocks)
+	app.metas = append(app.metas, meta)
+}
+
+// sysMsgApp is an Application instance receives system messages, and
+// records the order of deliveries.
+type sysMsgApp struct {
+	noDebugApp
+	delivered []string
+}
+
+func (app *sysMsgApp) SystemMessagesDelivered(
+	blockHash common.Hash, _ types.Position, msgs [][]byte) {
+	for _, msg := range msgs {
+		app.delivered = append(app.delivered, string(msg))
+	}
+}
+
+func (app *sysMsgApp) BlockDelivered(blockHash common.Hash,
+	blockPosition types.Position, rand []byte) {
+	app.noDebugApp.BlockDelivered(blockHash, blockPosition, rand)
+	app.delivered = append(app.delivered, blockHash.String())
+}
+
 type NonBlockingTestSuite struct {
 	suite.Suite
 }
@@ -125,6 +233,7 @@ func (s *NonBlockingTestSuite) TestNonBlocking() {
 			Hash:    hash,
 			Witness: types.Witness{},
 		})
+		nbModule.BlockConfirmedWithMeta(hash, BlockConfirmMeta{Period: 1})
 		nbModule.BlockDelivered(hash, types.Position{}, []byte(nil))
 	}
 
@@ -134,6 +243,7 @@ func (s *NonBlockingTestSuite) TestNonBlocking() {
 	nbModule.wait()
 	for _, hash := range hashes {
 		s.Contains(app.blockConfirmed, hash)
+		s.Contains(app.blockMeta, hash)
 		s.Contains(app.blockDelivered, hash)
 	}
 }
@@ -144,6 +254,8 @@ func (s *NonBlockingTestSuite) TestNoDebug() {
 	hash := common.NewRandomHash()
 	// Test BlockConfirmed.
 	nbModule.BlockConfirmed(types.Block{Hash: hash})
+	// BlockConfirmedWithMeta should be skipped when not implemented.
+	nbModule.BlockConfirmedWithMeta(hash, BlockConfirmMeta{})
 	// Test BlockDelivered
 	nbModule.BlockDelivered(hash, types.Position{}, []byte(nil))
 	nbModule.wait()
@@ -155,6 +267,147 @@ func (s *NonBlockingTestSuite) TestNoDebug() {
 	s.Panics(func() { nbModule.VerifyBlock(nil) })
 }
 
+func (s *NonBlockingTestSuite) TestConfirmWorkers() {
+	app := &parallelApp{
+		noDebugApp: *newNoDebugApp(),
+		workers:    10,
+		sleep:      50 * time.Millisecond,
+	}
+	nbModule := newNonBlocking(app, nil)
+	hashes := make(common.Hashes, 10)
+	for idx := range hashes {
+		hashes[idx] = common.NewRandomHash()
+	}
+	now := time.Now().UTC()
+	for _, hash := range hashes {
+		nbModule.BlockConfirmed(types.Block{Hash: hash})
+		nbModule.BlockDelivered(hash, types.Position{}, []byte(nil))
+	}
+	nbModule.wait()
+	// Confirmations are handled in parallel.
+	s.True(time.Now().UTC().Sub(now) < 10*app.sleep)
+	// Deliveries are kept in order and after their confirmations.
+	s.False(app.violated)
+	s.Equal(hashes, app.delivered)
+}
+
+func (s *NonBlockingTestSuite) TestDeadLetters() {
+	app := &panicApp{noDebugApp: *newNoDebugApp()}
+	nbModule := newNonBlocking(app, nil)
+	hash := common.NewRandomHash()
+	pos := types.Position{Round: 1, Height: 2}
+	nbModule.BlockConfirmed(types.Block{Hash: hash, Position: pos})
+	nbModule.BlockDelivered(hash, pos, []byte(nil))
+	nbModule.wait()
+	// Later deliveries are held back until the failed one is retried.
+	nextHash := common.NewRandomHash()
+	nextPos := types.Position{Round: 1, Height: 3}
+	nbModule.BlockConfirmed(types.Block{Hash: nextHash, Position: nextPos})
+	nbModule.BlockDelivered(nextHash, nextPos, []byte(nil))
+	nbModule.wait()
+	s.Contains(app.blockConfirmed, hash)
+	s.Contains(app.blockConfirmed, nextHash)
+	s.NotContains(app.blockDelivered, hash)
+	s.NotContains(app.blockDelivered, nextHash)
+	letters := nbModule.getDeadLetters()
+	s.Require().Len(letters, 1)
+	s.Equal("BlockDelivered", letters[0].Callback)
+	s.Equal(hash, letters[0].Hash)
+	s.Equal(pos, letters[0].Position)
+	s.Equal("not fixed", letters[0].Err.Error())
+	// Retry after the application is fixed.
+	func() {
+		app.lock.Lock()
+		defer app.lock.Unlock()
+		app.fixed = true
+	}()
+	s.Equal(1, nbModule.retryDeadLetters())
+	nbModule.wait()
+	s.Equal(common.Hashes{hash, nextHash}, app.delivered)
+	s.Empty(nbModule.getDeadLetters())
+}
+
+func (s *NonBlockingTestSuite) TestBatchDelivery() {
+	blocks := make([]*types.Block, 10)
+	for idx := range blocks {
+		blocks[idx] = &types.Block{
+			Hash:     common.NewRandomHash(),
+			Position: types.Position{Height: uint64(idx)},
+		}
+	}
+	// Blocks are delivered in one batch when supported.
+	app := &batchApp{noDebugApp: *newNoDebugApp()}
+	nbModule := newNonBlocking(app, nil)
+	for _, b := range blocks {
+		nbModule.BlockConfirmed(*b)
+	}
+	nbModule.BlocksDelivered(blocks)
+	nbModule.wait()
+	s.Empty(nbModule.getDeadLetters())
+	s.Require().Len(app.batches, 1)
+	s.Equal(blocks, app.batches[0])
+	s.Empty(app.blockDelivered)
+	// Fallback to BlockDelivered when not supported.
+	noBatchApp := newNoDebugApp()
+	nbModule = newNonBlocking(noBatchApp, nil)
+	nbModule.BlocksDelivered(blocks)
+	nbModule.wait()
+	s.Len(noBatchApp.blockDelivered, len(blocks))
+}
+
+func (s *NonBlockingTestSuite) TestBatchDeliveryWithMeta() {
+	blocks := make([]*types.Block, 10)
+	for idx := range blocks {
+		blocks[idx] = &types.Block{
+			Hash:     common.NewRandomHash(),
+			Position: types.Position{Height: uint64(idx)},
+		}
+	}
+	meta := DeliveryMeta{
+		DeliveryMetaBatchSeq: uint64(3),
+		DeliveryMetaOrdering: DeliveryOrderingHeight,
+	}
+	app := &batchMetaApp{batchApp: batchApp{noDebugApp: *newNoDebugApp()}}
+	nbModule := newNonBlocking(app, nil)
+	for _, b := range blocks {
+		nbModule.BlockConfirmed(*b)
+	}
+	nbModule.BlocksDeliveredWithMeta(blocks, meta)
+	nbModule.wait()
+	s.Empty(nbModule.getDeadLetters())
+	s.Require().Len(app.batches, 1)
+	s.Equal(blocks, app.batches[0])
+	s.Equal([]DeliveryMeta{meta}, app.metas)
+	// Fallback to BlocksDelivered when not supported.
+	noMetaApp := &batchApp{noDebugApp: *newNoDebugApp()}
+	nbModule = newNonBlocking(noMetaApp, nil)
+	for _, b := range blocks {
+		nbModule.BlockConfirmed(*b)
+	}
+	nbModule.BlocksDeliveredWithMeta(blocks, meta)
+	nbModule.wait()
+	s.Require().Len(noMetaApp.batches, 1)
+	s.Equal(blocks, noMetaApp.batches[0])
+}
+
+func (s *NonBlockingTestSuite) TestSystemMessagesDelivery() {
+	hash := common.NewRandomHash()
+	app := &sysMsgApp{noDebugApp: *newNoDebugApp()}
+	nbModule := newNonBlocking(app, nil)
+	nbModule.SystemMessagesDelivered(hash, types.Position{},
+		[][]byte{[]byte("msg0"), []byte("msg1")})
+	nbModule.BlockDelivered(hash, types.Position{}, nil)
+	nbModule.wait()
+	s.Equal([]string{"msg0", "msg1", hash.String()}, app.delivered)
+	// Ignored when not supported.
+	noSysMsgApp := newNoDebugApp()
+	nbModule = newNonBlocking(noSysMsgApp, nil)
+	nbModule.SystemMessagesDelivered(hash, types.Position{},
+		[][]byte{[]byte("msg0")})
+	nbModule.wait()
+	s.Empty(nbModule.getDeadLetters())
+}
+
 func TestNonBlocking(t *testing.T) {
 	suite.Run(t, new(NonBlockingTestSuite))
 }
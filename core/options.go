@@ -0,0 +1,247 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Option customizes stages of the pipeline composed by NewConsensus.
+type Option func(*options)
+
+type options struct {
+	newTicker       func(TickerType) Ticker
+	metrics         Metrics
+	verifierWorkers int
+	withoutDKG      bool
+	proposeJitter   float64
+	quarantineSize  int
+	alertThreshold  int
+	staleCutoff     uint64
+	now             func() time.Time
+	catchUpLag      uint64
+	pipelinedBA     bool
+	nullProposal    bool
+	archive         bool
+	archiveBegin    uint64
+	archiveEnd      uint64
+	payloadSource   func(types.Position) ([]byte, error)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithTicker replaces tickers of BA and DKG, the default ticker is used when
+// nil is returned by newTicker.
+func WithTicker(newTicker func(TickerType) Ticker) Option {
+	return func(o *options) {
+		o.newTicker = newTicker
+	}
+}
+
+// WithNullBlockProposal proposes the null block instead of building a block
+// when Application implements PayloadChecker and reports nothing to propose.
+// The leader votes for the null block in fast BA directly, neither payload nor
+// signatures of a block are prepared, and other nodes follow that vote as if
+// the block of the leader is received. System messages waiting to be proposed
+// are still carried by blocks.
+func WithNullBlockProposal() Option {
+	return func(o *options) {
+		o.nullProposal = true
+	}
+}
+
+// WithPayloadSource prepares payloads of blocks proposed by this node by the
+// function instead of Application.PreparePayload. Other callbacks are still
+// made to Application, and its optional interfaces are detected as usual.
+func WithPayloadSource(
+	prepare func(position types.Position) ([]byte, error)) Option {
+	return func(o *options) {
+		o.payloadSource = prepare
+	}
+}
+
+// WithMetrics reports measurements of the pipeline to metrics.
+func WithMetrics(metrics Metrics) Option {
+	return func(o *options) {
+		o.metrics = metrics
+	}
+}
+
+// WithVerifierPool sets the count of workers verifying DKG messages, the
+// count of CPUs is used when workers is not positive.
+func WithVerifierPool(workers int) Option {
+	return func(o *options) {
+		o.verifierWorkers = workers
+	}
+}
+
+// WithoutDKG stops the node from joining DKG even when selected, it relies on
+// other nodes to generate randomness of blocks.
+func WithoutDKG() Option {
+	return func(o *options) {
+		o.withoutDKG = true
+	}
+}
+
+// WithProposeJitter delays broadcasting proposals by an offset in the window
+// of ratio times lambda BA, ratio should be in [0, 1). The offset of each node
+// is derived from CRS, thus proposals of nodes are spread in the window
+// instead of bursting on the same tick. Proposals of leaders are delayed as
+// well, a larger ratio slows down confirmations.
+func WithProposeJitter(ratio float64) Option {
+	return func(o *options) {
+		switch {
+		case ratio < 0:
+			ratio = 0
+		case ratio >= 1:
+			ratio = 1
+		}
+		o.proposeJitter = ratio
+	}
+}
+
+// WithQuarantine captures latest size messages of unknown types received
+// from network, which are accessible by Consensus.QuarantinedMessages. When
+// threshold is positive, UnknownMessageReceiver is alerted once the count of
+// unknown messages received in 10 seconds reaches it.
+func WithQuarantine(size, threshold int) Option {
+	return func(o *options) {
+		o.quarantineSize = size
+		o.alertThreshold = threshold
+	}
+}
+
+// WithStaleCutoff drops votes and blocks for positions more than cutoff
+// heights behind the last delivered block before verifying their signatures.
+// They are counted by "stale-votes" and "stale-blocks" counters, and peers
+// sending them are not punished. Zero disables the cutoff.
+func WithStaleCutoff(cutoff uint64) Option {
+	return func(o *options) {
+		o.staleCutoff = cutoff
+	}
+}
+
+// WithClock replaces the clock used to suggest timestamps of proposed blocks
+// and to reject leader blocks from the future. It's mainly for simulating
+// nodes with drifting clocks.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// clock returns the current time of the node.
+func (o *options) clock() time.Time {
+	if o.now == nil {
+		return time.Now()
+	}
+	return o.now()
+}
+
+// WithCatchUp makes a node stop proposing and voting in BA when it lags
+// behind the network frontier, estimated by gossiped watermarks, by more than
+// lag heights. It catches up by pulling agreement results, and rejoins BA once
+// the lag is within half of that. It's disabled by default.
+func WithCatchUp(lag uint64) Option {
+	return func(o *options) {
+		o.catchUpLag = lag
+	}
+}
+
+// WithPipelinedBA lets BA start the next height as soon as the previous block
+// is confirmed, while that block still waits for randomness or is being
+// delivered asynchronously. At most one confirmed block is kept undelivered,
+// thus a slow Application.BlockDelivered stalls BA one height later than
+// usual instead of delaying every height.
+func WithPipelinedBA() Option {
+	return func(o *options) {
+		o.pipelinedBA = true
+	}
+}
+
+// WithArchive keeps agreement results of heights in [begin, end) instead of
+// purging them, the end is unbounded when zero. Results missing in that range,
+// ex. positions delivered before this node joined, are pulled from peers in
+// the background by AgreementResultPuller until the archive is complete.
+// Results of heights after begin are not purged either.
+func WithArchive(begin, end uint64) Option {
+	return func(o *options) {
+		o.archive = true
+		o.archiveBegin = begin
+		o.archiveEnd = end
+	}
+}
+
+// observeDuration returns a function to report the time elapsed since called.
+func (o *options) observeDuration(stage string) func() {
+	if o.metrics == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		o.metrics.ObserveDuration(stage, time.Since(start))
+	}
+}
+
+// observe reports a duration measured by callers.
+func (o *options) observe(stage string, duration time.Duration) {
+	if o.metrics != nil {
+		o.metrics.ObserveDuration(stage, duration)
+	}
+}
+
+func (o *options) incCounter(name string, delta uint64) {
+	if o.metrics != nil {
+		o.metrics.IncCounter(name, delta)
+	}
+}
+
+// gaugeMetrics returns the metrics receiving gauges, nil when not supported.
+func (o *options) gaugeMetrics() GaugeMetrics {
+	gauges, _ := o.metrics.(GaugeMetrics)
+	return gauges
+}
+
+// tickerGovernance decorates a governance to generate tickers by options.
+type tickerGovernance struct {
+	Governance
+
+	newTicker func(TickerType) Ticker
+}
+
+// NewTicker implements the ticker generator for newTicker.
+func (g *tickerGovernance) NewTicker(tickerType TickerType) Ticker {
+	if t := g.newTicker(tickerType); t != nil {
+		return t
+	}
+	type tickerGenerator interface {
+		NewTicker(TickerType) Ticker
+	}
+	if gen, ok := g.Governance.(tickerGenerator); ok {
+		return gen.NewTicker(tickerType)
+	}
+	return nil
+}
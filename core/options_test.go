@@ -0,0 +1,119 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+)
+
+type fakeMetrics struct {
+	durations map[string]int
+	counters  map[string]uint64
+}
+
+func (m *fakeMetrics) ObserveDuration(stage string, _ time.Duration) {
+	m.durations[stage]++
+}
+
+func (m *fakeMetrics) IncCounter(name string, delta uint64) {
+	m.counters[name] += delta
+}
+
+type OptionsTestSuite struct {
+	suite.Suite
+}
+
+func (s *OptionsTestSuite) TestDefault() {
+	o := newOptions(nil)
+	s.Nil(o.newTicker)
+	s.Zero(o.verifierWorkers)
+	s.False(o.withoutDKG)
+	// Reporting without metrics should be fine.
+	o.observeDuration("stage")()
+	o.incCounter("counter", 1)
+}
+
+func (s *OptionsTestSuite) TestTicker() {
+	_, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	baTicker := newDefaultTicker(time.Hour)
+	defer baTicker.Stop()
+	o := newOptions([]Option{WithTicker(func(t TickerType) Ticker {
+		if t == TickerBA {
+			return baTicker
+		}
+		return nil
+	})})
+	tickerGov := &tickerGovernance{Governance: gov, newTicker: o.newTicker}
+	s.Equal(baTicker, newTicker(tickerGov, 0, TickerBA))
+	// The default ticker is used when nil is returned.
+	dkgTicker := newTicker(tickerGov, 0, TickerDKG)
+	defer dkgTicker.Stop()
+	s.NotEqual(baTicker, dkgTicker)
+	s.IsType(&defaultTicker{}, dkgTicker)
+}
+
+func (s *OptionsTestSuite) TestMetrics() {
+	m := &fakeMetrics{
+		durations: make(map[string]int),
+		counters:  make(map[string]uint64),
+	}
+	o := newOptions([]Option{WithMetrics(m), WithVerifierPool(3), WithoutDKG()})
+	s.Equal(3, o.verifierWorkers)
+	s.True(o.withoutDKG)
+	o.observeDuration("stage")()
+	o.incCounter("counter", 2)
+	o.incCounter("counter", 3)
+	s.Equal(1, m.durations["stage"])
+	s.Equal(uint64(5), m.counters["counter"])
+}
+
+type fakeGaugeMetrics struct {
+	fakeMetrics
+	gauges map[string]float64
+}
+
+func (m *fakeGaugeMetrics) SetGauge(name string, value float64) {
+	m.gauges[name] = value
+}
+
+func (s *OptionsTestSuite) TestGaugeMetrics() {
+	s.Nil(newOptions(nil).gaugeMetrics())
+	s.Nil(newOptions([]Option{WithMetrics(&fakeMetrics{})}).gaugeMetrics())
+	m := &fakeGaugeMetrics{gauges: make(map[string]float64)}
+	s.Equal(m, newOptions([]Option{WithMetrics(m)}).gaugeMetrics())
+}
+
+func (s *OptionsTestSuite) TestProposeJitter() {
+	s.Equal(0.25, newOptions([]Option{WithProposeJitter(0.25)}).proposeJitter)
+	s.Zero(newOptions([]Option{WithProposeJitter(-1)}).proposeJitter)
+	s.Equal(1.0, newOptions([]Option{WithProposeJitter(3)}).proposeJitter)
+}
+
+func TestOptions(t *testing.T) {
+	suite.Run(t, new(OptionsTestSuite))
+}
@@ -0,0 +1,153 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// maxPendingMessages is the count of votes and blocks for future positions
+// cached by an agreement.
+const maxPendingMessages = 4096
+
+// pendingCache caches votes and blocks for positions the agreement hasn't
+// reached, they are replayed when the agreement restarts at those positions.
+// It's bounded, messages for the farthest position are evicted first when
+// full, since they are the least likely to be replayed in time.
+type pendingCache struct {
+	limit  int
+	count  int
+	votes  map[types.Position][]pendingVote
+	blocks map[types.Position][]pendingBlock
+}
+
+func newPendingCache(limit int) *pendingCache {
+	return &pendingCache{
+		limit:  limit,
+		votes:  make(map[types.Position][]pendingVote),
+		blocks: make(map[types.Position][]pendingBlock),
+	}
+}
+
+// addVote caches a vote, false is returned when it's dropped.
+func (c *pendingCache) addVote(vote *types.Vote, t time.Time) bool {
+	if !c.reserve(vote.Position) {
+		return false
+	}
+	c.votes[vote.Position] = append(c.votes[vote.Position],
+		pendingVote{vote: vote, receivedTime: t})
+	c.count++
+	return true
+}
+
+// addBlock caches a block, false is returned when it's dropped.
+func (c *pendingCache) addBlock(block *types.Block, t time.Time) bool {
+	if !c.reserve(block.Position) {
+		return false
+	}
+	c.blocks[block.Position] = append(c.blocks[block.Position],
+		pendingBlock{block: block, receivedTime: t})
+	c.count++
+	return true
+}
+
+// reserve evicts messages for positions farther than pos until there is
+// room for one more message.
+func (c *pendingCache) reserve(pos types.Position) bool {
+	for c.count >= c.limit {
+		var (
+			farthest types.Position
+			found    bool
+		)
+		for p := range c.votes {
+			if !found || p.Newer(farthest) {
+				farthest, found = p, true
+			}
+		}
+		for p := range c.blocks {
+			if !found || p.Newer(farthest) {
+				farthest, found = p, true
+			}
+		}
+		if !found || !farthest.Newer(pos) {
+			return false
+		}
+		c.count -= len(c.votes[farthest]) + len(c.blocks[farthest])
+		delete(c.votes, farthest)
+		delete(c.blocks, farthest)
+	}
+	return true
+}
+
+// pop removes and returns messages for pos. Messages for older positions,
+// and those received before expireTime, are purged.
+func (c *pendingCache) pop(pos types.Position, expireTime time.Time) (
+	votes []*types.Vote, blocks []*types.Block) {
+	for p, pendings := range c.votes {
+		if p == pos {
+			for _, pending := range pendings {
+				votes = append(votes, pending.vote)
+			}
+		}
+		c.count -= len(pendings)
+		if p != pos && !pos.Newer(p) {
+			kept := pendings[:0]
+			for _, pending := range pendings {
+				if pending.receivedTime.After(expireTime) {
+					kept = append(kept, pending)
+				}
+			}
+			if len(kept) > 0 {
+				c.votes[p] = kept
+				c.count += len(kept)
+				continue
+			}
+		}
+		delete(c.votes, p)
+	}
+	for p, pendings := range c.blocks {
+		if p == pos {
+			for _, pending := range pendings {
+				blocks = append(blocks, pending.block)
+			}
+		}
+		c.count -= len(pendings)
+		if p != pos && !pos.Newer(p) {
+			kept := pendings[:0]
+			for _, pending := range pendings {
+				if pending.receivedTime.After(expireTime) {
+					kept = append(kept, pending)
+				}
+			}
+			if len(kept) > 0 {
+				c.blocks[p] = kept
+				c.count += len(kept)
+				continue
+			}
+		}
+		delete(c.blocks, p)
+	}
+	return
+}
+
+// size returns the count of cached messages.
+func (c *pendingCache) size() int {
+	return c.count
+}
@@ -0,0 +1,91 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type PendingCacheTestSuite struct {
+	suite.Suite
+}
+
+func (s *PendingCacheTestSuite) newVote(height uint64) *types.Vote {
+	return &types.Vote{
+		VoteHeader: types.VoteHeader{
+			Position: types.Position{Height: height},
+		},
+	}
+}
+
+func (s *PendingCacheTestSuite) TestReplay() {
+	var (
+		c   = newPendingCache(10)
+		now = time.Now()
+	)
+	for h := uint64(1); h <= 3; h++ {
+		pos := types.Position{Height: h}
+		s.True(c.addVote(s.newVote(h), now))
+		s.True(c.addBlock(&types.Block{Position: pos}, now))
+	}
+	s.Equal(6, c.size())
+	votes, blocks := c.pop(types.Position{Height: 2}, now.Add(-time.Second))
+	s.Require().Len(votes, 1)
+	s.Require().Len(blocks, 1)
+	s.Equal(uint64(2), votes[0].Position.Height)
+	s.Equal(uint64(2), blocks[0].Position.Height)
+	// Messages for older positions are purged, those for newer positions are
+	// kept until expired.
+	s.Equal(2, c.size())
+	votes, blocks = c.pop(types.Position{Height: 2}, now)
+	s.Empty(votes)
+	s.Empty(blocks)
+	s.Equal(0, c.size())
+}
+
+func (s *PendingCacheTestSuite) TestEvictFarthest() {
+	var (
+		c   = newPendingCache(3)
+		now = time.Now()
+	)
+	for h := uint64(1); h <= 3; h++ {
+		s.True(c.addVote(s.newVote(h), now))
+	}
+	// Messages for positions farther than all cached ones are dropped.
+	s.False(c.addVote(s.newVote(4), now))
+	s.False(c.addVote(s.newVote(3), now))
+	// Messages for the farthest position are evicted for nearer ones.
+	s.True(c.addBlock(&types.Block{Position: types.Position{Height: 1}}, now))
+	s.Equal(3, c.size())
+	votes, blocks := c.pop(types.Position{Height: 1}, now.Add(-time.Second))
+	s.Len(votes, 1)
+	s.Len(blocks, 1)
+	// Only the vote for height 2 is left.
+	s.Equal(1, c.size())
+	votes, _ = c.pop(types.Position{Height: 3}, now.Add(-time.Second))
+	s.Empty(votes)
+}
+
+func TestPendingCache(t *testing.T) {
+	suite.Run(t, new(PendingCacheTestSuite))
+}
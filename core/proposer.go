@@ -0,0 +1,95 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// ProposerStats summarizes the proposing activity of a node.
+type ProposerStats struct {
+	// Prepared is the count of blocks prepared from scratch.
+	Prepared uint64
+	// Reused is the count of proposals served by a block prepared earlier
+	// for the same position.
+	Reused uint64
+	// Failed is the count of proposals failed to be prepared.
+	Failed uint64
+	// LastPosition is the position of the latest proposed block.
+	LastPosition types.Position
+	// LastProposeTime is the time the latest block is proposed.
+	LastProposeTime time.Time
+}
+
+type prepareBlockFn func(types.Position, time.Time) (*types.Block, error)
+
+// blockProposer coordinates block proposing for one node. BA might ask for a
+// proposal at the same position in several periods, and proposing different
+// blocks at the same position would be treated as a fork. The first prepared
+// block is kept and proposed again, thus the payload is prepared once per
+// position. The timestamp of a prepared block is adjusted by blockChain to
+// follow MinBlockInterval, and BA would wait for it before voting.
+type blockProposer struct {
+	lock     sync.Mutex
+	prepare  prepareBlockFn
+	proposed map[types.Position]*types.Block
+	stats    ProposerStats
+}
+
+func newBlockProposer(prepare prepareBlockFn) *blockProposer {
+	return &blockProposer{
+		prepare:  prepare,
+		proposed: make(map[types.Position]*types.Block),
+	}
+}
+
+func (p *blockProposer) propose(position types.Position, now time.Time) (
+	*types.Block, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if b, exist := p.proposed[position]; exist {
+		p.stats.Reused++
+		p.stats.LastProposeTime = now
+		return b, nil
+	}
+	b, err := p.prepare(position, now)
+	if err != nil {
+		p.stats.Failed++
+		return nil, err
+	}
+	// Blocks proposed at older positions are useless now.
+	for pos := range p.proposed {
+		if pos.Older(position) {
+			delete(p.proposed, pos)
+		}
+	}
+	p.proposed[position] = b
+	p.stats.Prepared++
+	p.stats.LastPosition = position
+	p.stats.LastProposeTime = now
+	return b, nil
+}
+
+func (p *blockProposer) getStats() ProposerStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.stats
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type ProposerTestSuite struct {
+	suite.Suite
+}
+
+func (s *ProposerTestSuite) TestReuseProposedBlock() {
+	var (
+		prepared int
+		failNext bool
+		now      = time.Now().UTC()
+	)
+	p := newBlockProposer(func(pos types.Position, t time.Time) (
+		*types.Block, error) {
+		if failNext {
+			return nil, errors.New("failed")
+		}
+		prepared++
+		return &types.Block{
+			Position:  pos,
+			Timestamp: t,
+			Hash:      common.NewRandomHash(),
+		}, nil
+	})
+	pos := types.Position{Round: 1, Height: 10}
+	b1, err := p.propose(pos, now)
+	s.Require().NoError(err)
+	// Proposing again at the same position should get the same block.
+	b2, err := p.propose(pos, now.Add(time.Second))
+	s.Require().NoError(err)
+	s.Require().Equal(b1.Hash, b2.Hash)
+	s.Require().Equal(1, prepared)
+	// Proposing at newer position should purge older ones.
+	nextPos := types.Position{Round: 1, Height: 11}
+	b3, err := p.propose(nextPos, now.Add(2*time.Second))
+	s.Require().NoError(err)
+	s.Require().NotEqual(b1.Hash, b3.Hash)
+	s.Require().Len(p.proposed, 1)
+	failNext = true
+	_, err = p.propose(types.Position{Round: 1, Height: 12}, now)
+	s.Require().Error(err)
+	stats := p.getStats()
+	s.Require().Equal(uint64(2), stats.Prepared)
+	s.Require().Equal(uint64(1), stats.Reused)
+	s.Require().Equal(uint64(1), stats.Failed)
+	s.Require().Equal(nextPos, stats.LastPosition)
+}
+
+func TestProposer(t *testing.T) {
+	suite.Run(t, new(ProposerTestSuite))
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// Versions of algorithms of the protocol, they should be increased when
+// those algorithms are changed.
+const (
+	// ProtocolVersion is the version of messages and block format.
+	ProtocolVersion uint32 = 1
+	// OrderingVersion is the version of rules to confirm and deliver blocks.
+	OrderingVersion uint32 = 1
+)
+
+// voteTypeNames are names of vote types enabled in BA.
+var voteTypeNames = map[types.VoteType]string{
+	types.VoteInit:    "init",
+	types.VotePreCom:  "pre-com",
+	types.VoteCom:     "com",
+	types.VoteFast:    "fast",
+	types.VoteFastCom: "fast-com",
+}
+
+// ProtocolParams are the protocol parameters and versions of algorithms
+// active in a round. Peers running with different parameters drift silently,
+// therefore its hash is carried by the first block of each round and verified
+// by peers.
+type ProtocolParams struct {
+	ProtocolVersion  uint32   `json:"protocol_version"`
+	OrderingVersion  uint32   `json:"ordering_version"`
+	DKGDelayRound    uint64   `json:"dkg_delay_round"`
+	ConfigRoundShift uint64   `json:"config_round_shift"`
+	VoteTypes        []string `json:"vote_types"`
+	HashDomains      []string `json:"hash_domains"`
+
+	// Durations are in nanoseconds.
+	LambdaBA             int64  `json:"lambda_ba"`
+	LambdaDKG            int64  `json:"lambda_dkg"`
+	NotarySetSize        uint32 `json:"notary_set_size"`
+	RoundLength          uint64 `json:"round_length"`
+	MinBlockInterval     int64  `json:"min_block_interval"`
+	MaxBlockInterval     int64  `json:"max_block_interval"`
+	DKGRegisterDuration  int64  `json:"dkg_register_duration"`
+	DKGComplaintDuration int64  `json:"dkg_complaint_duration"`
+	DKGFinalizeDuration  int64  `json:"dkg_finalize_duration"`
+	BATimeoutGrowth      uint32 `json:"ba_timeout_growth"`
+	BATimeoutMaxScale    uint32 `json:"ba_timeout_max_scale"`
+
+	// VoteThresholds are derived from the configured notary set size, a
+	// different formula of thresholds changes the hash as well.
+	VoteThresholds VoteThresholds `json:"vote_thresholds"`
+}
+
+// VoteThresholds are counts of votes required by BA from a notary set, where
+// the count of tolerated faulty nodes f is the set size minus the quorum.
+type VoteThresholds struct {
+	NotarySetSize int `json:"notary_set_size"`
+	// Quorum (2f+1) votes confirm a block or lock a value.
+	Quorum int `json:"quorum"`
+	// Weak (f+1) votes contain at least one from a correct node.
+	Weak int `json:"weak"`
+}
+
+// NewVoteThresholds returns the thresholds of a notary set of that size.
+func NewVoteThresholds(notarySetSize int) VoteThresholds {
+	quorum := utils.GetBAThreshold(
+		&types.Config{NotarySetSize: uint32(notarySetSize)})
+	return VoteThresholds{
+		NotarySetSize: notarySetSize,
+		Quorum:        quorum,
+		Weak:          notarySetSize - quorum + 1,
+	}
+}
+
+// NewProtocolParams exports protocol parameters active with a configuration.
+func NewProtocolParams(config *types.Config) *ProtocolParams {
+	p := &ProtocolParams{
+		ProtocolVersion:  ProtocolVersion,
+		OrderingVersion:  OrderingVersion,
+		DKGDelayRound:    DKGDelayRound,
+		ConfigRoundShift: ConfigRoundShift,
+		HashDomains:      []string{crsFallbackPrefix, crsEntropyPrefix},
+
+		LambdaBA:             config.LambdaBA.Nanoseconds(),
+		LambdaDKG:            config.LambdaDKG.Nanoseconds(),
+		NotarySetSize:        config.NotarySetSize,
+		RoundLength:          config.RoundLength,
+		MinBlockInterval:     config.MinBlockInterval.Nanoseconds(),
+		MaxBlockInterval:     config.MaxBlockInterval.Nanoseconds(),
+		DKGRegisterDuration:  config.DKGRegisterDuration.Nanoseconds(),
+		DKGComplaintDuration: config.DKGComplaintDuration.Nanoseconds(),
+		DKGFinalizeDuration:  config.DKGFinalizeDuration.Nanoseconds(),
+		BATimeoutGrowth:      config.BATimeoutGrowth,
+		BATimeoutMaxScale:    config.BATimeoutMaxScale,
+
+		VoteThresholds: NewVoteThresholds(int(config.NotarySetSize)),
+	}
+	for t := types.VoteInit; t < types.MaxVoteType; t++ {
+		p.VoteTypes = append(p.VoteTypes, voteTypeNames[t])
+	}
+	return p
+}
+
+// JSON returns the canonical JSON document of protocol parameters, fields are
+// always encoded in the same order.
+func (p *ProtocolParams) JSON() []byte {
+	b, err := json.Marshal(p)
+	if err != nil {
+		// It's not expected to fail for types of these fields.
+		panic(err)
+	}
+	return b
+}
+
+// Hash returns the hash of the canonical JSON document.
+func (p *ProtocolParams) Hash() common.Hash {
+	return crypto.Keccak256Hash(p.JSON())
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type ProtocolParamsTestSuite struct {
+	suite.Suite
+}
+
+func (s *ProtocolParamsTestSuite) TestExport() {
+	req := s.Require()
+	config := &types.Config{
+		LambdaBA:         250 * time.Millisecond,
+		LambdaDKG:        10 * time.Second,
+		NotarySetSize:    4,
+		RoundLength:      100,
+		MinBlockInterval: time.Second,
+	}
+	p := NewProtocolParams(config)
+	req.Equal([]string{"init", "pre-com", "com", "fast", "fast-com"},
+		p.VoteTypes)
+	req.Equal(int64(250*time.Millisecond), p.LambdaBA)
+	// The JSON document should be decodable and canonical.
+	decoded := &ProtocolParams{}
+	req.NoError(json.Unmarshal(p.JSON(), decoded))
+	req.Equal(p, decoded)
+	req.Equal(p.JSON(), decoded.JSON())
+	req.Equal(p.Hash(), NewProtocolParams(config.Clone()).Hash())
+	// Any change of parameters should change the hash.
+	config.RoundLength++
+	req.NotEqual(p.Hash(), NewProtocolParams(config).Hash())
+	// Thresholds are part of the hash as well.
+	modified := NewProtocolParams(config)
+	modified.VoteThresholds.Quorum++
+	req.NotEqual(modified.Hash(), NewProtocolParams(config).Hash())
+}
+
+func (s *ProtocolParamsTestSuite) TestVoteThresholds() {
+	req := s.Require()
+	for _, c := range []struct {
+		size, quorum, weak int
+	}{
+		{1, 1, 1},
+		{4, 3, 2},
+		{7, 5, 3},
+		{10, 7, 4},
+		{100, 67, 34},
+	} {
+		t := NewVoteThresholds(c.size)
+		req.Equal(c.size, t.NotarySetSize)
+		req.Equal(c.quorum, t.Quorum, "size %d", c.size)
+		req.Equal(c.weak, t.Weak, "size %d", c.size)
+	}
+	p := NewProtocolParams(&types.Config{NotarySetSize: 7})
+	req.Equal(NewVoteThresholds(7), p.VoteThresholds)
+}
+
+func TestProtocolParams(t *testing.T) {
+	suite.Run(t, new(ProtocolParamsTestSuite))
+}
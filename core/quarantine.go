@@ -0,0 +1,102 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// unknownMsgAlertWindow is the window to count unknown messages received, an
+// alert is raised when the count in a window reaches the threshold.
+const unknownMsgAlertWindow = 10 * time.Second
+
+// QuarantinedMessage is a message of unknown type received from network.
+type QuarantinedMessage struct {
+	// Type is the Go type of the message.
+	Type string
+	// Peer is the peer sending that message.
+	Peer interface{}
+	// Payload is the message itself.
+	Payload interface{}
+	// Time is when the message is received.
+	Time time.Time
+}
+
+// msgQuarantine counts unknown messages, and captures latest ones in a ring
+// buffer when its size is positive.
+type msgQuarantine struct {
+	lock        sync.Mutex
+	msgs        []QuarantinedMessage
+	next        int
+	full        bool
+	threshold   int
+	windowBegin time.Time
+	windowCount int
+}
+
+func newMsgQuarantine(size, threshold int) *msgQuarantine {
+	if size < 0 {
+		size = 0
+	}
+	return &msgQuarantine{
+		msgs:      make([]QuarantinedMessage, size),
+		threshold: threshold,
+	}
+}
+
+// add captures an unknown message, and returns the count of unknown messages
+// in current window when it just reaches the threshold.
+func (q *msgQuarantine) add(msg, peer interface{}, now time.Time) (
+	count int, alert bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.msgs) > 0 {
+		q.msgs[q.next] = QuarantinedMessage{
+			Type:    fmt.Sprintf("%T", msg),
+			Peer:    peer,
+			Payload: msg,
+			Time:    now,
+		}
+		q.next = (q.next + 1) % len(q.msgs)
+		if q.next == 0 {
+			q.full = true
+		}
+	}
+	if now.Sub(q.windowBegin) >= unknownMsgAlertWindow {
+		q.windowBegin = now
+		q.windowCount = 0
+	}
+	q.windowCount++
+	count = q.windowCount
+	alert = q.threshold > 0 && q.windowCount == q.threshold
+	return
+}
+
+// messages returns captured messages, the oldest one comes first.
+func (q *msgQuarantine) messages() []QuarantinedMessage {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.full {
+		msgs := make([]QuarantinedMessage, 0, len(q.msgs))
+		msgs = append(msgs, q.msgs[q.next:]...)
+		return append(msgs, q.msgs[:q.next]...)
+	}
+	return append([]QuarantinedMessage(nil), q.msgs[:q.next]...)
+}
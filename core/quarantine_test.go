@@ -0,0 +1,80 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type QuarantineTestSuite struct {
+	suite.Suite
+}
+
+func (s *QuarantineTestSuite) TestRingBuffer() {
+	q := newMsgQuarantine(3, 0)
+	s.Empty(q.messages())
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		_, alert := q.add(i, "peer", now)
+		s.False(alert)
+	}
+	msgs := q.messages()
+	s.Require().Len(msgs, 2)
+	s.Equal(0, msgs[0].Payload)
+	s.Equal("int", msgs[0].Type)
+	s.Equal("peer", msgs[0].Peer)
+	// Only latest messages are kept.
+	for i := 2; i < 5; i++ {
+		q.add(i, "peer", now)
+	}
+	msgs = q.messages()
+	s.Require().Len(msgs, 3)
+	for i, msg := range msgs {
+		s.Equal(i+2, msg.Payload)
+	}
+	// Nothing is captured when the size is zero.
+	q = newMsgQuarantine(0, 0)
+	count, _ := q.add("msg", nil, now)
+	s.Equal(1, count)
+	s.Empty(q.messages())
+}
+
+func (s *QuarantineTestSuite) TestAlert() {
+	q := newMsgQuarantine(0, 3)
+	now := time.Now()
+	for i := 1; i <= 5; i++ {
+		count, alert := q.add(i, nil, now)
+		s.Equal(i, count)
+		// Alerted only once per window.
+		s.Equal(i == 3, alert)
+	}
+	// Counting restarts in a new window.
+	now = now.Add(unknownMsgAlertWindow)
+	for i := 1; i <= 3; i++ {
+		count, alert := q.add(i, nil, now)
+		s.Equal(i, count)
+		s.Equal(i == 3, alert)
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	suite.Run(t, new(QuarantineTestSuite))
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+const (
+	// rebroadcastInterval is the base interval to re-broadcast own messages,
+	// it's doubled after each attempt.
+	rebroadcastInterval = 500 * time.Millisecond
+	// maxRebroadcastInterval is the upper bound of the interval.
+	maxRebroadcastInterval = 8 * time.Second
+	// maxRebroadcastAttempts is the count of attempts for a position.
+	maxRebroadcastAttempts = 5
+	// maxRebroadcastVotes is the count of the latest votes kept for a
+	// position.
+	maxRebroadcastVotes = 16
+	// rebroadcastExpiry is the duration a position would be re-broadcasted.
+	rebroadcastExpiry = 60 * time.Second
+)
+
+type rebroadcastEntry struct {
+	block    *types.Block
+	votes    []*types.Vote
+	attempts int
+	added    time.Time
+	next     time.Time
+}
+
+// selfRebroadcaster re-broadcasts proposals and votes of this node until
+// their positions are confirmed or expired, in case they are dropped by the
+// network. Intervals are jittered and backed off exponentially, and attempts
+// are bounded per position.
+type selfRebroadcaster struct {
+	lock      sync.Mutex
+	entries   map[types.Position]*rebroadcastEntry
+	confirmed types.Position
+	broadcast func(msg interface{})
+	rand      *rand.Rand
+}
+
+func newSelfRebroadcaster(
+	broadcast func(msg interface{})) *selfRebroadcaster {
+	return &selfRebroadcaster{
+		entries:   make(map[types.Position]*rebroadcastEntry),
+		broadcast: broadcast,
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// backoff returns the jittered interval before the next attempt, the lock
+// should be held.
+func (r *selfRebroadcaster) backoff(attempts int) time.Duration {
+	interval := rebroadcastInterval << uint(attempts)
+	if interval > maxRebroadcastInterval {
+		interval = maxRebroadcastInterval
+	}
+	// Jitter in [interval/2, interval*3/2).
+	return interval/2 + time.Duration(r.rand.Int63n(int64(interval)))
+}
+
+// entry returns the entry of a position, nil is returned when that position
+// is already confirmed. The lock should be held.
+func (r *selfRebroadcaster) entry(
+	pos types.Position, now time.Time) *rebroadcastEntry {
+	if !pos.Newer(r.confirmed) {
+		return nil
+	}
+	e, exist := r.entries[pos]
+	if !exist {
+		e = &rebroadcastEntry{added: now, next: now.Add(r.backoff(0))}
+		r.entries[pos] = e
+	}
+	return e
+}
+
+func (r *selfRebroadcaster) addBlock(b *types.Block) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if e := r.entry(b.Position, time.Now()); e != nil {
+		e.block = b
+	}
+}
+
+func (r *selfRebroadcaster) addVote(v *types.Vote) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	e := r.entry(v.Position, time.Now())
+	if e == nil {
+		return
+	}
+	e.votes = append(e.votes, v)
+	if len(e.votes) > maxRebroadcastVotes {
+		e.votes = e.votes[len(e.votes)-maxRebroadcastVotes:]
+	}
+}
+
+// confirm stops re-broadcasting messages of positions no newer than pos.
+func (r *selfRebroadcaster) confirm(pos types.Position) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !pos.Newer(r.confirmed) {
+		return
+	}
+	r.confirmed = pos
+	for p := range r.entries {
+		if !p.Newer(pos) {
+			delete(r.entries, p)
+		}
+	}
+}
+
+// due collects messages to re-broadcast at the moment.
+func (r *selfRebroadcaster) due(now time.Time) (msgs []interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for pos, e := range r.entries {
+		if e.attempts >= maxRebroadcastAttempts ||
+			now.Sub(e.added) >= rebroadcastExpiry {
+			delete(r.entries, pos)
+			continue
+		}
+		if now.Before(e.next) {
+			continue
+		}
+		if e.block != nil {
+			msgs = append(msgs, e.block)
+		}
+		for _, v := range e.votes {
+			msgs = append(msgs, v)
+		}
+		e.attempts++
+		e.next = now.Add(r.backoff(e.attempts))
+	}
+	return
+}
+
+func (r *selfRebroadcaster) run(ctx context.Context) {
+	ticker := time.NewTicker(rebroadcastInterval / 5)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, msg := range r.due(now) {
+				r.broadcast(msg)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type SelfRebroadcasterTestSuite struct {
+	suite.Suite
+}
+
+func (s *SelfRebroadcasterTestSuite) TestBackoff() {
+	r := newSelfRebroadcaster(func(interface{}) {})
+	for attempts := 0; attempts < 10; attempts++ {
+		interval := rebroadcastInterval << uint(attempts)
+		if interval > maxRebroadcastInterval {
+			interval = maxRebroadcastInterval
+		}
+		for i := 0; i < 100; i++ {
+			b := r.backoff(attempts)
+			s.Require().True(b >= interval/2)
+			s.Require().True(b < interval*3/2)
+		}
+	}
+}
+
+func (s *SelfRebroadcasterTestSuite) TestDue() {
+	r := newSelfRebroadcaster(func(interface{}) {})
+	pos := types.Position{Height: 10}
+	b := &types.Block{Position: pos}
+	r.addBlock(b)
+	for i := 0; i < maxRebroadcastVotes+1; i++ {
+		r.addVote(&types.Vote{VoteHeader: types.VoteHeader{
+			Position: pos, Period: uint64(i)}})
+	}
+	// The upper bound of the interval before the next attempt.
+	upper := func(attempts int) time.Duration {
+		interval := rebroadcastInterval << uint(attempts)
+		if interval > maxRebroadcastInterval {
+			interval = maxRebroadcastInterval
+		}
+		return interval * 3 / 2
+	}
+	now := time.Now()
+	// Not due yet.
+	s.Require().Empty(r.due(now))
+	// Only the latest votes are kept.
+	now = now.Add(upper(0))
+	msgs := r.due(now)
+	s.Require().Len(msgs, maxRebroadcastVotes+1)
+	s.Require().Equal(b, msgs[0])
+	s.Require().Equal(uint64(1), msgs[1].(*types.Vote).Period)
+	// Attempts are bounded per position.
+	for i := 1; i < maxRebroadcastAttempts; i++ {
+		now = now.Add(upper(i))
+		s.Require().NotEmpty(r.due(now))
+	}
+	now = now.Add(upper(maxRebroadcastAttempts))
+	s.Require().Empty(r.due(now))
+	s.Require().Empty(r.entries)
+	// Entries are expired.
+	r.addBlock(b)
+	s.Require().Empty(r.due(time.Now().Add(rebroadcastExpiry)))
+	s.Require().Empty(r.entries)
+}
+
+func (s *SelfRebroadcasterTestSuite) TestConfirm() {
+	var (
+		received = make(chan interface{}, 10)
+		r        = newSelfRebroadcaster(func(msg interface{}) {
+			received <- msg
+		})
+		b1 = &types.Block{Position: types.Position{Height: 1}}
+		b2 = &types.Block{Position: types.Position{Height: 2}}
+	)
+	r.addBlock(b1)
+	r.addBlock(b2)
+	r.confirm(b1.Position)
+	s.Require().Len(r.entries, 1)
+	// Messages of confirmed positions are ignored.
+	r.addBlock(b1)
+	r.addVote(&types.Vote{VoteHeader: types.VoteHeader{Position: b1.Position}})
+	s.Require().Len(r.entries, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.run(ctx)
+	select {
+	case msg := <-received:
+		s.Require().Equal(b2, msg)
+	case <-time.After(2 * rebroadcastInterval):
+		s.FailNow("not re-broadcasted")
+	}
+}
+
+func TestSelfRebroadcaster(t *testing.T) {
+	suite.Run(t, new(SelfRebroadcasterTestSuite))
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// Errors for validating the transition to next round.
+var (
+	ErrNextDKGNotReady           = errors.New("DKG of next round is not ready")
+	ErrNextGroupPublicKeyInvalid = errors.New(
+		"group public key of next round is invalid")
+	ErrNotarySetTooSmall = errors.New("notary set is smaller than configured")
+)
+
+// RoundTransitionReport is the result of validating the transition to a
+// round before it begins.
+type RoundTransitionReport struct {
+	// Round is the round validated.
+	Round uint64
+	// ProtocolParamsHash is the hash of protocol parameters of that round,
+	// it could be compared with other nodes, it's zero when the
+	// configuration is not ready.
+	ProtocolParamsHash common.Hash
+	// Problems are all problems found, the transition is expected to
+	// succeed when empty.
+	Problems []error
+}
+
+// OK checks if no problem is found.
+func (r *RoundTransitionReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *RoundTransitionReport) String() string {
+	return fmt.Sprintf("RoundTransitionReport{Round:%d Problems:%v}",
+		r.Round, r.Problems)
+}
+
+// ValidateNextRound checks if the round next to the tip is ready to begin,
+// including the configuration, CRS, notary set and DKG of that round. It
+// doesn't change any state, thus could be called periodically long before the
+// round boundary to alert operators.
+func (con *Consensus) ValidateNextRound() *RoundTransitionReport {
+	round := con.bcModule.tipRound() + 1
+	report := &RoundTransitionReport{Round: round}
+	addProblem := func(err error) {
+		report.Problems = append(report.Problems, err)
+	}
+	config := con.gov.Configuration(round)
+	if config == nil {
+		addProblem(ErrConfigurationNotReady)
+		return report
+	}
+	if err := config.Validate(); err != nil {
+		addProblem(err)
+	}
+	report.ProtocolParamsHash = NewProtocolParams(config).Hash()
+	if (con.gov.CRS(round) == common.Hash{}) {
+		addProblem(ErrCRSNotReady)
+	} else if notarySet, err := con.nodeSetCache.GetNotarySet(
+		round); err != nil {
+		addProblem(err)
+	} else if len(notarySet) < int(config.NotarySetSize) {
+		addProblem(ErrNotarySetTooSmall)
+	}
+	if round >= DKGDelayRound {
+		valid, gpkInvalid := utils.IsDKGValid(
+			con.gov, con.logger, round, con.gov.DKGResetCount(round))
+		switch {
+		case gpkInvalid:
+			addProblem(ErrNextGroupPublicKeyInvalid)
+		case !valid:
+			addProblem(ErrNextDKGNotReady)
+		}
+	}
+	return report
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// seenCacheSize is the count of recently processed votes and blocks kept to
+// drop duplicates received from gossip.
+const seenCacheSize = 16384
+
+// seenVoteKey identifies a vote message along with its signatures, a copy of
+// a vote with other signatures is not a duplicate and should be verified.
+type seenVoteKey struct {
+	header     types.VoteHeader
+	signatures common.Hash
+}
+
+// seenBlockKey identifies a block message, a finalized copy of a block is
+// different from the one proposed.
+type seenBlockKey struct {
+	hash      common.Hash
+	proposer  types.NodeID
+	position  types.Position
+	finalized bool
+}
+
+// seenCache keeps votes and blocks processed recently. Messages are marked
+// only after they are processed successfully, thus a forged copy received
+// earlier won't suppress the genuine one.
+type seenCache struct {
+	cache *lru.Cache
+}
+
+func newSeenCache(size int) *seenCache {
+	cache, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+	return &seenCache{cache: cache}
+}
+
+func (c *seenCache) key(msg interface{}) (interface{}, bool) {
+	switch val := msg.(type) {
+	case *types.Vote:
+		return seenVoteKey{
+			header: val.VoteHeader,
+			signatures: crypto.Keccak256Hash(
+				[]byte(val.Signature.Type),
+				val.Signature.Signature,
+				val.PartialSignature.Signature),
+		}, true
+	case *types.Block:
+		return seenBlockKey{
+			hash:      val.Hash,
+			proposer:  val.ProposerID,
+			position:  val.Position,
+			finalized: val.IsFinalized(),
+		}, true
+	}
+	return nil, false
+}
+
+// seen checks if a vote or block is processed recently.
+func (c *seenCache) seen(msg interface{}) bool {
+	key, ok := c.key(msg)
+	return ok && c.cache.Contains(key)
+}
+
+// mark marks a vote or block as processed.
+func (c *seenCache) mark(msg interface{}) {
+	if key, ok := c.key(msg); ok {
+		c.cache.Add(key, struct{}{})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type SeenCacheTestSuite struct {
+	suite.Suite
+}
+
+func (s *SeenCacheTestSuite) TestVotes() {
+	c := newSeenCache(seenCacheSize)
+	v := types.NewVote(types.VoteCom, common.NewRandomHash(), 1)
+	v.Position = types.Position{Round: 1, Height: 10}
+	s.Require().False(c.seen(v))
+	c.mark(v)
+	s.Require().True(c.seen(v))
+	s.Require().True(c.seen(v.Clone()))
+	// Copies with other signatures are not duplicates.
+	forged := v.Clone()
+	forged.Signature.Signature = []byte{1, 2, 3}
+	s.Require().False(c.seen(forged))
+	forged = v.Clone()
+	forged.PartialSignature.Signature = []byte{1, 2, 3}
+	s.Require().False(c.seen(forged))
+	// Votes of another period are not.
+	other := v.Clone()
+	other.Period++
+	s.Require().False(c.seen(other))
+}
+
+func (s *SeenCacheTestSuite) TestBlocks() {
+	c := newSeenCache(seenCacheSize)
+	b := &types.Block{
+		Hash:     common.NewRandomHash(),
+		Position: types.Position{Round: 1, Height: 10},
+	}
+	c.mark(b)
+	s.Require().True(c.seen(b))
+	// The finalized copy of a proposed block should not be suppressed.
+	finalized := b.Clone()
+	finalized.Randomness = []byte{1, 2, 3}
+	s.Require().False(c.seen(finalized))
+	c.mark(finalized)
+	s.Require().True(c.seen(finalized))
+}
+
+func (s *SeenCacheTestSuite) TestEviction() {
+	c := newSeenCache(2)
+	votes := make([]*types.Vote, 3)
+	for i := range votes {
+		votes[i] = types.NewVote(types.VoteCom, common.NewRandomHash(), 1)
+		c.mark(votes[i])
+	}
+	s.Require().False(c.seen(votes[0]))
+	s.Require().True(c.seen(votes[1]))
+	s.Require().True(c.seen(votes[2]))
+}
+
+func TestSeenCache(t *testing.T) {
+	suite.Run(t, new(SeenCacheTestSuite))
+}
@@ -18,7 +18,6 @@
 package syncer
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"time"
@@ -135,7 +134,8 @@ func (a *agreement) processFinalizedBlock(block *types.Block) {
 		a.logger.Trace("finalized block cached", "block", block)
 		return
 	}
-	if err := utils.VerifyBlockSignature(block); err != nil {
+	if err := utils.VerifyBlockSignature(
+		block, a.cache.Registry()); err != nil {
 		return
 	}
 	verifier, ok, err := a.tsigVerifierCache.UpdateAndGet(
@@ -182,8 +182,11 @@ func (a *agreement) processAgreementResult(r *types.AgreementResult) {
 			"error", err)
 		return
 	}
+	var verifier core.TSigVerifier
 	if r.Position.Round >= core.DKGDelayRound {
-		verifier, ok, err := a.tsigVerifierCache.UpdateAndGet(r.Position.Round)
+		var ok bool
+		var err error
+		verifier, ok, err = a.tsigVerifierCache.UpdateAndGet(r.Position.Round)
 		if err != nil {
 			a.logger.Error("error verifying agreement result randomness",
 				"result", r,
@@ -194,19 +197,12 @@ func (a *agreement) processAgreementResult(r *types.AgreementResult) {
 			a.logger.Error("cannot verify agreement result randomness", "result", r)
 			return
 		}
-		if !verifier.VerifySignature(r.BlockHash, crypto.Signature{
-			Type:      "bls",
-			Signature: r.Randomness,
-		}) {
-			a.logger.Error("incorrect agreement result randomness", "result", r)
-			return
-		}
-	} else {
-		// Special case for rounds before DKGDelayRound.
-		if bytes.Compare(r.Randomness, core.NoRand) != 0 {
-			a.logger.Error("incorrect agreement result randomness", "result", r)
-			return
-		}
+	}
+	if err := core.VerifyAgreementResultRandomness(r, verifier); err != nil {
+		a.logger.Error("incorrect agreement result randomness",
+			"result", r,
+			"error", err)
+		return
 	}
 	if r.IsEmptyBlock {
 		b := &types.Block{
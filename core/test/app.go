@@ -94,6 +94,7 @@ type App struct {
 	rEvt                *utils.RoundEvent
 	hEvt                *common.Event
 	roundToNotify       uint64
+	startHeight         uint64
 }
 
 // NewApp constructs a TestApp instance.
@@ -107,6 +108,7 @@ func NewApp(initRound uint64, gov *Governance, rEvt *utils.RoundEvent) (
 		rEvt:            rEvt,
 		hEvt:            common.NewEvent(),
 		roundToNotify:   initRound,
+		startHeight:     types.GenesisHeight,
 	}
 	if gov != nil {
 		app.state = gov.State()
@@ -123,6 +125,15 @@ func NewApp(initRound uint64, gov *Governance, rEvt *utils.RoundEvent) (
 	return app
 }
 
+// StartFrom makes the app expect blocks from a height, for nodes started
+// after genesis.
+func (app *App) StartFrom(height uint64) {
+	app.confirmedLock.Lock()
+	defer app.confirmedLock.Unlock()
+	app.LastConfirmedHeight = height - 1
+	app.startHeight = height
+}
+
 // PreparePayload implements Application interface.
 func (app *App) PreparePayload(position types.Position) ([]byte, error) {
 	if app.state == nil {
@@ -317,7 +328,7 @@ func (app *App) Verify() error {
 	if len(app.DeliverSequence) != len(app.Delivered) {
 		return ErrApplicationIntegrityFailed
 	}
-	expectHeight := uint64(1)
+	expectHeight := app.startHeight
 	prevTime := time.Time{}
 	for _, h := range app.DeliverSequence {
 		_, exist := app.Confirmed[h]
@@ -0,0 +1,160 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// BlockDefect describes how a block forged by BlockForger is broken.
+type BlockDefect int
+
+// BlockDefect enum.
+const (
+	// BlockDefectNone forges a valid block.
+	BlockDefectNone BlockDefect = iota
+	// BlockDefectWrongParent forges a block not acking its parent.
+	BlockDefectWrongParent
+	// BlockDefectWrongHeight forges a block skipping one height.
+	BlockDefectWrongHeight
+	// BlockDefectWrongRound forges a block in the round after its parent.
+	BlockDefectWrongRound
+	// BlockDefectEarlyTimestamp forges a block proposed right at the time of
+	// its parent.
+	BlockDefectEarlyTimestamp
+	// BlockDefectForgedCRSSignature forges a block with random bytes as its
+	// CRS signature.
+	BlockDefectForgedCRSSignature
+	// BlockDefectOversizedPayload forges a block carrying a payload one byte
+	// larger than the limit of the forger, the block is signed correctly.
+	BlockDefectOversizedPayload
+	// BlockDefectPayloadHashMismatch forges a block whose payload is altered
+	// after signed.
+	BlockDefectPayloadHashMismatch
+	// BlockDefectBadSignature forges a block signed by another key.
+	BlockDefectBadSignature
+)
+
+var blockDefectNames = map[BlockDefect]string{
+	BlockDefectNone:                "none",
+	BlockDefectWrongParent:         "wrong-parent",
+	BlockDefectWrongHeight:         "wrong-height",
+	BlockDefectWrongRound:          "wrong-round",
+	BlockDefectEarlyTimestamp:      "early-timestamp",
+	BlockDefectForgedCRSSignature:  "forged-crs-signature",
+	BlockDefectOversizedPayload:    "oversized-payload",
+	BlockDefectPayloadHashMismatch: "payload-hash-mismatch",
+	BlockDefectBadSignature:        "bad-signature",
+}
+
+func (d BlockDefect) String() string {
+	if name, exist := blockDefectNames[d]; exist {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(d))
+}
+
+// BlockForger builds blocks which are well-formed in structure but could be
+// invalid in semantics. It's used to feed adversarial inputs to modules in
+// negative tests.
+type BlockForger struct {
+	signer        *utils.Signer
+	blockInterval time.Duration
+	maxPayload    int
+}
+
+// NewBlockForger constructs a BlockForger instance. Blocks forged are
+// proposed by the owner of prvKey, and are blockInterval later than their
+// parents. maxPayload is the payload size limit assumed by
+// BlockDefectOversizedPayload.
+func NewBlockForger(prvKey crypto.PrivateKey, blockInterval time.Duration,
+	maxPayload int) *BlockForger {
+	return &BlockForger{
+		signer:        utils.NewSigner(prvKey),
+		blockInterval: blockInterval,
+		maxPayload:    maxPayload,
+	}
+}
+
+// Forge builds a child block of parent with the given defect. The CRS
+// signature is only attached for rounds not requiring a BLS signer.
+func (f *BlockForger) Forge(parent *types.Block, crs common.Hash,
+	defect BlockDefect) (b *types.Block, err error) {
+	b = &types.Block{
+		ParentHash: parent.Hash,
+		Position: types.Position{
+			Round:  parent.Position.Round,
+			Height: parent.Position.Height + 1,
+		},
+		Timestamp: parent.Timestamp.Add(f.blockInterval),
+	}
+	switch defect {
+	case BlockDefectWrongParent:
+		b.ParentHash = common.NewRandomHash()
+	case BlockDefectWrongHeight:
+		b.Position.Height++
+	case BlockDefectWrongRound:
+		b.Position.Round++
+	case BlockDefectEarlyTimestamp:
+		b.Timestamp = parent.Timestamp
+	case BlockDefectOversizedPayload:
+		b.Payload = common.GenerateRandomBytes()
+		for len(b.Payload) <= f.maxPayload {
+			b.Payload = append(b.Payload, common.GenerateRandomBytes()...)
+		}
+		b.Payload = b.Payload[:f.maxPayload+1]
+	case BlockDefectPayloadHashMismatch:
+		b.Payload = common.GenerateRandomBytes()
+	}
+	if err = f.signer.SignBlock(b); err != nil {
+		return
+	}
+	switch err = f.signer.SignCRS(b, crs); err {
+	case nil:
+	case utils.ErrNoBLSSigner:
+		err = nil
+	default:
+		return
+	}
+	switch defect {
+	case BlockDefectForgedCRSSignature:
+		b.CRSSignature = crypto.Signature{
+			Type:      "bls",
+			Signature: common.GenerateRandomBytes(),
+		}
+	case BlockDefectPayloadHashMismatch:
+		b.Payload = append(b.Payload, 0)
+	case BlockDefectBadSignature:
+		// Sign the block hash by another key.
+		var prvKey crypto.PrivateKey
+		if prvKey, err = ecdsa.NewPrivateKey(); err != nil {
+			return
+		}
+		if b.Signature, err = prvKey.Sign(b.Hash); err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+type BlockForgerTestSuite struct {
+	suite.Suite
+}
+
+func (s *BlockForgerTestSuite) TestForge() {
+	prvKeys, _, err := NewKeys(1)
+	s.Require().NoError(err)
+	maxPayload := 100
+	forger := NewBlockForger(prvKeys[0], time.Second, maxPayload)
+	parent := &types.Block{
+		Hash:      common.NewRandomHash(),
+		Position:  types.Position{Height: types.GenesisHeight},
+		Timestamp: time.Now().UTC(),
+	}
+	crs := common.NewRandomHash()
+	// A block without defect.
+	b, err := forger.Forge(parent, crs, BlockDefectNone)
+	s.Require().NoError(err)
+	s.Require().Equal(parent.Hash, b.ParentHash)
+	s.Require().Equal(parent.Position.Height+1, b.Position.Height)
+	s.Require().Equal(parent.Timestamp.Add(time.Second), b.Timestamp)
+	s.Require().NoError(utils.VerifyBlockSignature(b, nil))
+	// A block with oversized payload is still signed correctly.
+	b, err = forger.Forge(parent, crs, BlockDefectOversizedPayload)
+	s.Require().NoError(err)
+	s.Require().Len(b.Payload, maxPayload+1)
+	s.Require().NoError(utils.VerifyBlockSignature(b, nil))
+	// Blocks with broken signatures.
+	for _, d := range []BlockDefect{
+		BlockDefectPayloadHashMismatch, BlockDefectBadSignature} {
+		b, err = forger.Forge(parent, crs, d)
+		s.Require().NoError(err)
+		s.Require().Error(utils.VerifyBlockSignature(b, nil), d.String())
+	}
+	s.Require().Equal("unknown(100)", BlockDefect(100).String())
+}
+
+func TestBlockForger(t *testing.T) {
+	suite.Run(t, new(BlockForgerTestSuite))
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// ChainGeneratorConfig is the configuration for ChainGenerator.
+type ChainGeneratorConfig struct {
+	// DMoment is the time the genesis block follows.
+	DMoment time.Time
+	// MinBlockInterval is the minimum interval between a block and its parent.
+	MinBlockInterval time.Duration
+	// IntervalSkew is the maximum extra interval added to MinBlockInterval for
+	// non-empty blocks.
+	IntervalSkew time.Duration
+	// EmptyRatio is the probability that an empty block is confirmed at a
+	// height.
+	EmptyRatio float64
+	// ForkRatio is the probability that conflicting blocks are proposed at a
+	// height.
+	ForkRatio float64
+	// Seed is the seed of the random source, generated chains are
+	// reproducible with the same seed.
+	Seed int64
+}
+
+// GeneratedHeight is the outcome of one height generated by ChainGenerator.
+type GeneratedHeight struct {
+	// Block is the block confirmed at this height, it might be an empty one.
+	Block *types.Block
+	// Forks are blocks proposed at this height but not confirmed.
+	Forks []*types.Block
+}
+
+// Empty checks if the confirmed block is an empty block.
+func (h GeneratedHeight) Empty() bool {
+	return h.Block.IsEmpty()
+}
+
+// ChainGenerator generates valid chains in round 0 as fixtures for tests and
+// benchmarks. Empty blocks are generated in the way the blockchain module
+// does, thus parent hashes are linked through them.
+type ChainGenerator struct {
+	config  ChainGeneratorConfig
+	signers []*utils.Signer
+	rand    *rand.Rand
+	tip     *types.Block
+}
+
+// NewChainGenerator constructs a ChainGenerator instance, blocks are proposed
+// by owners of prvKeys.
+func NewChainGenerator(config ChainGeneratorConfig,
+	prvKeys []crypto.PrivateKey) *ChainGenerator {
+	gen := &ChainGenerator{
+		config: config,
+		rand:   rand.New(rand.NewSource(config.Seed)),
+	}
+	for _, k := range prvKeys {
+		gen.signers = append(gen.signers, utils.NewSigner(k))
+	}
+	return gen
+}
+
+// Generate generates the next count heights following previously generated
+// ones.
+func (gen *ChainGenerator) Generate(count int) (
+	heights []GeneratedHeight, err error) {
+	for i := 0; i < count; i++ {
+		var h GeneratedHeight
+		if gen.rand.Float64() < gen.config.EmptyRatio {
+			if h.Block, err = gen.emptyBlock(); err != nil {
+				return
+			}
+		} else if h.Block, err = gen.block(); err != nil {
+			return
+		}
+		if gen.rand.Float64() < gen.config.ForkRatio {
+			var fork *types.Block
+			if fork, err = gen.block(); err != nil {
+				return
+			}
+			h.Forks = append(h.Forks, fork)
+		}
+		heights = append(heights, h)
+		gen.tip = h.Block
+	}
+	return
+}
+
+func (gen *ChainGenerator) nextPosition() (
+	pos types.Position, parent common.Hash, t time.Time) {
+	if gen.tip == nil {
+		pos.Height = types.GenesisHeight
+		t = gen.config.DMoment.Add(gen.config.MinBlockInterval)
+		return
+	}
+	pos.Height = gen.tip.Position.Height + 1
+	parent = gen.tip.Hash
+	t = gen.tip.Timestamp.Add(gen.config.MinBlockInterval)
+	return
+}
+
+func (gen *ChainGenerator) block() (b *types.Block, err error) {
+	pos, parent, t := gen.nextPosition()
+	if gen.config.IntervalSkew > 0 {
+		t = t.Add(time.Duration(gen.rand.Int63n(int64(
+			gen.config.IntervalSkew))))
+	}
+	b = &types.Block{
+		ParentHash: parent,
+		Position:   pos,
+		Timestamp:  t,
+		Payload:    common.GenerateRandomBytes(),
+	}
+	if gen.tip != nil {
+		b.Witness.Height = gen.tip.Witness.Height
+	}
+	signer := gen.signers[gen.rand.Intn(len(gen.signers))]
+	if err = signer.SignBlock(b); err != nil {
+		return
+	}
+	return
+}
+
+func (gen *ChainGenerator) emptyBlock() (b *types.Block, err error) {
+	pos, parent, t := gen.nextPosition()
+	b = &types.Block{
+		ParentHash: parent,
+		Position:   pos,
+		Timestamp:  t,
+	}
+	if gen.tip != nil {
+		b.Witness.Height = gen.tip.Witness.Height
+		b.Witness.Data = append([]byte{}, gen.tip.Witness.Data...)
+	}
+	b.Hash, err = utils.HashBlock(b)
+	return
+}
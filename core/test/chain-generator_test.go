@@ -0,0 +1,92 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+type ChainGeneratorTestSuite struct {
+	suite.Suite
+}
+
+func (s *ChainGeneratorTestSuite) TestGenerate() {
+	prvKeys, _, err := NewKeys(4)
+	s.Require().NoError(err)
+	config := ChainGeneratorConfig{
+		DMoment:          time.Now().UTC(),
+		MinBlockInterval: time.Second,
+		IntervalSkew:     time.Second,
+		EmptyRatio:       0.3,
+		ForkRatio:        0.3,
+		Seed:             1,
+	}
+	gen := NewChainGenerator(config, prvKeys)
+	heights, err := gen.Generate(50)
+	s.Require().NoError(err)
+	// Generate more heights following previous ones.
+	more, err := gen.Generate(50)
+	s.Require().NoError(err)
+	heights = append(heights, more...)
+	s.Require().Len(heights, 100)
+	empty, forks := 0, 0
+	for i, h := range heights {
+		b := h.Block
+		s.Require().Equal(types.GenesisHeight+uint64(i), b.Position.Height)
+		if i == 0 {
+			s.Require().True(b.IsGenesis())
+		} else {
+			parent := heights[i-1].Block
+			s.Require().Equal(parent.Hash, b.ParentHash)
+			s.Require().True(b.Timestamp.Sub(parent.Timestamp) >=
+				config.MinBlockInterval)
+		}
+		if h.Empty() {
+			empty++
+			hash, err := utils.HashBlock(b)
+			s.Require().NoError(err)
+			s.Require().Equal(hash, b.Hash)
+		} else {
+			s.Require().NoError(utils.VerifyBlockSignature(b, nil))
+		}
+		for _, f := range h.Forks {
+			forks++
+			s.Require().Equal(b.Position, f.Position)
+			s.Require().Equal(b.ParentHash, f.ParentHash)
+			s.Require().NotEqual(b.Hash, f.Hash)
+		}
+	}
+	s.Require().True(empty > 0 && empty < len(heights))
+	s.Require().True(forks > 0)
+	// Chains are reproducible with the same seed.
+	again, err := NewChainGenerator(config, prvKeys).Generate(50)
+	s.Require().NoError(err)
+	for i := range again {
+		s.Require().Equal(heights[i].Block.Timestamp, again[i].Block.Timestamp)
+		s.Require().Equal(heights[i].Empty(), again[i].Empty())
+	}
+}
+
+func TestChainGenerator(t *testing.T) {
+	suite.Run(t, new(ChainGeneratorTestSuite))
+}
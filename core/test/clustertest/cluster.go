@@ -0,0 +1,460 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package clustertest provides an in-process cluster of fully wired consensus
+// nodes, which communicate with each other via fake network.
+package clustertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// Errors for cluster.
+var (
+	ErrUnknownNode      = errors.New("unknown node")
+	ErrNodeKilled       = errors.New("node is killed")
+	ErrClusterStarted   = errors.New("cluster is already started")
+	ErrClusterStopped   = errors.New("cluster is stopped")
+	ErrWaitingTimeout   = errors.New("waiting timeout")
+	ErrNoAliveNode      = errors.New("no alive node")
+	ErrInvalidNodeCount = errors.New("invalid node count")
+)
+
+// Config is the configuration of a cluster, zero values are replaced by
+// defaults.
+type Config struct {
+	// Lambda of BA, default is 100ms to make tests run faster.
+	Lambda time.Duration
+	// RoundLength is the count of blocks in one round, default is 100.
+	RoundLength uint64
+	// DKGDelayRound is the round to start running DKG, default is
+	// core.DKGDelayRound.
+	DKGDelayRound uint64
+	// DMoment is the time to start consensus, default is the time when the
+	// cluster is created.
+	DMoment time.Time
+	// DirectLatency and GossipLatency are latency models of each node, default
+	// is no latency.
+	DirectLatency test.LatencyModel
+	GossipLatency test.LatencyModel
+	// NewLogger creates the logger of a node, default is common.NullLogger.
+	NewLogger func(index int, nID types.NodeID) (common.Logger, error)
+	// PrepareGovernance is called against the seed governance instance before
+	// it's cloned to each node.
+	PrepareGovernance func(*test.Governance) error
+	// Options are passed to each consensus instance.
+	Options []core.Option
+}
+
+func (c *Config) setDefaults() {
+	if c.Lambda == 0 {
+		c.Lambda = 100 * time.Millisecond
+	}
+	if c.RoundLength == 0 {
+		c.RoundLength = 100
+	}
+	if c.DKGDelayRound == 0 {
+		c.DKGDelayRound = core.DKGDelayRound
+	}
+	if c.DMoment.IsZero() {
+		c.DMoment = time.Now().UTC()
+	}
+	if c.DirectLatency == nil {
+		c.DirectLatency = &test.FixedLatencyModel{}
+	}
+	if c.GossipLatency == nil {
+		c.GossipLatency = &test.FixedLatencyModel{}
+	}
+	if c.NewLogger == nil {
+		c.NewLogger = func(int, types.NodeID) (common.Logger, error) {
+			return &common.NullLogger{}, nil
+		}
+	}
+}
+
+// lagLatencyModel delays each message by an extra duration, which could be
+// adjusted when the node is running.
+type lagLatencyModel struct {
+	model test.LatencyModel
+	lag   int64
+}
+
+// Delay implements test.LatencyModel interface.
+func (m *lagLatencyModel) Delay() time.Duration {
+	return m.model.Delay() + time.Duration(atomic.LoadInt64(&m.lag))
+}
+
+func (m *lagLatencyModel) set(lag time.Duration) {
+	atomic.StoreInt64(&m.lag, int64(lag))
+}
+
+// partitionCensor censors every incoming message not sent from the same
+// partition.
+type partitionCensor struct {
+	partition map[types.NodeID]struct{}
+}
+
+// Censor implements test.NetworkCensor interface.
+func (c *partitionCensor) Censor(interface{}) bool { return false }
+
+// CensorFrom implements test.NetworkPeerCensor interface.
+func (c *partitionCensor) CensorFrom(from types.NodeID, _ interface{}) bool {
+	_, exist := c.partition[from]
+	return !exist
+}
+
+// Node is a consensus node in the cluster and its modules.
+type Node struct {
+	ID      types.NodeID
+	Con     *core.Consensus
+	App     *test.App
+	Gov     *test.Governance
+	DB      db.Database
+	Network *test.Network
+	Logger  common.Logger
+
+	directLatency *lagLatencyModel
+	gossipLatency *lagLatencyModel
+	killed        bool
+	stopDummy     func()
+}
+
+// Cluster is a set of consensus nodes running in the same process.
+type Cluster struct {
+	// Nodes are indexed by node ID.
+	Nodes map[types.NodeID]*Node
+	// NodeIDs are sorted IDs of nodes.
+	NodeIDs types.NodeIDs
+
+	lock    sync.Mutex
+	started bool
+	stopped bool
+}
+
+// NewCluster creates a cluster of n nodes, every node is ready to run and
+// connected with each other.
+func NewCluster(n int, config Config) (c *Cluster, err error) {
+	if n <= 0 {
+		err = ErrInvalidNodeCount
+		return
+	}
+	config.setDefaults()
+	prvKeys, pubKeys, err := test.NewKeys(n)
+	if err != nil {
+		return
+	}
+	seedGov, err := test.NewGovernance(
+		test.NewState(config.DKGDelayRound,
+			pubKeys, config.Lambda, &common.NullLogger{}, true),
+		core.ConfigRoundShift)
+	if err != nil {
+		return
+	}
+	if err = seedGov.State().RequestChange(
+		test.StateChangeRoundLength, config.RoundLength); err != nil {
+		return
+	}
+	if config.PrepareGovernance != nil {
+		if err = config.PrepareGovernance(seedGov); err != nil {
+			return
+		}
+	}
+	c = &Cluster{Nodes: make(map[types.NodeID]*Node)}
+	// Setup peer server at transport layer.
+	server := test.NewFakeTransportServer()
+	serverChannel, err := server.Host()
+	if err != nil {
+		return
+	}
+	var (
+		wg       sync.WaitGroup
+		errsLock sync.Mutex
+		errs     []error
+	)
+	for i, k := range prvKeys {
+		nID := types.NewNodeID(k.PublicKey())
+		node := &Node{
+			ID:            nID,
+			directLatency: &lagLatencyModel{model: config.DirectLatency},
+			gossipLatency: &lagLatencyModel{model: config.GossipLatency},
+		}
+		if node.DB, err = db.NewMemBackedDB(); err != nil {
+			return
+		}
+		if node.Logger, err = config.NewLogger(i, nID); err != nil {
+			return
+		}
+		node.Network = test.NewNetwork(k.PublicKey(), test.NetworkConfig{
+			Type:          test.NetworkTypeFake,
+			DirectLatency: node.directLatency,
+			GossipLatency: node.gossipLatency,
+			Marshaller:    test.NewDefaultMarshaller(nil),
+		})
+		node.Gov = seedGov.Clone()
+		node.Gov.SwitchToRemoteMode(node.Network)
+		node.Gov.NotifyRound(0, types.GenesisHeight)
+		node.Network.AttachNodeSetCache(utils.NewNodeSetCache(node.Gov))
+		node.Network.AttachAgreementResultStore(
+			node.DB.(db.AgreementResultStore))
+		node.App = test.NewApp(1, node.Gov, nil)
+		c.Nodes[nID] = node
+		c.NodeIDs = append(c.NodeIDs, nID)
+		wg.Add(1)
+		go func(network *test.Network) {
+			defer wg.Done()
+			if err := network.Setup(serverChannel); err != nil {
+				errsLock.Lock()
+				defer errsLock.Unlock()
+				errs = append(errs, err)
+				return
+			}
+			go network.Run()
+		}(node.Network)
+	}
+	sort.Sort(c.NodeIDs)
+	// Make sure transport layer is ready.
+	if err = server.WaitForPeers(uint32(n)); err != nil {
+		return
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		err = errs[0]
+		return
+	}
+	for _, k := range prvKeys {
+		c.newConsensus(k, config)
+	}
+	return
+}
+
+func (c *Cluster) newConsensus(prvKey crypto.PrivateKey, config Config) {
+	node := c.Nodes[types.NewNodeID(prvKey.PublicKey())]
+	node.Con = core.NewConsensus(
+		config.DMoment,
+		node.App,
+		node.Gov,
+		node.DB,
+		node.Network,
+		prvKey,
+		node.Logger,
+		config.Options...,
+	)
+}
+
+func (c *Cluster) node(nID types.NodeID) (*Node, error) {
+	node, exist := c.Nodes[nID]
+	if !exist {
+		return nil, ErrUnknownNode
+	}
+	if node.killed {
+		return nil, ErrNodeKilled
+	}
+	return node, nil
+}
+
+// Start runs consensus of every node not killed yet.
+func (c *Cluster) Start() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.stopped {
+		return ErrClusterStopped
+	}
+	if c.started {
+		return ErrClusterStarted
+	}
+	c.started = true
+	for _, nID := range c.NodeIDs {
+		if node := c.Nodes[nID]; !node.killed {
+			go node.Con.Run()
+		}
+	}
+	return nil
+}
+
+// Kill stops consensus of a node, the messages sent to it would be dropped.
+func (c *Cluster) Kill(nID types.NodeID) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.stopped {
+		return ErrClusterStopped
+	}
+	node, err := c.node(nID)
+	if err != nil {
+		return err
+	}
+	c.kill(node)
+	return nil
+}
+
+func (c *Cluster) kill(node *Node) {
+	node.killed = true
+	if c.started {
+		node.Con.Stop()
+	}
+	// Clean the network receive channel of killed node, or it might exceed the
+	// limit and block other go routines.
+	node.stopDummy, _ = utils.LaunchDummyReceiver(
+		context.Background(), node.Network.ReceiveChan(), nil)
+}
+
+// Partition splits nodes into groups, nodes could only receive messages from
+// nodes in the same group. Nodes not listed in any group are isolated.
+func (c *Cluster) Partition(groups ...[]types.NodeID) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	censors := make(map[types.NodeID]*partitionCensor)
+	for _, group := range groups {
+		censor := &partitionCensor{partition: make(map[types.NodeID]struct{})}
+		for _, nID := range group {
+			if _, exist := c.Nodes[nID]; !exist {
+				return ErrUnknownNode
+			}
+			if _, exist := censors[nID]; exist {
+				return fmt.Errorf("node in multiple partitions: %s", nID)
+			}
+			censor.partition[nID] = struct{}{}
+			censors[nID] = censor
+		}
+	}
+	for nID, node := range c.Nodes {
+		censor, exist := censors[nID]
+		if !exist {
+			censor = &partitionCensor{
+				partition: map[types.NodeID]struct{}{nID: {}}}
+		}
+		node.Network.SetCensor(censor, nil)
+	}
+	return nil
+}
+
+// Heal removes partitions, every node could receive messages from others.
+func (c *Cluster) Heal() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, node := range c.Nodes {
+		node.Network.SetCensor(nil, nil)
+	}
+}
+
+// Lag delays every message sent from a node by an extra duration, zero lag
+// recovers the node.
+func (c *Cluster) Lag(nID types.NodeID, lag time.Duration) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	node, exist := c.Nodes[nID]
+	if !exist {
+		return ErrUnknownNode
+	}
+	node.directLatency.set(lag)
+	node.gossipLatency.set(lag)
+	return nil
+}
+
+// Alive returns nodes not killed, sorted by node ID.
+func (c *Cluster) Alive() (nodes []*Node) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, nID := range c.NodeIDs {
+		if node := c.Nodes[nID]; !node.killed {
+			nodes = append(nodes, node)
+		}
+	}
+	return
+}
+
+// WaitFor blocks until the latest delivered position of every alive node is
+// no older than the given one.
+func (c *Cluster) WaitFor(pos types.Position, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+Loop:
+	for {
+		select {
+		case <-deadline:
+			return ErrWaitingTimeout
+		case <-ticker.C:
+		}
+		nodes := c.Alive()
+		if len(nodes) == 0 {
+			return ErrNoAliveNode
+		}
+		for _, node := range nodes {
+			latest := node.App.GetLatestDeliveredPosition()
+			if pos.Newer(latest) {
+				continue Loop
+			}
+		}
+		return nil
+	}
+}
+
+// Verify checks if the data delivered by every alive node is valid and
+// identical.
+func (c *Cluster) Verify() error {
+	nodes := c.Alive()
+	for _, node := range nodes {
+		if err := test.VerifyDB(node.DB); err != nil {
+			return err
+		}
+		if err := node.App.Verify(); err != nil {
+			return err
+		}
+		for _, other := range nodes {
+			if node == other {
+				continue
+			}
+			if err := node.App.Compare(other.App); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Stop stops consensus of every node, the cluster could not be restarted.
+func (c *Cluster) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.stopped {
+		return
+	}
+	c.stopped = true
+	for _, nID := range c.NodeIDs {
+		node := c.Nodes[nID]
+		if !node.killed {
+			c.kill(node)
+		}
+	}
+	for _, node := range c.Nodes {
+		node.stopDummy()
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package clustertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type ClusterTestSuite struct {
+	suite.Suite
+}
+
+func (s *ClusterTestSuite) TestKillAndLag() {
+	c, err := NewCluster(4, Config{})
+	s.Require().NoError(err)
+	defer c.Stop()
+	s.Require().Len(c.NodeIDs, 4)
+	s.Require().NoError(c.Kill(c.NodeIDs[0]))
+	s.Require().Equal(ErrNodeKilled, c.Kill(c.NodeIDs[0]))
+	s.Require().NoError(c.Lag(c.NodeIDs[1], 50*time.Millisecond))
+	s.Require().NoError(c.Start())
+	s.Require().Equal(ErrClusterStarted, c.Start())
+	s.Require().Len(c.Alive(), 3)
+	s.Require().NoError(c.WaitFor(types.Position{Height: 10}, time.Minute))
+	c.Stop()
+	s.Require().NoError(c.Verify())
+	s.Require().Equal(ErrClusterStopped, c.Start())
+}
+
+func (s *ClusterTestSuite) TestPartition() {
+	c, err := NewCluster(4, Config{})
+	s.Require().NoError(err)
+	defer c.Stop()
+	ids := c.NodeIDs
+	s.Require().Equal(ErrUnknownNode, c.Partition([]types.NodeID{{}}))
+	// No group could make progress without 2f+1 nodes.
+	s.Require().NoError(c.Partition(ids[:2], ids[2:]))
+	s.Require().NoError(c.Start())
+	s.Require().Equal(ErrWaitingTimeout,
+		c.WaitFor(types.Position{Height: 3}, 2*time.Second))
+	c.Heal()
+	s.Require().NoError(c.WaitFor(types.Position{Height: 10}, time.Minute))
+	c.Stop()
+	s.Require().NoError(c.Verify())
+}
+
+func TestCluster(t *testing.T) {
+	suite.Run(t, new(ClusterTestSuite))
+}
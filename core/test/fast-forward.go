@@ -0,0 +1,140 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	cryptoDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// PrepareDKG completes DKG of a round among nodes offline, as if it's run by
+// them without complaints. Artifacts are added to governance and the private
+// key of each node is stored into its database, thus nodes could start at
+// that round without running its DKG. dbs[i] is the database of the node
+// owning prvKeys[i].
+func PrepareDKG(gov *Governance, round uint64, prvKeys []crypto.PrivateKey,
+	dbs []db.Database) error {
+	if len(prvKeys) != len(dbs) {
+		return fmt.Errorf("mismatched count of keys and databases: %d %d",
+			len(prvKeys), len(dbs))
+	}
+	threshold := utils.GetDKGThreshold(gov.Configuration(round))
+	reset := gov.DKGResetCount(round)
+	signers := make([]*utils.Signer, 0, len(prvKeys))
+	IDs := make(cryptoDKG.IDs, 0, len(prvKeys))
+	for _, prvKey := range prvKeys {
+		signers = append(signers, utils.NewSigner(prvKey))
+		IDs = append(IDs, typesDKG.NewID(types.NewNodeID(prvKey.PublicKey())))
+	}
+	prvShares := make([]*cryptoDKG.PrivateKeyShares, 0, len(prvKeys))
+	for i, signer := range signers {
+		prvShare, pubShare := cryptoDKG.NewPrivateKeyShares(threshold)
+		prvShare.SetParticipants(IDs)
+		prvShares = append(prvShares, prvShare)
+		mpk := &typesDKG.MasterPublicKey{
+			Round:           round,
+			Reset:           reset,
+			DKGID:           IDs[i],
+			PublicKeyShares: *pubShare.Move(),
+		}
+		if err := signer.SignDKGMasterPublicKey(mpk); err != nil {
+			return err
+		}
+		gov.AddDKGMasterPublicKey(mpk)
+	}
+	for i, ID := range IDs {
+		received := cryptoDKG.NewEmptyPrivateKeyShares()
+		for j, prvShare := range prvShares {
+			share, exist := prvShare.Share(ID)
+			if !exist {
+				return fmt.Errorf("share not found: %d %d", j, i)
+			}
+			if err := received.AddShare(IDs[j], share); err != nil {
+				return err
+			}
+		}
+		prvKey, err := received.RecoverPrivateKey(IDs)
+		if err != nil {
+			return err
+		}
+		if err := dbs[i].PutDKGPrivateKey(round, reset, *prvKey); err != nil {
+			return err
+		}
+	}
+	for _, signer := range signers {
+		ready := &typesDKG.MPKReady{Round: round, Reset: reset}
+		if err := signer.SignDKGMPKReady(ready); err != nil {
+			return err
+		}
+		gov.AddDKGMPKReady(ready)
+	}
+	for _, signer := range signers {
+		final := &typesDKG.Finalize{Round: round, Reset: reset}
+		if err := signer.SignDKGFinalize(final); err != nil {
+			return err
+		}
+		gov.AddDKGFinalize(final)
+	}
+	for _, signer := range signers {
+		success := &typesDKG.Success{Round: round, Reset: reset}
+		if err := signer.SignDKGSuccess(success); err != nil {
+			return err
+		}
+		gov.AddDKGSuccess(success)
+	}
+	return nil
+}
+
+// NewInitBlock fabricates a finalized block at a position and stores it into
+// databases as the tip of compaction chain, thus nodes could start from it by
+// core.NewConsensusFromSyncer without delivering blocks before it.
+func NewInitBlock(pos types.Position, timestamp time.Time,
+	dbs []db.Database) (*types.Block, error) {
+	b := &types.Block{
+		Hash:       common.NewRandomHash(),
+		Position:   pos,
+		Timestamp:  timestamp,
+		Randomness: common.NewRandomHash().Bytes(),
+	}
+	for _, dbInst := range dbs {
+		if err := dbInst.PutBlock(*b); err != nil {
+			return nil, err
+		}
+		// Heights of compaction chain are continuous, blocks before it are
+		// not stored.
+		tipHash, tipHeight := dbInst.GetCompactionChainTipInfo()
+		for h := tipHeight + 1; h < pos.Height; h++ {
+			tipHash = crypto.Keccak256Hash(tipHash[:])
+			if err := dbInst.PutCompactionChainTipInfo(tipHash, h); err != nil {
+				return nil, err
+			}
+		}
+		if err := dbInst.PutCompactionChainTipInfo(b.Hash, pos.Height); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
@@ -45,6 +45,7 @@ type Governance struct {
 	networkModule        *Network
 	pendingConfigChanges map[uint64]map[StateChangeType]interface{}
 	prohibitedTypes      map[StateChangeType]struct{}
+	crsFallbacks         map[uint64]common.Hash
 	lock                 sync.RWMutex
 }
 
@@ -56,6 +57,7 @@ func NewGovernance(state *State, roundShift uint64) (g *Governance, err error) {
 		pendingConfigChanges: make(map[uint64]map[StateChangeType]interface{}),
 		stateModule:          state,
 		prohibitedTypes:      make(map[StateChangeType]struct{}),
+		crsFallbacks:         make(map[uint64]common.Hash),
 		roundBeginHeights:    []uint64{types.GenesisHeight},
 	}
 	return
@@ -158,8 +160,15 @@ func (g *Governance) ProposeCRS(round uint64, signedCRS []byte) {
 	defer g.lock.Unlock()
 	crs := crypto.Keccak256Hash(signedCRS)
 	if err := g.stateModule.ProposeCRS(round, crs); err != nil {
-		// CRS can be proposed multiple times, other errors are not
-		// accepted.
+		// CRS can be proposed multiple times, and CRS proposed after the
+		// fallback of that round is recorded is rejected, other errors are
+		// not accepted.
+		if err == ErrForkedCRS {
+			if fallback, exist := g.crsFallbacks[round]; exist &&
+				fallback == g.stateModule.CRS(round) {
+				return
+			}
+		}
 		if err != ErrDuplicatedChange {
 			panic(err)
 		}
@@ -168,6 +177,22 @@ func (g *Governance) ProposeCRS(round uint64, signedCRS []byte) {
 	g.broadcastPendingStateChanges()
 }
 
+// ProposeCRSFallback implements core.CRSFallbackGovernance interface to
+// record the fallback CRS of a round when its CRS is still missing.
+func (g *Governance) ProposeCRSFallback(round uint64, crs common.Hash) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.crsFallbacks[round] = crs
+	if err := g.stateModule.ProposeCRS(round, crs); err != nil {
+		// CRS of that round might be recorded by others already.
+		if err != ErrDuplicatedChange && err != ErrForkedCRS {
+			panic(err)
+		}
+		return
+	}
+	g.broadcastPendingStateChanges()
+}
+
 // AddDKGComplaint add a DKGComplaint.
 func (g *Governance) AddDKGComplaint(complaint *typesDKG.Complaint) {
 	if g.isProhibited(StateAddDKGComplaint) {
@@ -375,6 +400,25 @@ func (g *Governance) CatchUpWithRound(round uint64) {
 	}
 }
 
+// FastForward prepares governance as if rounds before the given round were
+// run without DKG reset: configurations, begin heights and CRS of them are
+// ready. It returns the begin height of that round, and DKG of that round
+// could be prepared by PrepareDKG.
+func (g *Governance) FastForward(round uint64) uint64 {
+	height := types.GenesisHeight
+	for r := uint64(0); r <= round; r++ {
+		if r > 0 {
+			height += g.Configuration(r - 1).RoundLength
+			if g.CRS(r) == (common.Hash{}) {
+				prevCRS := g.CRS(r - 1)
+				g.ProposeCRS(r, prevCRS[:])
+			}
+		}
+		g.NotifyRound(r, height)
+	}
+	return height
+}
+
 // Clone a governance instance with replicate internal state.
 func (g *Governance) Clone() *Governance {
 	g.lock.RLock()
@@ -413,14 +457,21 @@ func (g *Governance) Clone() *Governance {
 	for t := range g.prohibitedTypes {
 		copiedProhibitedTypes[t] = struct{}{}
 	}
+	// Clone proposed fallback CRS.
+	copiedCRSFallbacks := make(map[uint64]common.Hash)
+	for round, crs := range g.crsFallbacks {
+		copiedCRSFallbacks[round] = crs
+	}
 	// Clone pending changes.
 	return &Governance{
 		roundShift:           g.roundShift,
 		configs:              copiedConfigs,
 		stateModule:          copiedState,
 		nodeSets:             copiedNodeSets,
+		roundBeginHeights:    append([]uint64(nil), g.roundBeginHeights...),
 		pendingConfigChanges: copiedPendingChanges,
 		prohibitedTypes:      copiedProhibitedTypes,
+		crsFallbacks:         copiedCRSFallbacks,
 	}
 }
 
@@ -482,7 +533,7 @@ func (g *Governance) Equal(other *Governance, checkState bool) bool {
 // NOTE: this function should be called before running.
 func (g *Governance) RegisterConfigChange(
 	round uint64, t StateChangeType, v interface{}) (err error) {
-	if t < StateAddCRS || t > StateChangeNotarySetSize {
+	if t < StateAddCRS || t > StateChangeBATimeoutMaxScale {
 		return fmt.Errorf("state changes to register is not supported: %v", t)
 	}
 	if round < 2 {
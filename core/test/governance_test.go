@@ -24,6 +24,7 @@ import (
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
@@ -79,6 +80,60 @@ func (s *GovernanceTestSuite) TestEqual() {
 	req.False(g1.Equal(g6, true))
 }
 
+func (s *GovernanceTestSuite) TestFastForward() {
+	req := s.Require()
+	prvKeys, pubKeys, err := NewKeys(4)
+	req.NoError(err)
+	g, err := NewGovernance(NewState(
+		1, pubKeys, 100*time.Millisecond, &common.NullLogger{}, true), 2)
+	req.NoError(err)
+	height := g.FastForward(3)
+	req.Equal(types.GenesisHeight+3*g.Configuration(0).RoundLength, height)
+	req.Equal(height, g.GetRoundHeight(3))
+	req.NotNil(g.Configuration(5))
+	for r := uint64(1); r <= 3; r++ {
+		req.NotEqual(common.Hash{}, g.CRS(r))
+		req.NotEqual(g.CRS(r-1), g.CRS(r))
+	}
+	// Idempotent.
+	req.Equal(height, g.FastForward(3))
+	// Prepare DKG of that round.
+	dbs := make([]db.Database, 0, len(prvKeys))
+	for range prvKeys {
+		dbInst, err := db.NewMemBackedDB()
+		req.NoError(err)
+		dbs = append(dbs, dbInst)
+	}
+	req.NoError(PrepareDKG(g, 3, prvKeys, dbs))
+	req.True(g.IsDKGMPKReady(3))
+	req.True(g.IsDKGFinal(3))
+	req.True(g.IsDKGSuccess(3))
+	// Private keys of nodes are able to form a group signature.
+	mpks := g.DKGMasterPublicKeys(3)
+	req.Len(mpks, len(prvKeys))
+	pubShares := make([]*dkg.PublicKeyShares, 0, len(mpks))
+	for _, mpk := range mpks {
+		pubShares = append(pubShares, &mpk.PublicKeyShares)
+	}
+	hash := crypto.Keccak256Hash([]byte("fast-forward"))
+	threshold := utils.GetDKGThreshold(g.Configuration(3))
+	var (
+		psigs []dkg.PartialSignature
+		IDs   dkg.IDs
+	)
+	for i, prvKey := range prvKeys[:threshold] {
+		dkgPrvKey, err := dbs[i].GetDKGPrivateKey(3, 0)
+		req.NoError(err)
+		sig, err := dkgPrvKey.Sign(hash)
+		req.NoError(err)
+		psigs = append(psigs, dkg.PartialSignature(sig))
+		IDs = append(IDs, typesDKG.NewID(types.NewNodeID(prvKey.PublicKey())))
+	}
+	sig, err := dkg.RecoverSignature(psigs, IDs)
+	req.NoError(err)
+	req.True(dkg.RecoverGroupPublicKey(pubShares).VerifySignature(hash, sig))
+}
+
 func (s *GovernanceTestSuite) TestRegisterChange() {
 	var (
 		req                = s.Require()
@@ -0,0 +1,159 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package govtest provides a test suite to check if an implementation of
+// core.Governance behaves the way consensus core expects.
+package govtest
+
+import (
+	"fmt"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Contract is a test suite could be run against any Governance
+// implementation, ex.
+//
+//	suite.Run(t, &govtest.Contract{
+//	    NewGovernance: newMyGovernance,
+//	    AdvanceRound:  notifyMyGovernance,
+//	})
+type Contract struct {
+	suite.Suite
+
+	// NewGovernance constructs a fresh Governance instance for each test.
+	NewGovernance func() core.Governance
+	// AdvanceRound notifies the governance that a round begins at a height,
+	// rounds are advanced one by one starting from round 1. Only genesis
+	// rounds are checked when not provided.
+	AdvanceRound func(gov core.Governance, round, beginHeight uint64)
+	// Rounds is the count of rounds to advance, 5 rounds by default.
+	Rounds uint64
+}
+
+type roundData struct {
+	config  *types.Config
+	nodeSet [][]byte
+	crs     common.Hash
+}
+
+func packNodeSet(nodeSet []crypto.PublicKey) (packed [][]byte) {
+	for _, key := range nodeSet {
+		packed = append(packed, key.Bytes())
+	}
+	return
+}
+
+// snapshot collects data of a round and make sure they are stable when
+// queried repeatedly.
+func (s *Contract) snapshot(gov core.Governance, round uint64) *roundData {
+	data := &roundData{
+		config:  gov.Configuration(round),
+		nodeSet: packNodeSet(gov.NodeSet(round)),
+		crs:     gov.CRS(round),
+	}
+	s.Require().NotNil(data.config, "config not ready, round: %d", round)
+	s.Require().NotEmpty(data.nodeSet, "node set not ready, round: %d", round)
+	s.Require().Equal(data.config, gov.Configuration(round),
+		"config changed, round: %d", round)
+	s.Require().Equal(data.nodeSet, packNodeSet(gov.NodeSet(round)),
+		"node set changed, round: %d", round)
+	return data
+}
+
+func (s *Contract) checkUnchanged(
+	gov core.Governance, rounds map[uint64]*roundData) {
+	for round, data := range rounds {
+		s.Require().Equal(data.config, gov.Configuration(round),
+			"config changed, round: %d", round)
+		s.Require().Equal(data.nodeSet, packNodeSet(gov.NodeSet(round)),
+			"node set changed, round: %d", round)
+		if (data.crs != common.Hash{}) {
+			s.Require().Equal(data.crs, gov.CRS(round),
+				"crs changed without reset, round: %d", round)
+		}
+	}
+}
+
+// TestGenesis checks data of genesis rounds are available right after
+// constructed.
+func (s *Contract) TestGenesis() {
+	gov := s.NewGovernance()
+	for r := uint64(0); r < core.ConfigRoundShift; r++ {
+		data := s.snapshot(gov, r)
+		s.Require().NotZero(data.config.RoundLength)
+		s.Require().NotZero(data.config.NotarySetSize)
+	}
+	s.Require().NotEqual(common.Hash{}, gov.CRS(0), "genesis crs not ready")
+}
+
+// TestRounds advances rounds and checks:
+//   - config and node set are prepared ConfigRoundShift rounds ahead.
+//   - config, node set and CRS of a round never change once available.
+//   - begin heights of rounds are increasing.
+//   - CRS proposed in previous round is ready when a round begins.
+func (s *Contract) TestRounds() {
+	if s.AdvanceRound == nil {
+		s.T().Skip("unable to advance rounds")
+	}
+	var (
+		req    = s.Require()
+		gov    = s.NewGovernance()
+		rounds = make(map[uint64]*roundData)
+		total  = s.Rounds
+	)
+	if total == 0 {
+		total = 5
+	}
+	for r := uint64(0); r < core.ConfigRoundShift; r++ {
+		rounds[r] = s.snapshot(gov, r)
+	}
+	for r := uint64(1); r <= total; r++ {
+		// CRS of a round is proposed by DKG set of its previous round, CRS
+		// of rounds before DKG is run might be prepared at genesis.
+		if (gov.CRS(r) == common.Hash{}) {
+			gov.ProposeCRS(r, []byte(fmt.Sprintf("crs of round %d", r)))
+		}
+		var beginHeight uint64
+		if r == 1 {
+			beginHeight = gov.GetRoundHeight(r)
+		} else {
+			prev := r - 1
+			beginHeight = gov.GetRoundHeight(prev) +
+				gov.Configuration(prev).RoundLength
+		}
+		s.AdvanceRound(gov, r, beginHeight)
+		req.Equal(beginHeight, gov.GetRoundHeight(r))
+		if r > 1 {
+			req.True(gov.GetRoundHeight(r) > gov.GetRoundHeight(r-1),
+				"round height not increasing, round: %d", r)
+		}
+		crs := gov.CRS(r)
+		req.NotEqual(common.Hash{}, crs, "crs not ready, round: %d", r)
+		if data, exist := rounds[r]; exist {
+			data.crs = crs
+		}
+		shifted := r + core.ConfigRoundShift
+		rounds[shifted] = s.snapshot(gov, shifted)
+		s.checkUnchanged(gov, rounds)
+	}
+}
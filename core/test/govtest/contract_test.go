@@ -0,0 +1,88 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package govtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+)
+
+func newGovernance() core.Governance {
+	_, pubKeys, err := test.NewKeys(7)
+	if err != nil {
+		panic(err)
+	}
+	gov, err := test.NewGovernance(test.NewState(core.DKGDelayRound,
+		pubKeys, 100*time.Millisecond, &common.NullLogger{}, true),
+		core.ConfigRoundShift)
+	if err != nil {
+		panic(err)
+	}
+	return gov
+}
+
+func TestContract(t *testing.T) {
+	suite.Run(t, &Contract{
+		NewGovernance: newGovernance,
+		AdvanceRound: func(gov core.Governance, round, beginHeight uint64) {
+			gov.(*test.Governance).NotifyRound(round, beginHeight)
+		},
+	})
+}
+
+func TestMockContract(t *testing.T) {
+	suite.Run(t, &Contract{
+		NewGovernance: func() core.Governance {
+			return NewMock(newGovernance())
+		},
+		AdvanceRound: func(gov core.Governance, round, beginHeight uint64) {
+			gov.(*Mock).Governance.(*test.Governance).NotifyRound(
+				round, beginHeight)
+		},
+	})
+}
+
+type MockTestSuite struct {
+	suite.Suite
+}
+
+func (s *MockTestSuite) TestReplace() {
+	gov := NewMock(newGovernance())
+	crs := common.NewRandomHash()
+	gov.CRSFunc = func(round uint64) common.Hash {
+		return crs
+	}
+	gov.IsDKGSuccessFunc = func(round uint64) bool {
+		return round == 1
+	}
+	s.Require().Equal(crs, gov.CRS(0))
+	s.Require().False(gov.IsDKGSuccess(0))
+	s.Require().True(gov.IsDKGSuccess(1))
+	// Queries not replaced are forwarded.
+	s.Require().NotNil(gov.Configuration(0))
+}
+
+func TestMock(t *testing.T) {
+	suite.Run(t, new(MockTestSuite))
+}
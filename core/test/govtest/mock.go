@@ -0,0 +1,131 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package govtest
+
+import (
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+)
+
+// Mock is a Governance whose queries could be replaced one by one, ex. to
+// inject a config not ready or a CRS changed within a round. Queries not
+// replaced and all writes are forwarded to the embedded Governance. Optional
+// interfaces of the embedded Governance are hidden by Mock.
+type Mock struct {
+	core.Governance
+
+	ConfigurationFunc       func(round uint64) *types.Config
+	CRSFunc                 func(round uint64) common.Hash
+	NodeSetFunc             func(round uint64) []crypto.PublicKey
+	GetRoundHeightFunc      func(round uint64) uint64
+	DKGComplaintsFunc       func(round uint64) []*typesDKG.Complaint
+	DKGMasterPublicKeysFunc func(round uint64) []*typesDKG.MasterPublicKey
+	IsDKGMPKReadyFunc       func(round uint64) bool
+	IsDKGFinalFunc          func(round uint64) bool
+	IsDKGSuccessFunc        func(round uint64) bool
+	DKGResetCountFunc       func(round uint64) uint64
+}
+
+// NewMock constructs a Mock forwarding to a Governance.
+func NewMock(gov core.Governance) *Mock {
+	return &Mock{Governance: gov}
+}
+
+// Configuration implements core.Governance interface.
+func (m *Mock) Configuration(round uint64) *types.Config {
+	if m.ConfigurationFunc != nil {
+		return m.ConfigurationFunc(round)
+	}
+	return m.Governance.Configuration(round)
+}
+
+// CRS implements core.Governance interface.
+func (m *Mock) CRS(round uint64) common.Hash {
+	if m.CRSFunc != nil {
+		return m.CRSFunc(round)
+	}
+	return m.Governance.CRS(round)
+}
+
+// NodeSet implements core.Governance interface.
+func (m *Mock) NodeSet(round uint64) []crypto.PublicKey {
+	if m.NodeSetFunc != nil {
+		return m.NodeSetFunc(round)
+	}
+	return m.Governance.NodeSet(round)
+}
+
+// GetRoundHeight implements core.Governance interface.
+func (m *Mock) GetRoundHeight(round uint64) uint64 {
+	if m.GetRoundHeightFunc != nil {
+		return m.GetRoundHeightFunc(round)
+	}
+	return m.Governance.GetRoundHeight(round)
+}
+
+// DKGComplaints implements core.Governance interface.
+func (m *Mock) DKGComplaints(round uint64) []*typesDKG.Complaint {
+	if m.DKGComplaintsFunc != nil {
+		return m.DKGComplaintsFunc(round)
+	}
+	return m.Governance.DKGComplaints(round)
+}
+
+// DKGMasterPublicKeys implements core.Governance interface.
+func (m *Mock) DKGMasterPublicKeys(
+	round uint64) []*typesDKG.MasterPublicKey {
+	if m.DKGMasterPublicKeysFunc != nil {
+		return m.DKGMasterPublicKeysFunc(round)
+	}
+	return m.Governance.DKGMasterPublicKeys(round)
+}
+
+// IsDKGMPKReady implements core.Governance interface.
+func (m *Mock) IsDKGMPKReady(round uint64) bool {
+	if m.IsDKGMPKReadyFunc != nil {
+		return m.IsDKGMPKReadyFunc(round)
+	}
+	return m.Governance.IsDKGMPKReady(round)
+}
+
+// IsDKGFinal implements core.Governance interface.
+func (m *Mock) IsDKGFinal(round uint64) bool {
+	if m.IsDKGFinalFunc != nil {
+		return m.IsDKGFinalFunc(round)
+	}
+	return m.Governance.IsDKGFinal(round)
+}
+
+// IsDKGSuccess implements core.Governance interface.
+func (m *Mock) IsDKGSuccess(round uint64) bool {
+	if m.IsDKGSuccessFunc != nil {
+		return m.IsDKGSuccessFunc(round)
+	}
+	return m.Governance.IsDKGSuccess(round)
+}
+
+// DKGResetCount implements core.Governance interface.
+func (m *Mock) DKGResetCount(round uint64) uint64 {
+	if m.DKGResetCountFunc != nil {
+		return m.DKGResetCountFunc(round)
+	}
+	return m.Governance.DKGResetCount(round)
+}
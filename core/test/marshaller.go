@@ -25,6 +25,12 @@ import (
 	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
 )
 
+type namespacedPayload struct {
+	Namespace string `json:"ns"`
+	Type      string `json:"type"`
+	Payload   []byte `json:"payload"`
+}
+
 // DefaultMarshaller is the default marshaller for testing core.Consensus.
 type DefaultMarshaller struct {
 	fallback Marshaller
@@ -59,6 +65,24 @@ func (m *DefaultMarshaller) Unmarshal(
 			break
 		}
 		msg = result
+	case "watermark":
+		watermark := &types.Watermark{}
+		if err = json.Unmarshal(payload, watermark); err != nil {
+			break
+		}
+		msg = watermark
+	case "ping":
+		ping := &types.Ping{}
+		if err = json.Unmarshal(payload, ping); err != nil {
+			break
+		}
+		msg = ping
+	case "readiness":
+		readiness := &types.Readiness{}
+		if err = json.Unmarshal(payload, readiness); err != nil {
+			break
+		}
+		msg = readiness
 	case "dkg-private-share":
 		privateShare := &typesDKG.PrivateShare{}
 		if err = json.Unmarshal(payload, privateShare); err != nil {
@@ -89,6 +113,12 @@ func (m *DefaultMarshaller) Unmarshal(
 			break
 		}
 		msg = final
+	case "dkg-artifacts":
+		artifacts := &typesDKG.Artifacts{}
+		if err = json.Unmarshal(payload, artifacts); err != nil {
+			break
+		}
+		msg = artifacts
 	case "packed-state-changes":
 		packed := &packedStateChanges{}
 		if err = json.Unmarshal(payload, packed); err != nil {
@@ -101,6 +131,16 @@ func (m *DefaultMarshaller) Unmarshal(
 			break
 		}
 		msg = req
+	case "namespaced":
+		raw := &namespacedPayload{}
+		if err = json.Unmarshal(payload, raw); err != nil {
+			break
+		}
+		nsMsg := &NamespacedMsg{Namespace: raw.Namespace}
+		if nsMsg.Msg, err = m.Unmarshal(raw.Type, raw.Payload); err != nil {
+			break
+		}
+		msg = nsMsg
 	default:
 		if m.fallback == nil {
 			err = fmt.Errorf("unknown msg type: %v", msgType)
@@ -124,6 +164,15 @@ func (m *DefaultMarshaller) Marshal(
 	case *types.AgreementResult:
 		msgType = "agreement-result"
 		payload, err = json.Marshal(msg)
+	case *types.Watermark:
+		msgType = "watermark"
+		payload, err = json.Marshal(msg)
+	case *types.Ping:
+		msgType = "ping"
+		payload, err = json.Marshal(msg)
+	case *types.Readiness:
+		msgType = "readiness"
+		payload, err = json.Marshal(msg)
 	case *typesDKG.PrivateShare:
 		msgType = "dkg-private-share"
 		payload, err = json.Marshal(msg)
@@ -139,12 +188,23 @@ func (m *DefaultMarshaller) Marshal(
 	case *typesDKG.Finalize:
 		msgType = "dkg-finalize"
 		payload, err = json.Marshal(msg)
+	case *typesDKG.Artifacts:
+		msgType = "dkg-artifacts"
+		payload, err = json.Marshal(msg)
 	case packedStateChanges:
 		msgType = "packed-state-changes"
 		payload, err = json.Marshal(msg)
 	case *PullRequest:
 		msgType = "pull-request"
 		payload, err = json.Marshal(msg)
+	case *NamespacedMsg:
+		nsMsg := msg.(*NamespacedMsg)
+		raw := &namespacedPayload{Namespace: nsMsg.Namespace}
+		if raw.Type, raw.Payload, err = m.Marshal(nsMsg.Msg); err != nil {
+			break
+		}
+		msgType = "namespaced"
+		payload, err = json.Marshal(raw)
 	default:
 		if m.fallback == nil {
 			err = fmt.Errorf("unknwon message type: %v", msg)
@@ -29,6 +29,7 @@ import (
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
@@ -63,6 +64,15 @@ type NetworkConfig struct {
 	DirectLatency LatencyModel
 	GossipLatency LatencyModel
 	Marshaller    Marshaller
+	// TLSKey enables TLS for TCP networks when set, the key should be the
+	// private key of this node.
+	TLSKey crypto.PrivateKey
+	// Namespace isolates consensus instances sharing one network, messages
+	// from other namespaces are dropped.
+	Namespace string
+	// Mux is the transport shared with Network instances of other
+	// namespaces, a transport is created when it's nil.
+	Mux *TransportMux
 }
 
 // PullRequest is a generic request to pull everything (ex. vote, block...).
@@ -81,6 +91,10 @@ func (req *PullRequest) MarshalJSON() (b []byte, err error) {
 		idAsBytes, err = json.Marshal(req.Identity.(common.Hashes))
 	case "vote":
 		idAsBytes, err = json.Marshal(req.Identity.(types.Position))
+	case "dkg-artifacts":
+		idAsBytes, err = json.Marshal(req.Identity.(uint64))
+	case "agreement-result":
+		idAsBytes, err = json.Marshal(req.Identity.([]types.Position))
 	default:
 		err = fmt.Errorf("unknown ID type for pull request: %v", req.Type)
 	}
@@ -119,6 +133,18 @@ func (req *PullRequest) UnmarshalJSON(data []byte) (err error) {
 			break
 		}
 		ID = pos
+	case "dkg-artifacts":
+		var round uint64
+		if err = json.Unmarshal(rawReq.Identity, &round); err != nil {
+			break
+		}
+		ID = round
+	case "agreement-result":
+		positions := []types.Position{}
+		if err = json.Unmarshal(rawReq.Identity, &positions); err != nil {
+			break
+		}
+		ID = positions
 	default:
 		err = fmt.Errorf("unknown pull request type: %v", rawReq.Type)
 	}
@@ -136,6 +162,14 @@ type NetworkCensor interface {
 	Censor(interface{}) bool
 }
 
+// NetworkPeerCensor is a NetworkCensor which determines if a message should be
+// censored by its sender as well. It's only checked against incoming messages.
+type NetworkPeerCensor interface {
+	NetworkCensor
+
+	CensorFrom(types.NodeID, interface{}) bool
+}
+
 type censorClient struct {
 	TransportClient
 
@@ -191,6 +225,7 @@ type Network struct {
 	voteCacheSize        int
 	votePositions        []types.Position
 	stateModule          *State
+	resultStore          db.AgreementResultStore
 	peers                map[types.NodeID]struct{}
 	unreceivedBlocksLock sync.RWMutex
 	unreceivedBlocks     map[common.Hash]chan<- common.Hash
@@ -224,16 +259,26 @@ func NewNetwork(pubKey crypto.PublicKey, config NetworkConfig) (
 	n.ctx, n.ctxCancel = context.WithCancel(context.Background())
 	// Construct transport layer.
 	var trans TransportClient
-	switch config.Type {
-	case NetworkTypeTCPLocal:
-		trans = NewTCPTransportClient(pubKey, config.Marshaller, true)
-	case NetworkTypeTCP:
-		trans = NewTCPTransportClient(pubKey, config.Marshaller, false)
-	case NetworkTypeFake:
+	switch {
+	case config.Mux != nil:
+		trans = config.Mux.Client(config.Namespace)
+	case config.Type == NetworkTypeTCP || config.Type == NetworkTypeTCPLocal:
+		tcpTrans := NewTCPTransportClient(pubKey, config.Marshaller,
+			config.Type == NetworkTypeTCPLocal)
+		if config.TLSKey != nil {
+			if err := tcpTrans.EnableTLS(config.TLSKey); err != nil {
+				panic(err)
+			}
+		}
+		trans = tcpTrans
+	case config.Type == NetworkTypeFake:
 		trans = NewFakeTransportClient(pubKey)
 	default:
 		panic(fmt.Errorf("unknown network type: %v", config.Type))
 	}
+	if config.Mux == nil && config.Namespace != "" {
+		trans = NewTransportMux(trans).Client(config.Namespace)
+	}
 	n.trans = &censorClient{
 		TransportClient: trans,
 		censor:          &dummyCensor{},
@@ -261,11 +306,26 @@ func (n *Network) SetCensor(censorIn, censorOut NetworkCensor) {
 	}()
 }
 
+// Namespace implements core.NamespacedNetwork interface.
+func (n *Network) Namespace() string {
+	return n.config.Namespace
+}
+
 // PullBlocks implements core.Network interface.
 func (n *Network) PullBlocks(hashes common.Hashes) {
 	go n.pullBlocksAsync(hashes)
 }
 
+// GetDKGArtifacts implements core.DKGArtifactsPuller interface.
+func (n *Network) GetDKGArtifacts(round uint64) {
+	go n.pullDKGArtifactsAsync(round)
+}
+
+// PullAgreementResults implements core.AgreementResultPuller interface.
+func (n *Network) PullAgreementResults(positions []types.Position) {
+	go n.pullAgreementResultsAsync(positions)
+}
+
 // PullVotes implements core.Network interface.
 func (n *Network) PullVotes(pos types.Position) {
 	go n.pullVotesAsync(pos)
@@ -325,6 +385,27 @@ func (n *Network) BroadcastAgreementResult(
 	}
 }
 
+// BroadcastWatermark implements core.WatermarkGossiper interface.
+func (n *Network) BroadcastWatermark(watermark *types.Watermark) {
+	if err := n.trans.Broadcast(
+		n.peers, n.config.GossipLatency, watermark); err != nil {
+		panic(err)
+	}
+}
+
+// BroadcastReadiness implements core.ReadinessAnnouncer interface.
+func (n *Network) BroadcastReadiness(readiness *types.Readiness) {
+	if err := n.trans.Broadcast(
+		n.peers, n.config.GossipLatency, readiness); err != nil {
+		panic(err)
+	}
+}
+
+// SendPing implements core.LatencyProber interface.
+func (n *Network) SendPing(to types.NodeID, ping *types.Ping) {
+	n.send(to, ping)
+}
+
 // SendDKGPrivateShare implements core.Network interface.
 func (n *Network) SendDKGPrivateShare(
 	recv crypto.PublicKey, prvShare *typesDKG.PrivateShare) {
@@ -381,6 +462,9 @@ func (n *Network) dispatchMsg(e *TransportEnvelope) {
 	if func() bool {
 		n.censorLock.RLock()
 		defer n.censorLock.RUnlock()
+		if censor, ok := n.censor.(NetworkPeerCensor); ok {
+			return censor.CensorFrom(e.From, e.Msg)
+		}
 		return n.censor.Censor(e.Msg)
 	}() {
 		return
@@ -409,8 +493,9 @@ func (n *Network) dispatchMsg(e *TransportEnvelope) {
 			PeerID:  e.From,
 			Payload: v,
 		}
-	case *types.AgreementResult,
-		*typesDKG.PrivateShare, *typesDKG.PartialSignature:
+	case *types.AgreementResult, *types.Watermark, *types.Ping,
+		*types.Readiness, *typesDKG.PrivateShare, *typesDKG.PartialSignature,
+		*typesDKG.Artifacts:
 		n.toConsensus <- types.Msg{
 			PeerID:  e.From,
 			Payload: v,
@@ -462,6 +547,30 @@ func (n *Network) handlePullRequest(req *PullRequest) {
 				}
 			}
 		}()
+	case "dkg-artifacts":
+		// DKG artifacts are served from the attached state.
+		if n.stateModule == nil {
+			break
+		}
+		round := req.Identity.(uint64)
+		n.send(req.Requester, &typesDKG.Artifacts{
+			Round:            round,
+			Reset:            n.stateModule.DKGResetCount(round),
+			MasterPublicKeys: n.stateModule.DKGMasterPublicKeys(round),
+			Complaints:       n.stateModule.DKGComplaints(round),
+		})
+	case "agreement-result":
+		// Agreement results are served from the attached store.
+		if n.resultStore == nil {
+			break
+		}
+		for _, pos := range req.Identity.([]types.Position) {
+			result, err := n.resultStore.GetAgreementResult(pos)
+			if err != nil {
+				continue
+			}
+			n.send(req.Requester, &result)
+		}
 	default:
 		panic(fmt.Errorf("unknown type of pull request: %v", req.Type))
 	}
@@ -538,6 +647,13 @@ func (n *Network) addStateModule(s *State) {
 	n.stateModule = s
 }
 
+// AttachAgreementResultStore attaches a store of agreement results, which is
+// used to serve agreement results pulled by peers.
+func (n *Network) AttachAgreementResultStore(store db.AgreementResultStore) {
+	// This variable should be attached before run, no lock to protect it.
+	n.resultStore = store
+}
+
 // AttachNodeSetCache attaches an utils.NodeSetCache to this module. Once attached
 // The behavior of Broadcast-X methods would be switched to broadcast to correct
 // set of peers, instead of all peers.
@@ -627,6 +743,50 @@ func (n *Network) pullVotesAsync(pos types.Position) {
 	}
 }
 
+func (n *Network) pullDKGArtifactsAsync(round uint64) {
+	req := &PullRequest{
+		Requester: n.ID,
+		Type:      "dkg-artifacts",
+		Identity:  round,
+	}
+	// Pull from several peers in notary set, a peer might miss some artifacts
+	// as well.
+	sentCount := 0
+	for nID := range n.getNotarySet(round) {
+		if nID == n.ID {
+			continue
+		}
+		n.send(nID, req)
+		sentCount++
+		if sentCount >= maxPullingPeerCount {
+			break
+		}
+	}
+}
+
+func (n *Network) pullAgreementResultsAsync(positions []types.Position) {
+	if len(positions) == 0 {
+		return
+	}
+	req := &PullRequest{
+		Requester: n.ID,
+		Type:      "agreement-result",
+		Identity:  positions,
+	}
+	// Agreement results are kept by nodes in notary set of that round.
+	sentCount := 0
+	for nID := range n.getNotarySet(positions[0].Round) {
+		if nID == n.ID {
+			continue
+		}
+		n.send(nID, req)
+		sentCount++
+		if sentCount >= maxPullingPeerCount {
+			break
+		}
+	}
+}
+
 func (n *Network) addBlockToCache(b *types.Block) {
 	n.blockCacheLock.Lock()
 	defer n.blockCacheLock.Unlock()
@@ -27,6 +27,7 @@ import (
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
@@ -103,6 +104,19 @@ func (s *NetworkTestSuite) TestPullRequestMarshaling() {
 		req.Identity.(types.Position).Round)
 	s.Require().Equal(req.Identity.(types.Position).Height,
 		req.Identity.(types.Position).Height)
+	// Verify pull request for agreement results is able to be marshalled.
+	positions := []types.Position{{Round: 1, Height: 3}, {Round: 2, Height: 7}}
+	req = &PullRequest{
+		Requester: GenerateRandomNodeIDs(1)[0],
+		Type:      "agreement-result",
+		Identity:  positions,
+	}
+	b, err = json.Marshal(req)
+	s.Require().NoError(err)
+	req2 = &PullRequest{}
+	s.Require().NoError(json.Unmarshal(b, req2))
+	s.Require().Equal(req.Type, req2.Type)
+	s.Require().Equal(positions, req2.Identity)
 }
 
 func (s *NetworkTestSuite) TestPullBlocks() {
@@ -234,6 +248,63 @@ func (s *NetworkTestSuite) TestPullVotes() {
 	}
 }
 
+func (s *NetworkTestSuite) TestPullAgreementResults() {
+	var (
+		peerCount = maxPullingPeerCount
+		req       = s.Require()
+	)
+	_, pubKeys, err := NewKeys(peerCount)
+	req.NoError(err)
+	networks := s.setupNetworks(pubKeys)
+	// Randomly pick one network instance as master, others serve results
+	// from their stores.
+	var master *Network
+	for _, master = range networks {
+		break
+	}
+	positions := []types.Position{}
+	for h := uint64(1); h <= 5; h++ {
+		positions = append(positions, types.Position{Round: 1, Height: h})
+	}
+	for _, n := range networks {
+		if n.ID == master.ID {
+			continue
+		}
+		dbInst, err := db.NewMemBackedDB()
+		req.NoError(err)
+		for _, pos := range positions {
+			req.NoError(dbInst.PutAgreementResult(types.AgreementResult{
+				BlockHash: common.Hash{byte(pos.Height)},
+				Position:  pos,
+			}))
+		}
+		n.AttachAgreementResultStore(dbInst)
+	}
+	// Positions not stored by peers should be ignored.
+	master.PullAgreementResults(append(positions,
+		types.Position{Round: 1, Height: 100}))
+	awaitMap := make(map[types.Position]struct{})
+	for _, pos := range positions {
+		awaitMap[pos] = struct{}{}
+	}
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancelFunc()
+	for len(awaitMap) > 0 {
+		select {
+		case v := <-master.ReceiveChan():
+			result, ok := v.Payload.(*types.AgreementResult)
+			if !ok {
+				break
+			}
+			req.Equal(common.Hash{byte(result.Position.Height)},
+				result.BlockHash)
+			delete(awaitMap, result.Position)
+		case <-ctx.Done():
+			s.FailNow("PullAgreementResults Fail")
+		}
+	}
+}
+
 func (s *NetworkTestSuite) TestBroadcastToSet() {
 	// Make sure when a network module attached to a utils.NodeSetCache,
 	// These function would broadcast to correct nodes, not all peers.
@@ -305,6 +376,16 @@ func (vc *testVoteCensor) Censor(msg interface{}) bool {
 	return false
 }
 
+type testPeerCensor struct {
+	testVoteCensor
+
+	from types.NodeID
+}
+
+func (pc *testPeerCensor) CensorFrom(from types.NodeID, msg interface{}) bool {
+	return from == pc.from && pc.Censor(msg)
+}
+
 func (s *NetworkTestSuite) TestCensor() {
 	var (
 		req       = s.Require()
@@ -372,6 +453,95 @@ func (s *NetworkTestSuite) TestCensor() {
 		}
 	}
 
+	// Censor incoming votes from some peer.
+	censorNode.SetCensor(&testPeerCensor{from: otherNodeID}, nil)
+	otherNode.BroadcastVote(vote)
+	time.Sleep(50 * time.Millisecond)
+	req.Equal(0, len(receiveChans[censorNodeID]))
+	for nID, receiveChan := range receiveChans {
+		if nID != otherNodeID && nID != censorNodeID {
+			req.Equal(1, len(receiveChan))
+			<-receiveChan
+		}
+	}
+	anotherNode := networks[types.NewNodeID(pubKeys[2])]
+	anotherNode.BroadcastVote(vote)
+	time.Sleep(50 * time.Millisecond)
+	req.Equal(1, len(receiveChans[censorNodeID]))
+}
+
+func (s *NetworkTestSuite) TestNamespace() {
+	var (
+		req        = s.Require()
+		peerCount  = 4
+		namespaces = []string{"shard-0", "shard-1"}
+		server     = NewFakeTransportServer()
+		wg         sync.WaitGroup
+	)
+	_, pubKeys, err := NewKeys(peerCount)
+	req.NoError(err)
+	serverChannel, err := server.Host()
+	req.NoError(err)
+	// Each node hosts networks of all namespaces on one transport.
+	networks := make(map[string]map[types.NodeID]*Network)
+	for _, ns := range namespaces {
+		networks[ns] = make(map[types.NodeID]*Network)
+	}
+	for _, key := range pubKeys {
+		mux := NewTransportMux(NewFakeTransportClient(key))
+		for _, ns := range namespaces {
+			n := NewNetwork(key, NetworkConfig{
+				Type:          NetworkTypeFake,
+				DirectLatency: &FixedLatencyModel{},
+				GossipLatency: &FixedLatencyModel{},
+				Marshaller:    NewDefaultMarshaller(nil),
+				Namespace:     ns,
+				Mux:           mux,
+			})
+			req.Equal(ns, n.Namespace())
+			networks[ns][n.ID] = n
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.Require().NoError(n.Setup(serverChannel))
+				go n.Run()
+			}()
+		}
+	}
+	req.NoError(server.WaitForPeers(uint32(peerCount)))
+	wg.Wait()
+	// Votes are only received by networks in the same namespace.
+	sender := types.NewNodeID(pubKeys[0])
+	networks[namespaces[0]][sender].BroadcastVote(&types.Vote{})
+	time.Sleep(50 * time.Millisecond)
+	for ns, nodes := range networks {
+		for nID, n := range nodes {
+			if ns != namespaces[0] || nID == sender {
+				req.Equal(0, len(n.ReceiveChan()))
+			} else {
+				req.Equal(1, len(n.ReceiveChan()))
+				msg := <-n.ReceiveChan()
+				req.IsType(&types.Vote{}, msg.Payload)
+			}
+		}
+	}
+	// Namespaced messages are marshallable.
+	m := NewDefaultMarshaller(nil)
+	msgType, payload, err := m.Marshal(&NamespacedMsg{
+		Namespace: namespaces[1],
+		Msg:       &types.Vote{VoteHeader: types.VoteHeader{Period: 1}},
+	})
+	req.NoError(err)
+	msg, err := m.Unmarshal(msgType, payload)
+	req.NoError(err)
+	req.Equal(namespaces[1], msg.(*NamespacedMsg).Namespace)
+	req.Equal(uint64(1), msg.(*NamespacedMsg).Msg.(*types.Vote).Period)
+	// The transport is closed after all namespaces are closed.
+	for _, nodes := range networks {
+		for _, n := range nodes {
+			req.NoError(n.Close())
+		}
+	}
 }
 
 func TestNetwork(t *testing.T) {
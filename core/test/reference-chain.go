@@ -0,0 +1,107 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// ReferenceChain is a slow but straightforward implementation of the
+// ordering and timestamping of confirmed blocks. It's used as the reference
+// in differential tests against the real implementation.
+//
+// Confirmed blocks are delivered in height order starting from the genesis
+// height, and the delivery stops at the first height not confirmed yet. An
+// empty block follows its parent with minimum block interval.
+type ReferenceChain struct {
+	dMoment       time.Time
+	blockInterval time.Duration
+	confirmed     map[uint64]*types.Block
+	empty         map[uint64]types.Position
+}
+
+// NewReferenceChain constructs a ReferenceChain instance.
+func NewReferenceChain(
+	dMoment time.Time, blockInterval time.Duration) *ReferenceChain {
+	return &ReferenceChain{
+		dMoment:       dMoment,
+		blockInterval: blockInterval,
+		confirmed:     make(map[uint64]*types.Block),
+		empty:         make(map[uint64]types.Position),
+	}
+}
+
+// Confirm records a block confirmed by BA. The first confirmation of a height
+// wins.
+func (r *ReferenceChain) Confirm(b *types.Block) {
+	if r.exists(b.Position.Height) {
+		return
+	}
+	r.confirmed[b.Position.Height] = b
+}
+
+// ConfirmEmpty records an empty block confirmed by BA. The first confirmation
+// of a height wins.
+func (r *ReferenceChain) ConfirmEmpty(pos types.Position) {
+	if r.exists(pos.Height) {
+		return
+	}
+	r.empty[pos.Height] = pos
+}
+
+func (r *ReferenceChain) exists(h uint64) bool {
+	if _, exist := r.confirmed[h]; exist {
+		return true
+	}
+	_, exist := r.empty[h]
+	return exist
+}
+
+// Delivered recomputes blocks delivered from scratch.
+func (r *ReferenceChain) Delivered() (blocks []*types.Block) {
+	var parent *types.Block
+	for h := types.GenesisHeight; ; h++ {
+		if b, exist := r.confirmed[h]; exist {
+			blocks = append(blocks, b)
+			parent = b
+			continue
+		}
+		pos, exist := r.empty[h]
+		if !exist {
+			return
+		}
+		b := &types.Block{Position: pos}
+		if parent == nil {
+			b.Timestamp = r.dMoment.Add(r.blockInterval)
+		} else {
+			b.ParentHash = parent.Hash
+			b.Timestamp = parent.Timestamp.Add(r.blockInterval)
+			b.Witness.Height = parent.Witness.Height
+			b.Witness.Data = append([]byte{}, parent.Witness.Data...)
+		}
+		var err error
+		if b.Hash, err = utils.HashBlock(b); err != nil {
+			panic(err)
+		}
+		blocks = append(blocks, b)
+		parent = b
+	}
+}
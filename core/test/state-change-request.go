@@ -47,7 +47,13 @@ const (
 	StateChangeLambdaDKG
 	StateChangeRoundLength
 	StateChangeMinBlockInterval
+	StateChangeMaxBlockInterval
+	StateChangeDKGRegisterDuration
+	StateChangeDKGComplaintDuration
+	StateChangeDKGFinalizeDuration
 	StateChangeNotarySetSize
+	StateChangeBATimeoutGrowth
+	StateChangeBATimeoutMaxScale
 	// Node set related.
 	StateAddNode
 )
@@ -74,8 +80,20 @@ func (t StateChangeType) String() string {
 		return "ChangeRoundLength"
 	case StateChangeMinBlockInterval:
 		return "ChangeMinBlockInterval"
+	case StateChangeMaxBlockInterval:
+		return "ChangeMaxBlockInterval"
+	case StateChangeDKGRegisterDuration:
+		return "ChangeDKGRegisterDuration"
+	case StateChangeDKGComplaintDuration:
+		return "ChangeDKGComplaintDuration"
+	case StateChangeDKGFinalizeDuration:
+		return "ChangeDKGFinalizeDuration"
 	case StateChangeNotarySetSize:
 		return "ChangeNotarySetSize"
+	case StateChangeBATimeoutGrowth:
+		return "ChangeBATimeoutGrowth"
+	case StateChangeBATimeoutMaxScale:
+		return "ChangeBATimeoutMaxScale"
 	case StateAddNode:
 		return "AddNode"
 	}
@@ -191,7 +209,15 @@ func (req *StateChangeRequest) String() (ret string) {
 		ret += fmt.Sprintf("%v", time.Duration(req.Payload.(uint64)))
 	case StateChangeMinBlockInterval:
 		ret += fmt.Sprintf("%v", time.Duration(req.Payload.(uint64)))
-	case StateChangeNotarySetSize:
+	case StateChangeMaxBlockInterval:
+		ret += fmt.Sprintf("%v", time.Duration(req.Payload.(uint64)))
+	case StateChangeDKGRegisterDuration,
+		StateChangeDKGComplaintDuration,
+		StateChangeDKGFinalizeDuration:
+		ret += fmt.Sprintf("%v", time.Duration(req.Payload.(uint64)))
+	case StateChangeNotarySetSize,
+		StateChangeBATimeoutGrowth,
+		StateChangeBATimeoutMaxScale:
 		ret += fmt.Sprintf("%v", req.Payload.(uint32))
 	case StateAddNode:
 		ret += fmt.Sprintf(
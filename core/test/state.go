@@ -99,6 +99,14 @@ type State struct {
 	notarySetSize    uint32
 	roundInterval    uint64
 	minBlockInterval time.Duration
+	maxBlockInterval time.Duration
+	// DKG phases
+	dkgRegisterDuration  time.Duration
+	dkgComplaintDuration time.Duration
+	dkgFinalizeDuration  time.Duration
+	// BA timeout
+	baTimeoutGrowth   uint32
+	baTimeoutMaxScale uint32
 	// Nodes
 	nodes map[types.NodeID]crypto.PublicKey
 	// DKG & CRS
@@ -189,6 +197,14 @@ func (s *State) Snapshot() (*types.Config, []crypto.PublicKey) {
 		NotarySetSize:    s.notarySetSize,
 		RoundLength:      s.roundInterval,
 		MinBlockInterval: s.minBlockInterval,
+		MaxBlockInterval: s.maxBlockInterval,
+
+		DKGRegisterDuration:  s.dkgRegisterDuration,
+		DKGComplaintDuration: s.dkgComplaintDuration,
+		DKGFinalizeDuration:  s.dkgFinalizeDuration,
+
+		BATimeoutGrowth:   s.baTimeoutGrowth,
+		BATimeoutMaxScale: s.baTimeoutMaxScale,
 	}
 	s.logger.Info("Snapshot config", "config", cfg)
 	return cfg, nodes
@@ -240,7 +256,19 @@ func (s *State) unpackPayload(
 		var tmp uint64
 		err = rlp.DecodeBytes(raw.Payload, &tmp)
 		v = tmp
-	case StateChangeNotarySetSize:
+	case StateChangeMaxBlockInterval:
+		var tmp uint64
+		err = rlp.DecodeBytes(raw.Payload, &tmp)
+		v = tmp
+	case StateChangeDKGRegisterDuration,
+		StateChangeDKGComplaintDuration,
+		StateChangeDKGFinalizeDuration:
+		var tmp uint64
+		err = rlp.DecodeBytes(raw.Payload, &tmp)
+		v = tmp
+	case StateChangeNotarySetSize,
+		StateChangeBATimeoutGrowth,
+		StateChangeBATimeoutMaxScale:
 		var tmp uint32
 		err = rlp.DecodeBytes(raw.Payload, &tmp)
 		v = tmp
@@ -286,7 +314,13 @@ func (s *State) Equal(other *State) error {
 		s.lambdaDKG == other.lambdaDKG &&
 		s.notarySetSize == other.notarySetSize &&
 		s.roundInterval == other.roundInterval &&
-		s.minBlockInterval == other.minBlockInterval
+		s.minBlockInterval == other.minBlockInterval &&
+		s.maxBlockInterval == other.maxBlockInterval &&
+		s.dkgRegisterDuration == other.dkgRegisterDuration &&
+		s.dkgComplaintDuration == other.dkgComplaintDuration &&
+		s.dkgFinalizeDuration == other.dkgFinalizeDuration &&
+		s.baTimeoutGrowth == other.baTimeoutGrowth &&
+		s.baTimeoutMaxScale == other.baTimeoutMaxScale
 	if !configEqual {
 		return ErrStateConfigNotEqual
 	}
@@ -477,9 +511,17 @@ func (s *State) Clone() (copied *State) {
 		notarySetSize:    s.notarySetSize,
 		roundInterval:    s.roundInterval,
 		minBlockInterval: s.minBlockInterval,
+		maxBlockInterval: s.maxBlockInterval,
 		local:            s.local,
 		logger:           s.logger,
-		nodes:            make(map[types.NodeID]crypto.PublicKey),
+
+		dkgRegisterDuration:  s.dkgRegisterDuration,
+		dkgComplaintDuration: s.dkgComplaintDuration,
+		dkgFinalizeDuration:  s.dkgFinalizeDuration,
+		baTimeoutGrowth:      s.baTimeoutGrowth,
+		baTimeoutMaxScale:    s.baTimeoutMaxScale,
+
+		nodes: make(map[types.NodeID]crypto.PublicKey),
 		dkgComplaints: make(
 			map[uint64]map[types.NodeID][]*typesDKG.Complaint),
 		dkgMasterPublicKeys: make(
@@ -814,8 +856,20 @@ func (s *State) applyRequest(req *StateChangeRequest) error {
 		s.roundInterval = req.Payload.(uint64)
 	case StateChangeMinBlockInterval:
 		s.minBlockInterval = time.Duration(req.Payload.(uint64))
+	case StateChangeMaxBlockInterval:
+		s.maxBlockInterval = time.Duration(req.Payload.(uint64))
+	case StateChangeDKGRegisterDuration:
+		s.dkgRegisterDuration = time.Duration(req.Payload.(uint64))
+	case StateChangeDKGComplaintDuration:
+		s.dkgComplaintDuration = time.Duration(req.Payload.(uint64))
+	case StateChangeDKGFinalizeDuration:
+		s.dkgFinalizeDuration = time.Duration(req.Payload.(uint64))
 	case StateChangeNotarySetSize:
 		s.notarySetSize = req.Payload.(uint32)
+	case StateChangeBATimeoutGrowth:
+		s.baTimeoutGrowth = req.Payload.(uint32)
+	case StateChangeBATimeoutMaxScale:
+		s.baTimeoutMaxScale = req.Payload.(uint32)
 	default:
 		return errors.New("you are definitely kidding me")
 	}
@@ -841,7 +895,11 @@ func (s *State) RequestChange(
 		payload = payload.(crypto.PublicKey).Bytes()
 	case StateChangeLambdaBA,
 		StateChangeLambdaDKG,
-		StateChangeMinBlockInterval:
+		StateChangeMinBlockInterval,
+		StateChangeMaxBlockInterval,
+		StateChangeDKGRegisterDuration,
+		StateChangeDKGComplaintDuration,
+		StateChangeDKGFinalizeDuration:
 		payload = uint64(payload.(time.Duration))
 	// These cases for for type assertion, make sure callers pass expected types.
 	case StateAddCRS:
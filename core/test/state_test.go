@@ -146,7 +146,13 @@ func (s *StateTestSuite) makeConfigChanges(st *State) {
 	st.RequestChange(StateChangeLambdaDKG, time.Millisecond)
 	st.RequestChange(StateChangeRoundLength, uint64(1001))
 	st.RequestChange(StateChangeMinBlockInterval, time.Second)
+	st.RequestChange(StateChangeMaxBlockInterval, 2*time.Second)
+	st.RequestChange(StateChangeDKGRegisterDuration, 3*time.Millisecond)
+	st.RequestChange(StateChangeDKGComplaintDuration, 4*time.Millisecond)
+	st.RequestChange(StateChangeDKGFinalizeDuration, 5*time.Millisecond)
 	st.RequestChange(StateChangeNotarySetSize, uint32(5))
+	st.RequestChange(StateChangeBATimeoutGrowth, uint32(2))
+	st.RequestChange(StateChangeBATimeoutMaxScale, uint32(16))
 }
 
 func (s *StateTestSuite) checkConfigChanges(config *types.Config) {
@@ -155,7 +161,13 @@ func (s *StateTestSuite) checkConfigChanges(config *types.Config) {
 	req.Equal(config.LambdaDKG, time.Millisecond)
 	req.Equal(config.RoundLength, uint64(1001))
 	req.Equal(config.MinBlockInterval, time.Second)
+	req.Equal(config.MaxBlockInterval, 2*time.Second)
+	req.Equal(config.DKGRegisterDuration, 3*time.Millisecond)
+	req.Equal(config.DKGComplaintDuration, 4*time.Millisecond)
+	req.Equal(config.DKGFinalizeDuration, 5*time.Millisecond)
 	req.Equal(config.NotarySetSize, uint32(5))
+	req.Equal(config.BATimeoutGrowth, uint32(2))
+	req.Equal(config.BATimeoutMaxScale, uint32(16))
 }
 
 func (s *StateTestSuite) TestEqual() {
@@ -0,0 +1,198 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	gocrypto "crypto"
+	goecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Errors for TLS between TCP peers.
+var (
+	ErrTLSKeyMismatch       = errors.New("tls key mismatch with node key")
+	ErrTLSMissingNodeProof  = errors.New("tls certificate without node proof")
+	ErrTLSInvalidNodeProof  = errors.New("invalid node proof in certificate")
+	ErrTLSPeerNotAuthorized = errors.New("tls peer not authorized")
+)
+
+// oidNodeProof is the certificate extension carrying the node key and its
+// signature over the key of that certificate.
+var oidNodeProof = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57185, 1, 1}
+
+const tlsNodeProofPrefix = "dexon-consensus-test-tls:"
+
+type tlsNodeProof struct {
+	PublicKey     []byte
+	SignatureType string
+	Signature     []byte
+}
+
+// newNodeCertificate creates a self-signed certificate for a node. Node keys
+// are not supported by x509, therefore an ephemeral key is generated for TLS
+// and signed by the node key. Peers check that signature to know which node
+// is at the other end.
+func newNodeCertificate(prvKey crypto.PrivateKey) (
+	cert tls.Certificate, err error) {
+	tlsKey, err := goecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return
+	}
+	spki, err := x509.MarshalPKIXPublicKey(tlsKey.Public())
+	if err != nil {
+		return
+	}
+	sig, err := prvKey.Sign(
+		crypto.Keccak256Hash([]byte(tlsNodeProofPrefix), spki))
+	if err != nil {
+		return
+	}
+	proof, err := asn1.Marshal(tlsNodeProof{
+		PublicKey:     prvKey.PublicKey().Bytes(),
+		SignatureType: sig.Type,
+		Signature:     sig.Signature,
+	})
+	if err != nil {
+		return
+	}
+	nID := types.NewNodeID(prvKey.PublicKey())
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: nID.String()},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		ExtraExtensions: []pkix.Extension{{Id: oidNodeProof, Value: proof}},
+	}
+	der, err := x509.CreateCertificate(
+		rand.Reader, tmpl, tmpl, tlsKey.Public(), gocrypto.Signer(tlsKey))
+	if err != nil {
+		return
+	}
+	cert = tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  tlsKey,
+	}
+	return
+}
+
+// nodeIDFromCertificate returns the node ID proven by a certificate created
+// via newNodeCertificate.
+func nodeIDFromCertificate(cert *x509.Certificate) (
+	nID types.NodeID, err error) {
+	var raw []byte
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidNodeProof) {
+			raw = ext.Value
+			break
+		}
+	}
+	if raw == nil {
+		err = ErrTLSMissingNodeProof
+		return
+	}
+	proof := tlsNodeProof{}
+	if _, err = asn1.Unmarshal(raw, &proof); err != nil {
+		return
+	}
+	pubKey, err := ecdsa.NewPublicKeyFromByteSlice(proof.PublicKey)
+	if err != nil {
+		return
+	}
+	hash := crypto.Keccak256Hash(
+		[]byte(tlsNodeProofPrefix), cert.RawSubjectPublicKeyInfo)
+	if !pubKey.VerifySignature(hash, crypto.Signature{
+		Type:      proof.SignatureType,
+		Signature: proof.Signature,
+	}) {
+		err = ErrTLSInvalidNodeProof
+		return
+	}
+	nID = types.NewNodeID(pubKey)
+	return
+}
+
+// verifyNodeCertificate returns a function to verify certificates of peers,
+// which are accepted only when signed by their node keys and the proven node
+// IDs are authorized.
+func verifyNodeCertificate(authorize func(types.NodeID) error) func(
+	[][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return ErrTLSMissingNodeProof
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		nID, err := nodeIDFromCertificate(cert)
+		if err != nil {
+			return err
+		}
+		return authorize(nID)
+	}
+}
+
+// newTLSConfig setups a TLS config for both sides of a connection. The
+// certificate chain is not verified against any CA, peers are checked by
+// verifyNodeCertificate instead.
+func newTLSConfig(prvKey crypto.PrivateKey,
+	authorize func(types.NodeID) error) (*tls.Config, error) {
+	cert, err := newNodeCertificate(prvKey)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+		// #nosec G402
+		InsecureSkipVerify:    true,
+		MinVersion:            tls.VersionTLS12,
+		VerifyPeerCertificate: verifyNodeCertificate(authorize),
+	}, nil
+}
+
+// tlsPeerID returns the node ID of the remote side of a TLS connection, the
+// handshake should be done.
+func tlsPeerID(conn *tls.Conn) (nID types.NodeID, err error) {
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		err = ErrTLSMissingNodeProof
+		return
+	}
+	return nodeIDFromCertificate(certs[0])
+}
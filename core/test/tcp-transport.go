@@ -19,6 +19,7 @@ package test
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
@@ -31,6 +32,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -43,6 +45,9 @@ import (
 
 const (
 	tcpThroughputReportNum = 10
+	// tcpSendQueueSize is the count of messages queued to be written to a
+	// peer, messages to a peer whose queue is full are dropped.
+	tcpSendQueueSize = 1000
 )
 
 type tcpHandshake struct {
@@ -51,6 +56,8 @@ type tcpHandshake struct {
 }
 
 type tcpPeerRecord struct {
+	// dropped is accessed atomically, keep it 64-bit aligned.
+	dropped     uint64
 	conn        string
 	sendChannel chan<- []byte
 	pubKey      crypto.PublicKey
@@ -65,8 +72,12 @@ type tcpMessage struct {
 
 // BlockEventMessage is for monitoring block events' time.
 type BlockEventMessage struct {
-	BlockHash  common.Hash `json:"hash"`
-	Timestamps []time.Time `json:"timestamps"`
+	BlockHash  common.Hash  `json:"hash"`
+	ProposerID types.NodeID `json:"proposer"`
+	Timestamps []time.Time  `json:"timestamps"`
+	// BlockTimestamp is the timestamp in the block, which might be away
+	// from the real time by drifting clocks of proposers.
+	BlockTimestamp time.Time `json:"block_timestamp,omitempty"`
 }
 
 // buildPeerInfo is a tricky way to combine connection string and
@@ -118,6 +129,9 @@ type TCPTransport struct {
 	throughputRecords []ThroughputRecord
 	throughputLock    sync.Mutex
 	dMoment           time.Time
+	tlsConfig         *tls.Config
+	allowedPeers      map[types.NodeID]struct{}
+	allowedPeersLock  sync.RWMutex
 }
 
 // NewTCPTransport constructs an TCPTransport instance.
@@ -140,6 +154,108 @@ func NewTCPTransport(peerType TransportPeerType, pubKey crypto.PublicKey,
 
 const handshakeMsg = "Welcome to DEXON network for test."
 
+func (t *TCPTransport) enableTLS(prvKey crypto.PrivateKey) (err error) {
+	if types.NewNodeID(prvKey.PublicKey()) != t.nID {
+		return ErrTLSKeyMismatch
+	}
+	t.tlsConfig, err = newTLSConfig(prvKey, t.authorizePeer)
+	return
+}
+
+// AllowPeers limits incoming TLS connections to the given peers, connections
+// from any peer able to prove its node key are accepted when not set. It's
+// set by TCPTransportClient.Join and TCPTransportServer.WaitForPeers once
+// peers are known. It doesn't apply to outgoing connections, which are
+// checked against the peer being dialed.
+func (t *TCPTransport) AllowPeers(nIDs ...types.NodeID) {
+	t.allowedPeersLock.Lock()
+	defer t.allowedPeersLock.Unlock()
+	t.allowedPeers = make(map[types.NodeID]struct{}, len(nIDs))
+	for _, nID := range nIDs {
+		t.allowedPeers[nID] = struct{}{}
+	}
+}
+
+// authorizePeer checks if a node proven by its TLS certificate is allowed to
+// connect to this transport.
+func (t *TCPTransport) authorizePeer(nID types.NodeID) error {
+	if nID == t.nID {
+		return nil
+	}
+	t.allowedPeersLock.RLock()
+	defer t.allowedPeersLock.RUnlock()
+	if t.allowedPeers == nil {
+		return nil
+	}
+	if _, exist := t.allowedPeers[nID]; !exist {
+		return ErrTLSPeerNotAuthorized
+	}
+	return nil
+}
+
+// secure wraps a raw connection with TLS when enabled, peers failed to prove
+// their node keys are rejected here. When dialing, the peer at the other end
+// should be the expected one, unless it's unknown yet.
+func (t *TCPTransport) secure(
+	conn net.Conn, server bool, expected types.NodeID) (net.Conn, error) {
+	if t.tlsConfig == nil {
+		return conn, nil
+	}
+	var tlsConn *tls.Conn
+	if server {
+		tlsConn = tls.Server(conn, t.tlsConfig)
+	} else {
+		config := t.tlsConfig.Clone()
+		config.VerifyPeerCertificate = verifyNodeCertificate(
+			func(nID types.NodeID) error {
+				if expected != (types.NodeID{}) && nID != expected {
+					return ErrConnectToUnexpectedPeer
+				}
+				return nil
+			})
+		tlsConn = tls.Client(conn, config)
+	}
+	if err := tlsConn.SetDeadline(time.Now().Add(3 * time.Second)); err != nil {
+		return nil, err
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func (t *TCPTransport) dial(
+	addr string, expected types.NodeID) (net.Conn, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	secured, err := t.secure(conn, false, expected)
+	if err != nil {
+		// #nosec G104
+		conn.Close()
+		return nil, err
+	}
+	return secured, nil
+}
+
+// checkPeerID makes sure the node ID claimed in handshake messages is the
+// one proven by the TLS certificate.
+func (t *TCPTransport) checkPeerID(conn net.Conn, nID types.NodeID) error {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	certID, err := tlsPeerID(tlsConn)
+	if err != nil {
+		return err
+	}
+	if certID != nID {
+		return ErrTLSPeerNotAuthorized
+	}
+	return nil
+}
+
 func (t *TCPTransport) serverHandshake(conn net.Conn) (
 	nID types.NodeID, err error) {
 	if err := conn.SetDeadline(time.Now().Add(3 * time.Second)); err != nil {
@@ -168,6 +284,9 @@ func (t *TCPTransport) serverHandshake(conn net.Conn) (
 		err = ErrTCPHandShakeFail
 		return
 	}
+	if err = t.checkPeerID(conn, msg.NodeID); err != nil {
+		return
+	}
 	nID = msg.NodeID
 	return
 }
@@ -189,6 +308,9 @@ func (t *TCPTransport) clientHandshake(conn net.Conn) (
 		err = ErrTCPHandShakeFail
 		return
 	}
+	if err = t.checkPeerID(conn, msg.NodeID); err != nil {
+		return
+	}
 	nID = msg.NodeID
 	msg = &tcpMessage{
 		NodeID: t.nID,
@@ -214,8 +336,33 @@ func (t *TCPTransport) send(
 	endpoint types.NodeID, msg interface{}, payload []byte) {
 	t.peersLock.RLock()
 	defer t.peersLock.RUnlock()
+	rec, exist := t.peers[endpoint]
+	if !exist {
+		return
+	}
 	t.handleThroughputData(msg, payload)
-	t.peers[endpoint].sendChannel <- payload
+	// Each peer is written by its own routine, a stalled connection only
+	// fills its own queue. Messages to it are dropped instead of blocking
+	// the sender.
+	select {
+	case rec.sendChannel <- payload:
+	default:
+		atomic.AddUint64(&rec.dropped, 1)
+	}
+}
+
+// DroppedMessages returns the count of messages dropped for each peer whose
+// send queue is full.
+func (t *TCPTransport) DroppedMessages() map[types.NodeID]uint64 {
+	t.peersLock.RLock()
+	defer t.peersLock.RUnlock()
+	dropped := make(map[types.NodeID]uint64)
+	for nID, rec := range t.peers {
+		if count := atomic.LoadUint64(&rec.dropped); count > 0 {
+			dropped[nID] = count
+		}
+	}
+	return dropped
 }
 
 // Send implements Transport.Send method.
@@ -230,7 +377,7 @@ func (t *TCPTransport) Send(
 	if err != nil {
 		return
 	}
-	go t.send(endpoint, msg, payload)
+	t.send(endpoint, msg, payload)
 	return
 }
 
@@ -464,7 +611,7 @@ func (t *TCPTransport) connWriter(conn net.Conn) chan<- []byte {
 		panic(err)
 	}
 
-	ch := make(chan []byte, 1000)
+	ch := make(chan []byte, tcpSendQueueSize)
 	go func() {
 		defer func() {
 			close(ch)
@@ -530,12 +677,30 @@ func (t *TCPTransport) listenerRoutine(listener *net.TCPListener) {
 			}
 			continue
 		}
-		if _, err := t.serverHandshake(conn); err != nil {
-			fmt.Println(err)
-			continue
-		}
-		go t.connReader(conn)
+		// Handshakes are done in their own routines, a slow or malicious
+		// peer should not block accepting others.
+		go t.acceptConn(conn)
+	}
+}
+
+// acceptConn secures an incoming connection and starts reading from it once
+// the handshake is done.
+func (t *TCPTransport) acceptConn(conn net.Conn) {
+	secured, err := t.secure(conn, true, types.NodeID{})
+	if err != nil {
+		fmt.Println(err)
+		// #nosec G104
+		conn.Close()
+		return
 	}
+	conn = secured
+	if _, err := t.serverHandshake(conn); err != nil {
+		fmt.Println(err)
+		// #nosec G104
+		conn.Close()
+		return
+	}
+	t.connReader(conn)
 }
 
 // buildConnectionToPeers constructs TCP connections to each peer.
@@ -557,7 +722,7 @@ func (t *TCPTransport) buildConnectionsToPeers() (err error) {
 		wg.Add(1)
 		go func(nID types.NodeID, addr string) {
 			defer wg.Done()
-			conn, localErr := net.Dial("tcp", addr)
+			conn, localErr := t.dial(addr, nID)
 			if localErr != nil {
 				addErr(localErr)
 				return
@@ -611,6 +776,13 @@ func NewTCPTransportClient(
 	}
 }
 
+// EnableTLS secures connections to peers and peer server with TLS, the
+// certificate is signed by the key of this node. It should be called before
+// joining.
+func (t *TCPTransportClient) EnableTLS(prvKey crypto.PrivateKey) error {
+	return t.enableTLS(prvKey)
+}
+
 // Report implements TransportClient.Report method.
 func (t *TCPTransportClient) Report(msg interface{}) (err error) {
 	payload, err := t.marshalMessage(msg)
@@ -642,7 +814,7 @@ func (t *TCPTransportClient) Join(
 			go t.listenerRoutine(ln.(*net.TCPListener))
 			// It is possible to listen on the same port in some platform.
 			// Check if this one is actually listening.
-			testConn, e := net.Dial("tcp", addr)
+			testConn, e := t.dial(addr, types.NodeID{})
 			if e != nil {
 				err = e
 				return
@@ -684,11 +856,11 @@ func (t *TCPTransportClient) Join(
 	}
 
 	fmt.Println("Connecting to server", "endpoint", serverEndpoint)
-	serverConn, err := net.Dial("tcp", serverEndpoint.(string))
+	serverConn, err := t.dial(serverEndpoint.(string), types.NodeID{})
 	if err != nil {
 		return
 	}
-	_, err = t.clientHandshake(serverConn)
+	serverID, err := t.clientHandshake(serverConn)
 	if err != nil {
 		return
 	}
@@ -717,14 +889,18 @@ func (t *TCPTransportClient) Join(
 		panic(fmt.Errorf("expect handshake, not %v", e))
 	}
 	t.dMoment = handshake.DMoment
-	// Setup peers information.
+	// Setup peers information, only known peers and the server are allowed
+	// to connect afterward.
+	allowed := []types.NodeID{serverID}
 	for nID, info := range handshake.Peers {
 		pubKey, conn := parsePeerInfo(info)
 		t.peers[nID] = &tcpPeerRecord{
 			conn:   conn,
 			pubKey: pubKey,
 		}
+		allowed = append(allowed, nID)
 	}
+	t.AllowPeers(allowed...)
 	// Setup connections to other peers.
 	if err = t.buildConnectionsToPeers(); err != nil {
 		return
@@ -784,6 +960,7 @@ func (t *TCPTransportClient) DMoment() time.Time {
 // TCPTransportServer implements TransportServer via TCP connections.
 type TCPTransportServer struct {
 	TCPTransport
+	prvKey crypto.PrivateKey
 }
 
 // NewTCPTransportServer constructs TCPTransportServer instance.
@@ -800,9 +977,16 @@ func NewTCPTransportServer(
 		//       won't be zero.
 		TCPTransport: *NewTCPTransport(
 			TransportPeerServer, prvKey.PublicKey(), marshaller, serverPort),
+		prvKey: prvKey,
 	}
 }
 
+// EnableTLS makes peer server accept only TLS connections from peers able to
+// prove their node keys. It should be called before hosting.
+func (t *TCPTransportServer) EnableTLS() error {
+	return t.enableTLS(t.prvKey)
+}
+
 // Host implements TransportServer.Host method.
 func (t *TCPTransportServer) Host() (chan *TransportEnvelope, error) {
 	// The port of peer server should be known to other peers,
@@ -849,13 +1033,17 @@ func (t *TCPTransportServer) WaitForPeers(numPeers uint32) (err error) {
 			break
 		}
 	}
-	// Send collected peers back to them.
-	if err = t.buildConnectionsToPeers(); err != nil {
-		return
-	}
+	// Only collected peers are allowed to connect afterward.
 	peers := make(map[types.NodeID]struct{})
+	allowed := []types.NodeID{}
 	for ID := range t.peers {
 		peers[ID] = struct{}{}
+		allowed = append(allowed, ID)
+	}
+	t.AllowPeers(allowed...)
+	// Send collected peers back to them.
+	if err = t.buildConnectionsToPeers(); err != nil {
+		return
 	}
 	handshake := &tcpHandshake{
 		DMoment: t.dMoment,
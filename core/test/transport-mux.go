@@ -0,0 +1,187 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Errors returned from transport mux.
+var (
+	ErrDuplicatedNamespace = errors.New("duplicated namespace")
+)
+
+// NamespacedMsg is a message tagged with the namespace of the consensus
+// instance sending it.
+type NamespacedMsg struct {
+	Namespace string
+	Msg       interface{}
+}
+
+type muxRoute struct {
+	ch   chan *TransportEnvelope
+	done chan struct{}
+}
+
+// TransportMux shares one TransportClient among several consensus instances,
+// ex. different shards hosted by one node. Messages are tagged with the
+// namespace of the sender, and routed to the instance of the same namespace.
+// Messages from the peer server are routed to all instances.
+type TransportMux struct {
+	trans   TransportClient
+	lock    sync.Mutex
+	joined  bool
+	joinErr error
+	recv    <-chan *TransportEnvelope
+	routes  map[string]muxRoute
+}
+
+// NewTransportMux constructs a TransportMux instance.
+func NewTransportMux(trans TransportClient) *TransportMux {
+	return &TransportMux{
+		trans:  trans,
+		routes: make(map[string]muxRoute),
+	}
+}
+
+// Client returns a TransportClient for a namespace.
+func (m *TransportMux) Client(namespace string) TransportClient {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, exist := m.routes[namespace]; exist {
+		panic(ErrDuplicatedNamespace)
+	}
+	route := muxRoute{
+		ch:   make(chan *TransportEnvelope, 1000),
+		done: make(chan struct{}),
+	}
+	m.routes[namespace] = route
+	return &muxClient{
+		TransportClient: m.trans,
+		mux:             m,
+		namespace:       namespace,
+		route:           route,
+	}
+}
+
+func (m *TransportMux) join(serverEndpoint interface{}) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.joined {
+		m.joined = true
+		m.recv, m.joinErr = m.trans.Join(serverEndpoint)
+		if m.joinErr == nil {
+			go m.run()
+		}
+	}
+	return m.joinErr
+}
+
+func (m *TransportMux) run() {
+	for e := range m.recv {
+		var routes []muxRoute
+		namespace, msg := "", e.Msg
+		if nsMsg, ok := e.Msg.(*NamespacedMsg); ok {
+			namespace, msg = nsMsg.Namespace, nsMsg.Msg
+		}
+		func() {
+			m.lock.Lock()
+			defer m.lock.Unlock()
+			if e.PeerType == TransportPeerServer {
+				for _, r := range m.routes {
+					routes = append(routes, r)
+				}
+			} else if r, exist := m.routes[namespace]; exist {
+				routes = append(routes, r)
+			}
+		}()
+		// Messages of other namespaces are dropped.
+		for _, r := range routes {
+			select {
+			case r.ch <- &TransportEnvelope{
+				PeerType: e.PeerType,
+				From:     e.From,
+				Msg:      msg,
+			}:
+			case <-r.done:
+			}
+		}
+	}
+}
+
+// release removes a namespace, the underlying transport is closed when all
+// namespaces are released.
+func (m *TransportMux) release(namespace string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	r, exist := m.routes[namespace]
+	if !exist {
+		return nil
+	}
+	close(r.done)
+	delete(m.routes, namespace)
+	if len(m.routes) > 0 {
+		return nil
+	}
+	return m.trans.Close()
+}
+
+// muxClient implements TransportClient for one namespace of TransportMux.
+type muxClient struct {
+	TransportClient
+
+	mux       *TransportMux
+	namespace string
+	route     muxRoute
+}
+
+func (c *muxClient) wrap(msg interface{}) interface{} {
+	if c.namespace == "" {
+		return msg
+	}
+	return &NamespacedMsg{Namespace: c.namespace, Msg: msg}
+}
+
+// Send implements Transport.Send method.
+func (c *muxClient) Send(endpoint types.NodeID, msg interface{}) error {
+	return c.TransportClient.Send(endpoint, c.wrap(msg))
+}
+
+// Broadcast implements Transport.Broadcast method.
+func (c *muxClient) Broadcast(endpoints map[types.NodeID]struct{},
+	latency LatencyModel, msg interface{}) error {
+	return c.TransportClient.Broadcast(endpoints, latency, c.wrap(msg))
+}
+
+// Join implements TransportClient.Join method, the underlying transport joins
+// the network only once.
+func (c *muxClient) Join(
+	serverEndpoint interface{}) (<-chan *TransportEnvelope, error) {
+	if err := c.mux.join(serverEndpoint); err != nil {
+		return nil, err
+	}
+	return c.route.ch, nil
+}
+
+// Close implements Transport.Close method.
+func (c *muxClient) Close() error {
+	return c.mux.release(c.namespace)
+}
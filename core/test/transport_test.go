@@ -282,6 +282,108 @@ func (s *TransportTestSuite) TestTCPLocal() {
 	}
 }
 
+func (s *TransportTestSuite) TestTCPLocalTLS() {
+	var (
+		peerCount  = 4
+		req        = s.Require()
+		peers      = make(map[types.NodeID]*testPeer)
+		prvKeys    = GenerateRandomPrivateKeys(peerCount)
+		err        error
+		wg         sync.WaitGroup
+		serverPort = 8081
+		serverAddr = net.JoinHostPort("127.0.0.1", strconv.Itoa(serverPort))
+		tcpServer  = NewTCPTransportServer(&testMarshaller{}, serverPort)
+		server     = &testPeerServer{trans: tcpServer}
+	)
+	req.NoError(tcpServer.EnableTLS())
+	// Setup PeerServer
+	server.recv, err = server.trans.Host()
+	req.Nil(err)
+	// Plain connections without node proof should be rejected.
+	conn, err := net.Dial("tcp", serverAddr)
+	req.NoError(err)
+	_, err = tcpServer.read(conn)
+	req.Error(err)
+	req.NoError(conn.Close())
+	// A key not belonging to that node is not allowed.
+	stray := NewTCPTransportClient(
+		prvKeys[0].PublicKey(), &testMarshaller{}, true)
+	req.Equal(ErrTLSKeyMismatch, stray.EnableTLS(prvKeys[1]))
+	// Only expected peers are allowed when the peer set is known.
+	allowed := []types.NodeID{}
+	for _, prvKey := range prvKeys {
+		allowed = append(allowed, types.NewNodeID(prvKey.PublicKey()))
+	}
+	tcpServer.AllowPeers(allowed...)
+	strayKey := GenerateRandomPrivateKeys(1)[0]
+	stray = NewTCPTransportClient(
+		strayKey.PublicKey(), &testMarshaller{}, true)
+	req.NoError(stray.EnableTLS(strayKey))
+	dialStray := func(addr string) (err error) {
+		// With TLS 1.3, the rejection is only known when reading.
+		conn, err := stray.dial(addr, types.NodeID{})
+		if err == nil {
+			_, err = stray.clientHandshake(conn)
+			// #nosec G104
+			conn.Close()
+		}
+		return
+	}
+	req.Error(dialStray(serverAddr))
+	// Setup Peers
+	wg.Add(len(prvKeys))
+	for _, prvKey := range prvKeys {
+		nID := types.NewNodeID(prvKey.PublicKey())
+		trans := NewTCPTransportClient(
+			prvKey.PublicKey(), &testMarshaller{}, true)
+		req.NoError(trans.EnableTLS(prvKey))
+		peer := &testPeer{
+			nID:   nID,
+			trans: trans,
+		}
+		peers[nID] = peer
+		go func() {
+			defer wg.Done()
+
+			recv, err := peer.trans.Join(serverAddr)
+			req.Nil(err)
+			peer.recv = recv
+		}()
+	}
+	// Block here until we collect enough peers.
+	server.trans.WaitForPeers(uint32(peerCount))
+	// Make sure all clients are ready.
+	wg.Wait()
+	// Unknown peers are rejected by clients once peers are known.
+	for _, peer := range peers {
+		client := peer.trans.(*TCPTransportClient)
+		req.Error(dialStray(
+			net.JoinHostPort("127.0.0.1", strconv.Itoa(client.localPort))))
+	}
+
+	s.baseTest(server, peers, 300)
+	req.Nil(server.trans.Close())
+	for _, peer := range peers {
+		req.Nil(peer.trans.Close())
+	}
+}
+
+func (s *TransportTestSuite) TestTCPDropOnFullQueue() {
+	_, pubKeys, err := NewKeys(2)
+	s.Require().NoError(err)
+	trans := NewTCPTransport(TransportPeer, pubKeys[0], &testMarshaller{}, 0)
+	defer trans.cancel()
+	// A peer whose connection is stalled with one message queued.
+	ch := make(chan []byte, 1)
+	nID := types.NewNodeID(pubKeys[1])
+	trans.peers[nID] = &tcpPeerRecord{sendChannel: ch, pubKey: pubKeys[1]}
+	for i := 0; i < 3; i++ {
+		s.Require().NoError(trans.Send(nID, &types.Block{}))
+	}
+	s.Require().Len(ch, 1)
+	s.Require().Equal(map[types.NodeID]uint64{nID: 2}, trans.DroppedMessages())
+}
+
 func TestTransport(t *testing.T) {
 	suite.Run(t, new(TransportTestSuite))
 }
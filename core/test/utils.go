@@ -18,10 +18,12 @@
 package test
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
@@ -30,6 +32,7 @@ import (
 	"github.com/dexon-foundation/dexon-consensus/core/db"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	dexCrypto "github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/rlp"
 )
 
@@ -114,6 +117,65 @@ func NewKeys(count int) (
 	return
 }
 
+// FixtureKeySeed is the seed of keys returned by FixtureKeys.
+const FixtureKeySeed = "dexon-consensus-test-fixture"
+
+// fixtureKeys caches keys derived from FixtureKeySeed.
+var fixtureKeys struct {
+	lock    sync.Mutex
+	prvKeys []crypto.PrivateKey
+}
+
+// NewDeterministicKey derives the private key of an index from a seed, the
+// key is derived from Keccak256(seed, index in little endian). Keys, and
+// node IDs of them, are the same across runs and machines.
+func NewDeterministicKey(seed string, index int) (crypto.PrivateKey, error) {
+	binaryIndex := make([]byte, 8)
+	binary.LittleEndian.PutUint64(binaryIndex, uint64(index))
+	hash := crypto.Keccak256Hash([]byte(seed), binaryIndex)
+	key, err := dexCrypto.ToECDSA(hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return ecdsa.NewPrivateKeyFromECDSA(key), nil
+}
+
+// NewDeterministicKeys derives private keys from a seed and corresponding
+// public keys as slice, see NewDeterministicKey.
+func NewDeterministicKeys(seed string, count int) (
+	prvKeys []crypto.PrivateKey, pubKeys []crypto.PublicKey, err error) {
+	for i := 0; i < count; i++ {
+		var prvKey crypto.PrivateKey
+		if prvKey, err = NewDeterministicKey(seed, i); err != nil {
+			return
+		}
+		prvKeys = append(prvKeys, prvKey)
+		pubKeys = append(pubKeys, prvKey.PublicKey())
+	}
+	return
+}
+
+// FixtureKeys returns the first count keys derived from FixtureKeySeed, it
+// could replace NewKeys when outputs of tests and benchmarks should be
+// comparable across runs. Keys are derived once and shared by callers.
+func FixtureKeys(count int) (
+	prvKeys []crypto.PrivateKey, pubKeys []crypto.PublicKey, err error) {
+	fixtureKeys.lock.Lock()
+	defer fixtureKeys.lock.Unlock()
+	for i := len(fixtureKeys.prvKeys); i < count; i++ {
+		var prvKey crypto.PrivateKey
+		if prvKey, err = NewDeterministicKey(FixtureKeySeed, i); err != nil {
+			return nil, nil, err
+		}
+		fixtureKeys.prvKeys = append(fixtureKeys.prvKeys, prvKey)
+	}
+	for _, prvKey := range fixtureKeys.prvKeys[:count] {
+		prvKeys = append(prvKeys, prvKey)
+		pubKeys = append(pubKeys, prvKey.PublicKey())
+	}
+	return
+}
+
 // CloneDKGComplaint clones a tpyesDKG.Complaint instance.
 func CloneDKGComplaint(
 	comp *typesDKG.Complaint) (copied *typesDKG.Complaint) {
@@ -0,0 +1,66 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type UtilsTestSuite struct {
+	suite.Suite
+}
+
+func (s *UtilsTestSuite) TestFixtureKeys() {
+	req := s.Require()
+	prvKeys, pubKeys, err := FixtureKeys(4)
+	req.NoError(err)
+	req.Len(prvKeys, 4)
+	req.Len(pubKeys, 4)
+	// Node IDs should be the same when derived again.
+	for i, key := range pubKeys {
+		prvKey, err := NewDeterministicKey(FixtureKeySeed, i)
+		req.NoError(err)
+		req.Equal(types.NewNodeID(key), types.NewNodeID(prvKey.PublicKey()))
+	}
+	// Keys are shared by callers.
+	prvKeys2, pubKeys2, err := FixtureKeys(6)
+	req.NoError(err)
+	req.Equal(prvKeys, prvKeys2[:4])
+	req.Equal(pubKeys, pubKeys2[:4])
+	// The same as keys derived from the fixture seed.
+	_, pubKeys3, err := NewDeterministicKeys(FixtureKeySeed, 6)
+	req.NoError(err)
+	req.Equal(pubKeys2, pubKeys3)
+	ids := make(map[types.NodeID]struct{})
+	for _, key := range pubKeys3 {
+		ids[types.NewNodeID(key)] = struct{}{}
+	}
+	req.Len(ids, 6)
+	// Different seeds derive different keys.
+	_, pubKeys4, err := NewDeterministicKeys("another seed", 1)
+	req.NoError(err)
+	req.NotEqual(pubKeys3[0].Bytes(), pubKeys4[0].Bytes())
+}
+
+func TestUtils(t *testing.T) {
+	suite.Run(t, new(UtilsTestSuite))
+}
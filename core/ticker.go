@@ -76,6 +76,11 @@ func (t *defaultTicker) Restart() {
 	t.init()
 }
 
+// SetInterval implements SetInterval method of IntervalTicker interface.
+func (t *defaultTicker) SetInterval(interval time.Duration) {
+	t.duration = interval
+}
+
 func (t *defaultTicker) init() {
 	t.ticker = time.NewTicker(t.duration)
 	t.tickerChan = make(chan time.Time)
@@ -0,0 +1,48 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TickerTestSuite struct {
+	suite.Suite
+}
+
+func (s *TickerTestSuite) TestSetInterval() {
+	var ticker Ticker = newDefaultTicker(time.Hour)
+	defer ticker.Stop()
+	t, ok := ticker.(IntervalTicker)
+	s.Require().True(ok)
+	t.SetInterval(10 * time.Millisecond)
+	// The new interval takes effect after restarting.
+	ticker.Restart()
+	select {
+	case <-ticker.Tick():
+	case <-time.After(time.Second):
+		s.FailNow("no tick after interval changed")
+	}
+}
+
+func TestTicker(t *testing.T) {
+	suite.Run(t, new(TickerTestSuite))
+}
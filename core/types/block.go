@@ -34,6 +34,14 @@ import (
 // GenesisHeight refers to the initial height the genesis block should be.
 const GenesisHeight uint64 = 1
 
+// MaxSystemMessagesSize is the maximum total size, in bytes, of system
+// messages carried by a block.
+const MaxSystemMessagesSize = 4096
+
+// MaxSystemMessagesCount is the maximum count of system messages carried by a
+// block.
+const MaxSystemMessagesCount = 16
+
 // BlockVerifyStatus is the return code for core.Application.VerifyBlock
 type BlockVerifyStatus int
 
@@ -87,6 +95,14 @@ type Block struct {
 	Signature   crypto.Signature `json:"signature"`
 
 	CRSSignature crypto.Signature `json:"crs_signature"`
+
+	// SystemMessages are application-defined messages carried by consensus
+	// separately from the payload, ex. validator metadata updates.
+	SystemMessages [][]byte `json:"system_messages,omitempty"`
+
+	// ProtocolParamsHash is the hash of protocol parameters active in the
+	// round of this block, it's only carried by the first block of a round.
+	ProtocolParamsHash common.Hash `json:"protocol_params_hash"`
 }
 
 type rlpBlock struct {
@@ -102,10 +118,55 @@ type rlpBlock struct {
 	Signature   crypto.Signature
 
 	CRSSignature crypto.Signature
+
+	// Extensions are fields added after blocks are persisted by previous
+	// versions. They are encoded as optional trailing elements, in the order
+	// they are added, thus blocks written before remain decodable.
+	Extensions []rlp.RawValue `rlp:"tail"`
+}
+
+// encodeExtensions encodes optional fields of a block, trailing unset ones are
+// omitted.
+func (b *Block) encodeExtensions() (exts []rlp.RawValue, err error) {
+	fields := []interface{}{b.SystemMessages, b.ProtocolParamsHash}
+	count := 0
+	if b.ProtocolParamsHash != (common.Hash{}) {
+		count = 2
+	} else if len(b.SystemMessages) > 0 {
+		count = 1
+	}
+	for _, f := range fields[:count] {
+		var ext []byte
+		if ext, err = rlp.EncodeToBytes(f); err != nil {
+			return
+		}
+		exts = append(exts, ext)
+	}
+	return
+}
+
+// decodeExtensions decodes optional fields of a block, missing ones are unset.
+func (b *Block) decodeExtensions(exts []rlp.RawValue) (err error) {
+	if len(exts) > 0 {
+		if err = rlp.DecodeBytes(exts[0], &b.SystemMessages); err != nil {
+			return
+		}
+		if len(b.SystemMessages) == 0 {
+			b.SystemMessages = nil
+		}
+	}
+	if len(exts) > 1 {
+		err = rlp.DecodeBytes(exts[1], &b.ProtocolParamsHash)
+	}
+	return
 }
 
 // EncodeRLP implements rlp.Encoder
 func (b *Block) EncodeRLP(w io.Writer) error {
+	exts, err := b.encodeExtensions()
+	if err != nil {
+		return err
+	}
 	return rlp.Encode(w, rlpBlock{
 		ProposerID:   b.ProposerID,
 		ParentHash:   b.ParentHash,
@@ -118,6 +179,7 @@ func (b *Block) EncodeRLP(w io.Writer) error {
 		Randomness:   b.Randomness,
 		Signature:    b.Signature,
 		CRSSignature: b.CRSSignature,
+		Extensions:   exts,
 	})
 }
 
@@ -139,6 +201,7 @@ func (b *Block) DecodeRLP(s *rlp.Stream) error {
 			Signature:    dec.Signature,
 			CRSSignature: dec.CRSSignature,
 		}
+		err = b.decodeExtensions(dec.Extensions)
 	}
 	return err
 }
@@ -163,6 +226,21 @@ func (b *Block) Clone() (bcopy *Block) {
 	bcopy.Payload = common.CopyBytes(b.Payload)
 	bcopy.PayloadHash = b.PayloadHash
 	bcopy.Randomness = common.CopyBytes(b.Randomness)
+	bcopy.ProtocolParamsHash = b.ProtocolParamsHash
+	if b.SystemMessages != nil {
+		bcopy.SystemMessages = make([][]byte, len(b.SystemMessages))
+		for i, msg := range b.SystemMessages {
+			bcopy.SystemMessages[i] = common.CopyBytes(msg)
+		}
+	}
+	return
+}
+
+// SystemMessagesSize returns the total size of system messages in bytes.
+func (b *Block) SystemMessagesSize() (size int) {
+	for _, msg := range b.SystemMessages {
+		size += len(msg)
+	}
 	return
 }
 
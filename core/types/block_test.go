@@ -79,6 +79,8 @@ func (s *BlockTestSuite) createRandomBlock() *Block {
 		CRSSignature: crypto.Signature{
 			Type:      "some type",
 			Signature: common.GenerateRandomBytes()},
+		SystemMessages:     [][]byte{common.GenerateRandomBytes()},
+		ProtocolParamsHash: common.NewRandomHash(),
 	}
 	// Check if all fields are initialized with non zero values.
 	s.noZeroInStruct(reflect.ValueOf(*b))
@@ -195,6 +197,65 @@ func (s *BlockTestSuite) TestRLPEncodeDecode() {
 	s.Require().True(reflect.DeepEqual(block, &dec))
 }
 
+func (s *BlockTestSuite) TestRLPEncodeDecodeWithoutExtensions() {
+	block := s.createRandomBlock()
+	block.SystemMessages = nil
+	block.ProtocolParamsHash = common.Hash{}
+	b, err := rlp.EncodeToBytes(block)
+	s.Require().NoError(err)
+	var dec Block
+	s.Require().NoError(rlp.DecodeBytes(b, &dec))
+	s.Require().True(reflect.DeepEqual(block, &dec))
+	// Unset extensions are omitted from the encoded block.
+	var raw rlpBlock
+	s.Require().NoError(rlp.DecodeBytes(b, &raw))
+	s.Require().Empty(raw.Extensions)
+}
+
+func (s *BlockTestSuite) TestRLPDecodeLegacy() {
+	// Blocks persisted before extensions are added.
+	type legacyBlock struct {
+		ProposerID   NodeID
+		ParentHash   common.Hash
+		Hash         common.Hash
+		Position     Position
+		Timestamp    *rlpTimestamp
+		Payload      []byte
+		PayloadHash  common.Hash
+		Witness      *Witness
+		Randomness   []byte
+		Signature    crypto.Signature
+		CRSSignature crypto.Signature
+	}
+	block := s.createRandomBlock()
+	block.SystemMessages = nil
+	block.ProtocolParamsHash = common.Hash{}
+	b, err := rlp.EncodeToBytes(legacyBlock{
+		ProposerID:   block.ProposerID,
+		ParentHash:   block.ParentHash,
+		Hash:         block.Hash,
+		Position:     block.Position,
+		Timestamp:    &rlpTimestamp{block.Timestamp},
+		Payload:      block.Payload,
+		PayloadHash:  block.PayloadHash,
+		Witness:      &block.Witness,
+		Randomness:   block.Randomness,
+		Signature:    block.Signature,
+		CRSSignature: block.CRSSignature,
+	})
+	s.Require().NoError(err)
+	var dec Block
+	s.Require().NoError(rlp.DecodeBytes(b, &dec))
+	s.Require().True(reflect.DeepEqual(block, &dec))
+	// Only the hash of protocol parameters is set.
+	block.ProtocolParamsHash = common.NewRandomHash()
+	b, err = rlp.EncodeToBytes(block)
+	s.Require().NoError(err)
+	dec = Block{}
+	s.Require().NoError(rlp.DecodeBytes(b, &dec))
+	s.Require().True(reflect.DeepEqual(block, &dec))
+}
+
 func TestBlock(t *testing.T) {
 	suite.Run(t, new(BlockTestSuite))
 }
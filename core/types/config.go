@@ -19,9 +19,23 @@ package types
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
+// ConfigErrors collects all problems found when validating a configuration.
+type ConfigErrors []error
+
+func (e ConfigErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("invalid config: %s", strings.Join(msgs, "; "))
+}
+
 // Config stands for Current Configuration Parameters.
 type Config struct {
 	// Lambda related.
@@ -34,6 +48,21 @@ type Config struct {
 	// Time related.
 	RoundLength      uint64
 	MinBlockInterval time.Duration
+	// MaxBlockInterval is the upper bound of the timestamp difference between
+	// two consecutive blocks, zero means no upper bound.
+	MaxBlockInterval time.Duration
+
+	// DKG phase related, zero means LambdaDKG.
+	DKGRegisterDuration  time.Duration
+	DKGComplaintDuration time.Duration
+	DKGFinalizeDuration  time.Duration
+
+	// BA timeout related. Clocks waited in each state of BA are multiplied by
+	// BATimeoutGrowth per failed period, up to BATimeoutMaxScale times. Zero
+	// growth means the scale grows linearly by period, and zero max scale
+	// means 10.
+	BATimeoutGrowth   uint32
+	BATimeoutMaxScale uint32
 }
 
 // Clone return a copied configuration.
@@ -44,7 +73,104 @@ func (c *Config) Clone() *Config {
 		NotarySetSize:    c.NotarySetSize,
 		RoundLength:      c.RoundLength,
 		MinBlockInterval: c.MinBlockInterval,
+		MaxBlockInterval: c.MaxBlockInterval,
+
+		DKGRegisterDuration:  c.DKGRegisterDuration,
+		DKGComplaintDuration: c.DKGComplaintDuration,
+		DKGFinalizeDuration:  c.DKGFinalizeDuration,
+
+		BATimeoutGrowth:   c.BATimeoutGrowth,
+		BATimeoutMaxScale: c.BATimeoutMaxScale,
+	}
+}
+
+// Validate checks if the configuration is usable, all problems found are
+// reported at once by ConfigErrors.
+func (c *Config) Validate() error {
+	var errs ConfigErrors
+	if c.LambdaBA <= 0 {
+		errs = append(errs, fmt.Errorf("LambdaBA should be positive: %v",
+			c.LambdaBA))
+	}
+	if c.LambdaDKG <= 0 {
+		errs = append(errs, fmt.Errorf("LambdaDKG should be positive: %v",
+			c.LambdaDKG))
+	}
+	if c.NotarySetSize == 0 {
+		errs = append(errs, errors.New("NotarySetSize should be positive"))
+	}
+	if c.RoundLength == 0 {
+		errs = append(errs, errors.New("RoundLength should be positive"))
+	}
+	if c.MinBlockInterval < 0 {
+		errs = append(errs, fmt.Errorf(
+			"MinBlockInterval should not be negative: %v", c.MinBlockInterval))
+	}
+	if c.MaxBlockInterval < 0 {
+		errs = append(errs, fmt.Errorf(
+			"MaxBlockInterval should not be negative: %v", c.MaxBlockInterval))
+	} else if c.MaxBlockInterval != 0 &&
+		c.MaxBlockInterval < c.MinBlockInterval {
+		errs = append(errs, fmt.Errorf(
+			"MaxBlockInterval %v is less than MinBlockInterval %v",
+			c.MaxBlockInterval, c.MinBlockInterval))
+	}
+	for _, d := range []struct {
+		name string
+		val  time.Duration
+	}{
+		{"DKGRegisterDuration", c.DKGRegisterDuration},
+		{"DKGComplaintDuration", c.DKGComplaintDuration},
+		{"DKGFinalizeDuration", c.DKGFinalizeDuration},
+	} {
+		if d.val < 0 {
+			errs = append(errs, fmt.Errorf("%s should not be negative: %v",
+				d.name, d.val))
+		}
+	}
+	// DKG for the next round is registered at the half of a round, and reset
+	// at 85% of a round when it fails.
+	if c.RoundLength > 0 && c.DKGLength() > c.RoundLength*35/100 {
+		errs = append(errs, fmt.Errorf(
+			"DKG phases take %d blocks, not fit in a round of %d blocks",
+			c.DKGLength(), c.RoundLength))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// DKGPhaseDurations returns durations of DKG phases, LambdaDKG is used for
+// those not configured.
+func (c *Config) DKGPhaseDurations() (
+	register, complaint, finalize time.Duration) {
+	register, complaint, finalize =
+		c.DKGRegisterDuration, c.DKGComplaintDuration, c.DKGFinalizeDuration
+	if register == 0 {
+		register = c.LambdaDKG
+	}
+	if complaint == 0 {
+		complaint = c.LambdaDKG
+	}
+	if finalize == 0 {
+		finalize = c.LambdaDKG
+	}
+	return
+}
+
+// DKGLength returns the count of blocks DKG phases take, blocks are assumed
+// to be proposed in MinBlockInterval.
+func (c *Config) DKGLength() uint64 {
+	if c.MinBlockInterval <= 0 {
+		return 0
+	}
+	register, complaint, finalize := c.DKGPhaseDurations()
+	total := register + 5*complaint + finalize
+	if total <= 0 {
+		return 0
 	}
+	return uint64(total.Nanoseconds() / c.MinBlockInterval.Nanoseconds())
 }
 
 // Bytes returns []byte representation of Config.
@@ -64,12 +190,35 @@ func (c *Config) Bytes() []byte {
 	binaryMinBlockInterval := make([]byte, 8)
 	binary.LittleEndian.PutUint64(binaryMinBlockInterval,
 		uint64(c.MinBlockInterval.Nanoseconds()))
+	binaryMaxBlockInterval := make([]byte, 8)
+	binary.LittleEndian.PutUint64(binaryMaxBlockInterval,
+		uint64(c.MaxBlockInterval.Nanoseconds()))
+	binaryDKGRegisterDuration := make([]byte, 8)
+	binary.LittleEndian.PutUint64(binaryDKGRegisterDuration,
+		uint64(c.DKGRegisterDuration.Nanoseconds()))
+	binaryDKGComplaintDuration := make([]byte, 8)
+	binary.LittleEndian.PutUint64(binaryDKGComplaintDuration,
+		uint64(c.DKGComplaintDuration.Nanoseconds()))
+	binaryDKGFinalizeDuration := make([]byte, 8)
+	binary.LittleEndian.PutUint64(binaryDKGFinalizeDuration,
+		uint64(c.DKGFinalizeDuration.Nanoseconds()))
+	binaryBATimeoutGrowth := make([]byte, 4)
+	binary.LittleEndian.PutUint32(binaryBATimeoutGrowth, c.BATimeoutGrowth)
+	binaryBATimeoutMaxScale := make([]byte, 4)
+	binary.LittleEndian.PutUint32(
+		binaryBATimeoutMaxScale, c.BATimeoutMaxScale)
 
-	enc := make([]byte, 0, 40)
+	enc := make([]byte, 0, 76)
 	enc = append(enc, binaryLambdaBA...)
 	enc = append(enc, binaryLambdaDKG...)
 	enc = append(enc, binaryNotarySetSize...)
 	enc = append(enc, binaryRoundLength...)
 	enc = append(enc, binaryMinBlockInterval...)
+	enc = append(enc, binaryMaxBlockInterval...)
+	enc = append(enc, binaryDKGRegisterDuration...)
+	enc = append(enc, binaryDKGComplaintDuration...)
+	enc = append(enc, binaryDKGFinalizeDuration...)
+	enc = append(enc, binaryBATimeoutGrowth...)
+	enc = append(enc, binaryBATimeoutMaxScale...)
 	return enc
 }
@@ -35,10 +35,50 @@ func (s *ConfigTestSuite) TestClone() {
 		NotarySetSize:    5,
 		RoundLength:      1000,
 		MinBlockInterval: 7 * time.Nanosecond,
+		MaxBlockInterval: 9 * time.Nanosecond,
+
+		DKGRegisterDuration:  11 * time.Nanosecond,
+		DKGComplaintDuration: 13 * time.Nanosecond,
+		DKGFinalizeDuration:  17 * time.Nanosecond,
+
+		BATimeoutGrowth:   2,
+		BATimeoutMaxScale: 19,
 	}
 	s.Require().Equal(c, c.Clone())
 }
 
+func (s *ConfigTestSuite) TestValidate() {
+	c := &Config{
+		LambdaBA:         250 * time.Millisecond,
+		LambdaDKG:        10 * time.Second,
+		NotarySetSize:    4,
+		RoundLength:      1000,
+		MinBlockInterval: time.Second,
+	}
+	s.Require().NoError(c.Validate())
+	c.MaxBlockInterval = 2 * time.Second
+	s.Require().NoError(c.Validate())
+	// DKG phases should fit in a round.
+	c.RoundLength = 100
+	s.Require().Error(c.Validate())
+	c.RoundLength = 1000
+	// All problems should be reported at once.
+	c = &Config{
+		MinBlockInterval:    time.Second,
+		MaxBlockInterval:    time.Millisecond,
+		DKGFinalizeDuration: -time.Second,
+	}
+	err := c.Validate()
+	s.Require().Error(err)
+	errs, ok := err.(ConfigErrors)
+	s.Require().True(ok)
+	s.Require().Len(errs, 6)
+	for _, field := range []string{"LambdaBA", "LambdaDKG", "NotarySetSize",
+		"RoundLength", "MaxBlockInterval", "DKGFinalizeDuration"} {
+		s.Require().Contains(err.Error(), field)
+	}
+}
+
 func TestConfig(t *testing.T) {
 	suite.Run(t, new(ConfigTestSuite))
 }
@@ -0,0 +1,39 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"fmt"
+)
+
+// DeliveryProof proves the position where a block is delivered, it could be
+// checked offline when the ordering of blocks is disputed. The position and
+// parent of a block are committed by its hash, which is confirmed by the
+// threshold signature of the notary set, or by commit votes in rounds before
+// DKG is ready.
+type DeliveryProof struct {
+	TimestampProof
+	// Votes are commit votes confirming the block, they are only carried for
+	// rounds before DKG is ready.
+	Votes []Vote `json:"votes,omitempty"`
+}
+
+func (p *DeliveryProof) String() string {
+	return fmt.Sprintf("DeliveryProof{Pos:%s Parent:%s Votes:%d}",
+		p.Position, p.ParentHash.String()[:6], len(p.Votes))
+}
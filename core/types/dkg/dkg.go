@@ -328,6 +328,23 @@ func (s *Success) Equal(other *Success) bool {
 		bytes.Compare(s.Signature.Signature, other.Signature.Signature) == 0
 }
 
+// Artifacts is the transcript of DKG protocol of a round, it's pulled from
+// peers by nodes missing some of them.
+type Artifacts struct {
+	Round            uint64             `json:"round"`
+	Reset            uint64             `json:"reset"`
+	MasterPublicKeys []*MasterPublicKey `json:"master_public_keys"`
+	Complaints       []*Complaint       `json:"complaints"`
+}
+
+func (a *Artifacts) String() string {
+	return fmt.Sprintf("DKGArtifacts{Round:%d Reset:%d MPK:%d Complaint:%d}",
+		a.Round,
+		a.Reset,
+		len(a.MasterPublicKeys),
+		len(a.Complaints))
+}
+
 // GroupPublicKey is the result of DKG protocol.
 type GroupPublicKey struct {
 	Round          uint64
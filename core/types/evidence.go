@@ -0,0 +1,47 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+)
+
+// VoteEvidence proves a node equivocated by sending two conflicting votes of
+// the same type for the same position and period. It's signed by the node
+// reporting it.
+type VoteEvidence struct {
+	Vote1      *Vote            `json:"vote1"`
+	Vote2      *Vote            `json:"vote2"`
+	ReporterID NodeID           `json:"reporter_id"`
+	Signature  crypto.Signature `json:"signature"`
+}
+
+// NewVoteEvidence constructs an unsigned evidence from conflicting votes.
+func NewVoteEvidence(vote1, vote2 *Vote) *VoteEvidence {
+	return &VoteEvidence{
+		Vote1: vote1.Clone(),
+		Vote2: vote2.Clone(),
+	}
+}
+
+func (e *VoteEvidence) String() string {
+	return fmt.Sprintf("VoteEvidence{Reporter:%s Vote1:%s Vote2:%s}",
+		e.ReporterID.String()[:6], e.Vote1, e.Vote2)
+}
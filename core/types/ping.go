@@ -0,0 +1,45 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+)
+
+// Ping probes the round-trip latency between committee members. The target
+// echoes the nonce back by a pong signed by itself.
+type Ping struct {
+	ProposerID NodeID           `json:"proposer_id"`
+	TargetID   NodeID           `json:"target_id"`
+	Round      uint64           `json:"round"`
+	Nonce      uint64           `json:"nonce"`
+	IsPong     bool             `json:"is_pong"`
+	Signature  crypto.Signature `json:"signature"`
+}
+
+func (p *Ping) String() string {
+	kind := "Ping"
+	if p.IsPong {
+		kind = "Pong"
+	}
+	return fmt.Sprintf("%s{Proposer:%s Target:%s Round:%d Nonce:%d}",
+		kind, p.ProposerID.String()[:6], p.TargetID.String()[:6], p.Round,
+		p.Nonce)
+}
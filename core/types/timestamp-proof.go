@@ -0,0 +1,48 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+)
+
+// TimestampProof proves that a block is finalized with a consensus
+// timestamp. It carries fields required to rebuild the hash of that block,
+// without the payload, and the randomness of that block, which is the
+// threshold signature over the block hash by the notary set. Therefore, it
+// could be verified by the group public key of that round without running a
+// node.
+type TimestampProof struct {
+	ProposerID         NodeID      `json:"proposer_id"`
+	ParentHash         common.Hash `json:"parent_hash"`
+	Position           Position    `json:"position"`
+	Timestamp          time.Time   `json:"timestamp"`
+	PayloadHash        common.Hash `json:"payload_hash"`
+	WitnessHash        common.Hash `json:"witness_hash"`
+	SystemMessagesHash common.Hash `json:"system_messages_hash"`
+	Randomness         []byte      `json:"randomness"`
+	ProtocolParamsHash common.Hash `json:"protocol_params_hash"`
+}
+
+func (p *TimestampProof) String() string {
+	return fmt.Sprintf("TimestampProof{Pos:%s Time:%s}",
+		p.Position, p.Timestamp.UTC())
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+)
+
+// Watermark is the progress of a node, it's gossiped periodically to let
+// others estimate the progress of the network.
+type Watermark struct {
+	ProposerID NodeID           `json:"proposer_id"`
+	Confirmed  Position         `json:"confirmed"`
+	Delivered  Position         `json:"delivered"`
+	Timestamp  time.Time        `json:"timestamp"`
+	Signature  crypto.Signature `json:"signature"`
+}
+
+func (w *Watermark) String() string {
+	return fmt.Sprintf("Watermark{Proposer:%s Confirmed:%s Delivered:%s}",
+		w.ProposerID.String()[:6], w.Confirmed, w.Delivered)
+}
@@ -18,7 +18,9 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"os"
@@ -27,6 +29,7 @@ import (
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
 )
@@ -45,6 +48,38 @@ var (
 		"incorrect vote period")
 )
 
+// Retries for transient failures of database.
+const (
+	dbRetryCount    = 3
+	dbRetryInterval = 100 * time.Millisecond
+)
+
+// permanentDBErrors are database errors which won't be resolved by retrying.
+var permanentDBErrors = map[error]struct{}{
+	db.ErrBlockExists:                     struct{}{},
+	db.ErrBlockDoesNotExist:               struct{}{},
+	db.ErrClosed:                          struct{}{},
+	db.ErrNotImplemented:                  struct{}{},
+	db.ErrInvalidCompactionChainTipHeight: struct{}{},
+	db.ErrDKGPrivateKeyExists:             struct{}{},
+	db.ErrDKGProtocolDoesNotExist:         struct{}{},
+}
+
+// retryDB calls a database operation until it succeeds, fails permanently, or
+// runs out of retries, the last error is returned.
+func retryDB(op func() error) (err error) {
+	for i := 0; ; i++ {
+		if err = op(); err == nil {
+			return
+		}
+		if _, permanent := permanentDBErrors[err]; permanent ||
+			i >= dbRetryCount {
+			return
+		}
+		time.Sleep(dbRetryInterval)
+	}
+}
+
 // NodeSetCache is type alias to avoid fullnode compile error when moving
 // it to core/utils package.
 type NodeSetCache = utils.NodeSetCache
@@ -169,7 +204,7 @@ func VerifyAgreementResult(
 	if err != nil {
 		return err
 	}
-	if len(res.Votes) < len(notarySet)*2/3+1 {
+	if len(res.Votes) < baThreshold(notarySet) {
 		return ErrNotEnoughVotes
 	}
 	voted := make(map[types.NodeID]struct{}, len(notarySet))
@@ -200,7 +235,7 @@ func VerifyAgreementResult(
 		if _, exist := notarySet[vote.ProposerID]; !exist {
 			return ErrIncorrectVoteProposer
 		}
-		ok, err := utils.VerifyVoteSignature(&vote)
+		ok, err := utils.VerifyVoteSignature(&vote, cache.Registry())
 		if err != nil {
 			return err
 		}
@@ -209,12 +244,56 @@ func VerifyAgreementResult(
 		}
 		voted[vote.ProposerID] = struct{}{}
 	}
-	if len(voted) < len(notarySet)*2/3+1 {
+	if len(voted) < baThreshold(notarySet) {
 		return ErrNotEnoughVotes
 	}
 	return nil
 }
 
+// VerifyAgreementResultRandomness verifies the randomness of an agreement
+// result, which is the threshold signature of the notary set on the block hash
+// after DKG is ready. The verifier could be the group public key of that
+// round, and is not used for rounds before DKG is ready.
+func VerifyAgreementResultRandomness(
+	res *types.AgreementResult, verifier TSigVerifier) error {
+	if res.Position.Round < DKGDelayRound {
+		if !bytes.Equal(res.Randomness, NoRand) {
+			return ErrIncorrectAgreementResult
+		}
+		return nil
+	}
+	if len(res.Randomness) == 0 {
+		return ErrMissingRandomness
+	}
+	if !verifier.VerifySignature(res.BlockHash, crypto.Signature{
+		Type:      "bls",
+		Signature: res.Randomness,
+	}) {
+		return ErrIncorrectAgreementResult
+	}
+	return nil
+}
+
+// baThreshold returns the count of votes required by BA from a notary set. It's
+// derived from the size of the notary set actually selected in that round,
+// which is less than the configured size when there are not enough nodes.
+func baThreshold(notarySet map[types.NodeID]struct{}) int {
+	return utils.GetBAThreshold(&types.Config{
+		NotarySetSize: uint32(len(notarySet))})
+}
+
+// proposeOffset returns the offset of a node in a window derived from CRS, the
+// offsets of nodes are uniformly distributed in that window.
+func proposeOffset(
+	crs common.Hash, nID types.NodeID, window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+	hash := crypto.Keccak256Hash(crs[:], nID.Hash[:])
+	return time.Duration(
+		binary.BigEndian.Uint64(hash[:8]) % uint64(window))
+}
+
 // DiffUint64 calculates difference between two uint64.
 func DiffUint64(a, b uint64) uint64 {
 	if a > b {
@@ -20,6 +20,9 @@ package utils
 import (
 	"bytes"
 	"encoding/binary"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto"
@@ -27,49 +30,110 @@ import (
 	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
 )
 
-func hashWitness(witness *types.Witness) (common.Hash, error) {
-	binaryHeight := make([]byte, 8)
-	binary.LittleEndian.PutUint64(binaryHeight, witness.Height)
-	return crypto.Keccak256Hash(
-		binaryHeight,
-		witness.Data), nil
+// voteSignatureCacheSize is the maximum count of verified vote signatures
+// cached.
+const voteSignatureCacheSize = 8192
+
+// voteSignatureCache caches public keys recovered from vote signatures, the
+// same vote might be verified repeatedly when replayed across periods or
+// received from multiple peers.
+var voteSignatureCache *lru.Cache
+
+func init() {
+	var err error
+	if voteSignatureCache, err = lru.New(voteSignatureCacheSize); err != nil {
+		panic(err)
+	}
+}
+
+type voteSignatureKey struct {
+	hash    common.Hash
+	sigType string
+	sig     string
+}
+
+// PurgeVoteSignatureCache removes all cached vote signatures.
+func PurgeVoteSignatureCache() {
+	voteSignatureCache.Purge()
 }
 
 // HashBlock generates hash of a types.Block.
 func HashBlock(block *types.Block) (common.Hash, error) {
-	hashPosition := HashPosition(block.Position)
-	binaryTimestamp, err := block.Timestamp.UTC().MarshalBinary()
+	binaryWitness, err := hashWitness(&block.Witness)
 	if err != nil {
 		return common.Hash{}, err
 	}
-	binaryWitness, err := hashWitness(&block.Witness)
+	// System messages are only hashed when carried, hashes of blocks without
+	// them are kept unchanged.
+	var hashSysMsgs common.Hash
+	if len(block.SystemMessages) > 0 {
+		hashSysMsgs = HashSystemMessages(block.SystemMessages)
+	}
+	return hashBlockFields(block.ProposerID, block.ParentHash, block.Position,
+		block.Timestamp, block.PayloadHash, binaryWitness, hashSysMsgs,
+		block.ProtocolParamsHash)
+}
+
+// hashBlockFields generates hash of a block from its fields. Hashes of system
+// messages and protocol parameters are skipped when both empty.
+func hashBlockFields(proposerID types.NodeID, parentHash common.Hash,
+	position types.Position, timestamp time.Time, payloadHash common.Hash,
+	witnessHash common.Hash, sysMsgsHash common.Hash,
+	paramsHash common.Hash) (common.Hash, error) {
+	hashPosition := HashPosition(position)
+	binaryTimestamp, err := timestamp.UTC().MarshalBinary()
 	if err != nil {
 		return common.Hash{}, err
 	}
-
-	hash := crypto.Keccak256Hash(
-		block.ProposerID.Hash[:],
-		block.ParentHash[:],
+	data := [][]byte{
+		proposerID.Hash[:],
+		parentHash[:],
 		hashPosition[:],
 		binaryTimestamp[:],
-		block.PayloadHash[:],
-		binaryWitness[:])
-	return hash, nil
+		payloadHash[:],
+		witnessHash[:],
+	}
+	if (paramsHash != common.Hash{}) {
+		data = append(data, sysMsgsHash[:], paramsHash[:])
+	} else if (sysMsgsHash != common.Hash{}) {
+		data = append(data, sysMsgsHash[:])
+	}
+	return crypto.Keccak256Hash(data...), nil
+}
+
+// HashSystemMessages generates hash of system messages carried by a block.
+func HashSystemMessages(msgs [][]byte) common.Hash {
+	hashes := make([][]byte, 0, len(msgs))
+	for _, msg := range msgs {
+		hash := crypto.Keccak256Hash(msg)
+		hashes = append(hashes, hash[:])
+	}
+	return crypto.Keccak256Hash(hashes...)
 }
 
 // VerifyBlockSignature verifies the signature of types.Block.
-func VerifyBlockSignature(b *types.Block) (err error) {
+func VerifyBlockSignature(b *types.Block,
+	registry NodeIdentityRegistry) (err error) {
 	payloadHash := crypto.Keccak256Hash(b.Payload)
 	if payloadHash != b.PayloadHash {
 		err = ErrIncorrectHash
 		return
 	}
-	return VerifyBlockSignatureWithoutPayload(b)
+	if len(b.SystemMessages) > types.MaxSystemMessagesCount {
+		err = ErrTooManySystemMessages
+		return
+	}
+	if b.SystemMessagesSize() > types.MaxSystemMessagesSize {
+		err = ErrSystemMessagesTooLarge
+		return
+	}
+	return VerifyBlockSignatureWithoutPayload(b, registry)
 }
 
 // VerifyBlockSignatureWithoutPayload verifies the signature of types.Block but
 // does not check if PayloadHash is correct.
-func VerifyBlockSignatureWithoutPayload(b *types.Block) (err error) {
+func VerifyBlockSignatureWithoutPayload(b *types.Block,
+	registry NodeIdentityRegistry) (err error) {
 	hash, err := HashBlock(b)
 	if err != nil {
 		return
@@ -78,11 +142,14 @@ func VerifyBlockSignatureWithoutPayload(b *types.Block) (err error) {
 		err = ErrIncorrectHash
 		return
 	}
+	if skipSigVerification(SigKindBlock) {
+		return
+	}
 	pubKey, err := crypto.SigToPub(b.Hash, b.Signature)
 	if err != nil {
 		return
 	}
-	if !b.ProposerID.Equal(types.NewNodeID(pubKey)) {
+	if !b.ProposerID.Equal(NodeIdentity(registry, b.Position.Round, pubKey)) {
 		err = ErrIncorrectSignature
 		return
 	}
@@ -109,18 +176,165 @@ func HashVote(vote *types.Vote) common.Hash {
 }
 
 // VerifyVoteSignature verifies the signature of types.Vote.
-func VerifyVoteSignature(vote *types.Vote) (bool, error) {
+func VerifyVoteSignature(
+	vote *types.Vote, registry NodeIdentityRegistry) (bool, error) {
 	hash := HashVote(vote)
-	pubKey, err := crypto.SigToPub(hash, vote.Signature)
-	if err != nil {
-		return false, err
+	if skipSigVerification(SigKindVote) {
+		return true, nil
+	}
+	key := voteSignatureKey{
+		hash:    hash,
+		sigType: vote.Signature.Type,
+		sig:     string(vote.Signature.Signature),
+	}
+	var pubKey crypto.PublicKey
+	if cached, exist := voteSignatureCache.Get(key); exist {
+		pubKey = cached.(crypto.PublicKey)
+	} else {
+		var err error
+		if pubKey, err = crypto.SigToPub(hash, vote.Signature); err != nil {
+			return false, err
+		}
+		voteSignatureCache.Add(key, pubKey)
 	}
-	if vote.ProposerID != types.NewNodeID(pubKey) {
+	if vote.ProposerID != NodeIdentity(registry, vote.Position.Round, pubKey) {
 		return false, nil
 	}
 	return true, nil
 }
 
+// HashWatermark generates hash of a types.Watermark.
+func HashWatermark(w *types.Watermark) (common.Hash, error) {
+	hashConfirmed := HashPosition(w.Confirmed)
+	hashDelivered := HashPosition(w.Delivered)
+	binaryTimestamp, err := w.Timestamp.UTC().MarshalBinary()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(
+		w.ProposerID.Hash[:],
+		hashConfirmed[:],
+		hashDelivered[:],
+		binaryTimestamp,
+	), nil
+}
+
+// VerifyWatermarkSignature verifies the signature of types.Watermark.
+func VerifyWatermarkSignature(
+	w *types.Watermark, registry NodeIdentityRegistry) (bool, error) {
+	hash, err := HashWatermark(w)
+	if err != nil {
+		return false, err
+	}
+	if skipSigVerification(SigKindWatermark) {
+		return true, nil
+	}
+	pubKey, err := crypto.SigToPub(hash, w.Signature)
+	if err != nil {
+		return false, err
+	}
+	return w.ProposerID == NodeIdentity(
+		registry, w.Delivered.Round, pubKey), nil
+}
+
+// HashVoteEvidence generates hash of a types.VoteEvidence.
+func HashVoteEvidence(e *types.VoteEvidence) common.Hash {
+	hashVote1 := HashVote(e.Vote1)
+	hashVote2 := HashVote(e.Vote2)
+	return crypto.Keccak256Hash(
+		e.ReporterID.Hash[:],
+		hashVote1[:],
+		hashVote2[:],
+	)
+}
+
+// VerifyVoteEvidence verifies a types.VoteEvidence, both votes should be
+// signed by the accused node and conflict with each other, and the evidence
+// should be signed by the reporter.
+func VerifyVoteEvidence(
+	e *types.VoteEvidence, registry NodeIdentityRegistry) (bool, error) {
+	if e.Vote1 == nil || e.Vote2 == nil {
+		return false, ErrVotesNotConflicting
+	}
+	v1, v2 := e.Vote1, e.Vote2
+	if v1.ProposerID != v2.ProposerID || v1.Position != v2.Position ||
+		v1.Period != v2.Period || v1.Type != v2.Type ||
+		v1.BlockHash == v2.BlockHash {
+		return false, ErrVotesNotConflicting
+	}
+	for _, v := range []*types.Vote{v1, v2} {
+		if ok, err := VerifyVoteSignature(v, registry); err != nil || !ok {
+			return ok, err
+		}
+	}
+	if skipSigVerification(SigKindVoteEvidence) {
+		return true, nil
+	}
+	pubKey, err := crypto.SigToPub(HashVoteEvidence(e), e.Signature)
+	if err != nil {
+		return false, err
+	}
+	return e.ReporterID == NodeIdentity(
+		registry, v1.Position.Round, pubKey), nil
+}
+
+// HashPing generates hash of a types.Ping.
+func HashPing(p *types.Ping) common.Hash {
+	binaryRound := make([]byte, 8)
+	binary.LittleEndian.PutUint64(binaryRound, p.Round)
+	binaryNonce := make([]byte, 8)
+	binary.LittleEndian.PutUint64(binaryNonce, p.Nonce)
+	binaryIsPong := []byte{0}
+	if p.IsPong {
+		binaryIsPong[0] = 1
+	}
+	return crypto.Keccak256Hash(
+		p.ProposerID.Hash[:],
+		p.TargetID.Hash[:],
+		binaryRound,
+		binaryNonce,
+		binaryIsPong,
+	)
+}
+
+// VerifyPingSignature verifies the signature of types.Ping.
+func VerifyPingSignature(
+	p *types.Ping, registry NodeIdentityRegistry) (bool, error) {
+	hash := HashPing(p)
+	if skipSigVerification(SigKindPing) {
+		return true, nil
+	}
+	pubKey, err := crypto.SigToPub(hash, p.Signature)
+	if err != nil {
+		return false, err
+	}
+	return p.ProposerID == NodeIdentity(registry, p.Round, pubKey), nil
+}
+
+// HashReadiness generates hash of a types.Readiness.
+func HashReadiness(r *types.Readiness) common.Hash {
+	binaryRound := make([]byte, 8)
+	binary.LittleEndian.PutUint64(binaryRound, r.Round)
+	return crypto.Keccak256Hash(
+		r.ProposerID.Hash[:],
+		binaryRound,
+	)
+}
+
+// VerifyReadinessSignature verifies the signature of types.Readiness.
+func VerifyReadinessSignature(
+	r *types.Readiness, registry NodeIdentityRegistry) (bool, error) {
+	hash := HashReadiness(r)
+	if skipSigVerification(SigKindReadiness) {
+		return true, nil
+	}
+	pubKey, err := crypto.SigToPub(hash, r.Signature)
+	if err != nil {
+		return false, err
+	}
+	return r.ProposerID == NodeIdentity(registry, r.Round, pubKey), nil
+}
+
 func hashCRS(block *types.Block, crs common.Hash) common.Hash {
 	hashPos := HashPosition(block.Position)
 	if block.Position.Round < dkgDelayRound {
@@ -143,6 +357,9 @@ func VerifyCRSSignature(
 	if !exist {
 		return false
 	}
+	if skipSigVerification(SigKindCRS) {
+		return true
+	}
 	return pubKey.VerifySignature(hash, block.CRSSignature)
 }
 
@@ -177,14 +394,17 @@ func hashDKGPrivateShare(prvShare *typesDKG.PrivateShare) common.Hash {
 
 // VerifyDKGPrivateShareSignature verifies the signature of
 // typesDKG.PrivateShare.
-func VerifyDKGPrivateShareSignature(
-	prvShare *typesDKG.PrivateShare) (bool, error) {
+func VerifyDKGPrivateShareSignature(prvShare *typesDKG.PrivateShare,
+	registry NodeIdentityRegistry) (bool, error) {
+	if skipSigVerification(SigKindDKGPrivateShare) {
+		return true, nil
+	}
 	hash := hashDKGPrivateShare(prvShare)
 	pubKey, err := crypto.SigToPub(hash, prvShare.Signature)
 	if err != nil {
 		return false, err
 	}
-	if prvShare.ProposerID != types.NewNodeID(pubKey) {
+	if prvShare.ProposerID != NodeIdentity(registry, prvShare.Round, pubKey) {
 		return false, nil
 	}
 	return true, nil
@@ -205,15 +425,24 @@ func hashDKGMasterPublicKey(mpk *typesDKG.MasterPublicKey) common.Hash {
 	)
 }
 
+// HashDKGMasterPublicKey returns the hash of a DKGMasterPublicKey signed by
+// its proposer.
+func HashDKGMasterPublicKey(mpk *typesDKG.MasterPublicKey) common.Hash {
+	return hashDKGMasterPublicKey(mpk)
+}
+
 // VerifyDKGMasterPublicKeySignature verifies DKGMasterPublicKey signature.
-func VerifyDKGMasterPublicKeySignature(
-	mpk *typesDKG.MasterPublicKey) (bool, error) {
+func VerifyDKGMasterPublicKeySignature(mpk *typesDKG.MasterPublicKey,
+	registry NodeIdentityRegistry) (bool, error) {
+	if skipSigVerification(SigKindDKGMasterPublicKey) {
+		return true, nil
+	}
 	hash := hashDKGMasterPublicKey(mpk)
 	pubKey, err := crypto.SigToPub(hash, mpk.Signature)
 	if err != nil {
 		return false, err
 	}
-	if mpk.ProposerID != types.NewNodeID(pubKey) {
+	if mpk.ProposerID != NodeIdentity(registry, mpk.Round, pubKey) {
 		return false, nil
 	}
 	return true, nil
@@ -235,25 +464,34 @@ func hashDKGComplaint(complaint *typesDKG.Complaint) common.Hash {
 	)
 }
 
+// HashDKGComplaint returns the hash of a DKGComplaint signed by its proposer.
+func HashDKGComplaint(complaint *typesDKG.Complaint) common.Hash {
+	return hashDKGComplaint(complaint)
+}
+
 // VerifyDKGComplaintSignature verifies DKGCompliant signature.
-func VerifyDKGComplaintSignature(
-	complaint *typesDKG.Complaint) (bool, error) {
+func VerifyDKGComplaintSignature(complaint *typesDKG.Complaint,
+	registry NodeIdentityRegistry) (bool, error) {
 	if complaint.Round != complaint.PrivateShare.Round {
 		return false, nil
 	}
 	if complaint.Reset != complaint.PrivateShare.Reset {
 		return false, nil
 	}
-	hash := hashDKGComplaint(complaint)
-	pubKey, err := crypto.SigToPub(hash, complaint.Signature)
-	if err != nil {
-		return false, err
-	}
-	if complaint.ProposerID != types.NewNodeID(pubKey) {
-		return false, nil
+	if !skipSigVerification(SigKindDKGComplaint) {
+		hash := hashDKGComplaint(complaint)
+		pubKey, err := crypto.SigToPub(hash, complaint.Signature)
+		if err != nil {
+			return false, err
+		}
+		if complaint.ProposerID !=
+			NodeIdentity(registry, complaint.Round, pubKey) {
+			return false, nil
+		}
 	}
 	if !complaint.IsNack() {
-		return VerifyDKGPrivateShareSignature(&complaint.PrivateShare)
+		return VerifyDKGPrivateShareSignature(
+			&complaint.PrivateShare, registry)
 	}
 	return true, nil
 }
@@ -272,14 +510,17 @@ func hashDKGPartialSignature(psig *typesDKG.PartialSignature) common.Hash {
 
 // VerifyDKGPartialSignatureSignature verifies the signature of
 // typesDKG.PartialSignature.
-func VerifyDKGPartialSignatureSignature(
-	psig *typesDKG.PartialSignature) (bool, error) {
+func VerifyDKGPartialSignatureSignature(psig *typesDKG.PartialSignature,
+	registry NodeIdentityRegistry) (bool, error) {
+	if skipSigVerification(SigKindDKGPartialSig) {
+		return true, nil
+	}
 	hash := hashDKGPartialSignature(psig)
 	pubKey, err := crypto.SigToPub(hash, psig.Signature)
 	if err != nil {
 		return false, err
 	}
-	if psig.ProposerID != types.NewNodeID(pubKey) {
+	if psig.ProposerID != NodeIdentity(registry, psig.Round, pubKey) {
 		return false, nil
 	}
 	return true, nil
@@ -299,14 +540,17 @@ func hashDKGMPKReady(ready *typesDKG.MPKReady) common.Hash {
 }
 
 // VerifyDKGMPKReadySignature verifies DKGMPKReady signature.
-func VerifyDKGMPKReadySignature(
-	ready *typesDKG.MPKReady) (bool, error) {
+func VerifyDKGMPKReadySignature(ready *typesDKG.MPKReady,
+	registry NodeIdentityRegistry) (bool, error) {
+	if skipSigVerification(SigKindDKGMPKReady) {
+		return true, nil
+	}
 	hash := hashDKGMPKReady(ready)
 	pubKey, err := crypto.SigToPub(hash, ready.Signature)
 	if err != nil {
 		return false, err
 	}
-	if ready.ProposerID != types.NewNodeID(pubKey) {
+	if ready.ProposerID != NodeIdentity(registry, ready.Round, pubKey) {
 		return false, nil
 	}
 	return true, nil
@@ -339,28 +583,34 @@ func hashDKGSuccess(success *typesDKG.Success) common.Hash {
 }
 
 // VerifyDKGFinalizeSignature verifies DKGFinalize signature.
-func VerifyDKGFinalizeSignature(
-	final *typesDKG.Finalize) (bool, error) {
+func VerifyDKGFinalizeSignature(final *typesDKG.Finalize,
+	registry NodeIdentityRegistry) (bool, error) {
+	if skipSigVerification(SigKindDKGFinalize) {
+		return true, nil
+	}
 	hash := hashDKGFinalize(final)
 	pubKey, err := crypto.SigToPub(hash, final.Signature)
 	if err != nil {
 		return false, err
 	}
-	if final.ProposerID != types.NewNodeID(pubKey) {
+	if final.ProposerID != NodeIdentity(registry, final.Round, pubKey) {
 		return false, nil
 	}
 	return true, nil
 }
 
 // VerifyDKGSuccessSignature verifies DKGSuccess signature.
-func VerifyDKGSuccessSignature(
-	success *typesDKG.Success) (bool, error) {
+func VerifyDKGSuccessSignature(success *typesDKG.Success,
+	registry NodeIdentityRegistry) (bool, error) {
+	if skipSigVerification(SigKindDKGSuccess) {
+		return true, nil
+	}
 	hash := hashDKGSuccess(success)
 	pubKey, err := crypto.SigToPub(hash, success.Signature)
 	if err != nil {
 		return false, err
 	}
-	if success.ProposerID != types.NewNodeID(pubKey) {
+	if success.ProposerID != NodeIdentity(registry, success.Round, pubKey) {
 		return false, nil
 	}
 	return true, nil
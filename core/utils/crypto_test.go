@@ -104,7 +104,7 @@ func (s *CryptoTestSuite) TestBlockSignature() {
 			s.Require().NoError(err)
 			s.Equal(hash, block.ParentHash)
 		}
-		s.NoError(VerifyBlockSignature(block))
+		s.NoError(VerifyBlockSignature(block, nil))
 	}
 }
 
@@ -117,15 +117,121 @@ func (s *CryptoTestSuite) TestVoteSignature() {
 	vote.ProposerID = nID
 	vote.Signature, err = prv.Sign(HashVote(vote))
 	s.Require().NoError(err)
-	ok, err := VerifyVoteSignature(vote)
+	ok, err := VerifyVoteSignature(vote, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 	vote.Type = types.VoteCom
-	ok, err = VerifyVoteSignature(vote)
+	ok, err = VerifyVoteSignature(vote, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 }
 
+func (s *CryptoTestSuite) TestVoteEvidence() {
+	prv, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	accused := NewSigner(prv)
+	prv, err = ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	reporter := NewSigner(prv)
+	v1 := types.NewVote(types.VoteCom, common.NewRandomHash(), 1)
+	v2 := types.NewVote(types.VoteCom, common.NewRandomHash(), 1)
+	s.Require().NoError(accused.SignVote(v1))
+	s.Require().NoError(accused.SignVote(v2))
+	evidence := types.NewVoteEvidence(v1, v2)
+	s.Require().NoError(reporter.SignVoteEvidence(evidence))
+	s.Equal(reporter.proposerID, evidence.ReporterID)
+	ok, err := VerifyVoteEvidence(evidence, nil)
+	s.Require().NoError(err)
+	s.True(ok)
+	// The reporter should be verified.
+	forged := types.NewVoteEvidence(v1, v2)
+	forged.ReporterID = accused.proposerID
+	forged.Signature = evidence.Signature
+	ok, err = VerifyVoteEvidence(forged, nil)
+	s.Require().NoError(err)
+	s.False(ok)
+	// Votes of different periods are not conflicting.
+	v3 := types.NewVote(types.VoteCom, common.NewRandomHash(), 2)
+	s.Require().NoError(accused.SignVote(v3))
+	evidence = types.NewVoteEvidence(v1, v3)
+	s.Require().NoError(reporter.SignVoteEvidence(evidence))
+	_, err = VerifyVoteEvidence(evidence, nil)
+	s.Equal(ErrVotesNotConflicting, err)
+	// Votes should be signed by the accused node.
+	v4 := types.NewVote(types.VoteCom, common.NewRandomHash(), 1)
+	s.Require().NoError(reporter.SignVote(v4))
+	v4.ProposerID = accused.proposerID
+	evidence = types.NewVoteEvidence(v1, v4)
+	s.Require().NoError(reporter.SignVoteEvidence(evidence))
+	ok, err = VerifyVoteEvidence(evidence, nil)
+	s.Require().NoError(err)
+	s.False(ok)
+}
+
+func (s *CryptoTestSuite) TestVoteSignatureCache() {
+	PurgeVoteSignatureCache()
+	prv, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	vote := types.NewVote(types.VoteInit, common.NewRandomHash(), 1)
+	vote.ProposerID = types.NewNodeID(prv.PublicKey())
+	vote.Signature, err = prv.Sign(HashVote(vote))
+	s.Require().NoError(err)
+	s.Equal(0, voteSignatureCache.Len())
+	for i := 0; i < 2; i++ {
+		ok, err := VerifyVoteSignature(vote, nil)
+		s.Require().NoError(err)
+		s.True(ok)
+		s.Equal(1, voteSignatureCache.Len())
+	}
+	// Proposer is still checked when the signature is cached.
+	forged := vote.Clone()
+	forged.ProposerID = types.NodeID{Hash: common.NewRandomHash()}
+	forged.Signature, err = prv.Sign(HashVote(forged))
+	s.Require().NoError(err)
+	for i := 0; i < 2; i++ {
+		ok, err := VerifyVoteSignature(forged, nil)
+		s.Require().NoError(err)
+		s.False(ok)
+	}
+	PurgeVoteSignatureCache()
+	s.Equal(0, voteSignatureCache.Len())
+}
+
+func (s *CryptoTestSuite) TestTrustedCrypto() {
+	prv, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	vote := types.NewVote(types.VoteInit, common.NewRandomHash(), 1)
+	vote.ProposerID = types.NodeID{Hash: common.NewRandomHash()}
+	vote.Signature, err = prv.Sign(HashVote(vote))
+	s.Require().NoError(err)
+	block := s.newBlock(nil)
+	block.Signature, err = prv.Sign(block.Hash)
+	s.Require().NoError(err)
+	ResetSkippedSignatureVerifications()
+	SetTrustedCrypto(true)
+	defer SetTrustedCrypto(false)
+	s.True(IsTrustedCrypto())
+	// Signatures are not verified, only counted.
+	ok, err := VerifyVoteSignature(vote, nil)
+	s.Require().NoError(err)
+	s.True(ok)
+	s.NoError(VerifyBlockSignatureWithoutPayload(block, nil))
+	// Hashes are still verified.
+	block.Position.Height++
+	s.Equal(ErrIncorrectHash, VerifyBlockSignatureWithoutPayload(block, nil))
+	skipped := SkippedSignatureVerifications()
+	s.Equal(uint64(1), skipped[SigKindVote])
+	s.Equal(uint64(1), skipped[SigKindBlock])
+	s.Equal(uint64(0), skipped[SigKindDKGPrivateShare])
+	SetTrustedCrypto(false)
+	ok, err = VerifyVoteSignature(vote, nil)
+	s.Require().NoError(err)
+	s.False(ok)
+	s.Equal(uint64(1), SkippedSignatureVerifications()[SigKindVote])
+	ResetSkippedSignatureVerifications()
+	s.Equal(uint64(0), SkippedSignatureVerifications()[SigKindVote])
+}
+
 func (s *CryptoTestSuite) TestCRSSignature() {
 	dkgDelayRound = 1
 	crs := common.NewRandomHash()
@@ -157,16 +263,16 @@ func (s *CryptoTestSuite) TestDKGSignature() {
 	}
 	prvShare.Signature, err = prv.Sign(hashDKGPrivateShare(prvShare))
 	s.Require().NoError(err)
-	ok, err := VerifyDKGPrivateShareSignature(prvShare)
+	ok, err := VerifyDKGPrivateShareSignature(prvShare, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 	prvShare.Round++
-	ok, err = VerifyDKGPrivateShareSignature(prvShare)
+	ok, err = VerifyDKGPrivateShareSignature(prvShare, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	prvShare.Round--
 	prvShare.Reset++
-	ok, err = VerifyDKGPrivateShareSignature(prvShare)
+	ok, err = VerifyDKGPrivateShareSignature(prvShare, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	prvShare.Reset--
@@ -182,18 +288,18 @@ func (s *CryptoTestSuite) TestDKGSignature() {
 	}
 	mpk.Signature, err = prv.Sign(hashDKGMasterPublicKey(mpk))
 	s.Require().NoError(err)
-	ok, err = VerifyDKGMasterPublicKeySignature(mpk)
+	ok, err = VerifyDKGMasterPublicKeySignature(mpk, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 	// Test incorrect round.
 	mpk.Round++
-	ok, err = VerifyDKGMasterPublicKeySignature(mpk)
+	ok, err = VerifyDKGMasterPublicKeySignature(mpk, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	mpk.Round--
 	// Test incorrect reset.
 	mpk.Reset++
-	ok, err = VerifyDKGMasterPublicKeySignature(mpk)
+	ok, err = VerifyDKGMasterPublicKeySignature(mpk, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	mpk.Reset--
@@ -208,12 +314,12 @@ func (s *CryptoTestSuite) TestDKGSignature() {
 	}
 	complaint.Signature, err = prv.Sign(hashDKGComplaint(complaint))
 	s.Require().NoError(err)
-	ok, err = VerifyDKGComplaintSignature(complaint)
+	ok, err = VerifyDKGComplaintSignature(complaint, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 	// Test incorrect complaint signature.
 	complaint.Round++
-	ok, err = VerifyDKGComplaintSignature(complaint)
+	ok, err = VerifyDKGComplaintSignature(complaint, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	complaint.Round--
@@ -221,7 +327,7 @@ func (s *CryptoTestSuite) TestDKGSignature() {
 	complaint.PrivateShare.Round++
 	complaint.Signature, err = prv.Sign(hashDKGComplaint(complaint))
 	s.Require().NoError(err)
-	ok, err = VerifyDKGComplaintSignature(complaint)
+	ok, err = VerifyDKGComplaintSignature(complaint, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	complaint.PrivateShare.Round--
@@ -229,7 +335,7 @@ func (s *CryptoTestSuite) TestDKGSignature() {
 	complaint.PrivateShare.Reset++
 	complaint.Signature, err = prv.Sign(hashDKGComplaint(complaint))
 	s.Require().NoError(err)
-	ok, err = VerifyDKGComplaintSignature(complaint)
+	ok, err = VerifyDKGComplaintSignature(complaint, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	complaint.PrivateShare.Reset--
@@ -238,7 +344,7 @@ func (s *CryptoTestSuite) TestDKGSignature() {
 	complaint.PrivateShare.ReceiverID = types.NodeID{Hash: common.NewRandomHash()}
 	complaint.Signature, err = prv.Sign(hashDKGComplaint(complaint))
 	s.Require().NoError(err)
-	ok, err = VerifyDKGComplaintSignature(complaint)
+	ok, err = VerifyDKGComplaintSignature(complaint, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 
@@ -249,11 +355,11 @@ func (s *CryptoTestSuite) TestDKGSignature() {
 	}
 	sig.Signature, err = prv.Sign(hashDKGPartialSignature(sig))
 	s.Require().NoError(err)
-	ok, err = VerifyDKGPartialSignatureSignature(sig)
+	ok, err = VerifyDKGPartialSignatureSignature(sig, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 	sig.Round++
-	ok, err = VerifyDKGPartialSignatureSignature(sig)
+	ok, err = VerifyDKGPartialSignatureSignature(sig, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 
@@ -264,18 +370,18 @@ func (s *CryptoTestSuite) TestDKGSignature() {
 	}
 	ready.Signature, err = prv.Sign(hashDKGMPKReady(ready))
 	s.Require().NoError(err)
-	ok, err = VerifyDKGMPKReadySignature(ready)
+	ok, err = VerifyDKGMPKReadySignature(ready, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 	// Test incorrect round.
 	ready.Round++
-	ok, err = VerifyDKGMPKReadySignature(ready)
+	ok, err = VerifyDKGMPKReadySignature(ready, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	ready.Round--
 	// Test incorrect reset.
 	ready.Reset++
-	ok, err = VerifyDKGMPKReadySignature(ready)
+	ok, err = VerifyDKGMPKReadySignature(ready, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	ready.Reset--
@@ -287,18 +393,18 @@ func (s *CryptoTestSuite) TestDKGSignature() {
 	}
 	final.Signature, err = prv.Sign(hashDKGFinalize(final))
 	s.Require().NoError(err)
-	ok, err = VerifyDKGFinalizeSignature(final)
+	ok, err = VerifyDKGFinalizeSignature(final, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 	// Test incorrect round.
 	final.Round++
-	ok, err = VerifyDKGFinalizeSignature(final)
+	ok, err = VerifyDKGFinalizeSignature(final, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	final.Round--
 	// Test incorrect reset.
 	final.Reset++
-	ok, err = VerifyDKGFinalizeSignature(final)
+	ok, err = VerifyDKGFinalizeSignature(final, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	final.Reset--
@@ -310,18 +416,18 @@ func (s *CryptoTestSuite) TestDKGSignature() {
 	}
 	success.Signature, err = prv.Sign(hashDKGSuccess(success))
 	s.Require().NoError(err)
-	ok, err = VerifyDKGSuccessSignature(success)
+	ok, err = VerifyDKGSuccessSignature(success, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 	// Test incorrect round.
 	success.Round++
-	ok, err = VerifyDKGSuccessSignature(success)
+	ok, err = VerifyDKGSuccessSignature(success, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	success.Round--
 	// Test incorrect reset.
 	success.Reset++
-	ok, err = VerifyDKGSuccessSignature(success)
+	ok, err = VerifyDKGSuccessSignature(success, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 	success.Reset--
@@ -0,0 +1,137 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Errors for delivery proofs.
+var (
+	ErrNoDeliveryEvidence = errors.New(
+		"no votes to prove delivery for rounds before DKG is ready")
+	ErrNotEnoughDeliveryVotes = errors.New(
+		"not enough votes to prove delivery")
+	ErrMismatchedDeliveryProof = errors.New(
+		"delivery proof is not of the block")
+)
+
+// NewDeliveryProof constructs the delivery proof of a finalized block. For
+// rounds before DKG is ready, the agreement result confirming that block is
+// required.
+func NewDeliveryProof(b *types.Block, result *types.AgreementResult) (
+	*types.DeliveryProof, error) {
+	header, err := newTimestampProof(b)
+	if err != nil {
+		return nil, err
+	}
+	proof := &types.DeliveryProof{TimestampProof: *header}
+	if b.Position.Round >= dkgDelayRound {
+		return proof, nil
+	}
+	if result == nil || result.BlockHash != b.Hash || len(result.Votes) == 0 {
+		return nil, ErrNoDeliveryEvidence
+	}
+	proof.Votes = append([]types.Vote(nil), result.Votes...)
+	return proof, nil
+}
+
+// VerifyDeliveryProof verifies a delivery proof, and returns the hash of that
+// block. The group public key of that round is required for rounds after DKG
+// is ready, otherwise the notary set of that round and the registry mapping
+// identities of its notaries are required.
+func VerifyDeliveryProof(proof *types.DeliveryProof,
+	groupPublicKey crypto.PublicKey, notarySet map[types.NodeID]struct{},
+	registry NodeIdentityRegistry) (common.Hash, error) {
+	if proof.Position.Round >= dkgDelayRound {
+		return VerifyTimestampProof(&proof.TimestampProof, groupPublicKey)
+	}
+	hash, err := hashBlockFields(proof.ProposerID, proof.ParentHash,
+		proof.Position, proof.Timestamp, proof.PayloadHash, proof.WitnessHash,
+		proof.SystemMessagesHash, proof.ProtocolParamsHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// Votes confirming a block are of the same type and period.
+	type voteGroup struct {
+		t      types.VoteType
+		period uint64
+	}
+	voters := make(map[voteGroup]map[types.NodeID]struct{})
+	threshold := GetBAThreshold(&types.Config{
+		NotarySetSize: uint32(len(notarySet))})
+	for i := range proof.Votes {
+		vote := &proof.Votes[i]
+		if vote.Type != types.VoteCom && vote.Type != types.VoteFastCom {
+			continue
+		}
+		if vote.BlockHash != hash || vote.Position != proof.Position {
+			continue
+		}
+		if _, exist := notarySet[vote.ProposerID]; !exist {
+			continue
+		}
+		ok, err := VerifyVoteSignature(vote, registry)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if !ok {
+			continue
+		}
+		g := voteGroup{t: vote.Type, period: vote.Period}
+		if voters[g] == nil {
+			voters[g] = make(map[types.NodeID]struct{})
+		}
+		voters[g][vote.ProposerID] = struct{}{}
+		if len(voters[g]) >= threshold {
+			return hash, nil
+		}
+	}
+	return common.Hash{}, ErrNotEnoughDeliveryVotes
+}
+
+// VerifyFinalizedBlock verifies a finalized block imported from an untrusted
+// source without a consensus instance, ex. by bridges and auditors. The
+// content and the signature of that block are checked, and it should be the
+// one proved by the delivery proof. Only blocks in rounds after DKG is ready
+// could be verified by the group public key of that round.
+func VerifyFinalizedBlock(b *types.Block, proof *types.DeliveryProof,
+	groupPublicKey crypto.PublicKey, registry NodeIdentityRegistry) error {
+	if b.Position.Round < dkgDelayRound {
+		return ErrNoTimestampProof
+	}
+	if !b.IsFinalized() {
+		return ErrBlockNotFinalized
+	}
+	if err := VerifyBlockSignature(b, registry); err != nil {
+		return err
+	}
+	hash, err := VerifyDeliveryProof(proof, groupPublicKey, nil, registry)
+	if err != nil {
+		return err
+	}
+	if hash != b.Hash || !bytes.Equal(b.Randomness, proof.Randomness) {
+		return ErrMismatchedDeliveryProof
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type DeliveryProofTestSuite struct {
+	suite.Suite
+}
+
+func (s *DeliveryProofTestSuite) newBlock(round uint64) *types.Block {
+	b := &types.Block{
+		ProposerID: types.NodeID{Hash: common.NewRandomHash()},
+		ParentHash: common.NewRandomHash(),
+		Position:   types.Position{Round: round, Height: 10},
+		Timestamp:  time.Now().UTC(),
+		Payload:    []byte("payload"),
+	}
+	b.PayloadHash = crypto.Keccak256Hash(b.Payload)
+	var err error
+	b.Hash, err = HashBlock(b)
+	s.Require().NoError(err)
+	return b
+}
+
+func (s *DeliveryProofTestSuite) TestProveByRandomness() {
+	dkgDelayRound = 1
+	req := s.Require()
+	gprv := dkg.NewPrivateKey()
+	b := s.newBlock(1)
+	_, err := NewDeliveryProof(b, nil)
+	req.Equal(ErrBlockNotFinalized, err)
+	sig, err := gprv.Sign(b.Hash)
+	req.NoError(err)
+	b.Randomness = sig.Signature
+	proof, err := NewDeliveryProof(b, nil)
+	req.NoError(err)
+	req.Empty(proof.Votes)
+	hash, err := VerifyDeliveryProof(proof, gprv.PublicKey(), nil, nil)
+	req.NoError(err)
+	req.Equal(b.Hash, hash)
+	// Tampered position.
+	proof.Position.Height++
+	_, err = VerifyDeliveryProof(proof, gprv.PublicKey(), nil, nil)
+	req.Equal(ErrIncorrectRandomness, err)
+}
+
+func (s *DeliveryProofTestSuite) TestProveByVotes() {
+	dkgDelayRound = 1
+	req := s.Require()
+	b := s.newBlock(0)
+	b.Randomness = []byte{0}
+	notarySet := make(map[types.NodeID]struct{})
+	result := &types.AgreementResult{BlockHash: b.Hash, Position: b.Position}
+	for i := 0; i < 4; i++ {
+		prv, err := ecdsa.NewPrivateKey()
+		req.NoError(err)
+		signer := NewSigner(prv)
+		vote := types.NewVote(types.VoteCom, b.Hash, 1)
+		vote.Position = b.Position
+		req.NoError(signer.SignVote(vote))
+		notarySet[vote.ProposerID] = struct{}{}
+		result.Votes = append(result.Votes, *vote)
+	}
+	_, err := NewDeliveryProof(b, nil)
+	req.Equal(ErrNoDeliveryEvidence, err)
+	proof, err := NewDeliveryProof(b, result)
+	req.NoError(err)
+	hash, err := VerifyDeliveryProof(proof, nil, notarySet, nil)
+	req.NoError(err)
+	req.Equal(b.Hash, hash)
+	// Votes less than threshold.
+	proof.Votes = proof.Votes[:2]
+	_, err = VerifyDeliveryProof(proof, nil, notarySet, nil)
+	req.Equal(ErrNotEnoughDeliveryVotes, err)
+	// Votes of nodes not in notary set.
+	proof, err = NewDeliveryProof(b, result)
+	req.NoError(err)
+	otherSet := make(map[types.NodeID]struct{})
+	for _, vote := range proof.Votes[:2] {
+		otherSet[vote.ProposerID] = struct{}{}
+	}
+	for i := 0; i < 2; i++ {
+		otherSet[types.NodeID{Hash: common.NewRandomHash()}] = struct{}{}
+	}
+	_, err = VerifyDeliveryProof(proof, nil, otherSet, nil)
+	req.Equal(ErrNotEnoughDeliveryVotes, err)
+	// Tampered parent.
+	proof.ParentHash = common.NewRandomHash()
+	_, err = VerifyDeliveryProof(proof, nil, notarySet, nil)
+	req.Equal(ErrNotEnoughDeliveryVotes, err)
+}
+
+func (s *DeliveryProofTestSuite) TestVerifyFinalizedBlock() {
+	dkgDelayRound = 1
+	req := s.Require()
+	prv, err := ecdsa.NewPrivateKey()
+	req.NoError(err)
+	gprv := dkg.NewPrivateKey()
+	newFinalizedBlock := func(round uint64) *types.Block {
+		b := &types.Block{
+			ParentHash: common.NewRandomHash(),
+			Position:   types.Position{Round: round, Height: 10},
+			Timestamp:  time.Now().UTC(),
+			Payload:    []byte("payload"),
+		}
+		req.NoError(NewSigner(prv).SignBlock(b))
+		sig, err := gprv.Sign(b.Hash)
+		req.NoError(err)
+		b.Randomness = sig.Signature
+		return b
+	}
+	b := newFinalizedBlock(1)
+	proof, err := NewDeliveryProof(b, nil)
+	req.NoError(err)
+	req.NoError(VerifyFinalizedBlock(b, proof, gprv.PublicKey(), nil))
+	// Signed by other group.
+	req.Equal(ErrIncorrectRandomness, VerifyFinalizedBlock(
+		b, proof, dkg.NewPrivateKey().PublicKey(), nil))
+	// Proof of other block.
+	other := newFinalizedBlock(1)
+	otherProof, err := NewDeliveryProof(other, nil)
+	req.NoError(err)
+	req.Equal(ErrMismatchedDeliveryProof,
+		VerifyFinalizedBlock(b, otherProof, gprv.PublicKey(), nil))
+	// Tampered payload.
+	tampered := b.Clone()
+	tampered.Payload = []byte("tampered")
+	req.Equal(ErrIncorrectHash,
+		VerifyFinalizedBlock(tampered, proof, gprv.PublicKey(), nil))
+	// Not finalized.
+	tampered = b.Clone()
+	tampered.Randomness = nil
+	req.Equal(ErrBlockNotFinalized,
+		VerifyFinalizedBlock(tampered, proof, gprv.PublicKey(), nil))
+	// Rounds before DKG is ready.
+	b = newFinalizedBlock(0)
+	req.Equal(ErrNoTimestampProof, VerifyFinalizedBlock(b, nil, nil, nil))
+}
+
+func TestDeliveryProof(t *testing.T) {
+	suite.Run(t, new(DeliveryProofTestSuite))
+}
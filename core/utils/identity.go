@@ -0,0 +1,44 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// NodeIdentityRegistry maps public keys to identities of nodes, which allows
+// a node to rotate its key without changing its identity. It's usually
+// implemented by governance, and passed to functions verifying signers of
+// messages. A nil registry means the identity of a node is derived from its
+// public key.
+type NodeIdentityRegistry interface {
+	// NodeIdentity returns the identity of the node signing with that public
+	// key at a given round.
+	NodeIdentity(round uint64, key crypto.PublicKey) types.NodeID
+}
+
+// NodeIdentity returns the identity of the node signing with that public key
+// at a given round by the registry.
+func NodeIdentity(registry NodeIdentityRegistry, round uint64,
+	key crypto.PublicKey) types.NodeID {
+	if registry == nil {
+		return types.NewNodeID(key)
+	}
+	return registry.NodeIdentity(round, key)
+}
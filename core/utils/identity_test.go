@@ -0,0 +1,100 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// rotationRegistry maps a rotated key to the identity of the original key
+// since a specific round.
+type rotationRegistry struct {
+	origin, rotated crypto.PublicKey
+	since           uint64
+}
+
+func (r *rotationRegistry) NodeIdentity(
+	round uint64, key crypto.PublicKey) types.NodeID {
+	if round >= r.since && bytes.Equal(key.Bytes(), r.rotated.Bytes()) {
+		return types.NewNodeID(r.origin)
+	}
+	if round >= r.since && bytes.Equal(key.Bytes(), r.origin.Bytes()) {
+		// The original key is revoked.
+		return types.NodeID{}
+	}
+	return types.NewNodeID(key)
+}
+
+type IdentityTestSuite struct {
+	suite.Suite
+}
+
+func (s *IdentityTestSuite) TestKeyRotation() {
+	originKey, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	rotatedKey, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	nID := types.NewNodeID(originKey.PublicKey())
+	registry := &rotationRegistry{
+		origin:  originKey.PublicKey(),
+		rotated: rotatedKey.PublicKey(),
+		since:   5,
+	}
+	s.Require().Equal(nID, NodeIdentity(registry, 5, rotatedKey.PublicKey()))
+	// Without a registry, the identity is derived from the key.
+	s.Require().Equal(types.NewNodeID(rotatedKey.PublicKey()),
+		NodeIdentity(nil, 5, rotatedKey.PublicKey()))
+	signVote := func(prv crypto.PrivateKey, round uint64) *types.Vote {
+		signer := NewSigner(prv)
+		signer.SetNodeID(nID)
+		v := types.NewVote(types.VoteCom, common.NewRandomHash(), 1)
+		v.Position = types.Position{Round: round}
+		s.Require().NoError(signer.SignVote(v))
+		return v
+	}
+	// The original key is valid before rotation.
+	ok, err := VerifyVoteSignature(signVote(originKey, 4), registry)
+	s.Require().NoError(err)
+	s.Require().True(ok)
+	ok, err = VerifyVoteSignature(signVote(rotatedKey, 4), registry)
+	s.Require().NoError(err)
+	s.Require().False(ok)
+	// The rotated key is valid after rotation.
+	ok, err = VerifyVoteSignature(signVote(rotatedKey, 5), registry)
+	s.Require().NoError(err)
+	s.Require().True(ok)
+	ok, err = VerifyVoteSignature(signVote(originKey, 5), registry)
+	s.Require().NoError(err)
+	s.Require().False(ok)
+	// The registry is not consulted when it's not given.
+	ok, err = VerifyVoteSignature(signVote(rotatedKey, 5), nil)
+	s.Require().NoError(err)
+	s.Require().False(ok)
+}
+
+func TestIdentity(t *testing.T) {
+	suite.Run(t, new(IdentityTestSuite))
+}
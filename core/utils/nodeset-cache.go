@@ -33,6 +33,9 @@ var (
 	ErrCRSNotReady = errors.New("crs is not ready")
 	// ErrConfigurationNotReady means we go nil configuration.
 	ErrConfigurationNotReady = errors.New("configuration is not ready")
+	// ErrStaleCRS means we got the CRS of the previous round, CRS of
+	// consecutive rounds never repeat unless governance is stale.
+	ErrStaleCRS = errors.New("crs is stale")
 )
 
 type sets struct {
@@ -61,20 +64,32 @@ type NodeSetCacheInterface interface {
 // NOTE: this module doesn't handle DKG resetting and can only be used along
 //       with utils.RoundEvent.
 type NodeSetCache struct {
-	lock    sync.RWMutex
-	nsIntf  NodeSetCacheInterface
-	rounds  map[uint64]*sets
-	keyPool map[types.NodeID]*struct {
+	lock     sync.RWMutex
+	nsIntf   NodeSetCacheInterface
+	registry NodeIdentityRegistry
+	rounds   map[uint64]*sets
+	keyPool  map[types.NodeID]*struct {
 		pubKey crypto.PublicKey
 		refCnt int
 	}
 }
 
-// NewNodeSetCache constructs an NodeSetCache instance.
+// NewNodeSetCache constructs an NodeSetCache instance. Identities of nodes
+// are mapped by nsIntf when it implements NodeIdentityRegistry.
 func NewNodeSetCache(nsIntf NodeSetCacheInterface) *NodeSetCache {
+	registry, _ := nsIntf.(NodeIdentityRegistry)
+	return NewNodeSetCacheWithRegistry(nsIntf, registry)
+}
+
+// NewNodeSetCacheWithRegistry constructs an NodeSetCache instance mapping
+// identities of nodes by the registry, nil means identities are derived from
+// public keys.
+func NewNodeSetCacheWithRegistry(nsIntf NodeSetCacheInterface,
+	registry NodeIdentityRegistry) *NodeSetCache {
 	return &NodeSetCache{
-		nsIntf: nsIntf,
-		rounds: make(map[uint64]*sets),
+		nsIntf:   nsIntf,
+		registry: registry,
+		rounds:   make(map[uint64]*sets),
 		keyPool: make(map[types.NodeID]*struct {
 			pubKey crypto.PublicKey
 			refCnt int
@@ -82,6 +97,12 @@ func NewNodeSetCache(nsIntf NodeSetCacheInterface) *NodeSetCache {
 	}
 }
 
+// Registry returns the registry mapping identities of nodes, it should be
+// passed to functions verifying signers of messages.
+func (cache *NodeSetCache) Registry() NodeIdentityRegistry {
+	return cache.registry
+}
+
 // Exists checks if a node is in node set of that round.
 func (cache *NodeSetCache) Exists(
 	round uint64, nodeID types.NodeID) (exists bool, err error) {
@@ -193,12 +214,18 @@ func (cache *NodeSetCache) update(round uint64) (nIDs *sets, err error) {
 		err = ErrCRSNotReady
 		return
 	}
+	if round > 0 && cache.nsIntf.CRS(round-1) == crs {
+		err = ErrStaleCRS
+		return
+	}
 	// Cache new round.
 	nodeSet := types.NewNodeSet()
 	for _, key := range keySet {
-		nID := types.NewNodeID(key)
+		nID := NodeIdentity(cache.registry, round, key)
 		nodeSet.Add(nID)
 		if rec, exists := cache.keyPool[nID]; exists {
+			// The key might be rotated.
+			rec.pubKey = key
 			rec.refCnt++
 		} else {
 			cache.keyPool[nID] = &struct {
@@ -32,6 +32,8 @@ type nsIntf struct {
 	s       *NodeSetCacheTestSuite
 	crs     common.Hash
 	curKeys []crypto.PublicKey
+	// stale governance returns CRS of the previous round.
+	stale bool
 }
 
 func (g *nsIntf) Configuration(round uint64) (cfg *types.Config) {
@@ -42,7 +44,12 @@ func (g *nsIntf) Configuration(round uint64) (cfg *types.Config) {
 		MinBlockInterval: 1 * time.Second,
 	}
 }
-func (g *nsIntf) CRS(round uint64) (b common.Hash) { return g.crs }
+func (g *nsIntf) CRS(round uint64) (b common.Hash) {
+	if g.stale && round > 0 {
+		round--
+	}
+	return Rehash(g.crs, uint(round))
+}
 func (g *nsIntf) NodeSet(round uint64) []crypto.PublicKey {
 	// Randomly generating keys, and check them for verification.
 	g.curKeys = []crypto.PublicKey{}
@@ -144,6 +151,28 @@ func (s *NodeSetCacheTestSuite) TestPurge() {
 	req.False(exist)
 }
 
+func (s *NodeSetCacheTestSuite) TestStaleCRS() {
+	var (
+		nsIntf = &nsIntf{
+			s:     s,
+			crs:   common.NewRandomHash(),
+			stale: true,
+		}
+		cache = NewNodeSetCache(nsIntf)
+		req   = s.Require()
+	)
+	// The genesis round is not affected.
+	req.NoError(cache.Touch(0))
+	_, err := cache.GetNotarySet(1)
+	req.Equal(ErrStaleCRS, err)
+	_, exist := cache.get(1)
+	req.False(exist)
+	// Recovered once governance is updated.
+	nsIntf.stale = false
+	_, err = cache.GetNotarySet(1)
+	req.NoError(err)
+}
+
 func TestNodeSetCache(t *testing.T) {
 	suite.Run(t, new(NodeSetCacheTestSuite))
 }
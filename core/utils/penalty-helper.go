@@ -33,22 +33,23 @@ var (
 
 // NeedPenaltyDKGPrivateShare checks if the proposer of dkg private share
 // should be penalized.
-func NeedPenaltyDKGPrivateShare(
-	complaint *typesDKG.Complaint, mpk *typesDKG.MasterPublicKey) (bool, error) {
+func NeedPenaltyDKGPrivateShare(complaint *typesDKG.Complaint,
+	mpk *typesDKG.MasterPublicKey,
+	registry NodeIdentityRegistry) (bool, error) {
 	if complaint.IsNack() {
 		return false, nil
 	}
 	if mpk.ProposerID != complaint.PrivateShare.ProposerID {
 		return false, nil
 	}
-	ok, err := VerifyDKGMasterPublicKeySignature(mpk)
+	ok, err := VerifyDKGMasterPublicKeySignature(mpk, registry)
 	if err != nil {
 		return false, err
 	}
 	if !ok {
 		return false, ErrInvalidDKGMasterPublicKey
 	}
-	ok, err = VerifyDKGComplaintSignature(complaint)
+	ok, err = VerifyDKGComplaintSignature(complaint, registry)
 	if err != nil {
 		return false, err
 	}
@@ -65,7 +66,8 @@ func NeedPenaltyDKGPrivateShare(
 }
 
 // NeedPenaltyForkVote checks if two votes are fork vote.
-func NeedPenaltyForkVote(vote1, vote2 *types.Vote) (bool, error) {
+func NeedPenaltyForkVote(
+	vote1, vote2 *types.Vote, registry NodeIdentityRegistry) (bool, error) {
 	if vote1.ProposerID != vote2.ProposerID ||
 		vote1.Type != vote2.Type ||
 		vote1.Period != vote2.Period ||
@@ -73,14 +75,14 @@ func NeedPenaltyForkVote(vote1, vote2 *types.Vote) (bool, error) {
 		vote1.BlockHash == vote2.BlockHash {
 		return false, nil
 	}
-	ok, err := VerifyVoteSignature(vote1)
+	ok, err := VerifyVoteSignature(vote1, registry)
 	if err != nil {
 		return false, err
 	}
 	if !ok {
 		return false, nil
 	}
-	ok, err = VerifyVoteSignature(vote2)
+	ok, err = VerifyVoteSignature(vote2, registry)
 	if err != nil {
 		return false, err
 	}
@@ -91,7 +93,8 @@ func NeedPenaltyForkVote(vote1, vote2 *types.Vote) (bool, error) {
 }
 
 // NeedPenaltyForkBlock checks if two blocks are fork block.
-func NeedPenaltyForkBlock(block1, block2 *types.Block) (bool, error) {
+func NeedPenaltyForkBlock(block1, block2 *types.Block,
+	registry NodeIdentityRegistry) (bool, error) {
 	if block1.ProposerID != block2.ProposerID ||
 		block1.Position != block2.Position ||
 		block1.Hash == block2.Hash {
@@ -101,7 +104,7 @@ func NeedPenaltyForkBlock(block1, block2 *types.Block) (bool, error) {
 		return false, ErrPayloadNotEmpty
 	}
 	verifyBlock := func(block *types.Block) (bool, error) {
-		err := VerifyBlockSignatureWithoutPayload(block)
+		err := VerifyBlockSignatureWithoutPayload(block, registry)
 		switch err {
 		case nil:
 			return true, nil
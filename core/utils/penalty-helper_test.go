@@ -63,7 +63,7 @@ func (s *PenaltyHelperTestSuite) TestDKGComplaint() {
 	}
 	signComplaint(prv2, complaint)
 	s.Require().True(complaint.IsNack())
-	ok, err := NeedPenaltyDKGPrivateShare(complaint, mpk)
+	ok, err := NeedPenaltyDKGPrivateShare(complaint, mpk, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 
@@ -80,7 +80,7 @@ func (s *PenaltyHelperTestSuite) TestDKGComplaint() {
 	s.Require().NoError(err)
 	complaint.PrivateShare = *prvShare
 	signComplaint(prv2, complaint)
-	ok, err = NeedPenaltyDKGPrivateShare(complaint, mpk)
+	ok, err = NeedPenaltyDKGPrivateShare(complaint, mpk, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 
@@ -92,13 +92,13 @@ func (s *PenaltyHelperTestSuite) TestDKGComplaint() {
 	s.Require().NoError(err)
 	complaint.PrivateShare = *prvShare
 	signComplaint(prv2, complaint)
-	ok, err = NeedPenaltyDKGPrivateShare(complaint, mpk)
+	ok, err = NeedPenaltyDKGPrivateShare(complaint, mpk, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 
 	// Should not penalize if mpk is incorrect.
 	mpk.Round++
-	ok, err = NeedPenaltyDKGPrivateShare(complaint, mpk)
+	ok, err = NeedPenaltyDKGPrivateShare(complaint, mpk, nil)
 	s.Equal(ErrInvalidDKGMasterPublicKey, err)
 
 	// Should not penalize if mpk's proposer not match with prvShares'.
@@ -106,7 +106,7 @@ func (s *PenaltyHelperTestSuite) TestDKGComplaint() {
 	mpk.ProposerID = nID2
 	mpk.Signature, err = prv1.Sign(hashDKGMasterPublicKey(mpk))
 	s.Require().NoError(err)
-	ok, err = NeedPenaltyDKGPrivateShare(complaint, mpk)
+	ok, err = NeedPenaltyDKGPrivateShare(complaint, mpk, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 }
@@ -127,20 +127,20 @@ func (s *PenaltyHelperTestSuite) TestForkVote() {
 	vote2.Signature, err = prv1.Sign(HashVote(vote2))
 	s.Require().NoError(err)
 
-	ok, err := NeedPenaltyForkVote(vote1, vote2)
+	ok, err := NeedPenaltyForkVote(vote1, vote2, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 
 	// Invalid signature should not be penalized.
 	vote2.VoteHeader.Period++
-	ok, err = NeedPenaltyForkVote(vote1, vote2)
+	ok, err = NeedPenaltyForkVote(vote1, vote2, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 
 	// Period not matched.
 	vote2.Signature, err = prv1.Sign(HashVote(vote2))
 	s.Require().NoError(err)
-	ok, err = NeedPenaltyForkVote(vote1, vote2)
+	ok, err = NeedPenaltyForkVote(vote1, vote2, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 
@@ -154,7 +154,7 @@ func (s *PenaltyHelperTestSuite) TestForkVote() {
 	vote2.ProposerID = types.NewNodeID(prv2.PublicKey())
 	vote2.Signature, err = prv2.Sign(HashVote(vote2))
 	s.Require().NoError(err)
-	ok, err = NeedPenaltyForkVote(vote1, vote2)
+	ok, err = NeedPenaltyForkVote(vote1, vote2, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 }
@@ -184,13 +184,13 @@ func (s *PenaltyHelperTestSuite) TestForkBlock() {
 	block2.Signature, err = prv1.Sign(hashBlock(block2))
 	s.Require().NoError(err)
 
-	ok, err := NeedPenaltyForkBlock(block1, block2)
+	ok, err := NeedPenaltyForkBlock(block1, block2, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 
 	// Invalid signature should not be penalized.
 	block2.ParentHash[0]++
-	ok, err = NeedPenaltyForkBlock(block1, block2)
+	ok, err = NeedPenaltyForkBlock(block1, block2, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 
@@ -198,7 +198,7 @@ func (s *PenaltyHelperTestSuite) TestForkBlock() {
 	block2.Position.Height++
 	block2.Signature, err = prv1.Sign(hashBlock(block2))
 	s.Require().NoError(err)
-	ok, err = NeedPenaltyForkBlock(block1, block2)
+	ok, err = NeedPenaltyForkBlock(block1, block2, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 
@@ -212,7 +212,7 @@ func (s *PenaltyHelperTestSuite) TestForkBlock() {
 	block2.ProposerID = types.NewNodeID(prv2.PublicKey())
 	block2.Signature, err = prv2.Sign(hashBlock(block2))
 	s.Require().NoError(err)
-	ok, err = NeedPenaltyForkBlock(block1, block2)
+	ok, err = NeedPenaltyForkBlock(block1, block2, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 }
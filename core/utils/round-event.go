@@ -66,10 +66,25 @@ func (e RoundEventParam) NextCRSProposingHeight() uint64 {
 	return e.BeginHeight + e.Config.RoundLength/2
 }
 
+// NextCRSDeadlineHeight returns the height that CRS for next round should be
+// ready.
+func (e RoundEventParam) NextCRSDeadlineHeight() uint64 {
+	return e.BeginHeight + e.Config.RoundLength*7/10
+}
+
 // NextDKGPreparationHeight returns the height to prepare DKG set for next
-// round.
+// round. It's moved earlier, but not before DKG registration, when DKG phases
+// can't be done before the height to reset DKG.
 func (e RoundEventParam) NextDKGPreparationHeight() uint64 {
-	return e.BeginHeight + e.Config.RoundLength*2/3
+	height := e.BeginHeight + e.Config.RoundLength*2/3
+	dkgLength, reset := e.Config.DKGLength(), e.NextDKGResetHeight()
+	if height+dkgLength > reset {
+		height = e.NextDKGRegisterHeight()
+		if reset > height+dkgLength {
+			height = reset - dkgLength
+		}
+	}
+	return height
 }
 
 // NextRoundHeight returns the height of the beginning of next round.
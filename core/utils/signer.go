@@ -28,10 +28,15 @@ import (
 
 // Errors for signer.
 var (
-	ErrInvalidProposerID  = errors.New("invalid proposer id")
-	ErrIncorrectHash      = errors.New("hash of block is incorrect")
-	ErrIncorrectSignature = errors.New("signature of block is incorrect")
-	ErrNoBLSSigner        = errors.New("bls signer not set")
+	ErrInvalidProposerID      = errors.New("invalid proposer id")
+	ErrIncorrectHash          = errors.New("hash of block is incorrect")
+	ErrIncorrectSignature     = errors.New("signature of block is incorrect")
+	ErrNoBLSSigner            = errors.New("bls signer not set")
+	ErrSystemMessagesTooLarge = errors.New(
+		"system messages of block are too large")
+	ErrTooManySystemMessages = errors.New(
+		"too many system messages in block")
+	ErrVotesNotConflicting = errors.New("votes of evidence are not conflicting")
 )
 
 type blsSigner func(round uint64, hash common.Hash) (crypto.Signature, error)
@@ -54,6 +59,12 @@ func NewSigner(prvKey crypto.PrivateKey) (s *Signer) {
 	return
 }
 
+// SetNodeID sets the identity of this signer when it's not derived from the
+// public key, ex. after key rotation.
+func (s *Signer) SetNodeID(nID types.NodeID) {
+	s.proposerID = nID
+}
+
 // SetBLSSigner for signing CRSSignature
 func (s *Signer) SetBLSSigner(signer blsSigner) {
 	s.blsSign = signer
@@ -79,6 +90,38 @@ func (s *Signer) SignVote(v *types.Vote) (err error) {
 	return
 }
 
+// SignWatermark signs a types.Watermark.
+func (s *Signer) SignWatermark(w *types.Watermark) (err error) {
+	w.ProposerID = s.proposerID
+	hash, err := HashWatermark(w)
+	if err != nil {
+		return
+	}
+	w.Signature, err = s.prvKey.Sign(hash)
+	return
+}
+
+// SignVoteEvidence signs a types.VoteEvidence.
+func (s *Signer) SignVoteEvidence(e *types.VoteEvidence) (err error) {
+	e.ReporterID = s.proposerID
+	e.Signature, err = s.prvKey.Sign(HashVoteEvidence(e))
+	return
+}
+
+// SignPing signs a types.Ping.
+func (s *Signer) SignPing(p *types.Ping) (err error) {
+	p.ProposerID = s.proposerID
+	p.Signature, err = s.prvKey.Sign(HashPing(p))
+	return
+}
+
+// SignReadiness signs a types.Readiness.
+func (s *Signer) SignReadiness(r *types.Readiness) (err error) {
+	r.ProposerID = s.proposerID
+	r.Signature, err = s.prvKey.Sign(HashReadiness(r))
+	return
+}
+
 // SignCRS signs CRS signature of types.Block.
 func (s *Signer) SignCRS(b *types.Block, crs common.Hash) (err error) {
 	if b.ProposerID != s.proposerID {
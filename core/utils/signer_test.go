@@ -48,7 +48,7 @@ func (s *SignerTestSuite) TestBlock() {
 		Timestamp: time.Now().UTC(),
 	}
 	s.NoError(k.SignBlock(b))
-	s.NoError(VerifyBlockSignature(b))
+	s.NoError(VerifyBlockSignature(b, nil))
 }
 
 func (s *SignerTestSuite) TestVote() {
@@ -60,11 +60,29 @@ func (s *SignerTestSuite) TestVote() {
 	}
 	v.ProposerID = types.NodeID{Hash: common.NewRandomHash()}
 	s.NoError(k.SignVote(v))
-	ok, err := VerifyVoteSignature(v)
+	ok, err := VerifyVoteSignature(v, nil)
 	s.True(ok)
 	s.NoError(err)
 }
 
+func (s *SignerTestSuite) TestWatermark() {
+	k := s.setupSigner()
+	w := &types.Watermark{
+		Confirmed: types.Position{Round: 1, Height: 10},
+		Delivered: types.Position{Round: 1, Height: 8},
+		Timestamp: time.Now().UTC(),
+	}
+	s.NoError(k.SignWatermark(w))
+	ok, err := VerifyWatermarkSignature(w, nil)
+	s.NoError(err)
+	s.True(ok)
+	// Modified watermarks should not be verified.
+	w.Delivered.Height++
+	ok, err = VerifyWatermarkSignature(w, nil)
+	s.NoError(err)
+	s.False(ok)
+}
+
 func (s *SignerTestSuite) TestCRS() {
 	dkgDelayRound = 1
 	k := s.setupSigner()
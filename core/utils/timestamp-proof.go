@@ -0,0 +1,92 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"errors"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Errors for timestamp proofs.
+var (
+	ErrBlockNotFinalized = errors.New("block is not finalized")
+	ErrNoTimestampProof  = errors.New(
+		"no timestamp proof for rounds before DKG is ready")
+	ErrIncorrectRandomness = errors.New("randomness of block is incorrect")
+)
+
+// NewTimestampProof constructs the timestamp proof of a finalized block.
+// Blocks in rounds before DKG is ready are not provable, their randomness is
+// not signed by notary set.
+func NewTimestampProof(b *types.Block) (*types.TimestampProof, error) {
+	if b.Position.Round < dkgDelayRound {
+		return nil, ErrNoTimestampProof
+	}
+	return newTimestampProof(b)
+}
+
+// newTimestampProof constructs the timestamp proof of a finalized block
+// without checking its round.
+func newTimestampProof(b *types.Block) (*types.TimestampProof, error) {
+	if !b.IsFinalized() {
+		return nil, ErrBlockNotFinalized
+	}
+	witnessHash, err := hashWitness(&b.Witness)
+	if err != nil {
+		return nil, err
+	}
+	proof := &types.TimestampProof{
+		ProposerID:         b.ProposerID,
+		ParentHash:         b.ParentHash,
+		Position:           b.Position,
+		Timestamp:          b.Timestamp,
+		PayloadHash:        b.PayloadHash,
+		WitnessHash:        witnessHash,
+		Randomness:         common.CopyBytes(b.Randomness),
+		ProtocolParamsHash: b.ProtocolParamsHash,
+	}
+	if len(b.SystemMessages) > 0 {
+		proof.SystemMessagesHash = HashSystemMessages(b.SystemMessages)
+	}
+	return proof, nil
+}
+
+// VerifyTimestampProof verifies a timestamp proof by the group public key of
+// the round of that block, and returns the hash of that block.
+func VerifyTimestampProof(proof *types.TimestampProof,
+	groupPublicKey crypto.PublicKey) (common.Hash, error) {
+	if proof.Position.Round < dkgDelayRound {
+		return common.Hash{}, ErrNoTimestampProof
+	}
+	hash, err := hashBlockFields(proof.ProposerID, proof.ParentHash,
+		proof.Position, proof.Timestamp, proof.PayloadHash, proof.WitnessHash,
+		proof.SystemMessagesHash, proof.ProtocolParamsHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if !groupPublicKey.VerifySignature(hash, crypto.Signature{
+		Type:      "bls",
+		Signature: proof.Randomness,
+	}) {
+		return common.Hash{}, ErrIncorrectRandomness
+	}
+	return hash, nil
+}
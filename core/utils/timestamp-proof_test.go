@@ -0,0 +1,94 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type TimestampProofTestSuite struct {
+	suite.Suite
+}
+
+func (s *TimestampProofTestSuite) TestProveAndVerify() {
+	dkgDelayRound = 1
+	req := s.Require()
+	gprv := dkg.NewPrivateKey()
+	b := &types.Block{
+		ProposerID: types.NodeID{Hash: common.NewRandomHash()},
+		ParentHash: common.NewRandomHash(),
+		Position:   types.Position{Round: 1, Height: 10},
+		Timestamp:  time.Now().UTC(),
+		Payload:    []byte("payload"),
+		Witness: types.Witness{
+			Height: 9,
+			Data:   []byte("witness"),
+		},
+		SystemMessages: [][]byte{[]byte("sysmsg")},
+	}
+	b.PayloadHash = crypto.Keccak256Hash(b.Payload)
+	var err error
+	b.Hash, err = HashBlock(b)
+	req.NoError(err)
+	// Not provable before finalized.
+	_, err = NewTimestampProof(b)
+	req.Equal(ErrBlockNotFinalized, err)
+	sig, err := gprv.Sign(b.Hash)
+	req.NoError(err)
+	b.Randomness = sig.Signature
+	proof, err := NewTimestampProof(b)
+	req.NoError(err)
+	hash, err := VerifyTimestampProof(proof, gprv.PublicKey())
+	req.NoError(err)
+	req.Equal(b.Hash, hash)
+	// Verified by another group public key.
+	_, err = VerifyTimestampProof(proof, dkg.NewPrivateKey().PublicKey())
+	req.Equal(ErrIncorrectRandomness, err)
+	// Tampered timestamp.
+	proof.Timestamp = proof.Timestamp.Add(time.Second)
+	_, err = VerifyTimestampProof(proof, gprv.PublicKey())
+	req.Equal(ErrIncorrectRandomness, err)
+	// Blocks without system messages.
+	b.SystemMessages = nil
+	b.Hash, err = HashBlock(b)
+	req.NoError(err)
+	sig, err = gprv.Sign(b.Hash)
+	req.NoError(err)
+	b.Randomness = sig.Signature
+	proof, err = NewTimestampProof(b)
+	req.NoError(err)
+	hash, err = VerifyTimestampProof(proof, gprv.PublicKey())
+	req.NoError(err)
+	req.Equal(b.Hash, hash)
+	// Not provable before DKG is ready.
+	b.Position.Round = 0
+	_, err = NewTimestampProof(b)
+	req.Equal(ErrNoTimestampProof, err)
+}
+
+func TestTimestampProof(t *testing.T) {
+	suite.Run(t, new(TimestampProofTestSuite))
+}
@@ -0,0 +1,116 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"sync/atomic"
+)
+
+// Kinds of signatures counted in trusted crypto mode.
+const (
+	SigKindBlock              = "block"
+	SigKindVote               = "vote"
+	SigKindWatermark          = "watermark"
+	SigKindVoteEvidence       = "vote-evidence"
+	SigKindPing               = "ping"
+	SigKindReadiness          = "readiness"
+	SigKindCRS                = "crs"
+	SigKindDKGPrivateShare    = "dkg-private-share"
+	SigKindDKGMasterPublicKey = "dkg-master-public-key"
+	SigKindDKGComplaint       = "dkg-complaint"
+	SigKindDKGPartialSig      = "dkg-partial-signature"
+	SigKindDKGMPKReady        = "dkg-mpk-ready"
+	SigKindDKGFinalize        = "dkg-finalize"
+	SigKindDKGSuccess         = "dkg-success"
+)
+
+var (
+	trustedCrypto int32
+	// skippedSigs is never modified after init, only the counters it points
+	// to, so it's safe to be read without locks.
+	skippedSigs = map[string]*uint64{}
+)
+
+func init() {
+	for _, kind := range []string{
+		SigKindBlock,
+		SigKindVote,
+		SigKindWatermark,
+		SigKindVoteEvidence,
+		SigKindPing,
+		SigKindReadiness,
+		SigKindCRS,
+		SigKindDKGPrivateShare,
+		SigKindDKGMasterPublicKey,
+		SigKindDKGComplaint,
+		SigKindDKGPartialSig,
+		SigKindDKGMPKReady,
+		SigKindDKGFinalize,
+		SigKindDKGSuccess,
+	} {
+		skippedSigs[kind] = new(uint64)
+	}
+}
+
+// SetTrustedCrypto turns on/off the trusted crypto mode. In this mode,
+// signatures are assumed to be signed by whom they claim to be and are not
+// verified, only the count of skipped verifications is recorded.
+//
+// This mode is process-wide and is only meant for simulations where all
+// nodes are run in the same process and no one forges signatures, it should
+// never be turned on in production.
+func SetTrustedCrypto(trusted bool) {
+	var v int32
+	if trusted {
+		v = 1
+	}
+	atomic.StoreInt32(&trustedCrypto, v)
+}
+
+// IsTrustedCrypto checks if the trusted crypto mode is turned on.
+func IsTrustedCrypto() bool {
+	return atomic.LoadInt32(&trustedCrypto) == 1
+}
+
+// SkippedSignatureVerifications returns count of signature verifications
+// skipped in trusted crypto mode, indexed by the kind of signature.
+func SkippedSignatureVerifications() map[string]uint64 {
+	counts := make(map[string]uint64, len(skippedSigs))
+	for kind, count := range skippedSigs {
+		counts[kind] = atomic.LoadUint64(count)
+	}
+	return counts
+}
+
+// ResetSkippedSignatureVerifications resets counts of skipped signature
+// verifications.
+func ResetSkippedSignatureVerifications() {
+	for _, count := range skippedSigs {
+		atomic.StoreUint64(count, 0)
+	}
+}
+
+// skipSigVerification checks if the verification of this kind of signature
+// should be skipped, and records it when skipped.
+func skipSigVerification(kind string) bool {
+	if !IsTrustedCrypto() {
+		return false
+	}
+	atomic.AddUint64(skippedSigs[kind], 1)
+	return true
+}
@@ -70,9 +70,10 @@ func GetCRSWithPanic(accessor crsAccessor, round uint64,
 }
 
 // VerifyDKGComplaint verifies if its a valid DKGCompliant.
-func VerifyDKGComplaint(
-	complaint *typesDKG.Complaint, mpk *typesDKG.MasterPublicKey) (bool, error) {
-	ok, err := VerifyDKGComplaintSignature(complaint)
+func VerifyDKGComplaint(complaint *typesDKG.Complaint,
+	mpk *typesDKG.MasterPublicKey,
+	registry NodeIdentityRegistry) (bool, error) {
+	ok, err := VerifyDKGComplaintSignature(complaint, registry)
 	if err != nil {
 		return false, err
 	}
@@ -85,7 +86,7 @@ func VerifyDKGComplaint(
 	if complaint.Round != mpk.Round {
 		return false, nil
 	}
-	ok, err = VerifyDKGMasterPublicKeySignature(mpk)
+	ok, err = VerifyDKGMasterPublicKeySignature(mpk, registry)
 	if err != nil {
 		return false, err
 	}
@@ -64,7 +64,7 @@ func (s *UtilsTestSuite) TestVerifyDKGComplaint() {
 	}
 	signComplaint(prv2, complaint)
 	s.Require().True(complaint.IsNack())
-	ok, err := VerifyDKGComplaint(complaint, mpk)
+	ok, err := VerifyDKGComplaint(complaint, mpk, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 
@@ -81,7 +81,7 @@ func (s *UtilsTestSuite) TestVerifyDKGComplaint() {
 	s.Require().NoError(err)
 	complaint.PrivateShare = *prvShare
 	signComplaint(prv2, complaint)
-	ok, err = VerifyDKGComplaint(complaint, mpk)
+	ok, err = VerifyDKGComplaint(complaint, mpk, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 
@@ -93,13 +93,13 @@ func (s *UtilsTestSuite) TestVerifyDKGComplaint() {
 	s.Require().NoError(err)
 	complaint.PrivateShare = *prvShare
 	signComplaint(prv2, complaint)
-	ok, err = VerifyDKGComplaint(complaint, mpk)
+	ok, err = VerifyDKGComplaint(complaint, mpk, nil)
 	s.Require().NoError(err)
 	s.True(ok)
 
 	// MPK is incorrect.
 	mpk.Round++
-	ok, err = VerifyDKGComplaint(complaint, mpk)
+	ok, err = VerifyDKGComplaint(complaint, mpk, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 
@@ -108,7 +108,7 @@ func (s *UtilsTestSuite) TestVerifyDKGComplaint() {
 	mpk.ProposerID = nID2
 	mpk.Signature, err = prv1.Sign(hashDKGMasterPublicKey(mpk))
 	s.Require().NoError(err)
-	ok, err = VerifyDKGComplaint(complaint, mpk)
+	ok, err = VerifyDKGComplaint(complaint, mpk, nil)
 	s.Require().NoError(err)
 	s.False(ok)
 }
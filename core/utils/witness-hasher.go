@@ -0,0 +1,74 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// WitnessHasher lets applications define the commitment of witness data
+// included in the hash of blocks, and how the data is verified. All nodes
+// should use the same hasher, or they would disagree on block hashes.
+type WitnessHasher interface {
+	// HashWitness returns the commitment of a witness.
+	HashWitness(witness *types.Witness) (common.Hash, error)
+	// VerifyWitness verifies a witness acked by a block proposed by others.
+	VerifyWitness(witness *types.Witness) error
+}
+
+var (
+	witnessHasher     WitnessHasher
+	witnessHasherLock sync.RWMutex
+)
+
+// SetWitnessHasher sets the hasher of witness data, nil means witness data is
+// hashed with its height by Keccak256 and not verified.
+func SetWitnessHasher(hasher WitnessHasher) {
+	witnessHasherLock.Lock()
+	defer witnessHasherLock.Unlock()
+	witnessHasher = hasher
+}
+
+// VerifyWitness verifies a witness by the hasher set, it always passes when no
+// hasher is set.
+func VerifyWitness(witness *types.Witness) error {
+	witnessHasherLock.RLock()
+	defer witnessHasherLock.RUnlock()
+	if witnessHasher == nil {
+		return nil
+	}
+	return witnessHasher.VerifyWitness(witness)
+}
+
+func hashWitness(witness *types.Witness) (common.Hash, error) {
+	witnessHasherLock.RLock()
+	defer witnessHasherLock.RUnlock()
+	if witnessHasher != nil {
+		return witnessHasher.HashWitness(witness)
+	}
+	binaryHeight := make([]byte, 8)
+	binary.LittleEndian.PutUint64(binaryHeight, witness.Height)
+	return crypto.Keccak256Hash(
+		binaryHeight,
+		witness.Data), nil
+}
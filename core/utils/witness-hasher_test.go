@@ -0,0 +1,93 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+var errWitnessNotPrefixed = errors.New("witness not prefixed")
+
+// prefixHasher commits only witness data with a specific prefix.
+type prefixHasher struct {
+	prefix []byte
+}
+
+func (h *prefixHasher) HashWitness(
+	witness *types.Witness) (common.Hash, error) {
+	return crypto.Keccak256Hash(witness.Data), nil
+}
+
+func (h *prefixHasher) VerifyWitness(witness *types.Witness) error {
+	if !bytes.HasPrefix(witness.Data, h.prefix) {
+		return errWitnessNotPrefixed
+	}
+	return nil
+}
+
+type WitnessHasherTestSuite struct {
+	suite.Suite
+}
+
+func (s *WitnessHasherTestSuite) TearDownTest() {
+	SetWitnessHasher(nil)
+}
+
+func (s *WitnessHasherTestSuite) TestWitnessHasher() {
+	prv, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	signer := NewSigner(prv)
+	b := &types.Block{
+		Position: types.Position{Height: 1},
+		Witness:  types.Witness{Height: 1, Data: []byte("dexon:witness")},
+	}
+	s.Require().NoError(signer.SignBlock(b))
+	s.Require().NoError(VerifyWitness(&b.Witness))
+	// Block hashes are changed by the hasher.
+	hasher := &prefixHasher{prefix: []byte("dexon:")}
+	SetWitnessHasher(hasher)
+	s.Require().Error(VerifyBlockSignature(b, nil))
+	s.Require().NoError(signer.SignBlock(b))
+	s.Require().NoError(VerifyBlockSignature(b, nil))
+	s.Require().NoError(VerifyWitness(&b.Witness))
+	// Height is not committed by this hasher.
+	hash, err := hashWitness(&b.Witness)
+	s.Require().NoError(err)
+	b.Witness.Height = 2
+	hash2, err := hashWitness(&b.Witness)
+	s.Require().NoError(err)
+	s.Require().Equal(hash, hash2)
+	b.Witness.Data = []byte("other:witness")
+	s.Require().Equal(errWitnessNotPrefixed, VerifyWitness(&b.Witness))
+	// Reset to the default one.
+	SetWitnessHasher(nil)
+	s.Require().NoError(VerifyWitness(&b.Witness))
+}
+
+func TestWitnessHasher(t *testing.T) {
+	suite.Run(t, new(WitnessHasherTestSuite))
+}
@@ -18,12 +18,15 @@
 package core
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/suite"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
 	"github.com/dexon-foundation/dexon-consensus/core/test"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
@@ -119,6 +122,115 @@ func (s *UtilsTestSuite) TestVerifyAgreementResult() {
 	s.Equal(ErrNotEnoughVotes, VerifyAgreementResult(baResult, cache))
 }
 
+func (s *UtilsTestSuite) TestVerifyAgreementResultRandomness() {
+	prvKey := dkg.NewPrivateKey()
+	hash := common.NewRandomHash()
+	sig, err := prvKey.Sign(hash)
+	s.Require().NoError(err)
+	res := &types.AgreementResult{
+		BlockHash:  hash,
+		Position:   types.Position{Round: DKGDelayRound, Height: 20},
+		Randomness: sig.Signature,
+	}
+	s.Require().NoError(
+		VerifyAgreementResultRandomness(res, prvKey.PublicKey()))
+	// Signed by other key.
+	s.Equal(ErrIncorrectAgreementResult, VerifyAgreementResultRandomness(
+		res, dkg.NewPrivateKey().PublicKey()))
+	// Signed on other hash.
+	res.BlockHash = common.NewRandomHash()
+	s.Equal(ErrIncorrectAgreementResult,
+		VerifyAgreementResultRandomness(res, prvKey.PublicKey()))
+	res.BlockHash = hash
+	res.Randomness = nil
+	s.Equal(ErrMissingRandomness,
+		VerifyAgreementResultRandomness(res, prvKey.PublicKey()))
+	// Rounds before DKG is ready are not signed.
+	res.Position.Round = DKGDelayRound - 1
+	s.Equal(ErrIncorrectAgreementResult,
+		VerifyAgreementResultRandomness(res, nil))
+	res.Randomness = NoRand
+	s.Require().NoError(VerifyAgreementResultRandomness(res, nil))
+}
+
+func (s *UtilsTestSuite) TestBAThreshold() {
+	newSet := func(size int) map[types.NodeID]struct{} {
+		set := make(map[types.NodeID]struct{})
+		for i := 0; i < size; i++ {
+			set[types.NodeID{Hash: common.NewRandomHash()}] = struct{}{}
+		}
+		return set
+	}
+	// Notary sets of consecutive rounds, the last one has less nodes than
+	// configured.
+	for _, c := range []struct {
+		configured uint32
+		actual     int
+		threshold  int
+	}{
+		{4, 4, 3},
+		{7, 7, 5},
+		{10, 10, 7},
+		{10, 5, 4},
+		{4, 4, 3},
+	} {
+		set := newSet(c.actual)
+		threshold := baThreshold(set)
+		s.Require().Equal(c.threshold, threshold)
+		// Any two quorums intersect in more than 1/3 of the notary set.
+		s.Require().True(3*(2*threshold-c.actual) > c.actual)
+		// A quorum is reachable.
+		s.Require().True(threshold <= c.actual)
+		s.Require().True(threshold <= utils.GetBAThreshold(
+			&types.Config{NotarySetSize: c.configured}))
+	}
+}
+
+func (s *UtilsTestSuite) TestProposeOffset() {
+	var (
+		crs    = common.NewRandomHash()
+		window = 100 * time.Millisecond
+		slots  = make(map[time.Duration]struct{})
+	)
+	for i := 0; i < 100; i++ {
+		nID := types.NodeID{Hash: common.NewRandomHash()}
+		offset := proposeOffset(crs, nID, window)
+		s.Require().True(offset >= 0 && offset < window)
+		// Offsets are deterministic.
+		s.Require().Equal(offset, proposeOffset(crs, nID, window))
+		slots[offset/(10*time.Millisecond)] = struct{}{}
+	}
+	// Offsets are spread in the window.
+	s.Require().True(len(slots) > 5)
+	s.Require().Zero(proposeOffset(crs, types.NodeID{}, 0))
+}
+
+func (s *UtilsTestSuite) TestRetryDB() {
+	calls := 0
+	transient := errors.New("transient")
+	s.Require().NoError(retryDB(func() error {
+		if calls++; calls < 2 {
+			return transient
+		}
+		return nil
+	}))
+	s.Require().Equal(2, calls)
+	// Give up after retries.
+	calls = 0
+	s.Require().Equal(transient, retryDB(func() error {
+		calls++
+		return transient
+	}))
+	s.Require().Equal(dbRetryCount+1, calls)
+	// Permanent errors are not retried.
+	calls = 0
+	s.Require().Equal(db.ErrBlockExists, retryDB(func() error {
+		calls++
+		return db.ErrBlockExists
+	}))
+	s.Require().Equal(1, calls)
+}
+
 func TestUtils(t *testing.T) {
 	suite.Run(t, new(UtilsTestSuite))
 }
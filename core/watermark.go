@@ -0,0 +1,114 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+const (
+	// watermarkInterval is the interval to gossip own watermark.
+	watermarkInterval = 5 * time.Second
+	// watermarkExpiry is the duration that a watermark is taken into account
+	// when estimating the network frontier.
+	watermarkExpiry = 30 * time.Second
+	// behindThreshold is the count of heights that a node lags behind the
+	// network frontier to be considered falling behind.
+	behindThreshold = 10
+)
+
+// watermarkTracker keeps the latest watermark gossiped by each node, and
+// estimates the frontier of the network from them.
+type watermarkTracker struct {
+	lock   sync.RWMutex
+	marks  map[types.NodeID]*types.Watermark
+	behind bool
+}
+
+func newWatermarkTracker() *watermarkTracker {
+	return &watermarkTracker{
+		marks: make(map[types.NodeID]*types.Watermark),
+	}
+}
+
+// update records a watermark, it returns false when a newer watermark from
+// the same node is already recorded. The timestamp of a watermark is capped
+// at the local time, thus watermarks dated in the future still expire.
+func (t *watermarkTracker) update(w *types.Watermark, now time.Time) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	mark := *w
+	if mark.Timestamp.After(now) {
+		mark.Timestamp = now
+	}
+	if old, exist := t.marks[mark.ProposerID]; exist &&
+		!mark.Timestamp.After(old.Timestamp) {
+		return false
+	}
+	t.marks[mark.ProposerID] = &mark
+	return true
+}
+
+// frontier estimates the delivered position of the network by watermarks not
+// expired from the notary set. It's estimated only when at least f+1 notaries
+// reported, and at least f+1 of them delivered the returned position, thus
+// it's reached by some honest notary when at most f notaries are byzantine.
+func (t *watermarkTracker) frontier(
+	now time.Time, notarySet map[types.NodeID]struct{}) (
+	pos types.Position, count int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	positions := make([]types.Position, 0, len(t.marks))
+	for nID, w := range t.marks {
+		if now.Sub(w.Timestamp) > watermarkExpiry {
+			delete(t.marks, nID)
+			continue
+		}
+		if _, exist := notarySet[nID]; !exist {
+			continue
+		}
+		positions = append(positions, w.Delivered)
+	}
+	f := (len(notarySet) - 1) / 3
+	if len(positions) < f+1 {
+		return
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].Height > positions[j].Height
+	})
+	pos, count = positions[f], len(positions)
+	return
+}
+
+// checkBehind compares the delivered height of this node with the network
+// frontier, and returns true when this node starts falling behind.
+func (t *watermarkTracker) checkBehind(
+	delivered uint64, now time.Time, notarySet map[types.NodeID]struct{}) (
+	started bool, frontier types.Position) {
+	frontier, count := t.frontier(now, notarySet)
+	behind := count > 0 && frontier.Height > delivered+behindThreshold
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	started = behind && !t.behind
+	t.behind = behind
+	return
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type WatermarkTestSuite struct {
+	suite.Suite
+}
+
+func (s *WatermarkTestSuite) newWatermark(
+	nID types.NodeID, height uint64, t time.Time) *types.Watermark {
+	return &types.Watermark{
+		ProposerID: nID,
+		Confirmed:  types.Position{Round: 1, Height: height + 1},
+		Delivered:  types.Position{Round: 1, Height: height},
+		Timestamp:  t,
+	}
+}
+
+func (s *WatermarkTestSuite) TestFrontier() {
+	var (
+		req       = s.Require()
+		t         = newWatermarkTracker()
+		now       = time.Now().UTC()
+		nodes     = []types.NodeID{}
+		notarySet = make(map[types.NodeID]struct{})
+	)
+	for i := 0; i < 5; i++ {
+		nodes = append(nodes, types.NodeID{Hash: common.NewRandomHash()})
+	}
+	for _, nID := range nodes[:4] {
+		notarySet[nID] = struct{}{}
+	}
+	_, count := t.frontier(now, notarySet)
+	req.Zero(count)
+	// A single byzantine node reporting a huge height should not be trusted.
+	req.True(t.update(s.newWatermark(nodes[0], 1000, now), now))
+	_, count = t.frontier(now, notarySet)
+	req.Zero(count)
+	// Nodes not in notary set are ignored.
+	req.True(t.update(s.newWatermark(nodes[4], 1000, now), now))
+	_, count = t.frontier(now, notarySet)
+	req.Zero(count)
+	req.True(t.update(s.newWatermark(nodes[1], 20, now), now))
+	req.True(t.update(s.newWatermark(nodes[2], 15, now), now))
+	req.True(t.update(s.newWatermark(nodes[3], 10, now), now))
+	pos, count := t.frontier(now, notarySet)
+	req.Equal(4, count)
+	req.Equal(uint64(20), pos.Height)
+	// Older watermarks are ignored.
+	req.False(t.update(
+		s.newWatermark(nodes[1], 30, now.Add(-time.Second)), now))
+	req.True(t.update(
+		s.newWatermark(nodes[1], 12, now.Add(time.Second)), now.Add(time.Second)))
+	pos, _ = t.frontier(now, notarySet)
+	req.Equal(uint64(15), pos.Height)
+	// Expired watermarks are purged, the frontier is not estimated by a single
+	// notary.
+	_, count = t.frontier(now.Add(watermarkExpiry+time.Millisecond), notarySet)
+	req.Zero(count)
+	// Watermarks dated in the future still expire.
+	req.True(t.update(s.newWatermark(nodes[2], 1000, now.Add(time.Hour)), now))
+	req.True(t.update(s.newWatermark(nodes[3], 1000, now.Add(time.Hour)), now))
+	pos, count = t.frontier(now, notarySet)
+	req.Equal(3, count)
+	req.Equal(uint64(1000), pos.Height)
+	_, count = t.frontier(now.Add(watermarkExpiry+time.Second), notarySet)
+	req.Zero(count)
+}
+
+func (s *WatermarkTestSuite) TestCheckBehind() {
+	var (
+		req       = s.Require()
+		t         = newWatermarkTracker()
+		now       = time.Now().UTC()
+		notarySet = make(map[types.NodeID]struct{})
+	)
+	for i := 0; i < 4; i++ {
+		nID := types.NodeID{Hash: common.NewRandomHash()}
+		notarySet[nID] = struct{}{}
+		t.update(s.newWatermark(nID, 100, now), now)
+	}
+	started, frontier := t.checkBehind(100-behindThreshold, now, notarySet)
+	req.False(started)
+	req.Equal(uint64(100), frontier.Height)
+	started, _ = t.checkBehind(100-behindThreshold-1, now, notarySet)
+	req.True(started)
+	// Only reported when starting falling behind.
+	started, _ = t.checkBehind(50, now, notarySet)
+	req.False(started)
+	started, _ = t.checkBehind(100, now, notarySet)
+	req.False(started)
+	started, _ = t.checkBehind(50, now, notarySet)
+	req.True(started)
+}
+
+func TestWatermark(t *testing.T) {
+	suite.Run(t, new(WatermarkTestSuite))
+}
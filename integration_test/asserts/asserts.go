@@ -0,0 +1,201 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package asserts checks invariants of consensus held by nodes in integration
+// tests, they should be checked by every integration test.
+package asserts
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Errors for invariants not held.
+var (
+	ErrForkDetected = errors.New(
+		"different blocks delivered at the same height")
+	ErrRandomnessForked = errors.New(
+		"different randomness delivered for the same block")
+	ErrHeightNotIncreasing = errors.New(
+		"heights of delivered blocks are not increasing one by one")
+	ErrBlockNotConfirmed = errors.New("delivered block not confirmed")
+	ErrMismatchPosition  = errors.New(
+		"delivered position mismatches the confirmed block")
+	ErrParentNotDelivered = errors.New(
+		"parent of block not delivered right before it")
+	ErrRandomnessNotReady = errors.New(
+		"group public key to verify randomness not ready")
+	ErrEmptyDeliverSequence = errors.New("no block delivered")
+)
+
+// Node is the view of a node checked by assertions.
+type Node struct {
+	ID  types.NodeID
+	App *test.App
+	Gov *test.Governance
+	DB  db.Database
+}
+
+// Check checks all invariants on nodes, they are assumed to be honest and
+// begin delivering from the same height.
+func Check(nodes []Node) error {
+	for _, n := range nodes {
+		if err := test.VerifyDB(n.DB); err != nil {
+			return err
+		}
+		if err := n.App.Verify(); err != nil {
+			return err
+		}
+		for _, check := range []func(Node) error{
+			HeightIncreasing,
+			DeliveredConfirmed,
+			RandomnessVerifiable,
+		} {
+			if err := check(n); err != nil {
+				return err
+			}
+		}
+	}
+	return NoFork(nodes)
+}
+
+// NoFork checks that blocks and their randomness delivered by nodes are the
+// same at each height delivered by all of them.
+func NoFork(nodes []Node) (err error) {
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			if err = noFork(nodes[i].App, nodes[j].App); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func noFork(app, other *test.App) (err error) {
+	app.WithLock(func(app *test.App) {
+		other.WithLock(func(other *test.App) {
+			if len(app.DeliverSequence) == 0 ||
+				len(other.DeliverSequence) == 0 {
+				err = ErrEmptyDeliverSequence
+				return
+			}
+			hashes := make(map[uint64]common.Hash)
+			for _, h := range app.DeliverSequence {
+				hashes[app.Delivered[h].Pos.Height] = h
+			}
+			for _, h := range other.DeliverSequence {
+				rec := other.Delivered[h]
+				hash, exist := hashes[rec.Pos.Height]
+				if !exist {
+					continue
+				}
+				if hash != h {
+					err = ErrForkDetected
+					return
+				}
+				if !bytes.Equal(app.Delivered[hash].Rand, rec.Rand) {
+					err = ErrRandomnessForked
+					return
+				}
+			}
+		})
+	})
+	return
+}
+
+// HeightIncreasing checks that heights of blocks delivered by a node increase
+// one by one, without regression or gap.
+func HeightIncreasing(n Node) (err error) {
+	n.App.WithLock(func(app *test.App) {
+		var prev *test.AppDeliveredRecord
+		for _, h := range app.DeliverSequence {
+			rec := app.Delivered[h]
+			if prev != nil && prev.Pos.Height+1 != rec.Pos.Height {
+				err = ErrHeightNotIncreasing
+				return
+			}
+			prev = rec
+		}
+	})
+	return
+}
+
+// DeliveredConfirmed checks that every block delivered by a node is
+// confirmed, and is chained to the block delivered right before it.
+func DeliveredConfirmed(n Node) (err error) {
+	n.App.WithLock(func(app *test.App) {
+		var prev common.Hash
+		for i, h := range app.DeliverSequence {
+			b, exist := app.Confirmed[h]
+			if !exist {
+				err = ErrBlockNotConfirmed
+				return
+			}
+			if !b.Position.Equal(app.Delivered[h].Pos) {
+				err = ErrMismatchPosition
+				return
+			}
+			if i > 0 && b.ParentHash != prev {
+				err = ErrParentNotDelivered
+				return
+			}
+			prev = h
+		}
+	})
+	return
+}
+
+// RandomnessVerifiable checks that randomness of every block delivered by a
+// node is present, and is verifiable by the group public key of that round.
+func RandomnessVerifiable(n Node) (err error) {
+	var results []*types.AgreementResult
+	n.App.WithLock(func(app *test.App) {
+		for _, h := range app.DeliverSequence {
+			rec := app.Delivered[h]
+			results = append(results, &types.AgreementResult{
+				BlockHash:  h,
+				Position:   rec.Pos,
+				Randomness: rec.Rand,
+			})
+		}
+	})
+	verifiers := core.NewTSigVerifierCache(n.Gov, 1)
+	for _, r := range results {
+		var verifier core.TSigVerifier
+		if r.Position.Round >= core.DKGDelayRound {
+			var ok bool
+			verifier, ok, err = verifiers.UpdateAndGet(r.Position.Round)
+			if err != nil {
+				return
+			}
+			if !ok {
+				return ErrRandomnessNotReady
+			}
+		}
+		if err = core.VerifyAgreementResultRandomness(r, verifier); err != nil {
+			return
+		}
+	}
+	return
+}
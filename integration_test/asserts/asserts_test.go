@@ -0,0 +1,91 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package asserts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/stretchr/testify/suite"
+)
+
+type AssertsTestSuite struct {
+	suite.Suite
+}
+
+func (s *AssertsTestSuite) newBlocks(count int) (blocks []*types.Block) {
+	var parentHash common.Hash
+	for i := 0; i < count; i++ {
+		b := &types.Block{
+			ParentHash: parentHash,
+			Hash:       common.NewRandomHash(),
+			Position:   types.Position{Height: types.GenesisHeight + uint64(i)},
+			Timestamp:  time.Now().UTC(),
+		}
+		blocks = append(blocks, b)
+		parentHash = b.Hash
+	}
+	return
+}
+
+func (s *AssertsTestSuite) newNode(blocks []*types.Block) Node {
+	app := test.NewApp(0, nil, nil)
+	for _, b := range blocks {
+		app.BlockConfirmed(*b)
+		app.BlockDelivered(b.Hash, b.Position, core.NoRand)
+	}
+	return Node{App: app}
+}
+
+func (s *AssertsTestSuite) TestNoFork() {
+	blocks := s.newBlocks(5)
+	nodes := []Node{
+		s.newNode(blocks),
+		s.newNode(blocks[:3]),
+	}
+	s.Require().NoError(NoFork(nodes))
+	// A node delivers another block at the last height.
+	forked := append([]*types.Block{}, blocks[:4]...)
+	forked = append(forked, s.newBlocks(1)[0])
+	forked[4].Position = blocks[4].Position
+	forked[4].ParentHash = blocks[3].Hash
+	nodes = append(nodes, s.newNode(forked))
+	s.Require().Equal(ErrForkDetected, NoFork(nodes))
+}
+
+func (s *AssertsTestSuite) TestNodeInvariants() {
+	blocks := s.newBlocks(5)
+	n := s.newNode(blocks)
+	s.Require().NoError(HeightIncreasing(n))
+	s.Require().NoError(DeliveredConfirmed(n))
+	s.Require().NoError(RandomnessVerifiable(n))
+	// Break the chain of parent hashes.
+	n.App.Confirmed[blocks[3].Hash].ParentHash = common.NewRandomHash()
+	s.Require().Equal(ErrParentNotDelivered, DeliveredConfirmed(n))
+	// Randomness of rounds before DKG is ready should be NoRand.
+	n.App.Delivered[blocks[2].Hash].Rand = []byte{1}
+	s.Require().Error(RandomnessVerifiable(n))
+}
+
+func TestAsserts(t *testing.T) {
+	suite.Run(t, new(AssertsTestSuite))
+}
@@ -18,21 +18,16 @@
 package integration
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"os"
-	"sync"
 	"testing"
 	"time"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
-	"github.com/dexon-foundation/dexon-consensus/core"
-	"github.com/dexon-foundation/dexon-consensus/core/crypto"
-	"github.com/dexon-foundation/dexon-consensus/core/db"
-	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/test/clustertest"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
-	"github.com/dexon-foundation/dexon-consensus/core/utils"
+	"github.com/dexon-foundation/dexon-consensus/integration_test/asserts"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -40,99 +35,37 @@ import (
 // sure these tests are ok.
 type ByzantineTestSuite struct {
 	suite.Suite
-
-	directLatencyModel map[types.NodeID]test.LatencyModel
-}
-
-func (s *ByzantineTestSuite) SetupTest() {
-	s.directLatencyModel = make(map[types.NodeID]test.LatencyModel)
 }
 
-func (s *ByzantineTestSuite) setupNodes(
-	dMoment time.Time,
-	prvKeys []crypto.PrivateKey,
-	seedGov *test.Governance) map[types.NodeID]*node {
-	var (
-		wg sync.WaitGroup
-	)
-	// Setup peer server at transport layer.
-	server := test.NewFakeTransportServer()
-	serverChannel, err := server.Host()
+func (s *ByzantineTestSuite) newCluster(peerCount int) *clustertest.Cluster {
+	// Give a short latency to make this test run faster.
+	c, err := clustertest.NewCluster(peerCount, clustertest.Config{
+		Lambda:      100 * time.Millisecond,
+		RoundLength: 100,
+		NewLogger: func(i int, _ types.NodeID) (common.Logger, error) {
+			f, err := os.Create(fmt.Sprintf("log.%d.log", i))
+			if err != nil {
+				return nil, err
+			}
+			return common.NewCustomLogger(
+				log.New(f, "", log.LstdFlags|log.Lmicroseconds)), nil
+		},
+	})
 	s.Require().NoError(err)
-	// setup nodes.
-	nodes := make(map[types.NodeID]*node)
-	wg.Add(len(prvKeys))
-	for i, k := range prvKeys {
-		dbInst, err := db.NewMemBackedDB()
-		s.Require().NoError(err)
-		nID := types.NewNodeID(k.PublicKey())
-		// Prepare essential modules: app, gov, db.
-		var directLatencyModel test.LatencyModel
-		if model, exist := s.directLatencyModel[nID]; exist {
-			directLatencyModel = model
-		} else {
-			directLatencyModel = &test.FixedLatencyModel{}
-		}
-		networkModule := test.NewNetwork(k.PublicKey(), test.NetworkConfig{
-			Type:          test.NetworkTypeFake,
-			DirectLatency: directLatencyModel,
-			GossipLatency: &test.FixedLatencyModel{},
-			Marshaller:    test.NewDefaultMarshaller(nil)},
-		)
-		gov := seedGov.Clone()
-		gov.SwitchToRemoteMode(networkModule)
-		gov.NotifyRound(0, types.GenesisHeight)
-		networkModule.AttachNodeSetCache(utils.NewNodeSetCache(gov))
-		f, err := os.Create(fmt.Sprintf("log.%d.log", i))
-		if err != nil {
-			panic(err)
-		}
-		logger := common.NewCustomLogger(log.New(f, "", log.LstdFlags|log.Lmicroseconds))
-		app := test.NewApp(1, gov, nil)
-		nodes[nID] = &node{
-			ID:      nID,
-			app:     app,
-			gov:     gov,
-			db:      dbInst,
-			network: networkModule,
-			logger:  logger,
-		}
-		go func() {
-			defer wg.Done()
-			s.Require().NoError(networkModule.Setup(serverChannel))
-			go networkModule.Run()
-		}()
-	}
-	// Make sure transport layer is ready.
-	s.Require().NoError(server.WaitForPeers(uint32(len(prvKeys))))
-	wg.Wait()
-	for _, k := range prvKeys {
-		node := nodes[types.NewNodeID(k.PublicKey())]
-		// Now is the consensus module.
-		node.con = core.NewConsensus(
-			dMoment,
-			node.app,
-			node.gov,
-			node.db,
-			node.network,
-			k,
-			node.logger,
-		)
-	}
-	return nodes
+	return c
 }
 
-func (s *ByzantineTestSuite) verifyNodes(nodes map[types.NodeID]*node) {
-	for ID, node := range nodes {
-		s.Require().NoError(test.VerifyDB(node.db))
-		s.Require().NoError(node.app.Verify())
-		for otherID, otherNode := range nodes {
-			if ID == otherID {
-				continue
-			}
-			s.Require().NoError(node.app.Compare(otherNode.app))
-		}
+func (s *ByzantineTestSuite) verifyCluster(c *clustertest.Cluster) {
+	var checked []asserts.Node
+	for _, n := range c.Alive() {
+		checked = append(checked, asserts.Node{
+			ID:  n.ID,
+			App: n.App,
+			Gov: n.Gov,
+			DB:  n.DB,
+		})
 	}
+	s.Require().NoError(asserts.Check(checked))
 }
 
 func (s *ByzantineTestSuite) TestOneSlowNodeOneDeadNode() {
@@ -141,51 +74,24 @@ func (s *ByzantineTestSuite) TestOneSlowNodeOneDeadNode() {
 	var (
 		req        = s.Require()
 		peerCount  = 4
-		dMoment    = time.Now().UTC()
 		untilRound = uint64(3)
 	)
 	if testing.Short() {
 		untilRound = 1
 	}
-	prvKeys, pubKeys, err := test.NewKeys(peerCount)
-	req.NoError(err)
-	// Setup seed governance instance. Give a short latency to make this test
-	// run faster.
-	lambda := 100 * time.Millisecond
-	seedGov, err := test.NewGovernance(
-		test.NewState(core.DKGDelayRound,
-			pubKeys, lambda, &common.NullLogger{}, true),
-		core.ConfigRoundShift)
-	req.NoError(err)
-	req.NoError(seedGov.State().RequestChange(
-		test.StateChangeRoundLength, uint64(100)))
-	slowNodeID := types.NewNodeID(pubKeys[0])
-	deadNodeID := types.NewNodeID(pubKeys[1])
-	s.directLatencyModel[slowNodeID] = &test.FixedLatencyModel{
-		Latency: lambda.Seconds() * 1000 * 2,
-	}
-	nodes := s.setupNodes(dMoment, prvKeys, seedGov)
-	for _, n := range nodes {
-		if n.ID == deadNodeID {
-			continue
-		}
-		go n.con.Run()
-		defer n.con.Stop()
-	}
-	// Clean deadNode's network receive channel, or it might exceed the limit
-	// and block other go routines.
-	dummyReceiverCtxCancel, _ := utils.LaunchDummyReceiver(
-		context.Background(), nodes[deadNodeID].network.ReceiveChan(), nil)
-	defer dummyReceiverCtxCancel()
+	c := s.newCluster(peerCount)
+	defer c.Stop()
+	slowNodeID := c.NodeIDs[0]
+	deadNodeID := c.NodeIDs[1]
+	req.NoError(c.Lag(slowNodeID, 200*time.Millisecond))
+	req.NoError(c.Kill(deadNodeID))
+	req.NoError(c.Start())
 Loop:
 	for {
 		<-time.After(5 * time.Second)
 		fmt.Println("check latest position delivered by each node")
-		for _, n := range nodes {
-			if n.ID == deadNodeID {
-				continue
-			}
-			latestPos := n.app.GetLatestDeliveredPosition()
+		for _, n := range c.Alive() {
+			latestPos := n.App.GetLatestDeliveredPosition()
 			fmt.Println("latestPos", n.ID, &latestPos)
 			if latestPos.Round < untilRound {
 				continue Loop
@@ -194,8 +100,8 @@ Loop:
 		// Oh ya.
 		break
 	}
-	delete(nodes, deadNodeID)
-	s.verifyNodes(nodes)
+	c.Stop()
+	s.verifyCluster(c)
 }
 
 type voteCensor struct{}
@@ -211,44 +117,29 @@ func (s *ByzantineTestSuite) TestOneNodeWithoutVote() {
 	var (
 		req        = s.Require()
 		peerCount  = 4
-		dMoment    = time.Now().UTC()
 		untilRound = uint64(3)
 		tolerence  = uint64(2)
 	)
 	if testing.Short() {
 		untilRound = 2
 	}
-	prvKeys, pubKeys, err := test.NewKeys(peerCount)
-	req.NoError(err)
-	// Setup seed governance instance. Give a short latency to make this test
-	// run faster.
-	lambda := 100 * time.Millisecond
-	seedGov, err := test.NewGovernance(
-		test.NewState(core.DKGDelayRound,
-			pubKeys, lambda, &common.NullLogger{}, true),
-		core.ConfigRoundShift)
-	req.NoError(err)
-	req.NoError(seedGov.State().RequestChange(
-		test.StateChangeRoundLength, uint64(100)))
-	votelessNodeID := types.NewNodeID(pubKeys[0])
-	nodes := s.setupNodes(dMoment, prvKeys, seedGov)
-	votelessNode := nodes[votelessNodeID]
-	votelessNode.network.SetCensor(&voteCensor{}, &voteCensor{})
-	for _, n := range nodes {
-		go n.con.Run()
-		defer n.con.Stop()
-	}
+	c := s.newCluster(peerCount)
+	defer c.Stop()
+	votelessNodeID := c.NodeIDs[0]
+	votelessNode := c.Nodes[votelessNodeID]
+	votelessNode.Network.SetCensor(&voteCensor{}, &voteCensor{})
+	req.NoError(c.Start())
 Loop:
 	for {
 		<-time.After(5 * time.Second)
 		fmt.Println("check latest position delivered by voteless node")
-		latestPos := votelessNode.app.GetLatestDeliveredPosition()
+		latestPos := votelessNode.App.GetLatestDeliveredPosition()
 		fmt.Println("latestPos", votelessNode.ID, &latestPos)
-		for _, n := range nodes {
+		for _, n := range c.Alive() {
 			if n.ID == votelessNodeID {
 				continue
 			}
-			otherPos := n.app.GetLatestDeliveredPosition()
+			otherPos := n.App.GetLatestDeliveredPosition()
 			if otherPos.Newer(latestPos) {
 				fmt.Println("otherPos", n.ID, &otherPos)
 				s.Require().True(
@@ -261,7 +152,8 @@ Loop:
 		// Oh ya.
 		break
 	}
-	s.verifyNodes(nodes)
+	c.Stop()
+	s.verifyCluster(c)
 }
 
 func TestByzantine(t *testing.T) {
@@ -34,6 +34,7 @@ import (
 	"github.com/dexon-foundation/dexon-consensus/core/test"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
+	"github.com/dexon-foundation/dexon-consensus/integration_test/asserts"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -101,20 +102,48 @@ func (s *ConsensusTestSuite) setupNodes(
 	dMoment time.Time,
 	prvKeys []crypto.PrivateKey,
 	seedGov *test.Governance) map[types.NodeID]*node {
-	var (
-		wg        sync.WaitGroup
-		initRound uint64
-	)
+	return s.setupNodesFromRound(dMoment, prvKeys, seedGov, 0)
+}
+
+// setupNodesFromRound setups nodes starting at the beginning of a round,
+// previous rounds and DKG of that round are prepared without running them.
+func (s *ConsensusTestSuite) setupNodesFromRound(
+	dMoment time.Time,
+	prvKeys []crypto.PrivateKey,
+	seedGov *test.Governance,
+	initRound uint64) map[types.NodeID]*node {
+	var wg sync.WaitGroup
 	// Setup peer server at transport layer.
 	server := test.NewFakeTransportServer()
 	serverChannel, err := server.Host()
 	s.Require().NoError(err)
+	dbs := make([]*db.MemBackedDB, 0, len(prvKeys))
+	coreDBs := make([]db.Database, 0, len(prvKeys))
+	for range prvKeys {
+		dbInst, err := db.NewMemBackedDB()
+		s.Require().NoError(err)
+		dbs = append(dbs, dbInst)
+		coreDBs = append(coreDBs, dbInst)
+	}
+	initHeight := types.GenesisHeight
+	var initBlock *types.Block
+	if initRound > 0 {
+		initHeight = seedGov.FastForward(initRound)
+		if initRound >= core.DKGDelayRound {
+			s.Require().NoError(test.PrepareDKG(
+				seedGov, initRound, prvKeys, coreDBs))
+		}
+		initBlock, err = test.NewInitBlock(types.Position{
+			Round:  initRound - 1,
+			Height: initHeight - 1,
+		}, dMoment, coreDBs)
+		s.Require().NoError(err)
+	}
 	// setup nodes.
 	nodes := make(map[types.NodeID]*node)
 	wg.Add(len(prvKeys))
 	for i, k := range prvKeys {
-		dbInst, err := db.NewMemBackedDB()
-		s.Require().NoError(err)
+		dbInst := dbs[i]
 		// Prepare essential modules: app, gov, db.
 		networkModule := test.NewNetwork(k.PublicKey(), test.NetworkConfig{
 			Type:          test.NetworkTypeFake,
@@ -124,20 +153,24 @@ func (s *ConsensusTestSuite) setupNodes(
 		)
 		gov := seedGov.Clone()
 		gov.SwitchToRemoteMode(networkModule)
-		gov.NotifyRound(initRound, types.GenesisHeight)
+		gov.NotifyRound(initRound, initHeight)
 		networkModule.AttachNodeSetCache(utils.NewNodeSetCache(gov))
+		networkModule.AttachAgreementResultStore(dbInst)
 		f, err := os.Create(fmt.Sprintf("log.%d.log", i))
 		if err != nil {
 			panic(err)
 		}
 		logger := common.NewCustomLogger(log.New(f, "", log.LstdFlags|log.Lmicroseconds))
 		rEvt, err := utils.NewRoundEvent(context.Background(), gov, logger,
-			types.Position{Height: types.GenesisHeight}, core.ConfigRoundShift)
+			types.Position{Round: initRound, Height: initHeight},
+			core.ConfigRoundShift)
 		s.Require().NoError(err)
+		app := test.NewApp(initRound+1, gov, rEvt)
+		app.StartFrom(initHeight)
 		nID := types.NewNodeID(k.PublicKey())
 		nodes[nID] = &node{
 			ID:      nID,
-			app:     test.NewApp(initRound+1, gov, rEvt),
+			app:     app,
 			gov:     gov,
 			db:      dbInst,
 			logger:  logger,
@@ -156,30 +189,47 @@ func (s *ConsensusTestSuite) setupNodes(
 	for _, k := range prvKeys {
 		node := nodes[types.NewNodeID(k.PublicKey())]
 		// Now is the consensus module.
-		node.con = core.NewConsensus(
+		if initBlock == nil {
+			node.con = core.NewConsensus(
+				dMoment,
+				node.app,
+				node.gov,
+				node.db,
+				node.network,
+				k,
+				node.logger,
+			)
+			continue
+		}
+		node.con, err = core.NewConsensusFromSyncer(
+			initBlock,
+			false,
 			dMoment,
 			node.app,
 			node.gov,
 			node.db,
 			node.network,
 			k,
+			nil,
+			nil,
 			node.logger,
 		)
+		s.Require().NoError(err)
 	}
 	return nodes
 }
 
 func (s *ConsensusTestSuite) verifyNodes(nodes map[types.NodeID]*node) {
-	for ID, node := range nodes {
-		s.Require().NoError(test.VerifyDB(node.db))
-		s.Require().NoError(node.app.Verify())
-		for otherID, otherNode := range nodes {
-			if ID == otherID {
-				continue
-			}
-			s.Require().NoError(node.app.Compare(otherNode.app))
-		}
-	}
+	var checked []asserts.Node
+	for _, n := range nodes {
+		checked = append(checked, asserts.Node{
+			ID:  n.ID,
+			App: n.app,
+			Gov: n.gov,
+			DB:  n.db,
+		})
+	}
+	s.Require().NoError(asserts.Check(checked))
 }
 
 func (s *ConsensusTestSuite) syncBlocksWithSomeNode(
@@ -305,6 +355,45 @@ Loop:
 	s.verifyNodes(nodes)
 }
 
+func (s *ConsensusTestSuite) TestStartFromRound() {
+	// Nodes start at round 3 without running previous rounds, and go through
+	// the transition to round 4, including DKG of round 4.
+	var (
+		req        = s.Require()
+		peerCount  = 4
+		dMoment    = time.Now().UTC()
+		initRound  = uint64(3)
+		untilRound = uint64(4)
+	)
+	prvKeys, pubKeys, err := test.NewKeys(peerCount)
+	req.NoError(err)
+	seedGov, err := test.NewGovernance(
+		test.NewState(core.DKGDelayRound,
+			pubKeys, 100*time.Millisecond, &common.NullLogger{}, true),
+		core.ConfigRoundShift)
+	req.NoError(err)
+	req.NoError(seedGov.State().RequestChange(
+		test.StateChangeRoundLength, uint64(100)))
+	nodes := s.setupNodesFromRound(dMoment, prvKeys, seedGov, initRound)
+	for _, n := range nodes {
+		go n.con.Run()
+		defer n.con.Stop()
+	}
+Loop:
+	for {
+		<-time.After(5 * time.Second)
+		for _, n := range nodes {
+			latestPos := n.app.GetLatestDeliveredPosition()
+			fmt.Println("latestPos", n.ID, &latestPos)
+			if latestPos.Round < untilRound {
+				continue Loop
+			}
+		}
+		break
+	}
+	s.verifyNodes(nodes)
+}
+
 func (s *ConsensusTestSuite) TestSetSizeChange() {
 	var (
 		req        = s.Require()
@@ -68,7 +68,9 @@ type simApp struct {
 	stateModule     *test.State
 	DeliverID       int
 	blockTimestamps map[common.Hash][]time.Time
+	blockProposers  map[common.Hash]types.NodeID
 	blockSeen       map[common.Hash]time.Time
+	blockTimes      map[common.Hash]time.Time
 	// uncofirmBlocks stores the blocks whose timestamps are not ready.
 	unconfirmedBlocks  map[types.NodeID]common.Hashes
 	blockByHash        map[common.Hash]*types.Block
@@ -88,6 +90,8 @@ func newSimApp(
 		DeliverID:          0,
 		blockSeen:          make(map[common.Hash]time.Time),
 		blockTimestamps:    make(map[common.Hash][]time.Time),
+		blockProposers:     make(map[common.Hash]types.NodeID),
+		blockTimes:         make(map[common.Hash]time.Time),
 		unconfirmedBlocks:  make(map[types.NodeID]common.Hashes),
 		blockByHash:        make(map[common.Hash]*types.Block),
 		latestWitnessReady: sync.NewCond(&sync.Mutex{}),
@@ -101,6 +105,12 @@ func (a *simApp) BlockConfirmed(block types.Block) {
 	// TODO(jimmy-dexon) : Remove block in this hash if it's no longer needed.
 	a.blockByHash[block.Hash] = &block
 	a.blockSeen[block.Hash] = time.Now().UTC()
+	func() {
+		a.lock.Lock()
+		defer a.lock.Unlock()
+		a.blockProposers[block.Hash] = block.ProposerID
+		a.blockTimes[block.Hash] = block.Timestamp
+	}()
 	a.updateBlockEvent(block.Hash)
 }
 
@@ -237,11 +247,16 @@ func (a *simApp) updateBlockEvent(hash common.Hash) {
 	if len(a.blockTimestamps[hash]) == blockEventCount {
 		msg := &test.BlockEventMessage{
 			BlockHash:  hash,
+			ProposerID: a.blockProposers[hash],
 			Timestamps: a.blockTimestamps[hash],
+
+			BlockTimestamp: a.blockTimes[hash],
 		}
 		if err := a.netModule.Report(msg); err != nil {
 			panic(err)
 		}
 		delete(a.blockTimestamps, hash)
+		delete(a.blockProposers, hash)
+		delete(a.blockTimes, hash)
 	}
 }
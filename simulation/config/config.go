@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"time"
 
 	"github.com/dexon-foundation/dexon-consensus/core"
 	"github.com/dexon-foundation/dexon-consensus/core/test"
@@ -36,9 +37,14 @@ type Consensus struct {
 	NotarySetSize    uint32
 	DKGSetSize       uint32 `toml:"dkg_set_size"`
 	MinBlockInterval int
+	MaxBlockInterval int
 }
 
-// Legacy config.
+// Legacy config of the proposing loop before BA is introduced.
+//
+// Deprecated: blocks are always proposed by BA now and this section is
+// ignored, it's only kept to load configuration files written for the legacy
+// flow.
 type Legacy struct {
 	ProposeIntervalMean  float64
 	ProposeIntervalSigma float64
@@ -47,10 +53,53 @@ type Legacy struct {
 // Node config for the simulation.
 type Node struct {
 	Consensus Consensus
-	Legacy    Legacy
+	Legacy    *Legacy `toml:",omitempty"`
 	Num       uint32
 	MaxBlock  uint64
 	Changes   []Change
+	// ProposeJitter spreads proposals of nodes in a window of this ratio of
+	// lambda BA, 0 means proposals are broadcasted as soon as proposed.
+	ProposeJitter float64
+	// TrustedCrypto skips verifications of signatures, only counts of skipped
+	// verifications are reported. It's meant for simulating large notary
+	// sets in one process, where most time would be spent on verifying
+	// signatures from honest nodes.
+	TrustedCrypto bool `toml:",omitempty"`
+	// KeySeed, when not empty, derives private keys of nodes from it, so runs
+	// with the same seed share the same key material and node IDs. It only
+	// applies to nodes initialized in the same process.
+	KeySeed string `toml:",omitempty"`
+	// ClockOffsets are offsets in milliseconds of clocks of nodes, indexed by
+	// the order nodes are initialized.
+	ClockOffsets []int `toml:"clock_offsets"`
+	// ClockDrifts are rates clocks of nodes drift away from the real time,
+	// indexed by the order nodes are initialized, ex. 1e-4 means the clock
+	// gains 0.1 millisecond per second.
+	ClockDrifts []float64 `toml:"clock_drifts"`
+}
+
+// NodeClock returns the clock of a node, whose offset to the real time is
+// the configured offset at start and drifts in the configured rate since
+// then. Nil is returned when neither offset nor drift is set to that node.
+func (n Node) NodeClock(index int, start time.Time) func() time.Time {
+	var (
+		offset time.Duration
+		drift  float64
+	)
+	if index >= 0 && index < len(n.ClockOffsets) {
+		offset = time.Duration(n.ClockOffsets[index]) * time.Millisecond
+	}
+	if index >= 0 && index < len(n.ClockDrifts) {
+		drift = n.ClockDrifts[index]
+	}
+	if offset == 0 && drift == 0 {
+		return nil
+	}
+	return func() time.Time {
+		now := time.Now()
+		return now.Add(
+			offset + time.Duration(float64(now.Sub(start))*drift))
+	}
 }
 
 // LatencyModel for ths simulation.
@@ -63,6 +112,7 @@ type LatencyModel struct {
 type Networking struct {
 	Type       test.NetworkType
 	PeerServer string
+	TLS        bool
 	Direct     LatencyModel
 	Gossip     LatencyModel
 }
@@ -70,6 +120,23 @@ type Networking struct {
 // Scheduler Settings.
 type Scheduler struct {
 	WorkerNum int
+	// ExecInterval is the time in milliseconds a node with normal speed takes
+	// to handle a message.
+	ExecInterval int
+	// CPUSpeeds are speed multipliers of nodes, indexed by the order nodes
+	// are initialized. Nodes not listed run in normal speed, i.e. 1.0.
+	CPUSpeeds []float64 `toml:"cpu_speeds"`
+}
+
+// NodeExecInterval returns the time for a node to handle a message, which
+// is ExecInterval scaled by the CPU speed of that node.
+func (s Scheduler) NodeExecInterval(index int) time.Duration {
+	speed := 1.0
+	if index >= 0 && index < len(s.CPUSpeeds) && s.CPUSpeeds[index] > 0 {
+		speed = s.CPUSpeeds[index]
+	}
+	return time.Duration(
+		float64(time.Duration(s.ExecInterval)*time.Millisecond) / speed)
 }
 
 // Change represent future configuration changes.
@@ -119,10 +186,6 @@ func GenerateDefault(path string) error {
 				DKGSetSize:       7,
 				MinBlockInterval: 750,
 			},
-			Legacy: Legacy{
-				ProposeIntervalMean:  500,
-				ProposeIntervalSigma: 50,
-			},
 			Num:      7,
 			MaxBlock: math.MaxUint64,
 		},
@@ -35,8 +35,20 @@ func StateChangeTypeFromString(s string) test.StateChangeType {
 		return test.StateChangeRoundLength
 	case "min_block_interval":
 		return test.StateChangeMinBlockInterval
+	case "max_block_interval":
+		return test.StateChangeMaxBlockInterval
+	case "dkg_register_duration":
+		return test.StateChangeDKGRegisterDuration
+	case "dkg_complaint_duration":
+		return test.StateChangeDKGComplaintDuration
+	case "dkg_finalize_duration":
+		return test.StateChangeDKGFinalizeDuration
 	case "notary_set_size":
 		return test.StateChangeNotarySetSize
+	case "ba_timeout_growth":
+		return test.StateChangeBATimeoutGrowth
+	case "ba_timeout_max_scale":
+		return test.StateChangeBATimeoutMaxScale
 	}
 	panic(fmt.Errorf("unsupported state change type %s", s))
 }
@@ -46,14 +58,18 @@ func StateChangeTypeFromString(s string) test.StateChangeType {
 func StateChangeValueFromString(
 	t test.StateChangeType, v string) interface{} {
 	switch t {
-	case test.StateChangeNotarySetSize:
+	case test.StateChangeNotarySetSize, test.StateChangeBATimeoutGrowth,
+		test.StateChangeBATimeoutMaxScale:
 		ret, err := strconv.ParseUint(v, 10, 32)
 		if err != nil {
 			panic(err)
 		}
 		return uint32(ret)
 	case test.StateChangeLambdaBA, test.StateChangeLambdaDKG,
-		test.StateChangeRoundLength, test.StateChangeMinBlockInterval:
+		test.StateChangeRoundLength, test.StateChangeMinBlockInterval,
+		test.StateChangeMaxBlockInterval, test.StateChangeDKGRegisterDuration,
+		test.StateChangeDKGComplaintDuration,
+		test.StateChangeDKGFinalizeDuration:
 		ret, err := strconv.ParseInt(v, 10, 32)
 		if err != nil {
 			panic(err)
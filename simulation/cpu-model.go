@@ -0,0 +1,55 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// throttledNetwork models a node with limited CPU speed, messages are handled
+// one by one and each of them takes a fixed interval before reaching
+// consensus core.
+type throttledNetwork struct {
+	*test.Network
+
+	recv chan types.Msg
+}
+
+func newThrottledNetwork(
+	n *test.Network, execInterval time.Duration) *throttledNetwork {
+	tn := &throttledNetwork{
+		Network: n,
+		recv:    make(chan types.Msg, cap(n.ReceiveChan())),
+	}
+	go func() {
+		defer close(tn.recv)
+		for msg := range n.ReceiveChan() {
+			time.Sleep(execInterval)
+			tn.recv <- msg
+		}
+	}()
+	return tn
+}
+
+// ReceiveChan implements core.Network interface.
+func (tn *throttledNetwork) ReceiveChan() <-chan types.Msg {
+	return tn.recv
+}
@@ -0,0 +1,172 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// criticalPathTopCount is the count of slowest blocks to report.
+const criticalPathTopCount = 10
+
+// Stages of the causal chain leading to the delivery of a block, a stage is
+// considered done when a quorum of nodes finished it.
+const (
+	// From the first node received it, to a quorum of nodes received it.
+	stageGossip int = iota
+	// From a quorum of nodes received it, to a quorum of nodes confirmed it.
+	stageConfirm
+	// From confirmed to delivered by a quorum of nodes.
+	stageDeliver
+	// From delivered to its randomness is ready for a quorum of nodes.
+	stageRandomness
+	// From ready to witnessed by a quorum of nodes.
+	stageWitness
+
+	stageCount
+)
+
+var stageNames = [stageCount]string{
+	"gossip", "confirm", "deliver", "randomness", "witness",
+}
+
+// stageEndEvents are the block events ending each stage.
+var stageEndEvents = [stageCount]int{
+	blockEventReceived,
+	blockEventConfirmed,
+	blockEventDelivered,
+	blockEventReady,
+	blockEventWitnessed,
+}
+
+// criticalPath is the time spent in each stage for one block.
+type criticalPath struct {
+	hash   common.Hash
+	stages [stageCount]time.Duration
+}
+
+func (c *criticalPath) total() (sum time.Duration) {
+	for _, d := range c.stages {
+		sum += d
+	}
+	return
+}
+
+// dominant returns the stage taking the most time.
+func (c *criticalPath) dominant() (stage int) {
+	for i, d := range c.stages {
+		if d > c.stages[stage] {
+			stage = i
+		}
+	}
+	return
+}
+
+// quorumTime returns the time when a quorum of nodes reach an event.
+func quorumTime(times []time.Time, quorum int) time.Time {
+	sorted := make([]time.Time, len(times))
+	copy(sorted, times)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Before(sorted[j])
+	})
+	if quorum > len(sorted) {
+		quorum = len(sorted)
+	}
+	return sorted[quorum-1]
+}
+
+// calcCriticalPaths reconstructs the causal chain of each block from block
+// events reported by nodes. Blocks not reported by a quorum of nodes are
+// skipped.
+func calcCriticalPaths(
+	events map[types.NodeID]map[common.Hash][]time.Time) []*criticalPath {
+	quorum := len(events)*2/3 + 1
+	byBlock := make(map[common.Hash][][]time.Time)
+	for _, blocks := range events {
+		for hash, timestamps := range blocks {
+			if len(timestamps) != blockEventCount {
+				continue
+			}
+			byBlock[hash] = append(byBlock[hash], timestamps)
+		}
+	}
+	paths := make([]*criticalPath, 0, len(byBlock))
+	for hash, reports := range byBlock {
+		if len(reports) < quorum {
+			continue
+		}
+		var (
+			path   = &criticalPath{hash: hash}
+			byType = [blockEventCount][]time.Time{}
+		)
+		for _, timestamps := range reports {
+			for i, t := range timestamps {
+				byType[i] = append(byType[i], t)
+			}
+		}
+		// The block is proposed no later than the first node received it.
+		prev := quorumTime(byType[blockEventReceived], 1)
+		for stage, event := range stageEndEvents {
+			end := quorumTime(byType[event], quorum)
+			if end.After(prev) {
+				path.stages[stage] = end.Sub(prev)
+				prev = end
+			}
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func logCriticalPaths(paths []*criticalPath) {
+	log.Printf("======== critical path (%d blocks) ============", len(paths))
+	if len(paths) == 0 {
+		return
+	}
+	var (
+		dominated = [stageCount]int{}
+		sums      = [stageCount]time.Duration{}
+	)
+	for _, p := range paths {
+		dominated[p.dominant()]++
+		for i, d := range p.stages {
+			sums[i] += d
+		}
+	}
+	for i := 0; i < stageCount; i++ {
+		log.Printf("    %-10s mean: %v, dominating %d blocks",
+			stageNames[i], sums[i]/time.Duration(len(paths)), dominated[i])
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i].total() > paths[j].total()
+	})
+	if len(paths) > criticalPathTopCount {
+		paths = paths[:criticalPathTopCount]
+	}
+	log.Printf("    slowest %d blocks:", len(paths))
+	for _, p := range paths {
+		log.Printf("        %s total: %v, dominated by %s %v",
+			p.hash.String()[:6], p.total(), stageNames[p.dominant()],
+			p.stages[:])
+	}
+}
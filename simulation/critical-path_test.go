@@ -0,0 +1,77 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type CriticalPathTestSuite struct {
+	suite.Suite
+}
+
+func (s *CriticalPathTestSuite) TestCalcCriticalPaths() {
+	var (
+		req    = s.Require()
+		base   = time.Now().UTC()
+		hash   = common.NewRandomHash()
+		events = make(map[types.NodeID]map[common.Hash][]time.Time)
+		// Durations of each stage, confirming is the slowest one.
+		stages = []time.Duration{
+			100 * time.Millisecond,
+			800 * time.Millisecond,
+			50 * time.Millisecond,
+			200 * time.Millisecond,
+			300 * time.Millisecond,
+		}
+	)
+	for i := 0; i < 4; i++ {
+		nID := types.NodeID{Hash: common.NewRandomHash()}
+		// Nodes received the block one after another.
+		t := base.Add(time.Duration(i) * stages[stageGossip] / 2)
+		timestamps := []time.Time{t}
+		for _, d := range stages[1:] {
+			t = t.Add(d)
+			timestamps = append(timestamps, t)
+		}
+		events[nID] = map[common.Hash][]time.Time{
+			hash: timestamps,
+			// Incomplete events are ignored.
+			common.NewRandomHash(): timestamps[:2],
+		}
+	}
+	paths := calcCriticalPaths(events)
+	req.Len(paths, 1)
+	p := paths[0]
+	req.Equal(hash, p.hash)
+	for i, d := range stages {
+		req.Equal(d, p.stages[i], "stage: %s", stageNames[i])
+	}
+	req.Equal(stageConfirm, p.dominant())
+	s.NotPanics(func() { logCriticalPaths(paths) })
+}
+
+func TestCriticalPath(t *testing.T) {
+	suite.Run(t, new(CriticalPathTestSuite))
+}
@@ -64,12 +64,21 @@ type node struct {
 	logger    common.Logger
 	consensus *core.Consensus
 	cfg       *config.Config
+	// execInterval is the time for this node to handle a message.
+	execInterval time.Duration
+	// clock is the drifting clock of this node, nil means the real clock.
+	clock func() time.Time
 }
 
-// newNode returns a new empty node.
+// newNode returns a new empty node, index is the order this node is
+// initialized in simulation.
 func newNode(prvKey crypto.PrivateKey, logger common.Logger,
-	cfg config.Config) *node {
+	cfg config.Config, index int) *node {
 	pubKey := prvKey.PublicKey()
+	var tlsKey crypto.PrivateKey
+	if cfg.Networking.TLS {
+		tlsKey = prvKey
+	}
 	netModule := test.NewNetwork(pubKey, test.NetworkConfig{
 		Type:       cfg.Networking.Type,
 		PeerServer: cfg.Networking.PeerServer,
@@ -82,12 +91,14 @@ func newNode(prvKey crypto.PrivateKey, logger common.Logger,
 			Mean:  cfg.Networking.Gossip.Mean,
 			Sigma: cfg.Networking.Gossip.Sigma,
 		},
-		Marshaller: test.NewDefaultMarshaller(&jsonMarshaller{})})
+		Marshaller: test.NewDefaultMarshaller(&jsonMarshaller{}),
+		TLSKey:     tlsKey})
 	id := types.NewNodeID(pubKey)
 	dbInst, err := db.NewMemBackedDB(id.String() + ".db")
 	if err != nil {
 		panic(err)
 	}
+	netModule.AttachAgreementResultStore(dbInst)
 	// Sync config to state in governance.
 	gov, err := test.NewGovernance(
 		test.NewState(core.DKGDelayRound,
@@ -105,6 +116,9 @@ func newNode(prvKey crypto.PrivateKey, logger common.Logger,
 		db:        dbInst,
 		netModule: netModule,
 		cfg:       &cfg,
+
+		execInterval: cfg.Scheduler.NodeExecInterval(index),
+		clock:        cfg.Node.NodeClock(index, time.Now()),
 	}
 }
 
@@ -164,14 +178,26 @@ readyLoop:
 		}
 	}
 	// Setup Consensus.
+	var network core.Network = n.netModule
+	if n.execInterval > 0 {
+		n.logger.Info("Throttle message handling",
+			"interval", n.execInterval)
+		network = newThrottledNetwork(n.netModule, n.execInterval)
+	}
+	opts := []core.Option{core.WithProposeJitter(n.cfg.Node.ProposeJitter)}
+	if n.clock != nil {
+		n.logger.Info("Simulate clock drift", "offset", n.clock().Sub(time.Now()))
+		opts = append(opts, core.WithClock(n.clock))
+	}
 	n.consensus = core.NewConsensusForSimulation(
 		dMoment,
 		n.app,
 		n.gov,
 		n.db,
-		n.netModule,
+		network,
 		n.prvKey,
-		n.logger)
+		n.logger,
+		opts...)
 	go n.consensus.Run()
 
 	// Blocks forever.
@@ -213,6 +239,8 @@ func (n *node) prepareConfigs() {
 	n.gov.State().RequestChange(test.StateChangeRoundLength, cConfig.RoundLength) // #nosec G104
 	n.gov.State().RequestChange(test.StateChangeMinBlockInterval, time.Duration(
 		cConfig.MinBlockInterval)*time.Millisecond) // #nosec G104
+	n.gov.State().RequestChange(test.StateChangeMaxBlockInterval, time.Duration(
+		cConfig.MaxBlockInterval)*time.Millisecond) // #nosec G104
 	n.gov.State().ProposeCRS(0, crypto.Keccak256Hash([]byte(cConfig.GenesisCRS))) // #nosec G104
 	// These rounds are not safe to be registered as pending state change
 	// requests.
@@ -41,6 +41,8 @@ type PeerServer struct {
 	ctx               context.Context
 	ctxCancel         context.CancelFunc
 	blockEvents       map[types.NodeID]map[common.Hash][]time.Time
+	blockProposers    map[common.Hash]types.NodeID
+	blockTimes        map[common.Hash]time.Time
 	throughputRecords map[types.NodeID][]test.ThroughputRecord
 }
 
@@ -52,6 +54,8 @@ func NewPeerServer() *PeerServer {
 		ctx:               ctx,
 		ctxCancel:         cancel,
 		blockEvents:       make(map[types.NodeID]map[common.Hash][]time.Time),
+		blockProposers:    make(map[common.Hash]types.NodeID),
+		blockTimes:        make(map[common.Hash]time.Time),
 		throughputRecords: make(map[types.NodeID][]test.ThroughputRecord),
 	}
 }
@@ -88,6 +92,12 @@ func (p *PeerServer) handleBlockEventMessage(
 		nodeEvents[msg.BlockHash] = []time.Time{}
 	}
 	nodeEvents[msg.BlockHash] = msg.Timestamps
+	if (msg.ProposerID != types.NodeID{}) {
+		p.blockProposers[msg.BlockHash] = msg.ProposerID
+	}
+	if !msg.BlockTimestamp.IsZero() {
+		p.blockTimes[msg.BlockHash] = msg.BlockTimestamp
+	}
 }
 
 func (p *PeerServer) handleThroughputData(
@@ -132,7 +142,13 @@ func (p *PeerServer) Setup(
 	// Setup transport layer.
 	switch cfg.Networking.Type {
 	case "tcp", "tcp-local":
-		p.trans = test.NewTCPTransportServer(&jsonMarshaller{}, peerPort)
+		server := test.NewTCPTransportServer(&jsonMarshaller{}, peerPort)
+		if cfg.Networking.TLS {
+			if err = server.EnableTLS(); err != nil {
+				return
+			}
+		}
+		p.trans = server
 		dMoment = dMoment.Add(10 * time.Second)
 	case "fake":
 		p.trans = test.NewFakeTransportServer()
@@ -194,9 +210,18 @@ func (p *PeerServer) Run() {
 		log.Printf("Error shutting down peerServer: %v\n", err)
 	}
 	p.logBlockEvents()
+	logCriticalPaths(calcCriticalPaths(p.blockEvents))
 	p.logThroughputRecords()
 }
 
+// Report summarizes the result of simulation, it should be called after Run
+// returns.
+func (p *PeerServer) Report() *Report {
+	r := newReport(p.cfg, p.blockEvents, p.blockProposers)
+	r.TimestampError = newStat(timestampErrors(p.blockEvents, p.blockTimes))
+	return r
+}
+
 func (p *PeerServer) logThroughputRecords() {
 	// Interval is the sample rate of calculating throughput data, the unit is
 	// nano second.
@@ -0,0 +1,302 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/simulation/config"
+)
+
+// Stat summarizes a set of samples.
+type Stat struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+	Min    float64 `json:"min"`
+	Median float64 `json:"median"`
+	Max    float64 `json:"max"`
+}
+
+func newStat(samples []float64) (s Stat) {
+	if len(samples) == 0 {
+		return
+	}
+	s.Mean, s.StdDev = calculateMeanStdDeviationFloat64s(samples)
+	s.Min, s.Median, s.Max = getMinMedianMaxFloat64s(samples)
+	return
+}
+
+// Report summarizes the result of a simulation, it could be saved as an
+// artifact and compared with other runs.
+type Report struct {
+	Config *config.Config `json:"config"`
+	Nodes  int            `json:"nodes"`
+	// Blocks is the count of blocks whose events are reported by a quorum of
+	// nodes.
+	Blocks int `json:"blocks"`
+	// Duration is the time from the first block received to the last block
+	// witnessed.
+	Duration time.Duration `json:"duration"`
+	// BPS is the count of blocks per second.
+	BPS float64 `json:"bps"`
+	// Latency is the time, in seconds, from a block proposed to witnessed.
+	Latency Stat `json:"latency"`
+	// Stages are latencies of each stage of the critical path of blocks.
+	Stages map[string]Stat `json:"stages"`
+	// Proposed is the count of blocks proposed by each node.
+	Proposed map[string]int `json:"proposed"`
+	// Fairness is the Jain's fairness index of proposed blocks among nodes,
+	// 1 means every node proposed the same count of blocks.
+	Fairness float64 `json:"fairness"`
+	// SkippedVerifications is the count of signature verifications skipped
+	// in trusted crypto mode, indexed by the kind of signature.
+	SkippedVerifications map[string]uint64 `json:"skipped_verifications,omitempty"`
+	// TimestampError is the difference, in seconds, between the timestamp of
+	// a block and the time it's first received by nodes. It's affected by
+	// drifting clocks of proposers and the range of block interval.
+	TimestampError Stat `json:"timestamp_error"`
+}
+
+// newReport summarizes block events reported by nodes.
+func newReport(cfg *config.Config,
+	events map[types.NodeID]map[common.Hash][]time.Time,
+	proposers map[common.Hash]types.NodeID) *Report {
+	r := &Report{
+		Config:   cfg,
+		Nodes:    len(events),
+		Stages:   make(map[string]Stat),
+		Proposed: make(map[string]int),
+	}
+	paths := calcCriticalPaths(events)
+	r.Blocks = len(paths)
+	var (
+		begin, end time.Time
+		latencies  = make([]float64, 0, len(paths))
+		stages     = [stageCount][]float64{}
+	)
+	for _, blocks := range events {
+		for _, timestamps := range blocks {
+			if len(timestamps) != blockEventCount {
+				continue
+			}
+			first, last := timestamps[blockEventReceived],
+				timestamps[blockEventWitnessed]
+			if begin.IsZero() || first.Before(begin) {
+				begin = first
+			}
+			if last.After(end) {
+				end = last
+			}
+		}
+	}
+	if end.After(begin) {
+		r.Duration = end.Sub(begin)
+		r.BPS = float64(r.Blocks) / r.Duration.Seconds()
+	}
+	for _, p := range paths {
+		latencies = append(latencies, p.total().Seconds())
+		for i, d := range p.stages {
+			stages[i] = append(stages[i], d.Seconds())
+		}
+		if proposer, exist := proposers[p.hash]; exist {
+			r.Proposed[proposer.String()]++
+		}
+	}
+	r.Latency = newStat(latencies)
+	for i, samples := range stages {
+		r.Stages[stageNames[i]] = newStat(samples)
+	}
+	counts := make([]float64, 0, len(events))
+	for nID := range events {
+		counts = append(counts, float64(r.Proposed[nID.String()]))
+	}
+	r.Fairness = jainsFairnessIndex(counts)
+	return r
+}
+
+// timestampErrors returns differences, in seconds, between timestamps of
+// blocks and the earliest time they are received by nodes.
+func timestampErrors(events map[types.NodeID]map[common.Hash][]time.Time,
+	blockTimes map[common.Hash]time.Time) []float64 {
+	received := make(map[common.Hash]time.Time)
+	for _, blocks := range events {
+		for hash, timestamps := range blocks {
+			if len(timestamps) <= blockEventReceived {
+				continue
+			}
+			t := timestamps[blockEventReceived]
+			if first, exist := received[hash]; !exist || t.Before(first) {
+				received[hash] = t
+			}
+		}
+	}
+	errs := make([]float64, 0, len(received))
+	for hash, t := range received {
+		if blockTime, exist := blockTimes[hash]; exist {
+			errs = append(errs, blockTime.Sub(t).Seconds())
+		}
+	}
+	return errs
+}
+
+// jainsFairnessIndex returns (sum of x)^2 / (n * sum of x^2).
+func jainsFairnessIndex(a []float64) float64 {
+	var sum, sumSquare float64
+	for _, x := range a {
+		sum += x
+		sumSquare += x * x
+	}
+	if sumSquare == 0 {
+		return 0
+	}
+	return sum * sum / (float64(len(a)) * sumSquare)
+}
+
+// Save writes the report to a file in JSON.
+func (r *Report) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// LoadReport reads a report saved by Report.Save.
+func LoadReport(path string) (*Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := &Report{}
+	if err = json.NewDecoder(f).Decode(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// metrics flattens the report into named values, in the order to be printed.
+func (r *Report) metrics() (names []string, values map[string]float64) {
+	values = map[string]float64{
+		"blocks":       float64(r.Blocks),
+		"duration(s)":  r.Duration.Seconds(),
+		"bps":          r.BPS,
+		"latency.mean": r.Latency.Mean,
+		"latency.std":  r.Latency.StdDev,
+		"latency.max":  r.Latency.Max,
+		"fairness":     r.Fairness,
+
+		"timestamp_error.mean": r.TimestampError.Mean,
+		"timestamp_error.max":  r.TimestampError.Max,
+	}
+	names = []string{"blocks", "duration(s)", "bps", "latency.mean",
+		"latency.std", "latency.max", "fairness", "timestamp_error.mean",
+		"timestamp_error.max"}
+	for _, name := range stageNames {
+		key := "stage." + name + ".mean"
+		values[key] = r.Stages[name].Mean
+		names = append(names, key)
+	}
+	return
+}
+
+// Print writes the report in human readable format.
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "nodes: %d\n", r.Nodes)
+	names, values := r.metrics()
+	for _, name := range names {
+		fmt.Fprintf(w, "%-24s %f\n", name, values[name])
+	}
+	proposers := make([]string, 0, len(r.Proposed))
+	for nID := range r.Proposed {
+		proposers = append(proposers, nID)
+	}
+	sort.Strings(proposers)
+	fmt.Fprintln(w, "proposed:")
+	for _, nID := range proposers {
+		fmt.Fprintf(w, "    %s %d\n", nID, r.Proposed[nID])
+	}
+	if len(r.SkippedVerifications) == 0 {
+		return
+	}
+	kinds := make([]string, 0, len(r.SkippedVerifications))
+	for kind := range r.SkippedVerifications {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	fmt.Fprintln(w, "skipped verifications:")
+	for _, kind := range kinds {
+		fmt.Fprintf(w, "    %-22s %d\n", kind, r.SkippedVerifications[kind])
+	}
+}
+
+// MetricDelta is the change of a metric between two reports.
+type MetricDelta struct {
+	Name   string
+	Base   float64
+	Target float64
+}
+
+// Delta returns the difference from base to target.
+func (d MetricDelta) Delta() float64 {
+	return d.Target - d.Base
+}
+
+// Ratio returns the relative change from base to target, it's zero when base
+// is zero.
+func (d MetricDelta) Ratio() float64 {
+	if d.Base == 0 {
+		return 0
+	}
+	return d.Delta() / d.Base
+}
+
+// CompareReports returns changes of each metric from base to target.
+func CompareReports(base, target *Report) []MetricDelta {
+	names, baseValues := base.metrics()
+	_, targetValues := target.metrics()
+	deltas := make([]MetricDelta, 0, len(names))
+	for _, name := range names {
+		deltas = append(deltas, MetricDelta{
+			Name:   name,
+			Base:   baseValues[name],
+			Target: targetValues[name],
+		})
+	}
+	return deltas
+}
+
+// PrintDeltas writes changes between two reports in human readable format.
+func PrintDeltas(w io.Writer, deltas []MetricDelta) {
+	fmt.Fprintf(w, "%-24s %12s %12s %12s %8s\n",
+		"metric", "base", "target", "delta", "ratio")
+	for _, d := range deltas {
+		fmt.Fprintf(w, "%-24s %12.4f %12.4f %+12.4f %+7.1f%%\n",
+			d.Name, d.Base, d.Target, d.Delta(), d.Ratio()*100)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type ReportTestSuite struct {
+	suite.Suite
+}
+
+func (s *ReportTestSuite) newEvents(blockCount int, interval time.Duration) (
+	map[types.NodeID]map[common.Hash][]time.Time,
+	map[common.Hash]types.NodeID) {
+	var (
+		base      = time.Now().UTC()
+		nodes     = make([]types.NodeID, 4)
+		events    = make(map[types.NodeID]map[common.Hash][]time.Time)
+		proposers = make(map[common.Hash]types.NodeID)
+	)
+	for i := range nodes {
+		nodes[i] = types.NodeID{Hash: common.NewRandomHash()}
+		events[nodes[i]] = make(map[common.Hash][]time.Time)
+	}
+	for i := 0; i < blockCount; i++ {
+		hash := common.NewRandomHash()
+		// Only the first node proposes blocks.
+		proposers[hash] = nodes[0]
+		t := base.Add(time.Duration(i) * interval)
+		timestamps := []time.Time{t}
+		for j := 1; j < blockEventCount; j++ {
+			t = t.Add(interval)
+			timestamps = append(timestamps, t)
+		}
+		for _, nID := range nodes {
+			events[nID][hash] = timestamps
+		}
+	}
+	return events, proposers
+}
+
+func (s *ReportTestSuite) TestNewReport() {
+	var (
+		req      = s.Require()
+		interval = 100 * time.Millisecond
+	)
+	events, proposers := s.newEvents(10, interval)
+	r := newReport(nil, events, proposers)
+	req.Equal(4, r.Nodes)
+	req.Equal(10, r.Blocks)
+	req.Equal(interval*time.Duration(10-1+blockEventCount-1), r.Duration)
+	req.InDelta(float64(10)/r.Duration.Seconds(), r.BPS, 1e-9)
+	req.InDelta((interval * time.Duration(blockEventCount-1)).Seconds(),
+		r.Latency.Mean, 1e-9)
+	req.InDelta(0.25, r.Fairness, 1e-9)
+	// An empty report should not panic.
+	r = newReport(nil, nil, nil)
+	req.Equal(0, r.Blocks)
+	req.Equal(float64(0), r.Fairness)
+	s.NotPanics(func() { r.Print(&bytes.Buffer{}) })
+}
+
+func (s *ReportTestSuite) TestFairness() {
+	req := s.Require()
+	req.InDelta(1, jainsFairnessIndex([]float64{3, 3, 3, 3}), 1e-9)
+	req.InDelta(0.5, jainsFairnessIndex([]float64{1, 1, 0, 0}), 1e-9)
+	req.Equal(float64(0), jainsFairnessIndex([]float64{0, 0}))
+}
+
+func (s *ReportTestSuite) TestTimestampErrors() {
+	req := s.Require()
+	events, _ := s.newEvents(3, 100*time.Millisecond)
+	blockTimes := make(map[common.Hash]time.Time)
+	for _, blocks := range events {
+		for hash, timestamps := range blocks {
+			// Timestamps of blocks are 200ms ahead of the real time.
+			blockTimes[hash] = timestamps[blockEventReceived].Add(
+				200 * time.Millisecond)
+		}
+		break
+	}
+	// A block with unknown timestamp should be ignored.
+	for _, blocks := range events {
+		blocks[common.NewRandomHash()] = []time.Time{time.Now()}
+	}
+	errs := timestampErrors(events, blockTimes)
+	req.Len(errs, 3)
+	st := newStat(errs)
+	req.InDelta(0.2, st.Mean, 1e-9)
+	req.InDelta(0.2, st.Max, 1e-9)
+}
+
+func (s *ReportTestSuite) TestSaveLoadAndCompare() {
+	var (
+		req  = s.Require()
+		path = "test-report.json"
+	)
+	events, proposers := s.newEvents(10, 100*time.Millisecond)
+	base := newReport(nil, events, proposers)
+	req.NoError(base.Save(path))
+	defer func() {
+		req.NoError(os.Remove(path))
+	}()
+	loaded, err := LoadReport(path)
+	req.NoError(err)
+	req.Equal(base, loaded)
+	events, proposers = s.newEvents(10, 50*time.Millisecond)
+	target := newReport(nil, events, proposers)
+	deltas := CompareReports(base, target)
+	for _, d := range deltas {
+		switch d.Name {
+		case "bps":
+			req.True(d.Delta() > 0)
+		case "latency.mean":
+			req.InDelta(-0.5, d.Ratio(), 1e-9)
+		case "fairness", "blocks":
+			req.Equal(float64(0), d.Delta())
+		}
+	}
+	s.NotPanics(func() { PrintDeltas(&bytes.Buffer{}, deltas) })
+}
+
+func TestReport(t *testing.T) {
+	suite.Run(t, new(ReportTestSuite))
+}
@@ -26,13 +26,16 @@ import (
 	"github.com/dexon-foundation/dexon/log"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
 	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
 	"github.com/dexon-foundation/dexon-consensus/simulation/config"
 )
 
-// Run starts the simulation.
-func Run(cfg *config.Config, logPrefix string) {
+// Run starts the simulation, the report is only available when the peer
+// server is run in the same process.
+func Run(cfg *config.Config, logPrefix string) (report *Report) {
 	var (
 		networkType = cfg.Networking.Type
 		server      *PeerServer
@@ -62,13 +65,20 @@ func Run(cfg *config.Config, logPrefix string) {
 		return logger
 	}
 
+	if cfg.Node.TrustedCrypto {
+		utils.ResetSkippedSignatureVerifications()
+		utils.SetTrustedCrypto(true)
+		defer utils.SetTrustedCrypto(false)
+	}
+
 	// init is a function to init a node.
-	init := func(serverEndpoint interface{}, logger common.Logger) {
-		prv, err := ecdsa.NewPrivateKey()
+	init := func(
+		serverEndpoint interface{}, logger common.Logger, index int) {
+		prv, err := newNodeKey(cfg.Node.KeySeed, index)
 		if err != nil {
 			panic(err)
 		}
-		v := newNode(prv, logger, *cfg)
+		v := newNode(prv, logger, *cfg, index)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -80,7 +90,7 @@ func Run(cfg *config.Config, logPrefix string) {
 	case test.NetworkTypeTCP:
 		// Intialized a simulation on multiple remotely peers.
 		// The peer-server would be initialized with another command.
-		init(nil, newLogger(logPrefix))
+		init(nil, newLogger(logPrefix), 0)
 	case test.NetworkTypeTCPLocal, test.NetworkTypeFake:
 		// Initialize a local simulation with a peer server.
 		var serverEndpoint interface{}
@@ -99,7 +109,7 @@ func Run(cfg *config.Config, logPrefix string) {
 			if logPrefix == "" {
 				prefix = ""
 			}
-			init(serverEndpoint, newLogger(prefix))
+			init(serverEndpoint, newLogger(prefix), int(i))
 		}
 	}
 	wg.Wait()
@@ -109,4 +119,18 @@ func Run(cfg *config.Config, logPrefix string) {
 	if networkType == test.NetworkTypeTCP {
 		select {}
 	}
+	report = server.Report()
+	if cfg.Node.TrustedCrypto {
+		report.SkippedVerifications = utils.SkippedSignatureVerifications()
+	}
+	return
+}
+
+// newNodeKey generates the private key of a node, the key is derived from the
+// seed and the index of that node when the seed is not empty.
+func newNodeKey(seed string, index int) (crypto.PrivateKey, error) {
+	if seed == "" {
+		return ecdsa.NewPrivateKey()
+	}
+	return test.NewDeterministicKey(seed, index)
 }
@@ -0,0 +1,64 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type SimulationTestSuite struct {
+	suite.Suite
+}
+
+func (s *SimulationTestSuite) TestNewNodeKey() {
+	// Keys derived from the same seed and index are identical.
+	prv1, err := newNodeKey("seed", 1)
+	s.Require().NoError(err)
+	prv2, err := newNodeKey("seed", 1)
+	s.Require().NoError(err)
+	s.Equal(types.NewNodeID(prv1.PublicKey()), types.NewNodeID(prv2.PublicKey()))
+	// Keys of different indexes or seeds are different.
+	prv3, err := newNodeKey("seed", 2)
+	s.Require().NoError(err)
+	s.NotEqual(types.NewNodeID(prv1.PublicKey()),
+		types.NewNodeID(prv3.PublicKey()))
+	prv4, err := newNodeKey("another seed", 1)
+	s.Require().NoError(err)
+	s.NotEqual(types.NewNodeID(prv1.PublicKey()),
+		types.NewNodeID(prv4.PublicKey()))
+	// Keys are random without seed.
+	prv5, err := newNodeKey("", 1)
+	s.Require().NoError(err)
+	prv6, err := newNodeKey("", 1)
+	s.Require().NoError(err)
+	s.NotEqual(types.NewNodeID(prv5.PublicKey()),
+		types.NewNodeID(prv6.PublicKey()))
+	// Signatures signed by derived keys are verifiable.
+	hash := types.NewNodeID(prv1.PublicKey()).Hash
+	sig, err := prv1.Sign(hash)
+	s.Require().NoError(err)
+	s.True(prv2.PublicKey().VerifySignature(hash, sig))
+}
+
+func TestSimulation(t *testing.T) {
+	suite.Run(t, new(SimulationTestSuite))
+}